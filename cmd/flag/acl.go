@@ -0,0 +1,34 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type ACLFlagValues struct {
+	ShowACL bool
+}
+
+type ChmodFlagValues struct {
+	AdminMode bool
+}
+
+var (
+	aclFlagValues   ACLFlagValues
+	chmodFlagValues ChmodFlagValues
+)
+
+func SetACLFlags(command *cobra.Command) {
+	command.Flags().BoolVarP(&aclFlagValues.ShowACL, "acl", "A", false, "Display access control lists")
+}
+
+func GetACLFlagValues() *ACLFlagValues {
+	return &aclFlagValues
+}
+
+func SetChmodFlags(command *cobra.Command) {
+	command.Flags().BoolVarP(&chmodFlagValues.AdminMode, "admin", "M", false, "Run in administrator mode to change access of entries owned by others (requires rodsadmin)")
+}
+
+func GetChmodFlagValues() *ChmodFlagValues {
+	return &chmodFlagValues
+}
@@ -4,6 +4,8 @@ import "github.com/spf13/cobra"
 
 type BundleFlagValues struct {
 	Extract          bool
+	Create           bool
+	List             bool
 	BulkRegistration bool
 	DataType         string
 }
@@ -14,8 +16,12 @@ var (
 
 func SetBundleFlags(command *cobra.Command) {
 	command.Flags().BoolVarP(&bundleFlagValues.Extract, "extract", "x", false, "Extract")
+	command.Flags().BoolVarP(&bundleFlagValues.Create, "create", "C", false, "Create a structured file from a collection")
+	command.Flags().BoolVarP(&bundleFlagValues.List, "list", "l", false, "List entries in a structured file without extracting")
 	command.Flags().BoolVarP(&bundleFlagValues.BulkRegistration, "bulk", "b", false, "Enable bulk registration")
 	command.Flags().StringVarP(&bundleFlagValues.DataType, "data_type", "D", "", "Set data type (tar, zip ...)")
+
+	command.MarkFlagsMutuallyExclusive("extract", "create", "list")
 }
 
 func GetBundleFlagValues() *BundleFlagValues {
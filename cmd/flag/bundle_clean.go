@@ -0,0 +1,56 @@
+package flag
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cyverse/gocommands/commons"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+)
+
+type BundleCleanFlagValues struct {
+	List           bool
+	Scan           bool
+	OlderThan      time.Duration
+	olderThanInput string
+}
+
+var (
+	bundleCleanFlagValues BundleCleanFlagValues
+)
+
+func SetBundleCleanFlags(command *cobra.Command) {
+	command.Flags().BoolVarP(&bundleCleanFlagValues.List, "list", "l", false, "List stale bundle files with size, age, and staging directory instead of deleting them")
+	command.Flags().BoolVar(&bundleCleanFlagValues.Scan, "scan", false, "Find staging collections anywhere under the given collections")
+	command.Flags().StringVar(&bundleCleanFlagValues.olderThanInput, "older_than", "", "Only handle bundle files older than the given age (e.g., '30m', '12h', '2d')")
+}
+
+func GetBundleCleanFlagValues() *BundleCleanFlagValues {
+	bundleCleanFlagValues.olderThanInput = strings.TrimSpace(bundleCleanFlagValues.olderThanInput)
+	bundleCleanFlagValues.OlderThan = 0
+	if len(bundleCleanFlagValues.olderThanInput) > 0 {
+		seconds, _ := commons.ParseTime(bundleCleanFlagValues.olderThanInput)
+		bundleCleanFlagValues.OlderThan = time.Duration(seconds) * time.Second
+	}
+
+	return &bundleCleanFlagValues
+}
+
+// CheckOlderThan returns an error if the age given is not parsable
+func (values *BundleCleanFlagValues) CheckOlderThan() error {
+	if len(values.olderThanInput) == 0 {
+		return nil
+	}
+
+	seconds, err := commons.ParseTime(values.olderThanInput)
+	if err != nil {
+		return xerrors.Errorf("failed to parse age %q: %w", values.olderThanInput, err)
+	}
+
+	if seconds < 0 {
+		return xerrors.Errorf("age %q must not be negative", values.olderThanInput)
+	}
+
+	return nil
+}
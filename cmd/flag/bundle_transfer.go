@@ -6,6 +6,7 @@ import (
 
 	"github.com/cyverse/gocommands/commons"
 	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
 )
 
 type BundleTransferFlagValues struct {
@@ -16,7 +17,13 @@ type BundleTransferFlagValues struct {
 	MaxFileNum         int
 	MaxFileSize        int64
 	NoBulkRegistration bool
+	Format             commons.BundleFormat
+	PackingStrategy    commons.BundlePackingStrategy
+	DirectUploadSize   int64
 	maxFileSizeInput   string
+	formatInput        string
+	packingInput       string
+	directUploadInput  string
 }
 
 var (
@@ -30,7 +37,6 @@ func SetBundleTransferFlags(command *cobra.Command, hideTempPathConfig bool, hid
 	command.Flags().IntVar(&bundleTransferFlagValues.MinFileNum, "min_file_num", commons.MinBundleFileNumDefault, "Specify min file number in a bundle file")
 	command.Flags().IntVar(&bundleTransferFlagValues.MaxFileNum, "max_file_num", commons.MaxBundleFileNumDefault, "Specify max file number in a bundle file")
 	command.Flags().StringVar(&bundleTransferFlagValues.maxFileSizeInput, "max_file_size", strconv.FormatInt(commons.MaxBundleFileSizeDefault, 10), "Specify max file size of a bundle file")
-	command.Flags().BoolVar(&bundleTransferFlagValues.NoBulkRegistration, "no_bulk_reg", false, "Disable bulk registration")
 
 	if hideTempPathConfig {
 		command.Flags().MarkHidden("local_temp")
@@ -42,13 +48,61 @@ func SetBundleTransferFlags(command *cobra.Command, hideTempPathConfig bool, hid
 		command.Flags().MarkHidden("min_file_num")
 		command.Flags().MarkHidden("max_file_num")
 		command.Flags().MarkHidden("max_file_size")
+	}
+}
+
+// SetBulkRegistrationFlags sets flags for registering extracted bundle files, for uploads only
+func SetBulkRegistrationFlags(command *cobra.Command, hidden bool) {
+	command.Flags().BoolVar(&bundleTransferFlagValues.NoBulkRegistration, "no_bulk_reg", false, "Disable bulk registration")
+
+	if hidden {
 		command.Flags().MarkHidden("no_bulk_reg")
 	}
 }
 
+func SetBundleFormatFlags(command *cobra.Command) {
+	command.Flags().StringVar(&bundleTransferFlagValues.formatInput, "bundle_format", string(commons.BundleFormatTar), "Set file format of bundle files ('tar', 'tar.gz', 'tar.bz2', or 'zip')")
+}
+
+func SetBundlePackingFlags(command *cobra.Command) {
+	command.Flags().StringVar(&bundleTransferFlagValues.packingInput, "packing", string(commons.BundlePackingStrategyWalk), "Set how files are packed into bundles ('walk' fills bundles in walk order, 'collection' groups files by collection, uploads large files directly, and balances bundle sizes)")
+	command.Flags().StringVar(&bundleTransferFlagValues.directUploadInput, "direct_upload_size", strconv.FormatInt(commons.DirectUploadFileSizeDefault, 10), "Specify min size of files to upload directly without bundling, used with 'collection' packing")
+}
+
 func GetBundleTransferFlagValues() *BundleTransferFlagValues {
 	size, _ := commons.ParseSize(bundleTransferFlagValues.maxFileSizeInput)
 	bundleTransferFlagValues.MaxFileSize = size
 
+	bundleTransferFlagValues.Format = commons.BundleFormatTar
+	if len(bundleTransferFlagValues.formatInput) > 0 {
+		bundleTransferFlagValues.Format = commons.GetBundleFormat(bundleTransferFlagValues.formatInput)
+	}
+
+	bundleTransferFlagValues.PackingStrategy = commons.BundlePackingStrategyWalk
+	if len(bundleTransferFlagValues.packingInput) > 0 {
+		bundleTransferFlagValues.PackingStrategy = commons.GetBundlePackingStrategy(bundleTransferFlagValues.packingInput)
+	}
+
+	directUploadSize, _ := commons.ParseSize(bundleTransferFlagValues.directUploadInput)
+	bundleTransferFlagValues.DirectUploadSize = directUploadSize
+
 	return &bundleTransferFlagValues
 }
+
+// CheckBundlePacking returns an error if the packing strategy given is unknown
+func (values *BundleTransferFlagValues) CheckBundlePacking() error {
+	if values.PackingStrategy == commons.BundlePackingStrategyUnknown {
+		return xerrors.Errorf("unknown bundle packing %q, must be one of 'walk' or 'collection'", values.packingInput)
+	}
+
+	return nil
+}
+
+// CheckBundleFormat returns an error if the bundle format given is unknown
+func (values *BundleTransferFlagValues) CheckBundleFormat() error {
+	if values.Format == commons.BundleFormatUnknown {
+		return xerrors.Errorf("unknown bundle format %q, must be one of 'tar', 'tar.gz', 'tar.bz2', or 'zip'", values.formatInput)
+	}
+
+	return nil
+}
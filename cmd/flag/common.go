@@ -11,17 +11,19 @@ import (
 )
 
 type CommonFlagValues struct {
-	ConfigFilePath  string
-	ShowVersion     bool
-	ShowHelp        bool
-	DebugMode       bool
-	Quiet           bool
-	logLevelInput   string
-	LogLevel        log.Level
-	LogLevelUpdated bool
-	SessionID       int
-	Resource        string
-	ResourceUpdated bool
+	ConfigFilePath   string
+	ShowVersion      bool
+	ShowHelp         bool
+	DebugMode        bool
+	Quiet            bool
+	logLevelInput    string
+	LogLevel         log.Level
+	LogLevelUpdated  bool
+	SessionID        int
+	Resource         string
+	ResourceUpdated  bool
+	errorFormatInput string
+	ErrorFormat      commons.OutputFormat
 }
 
 const (
@@ -40,6 +42,7 @@ func SetCommonFlags(command *cobra.Command, hideResource bool) {
 	command.Flags().BoolVarP(&commonFlagValues.Quiet, "quiet", "q", false, "Suppress usual output messages")
 	command.Flags().StringVar(&commonFlagValues.logLevelInput, "log_level", "", "Set log level")
 	command.Flags().IntVarP(&commonFlagValues.SessionID, "session", "s", os.Getppid(), "Set session ID")
+	command.Flags().StringVar(&commonFlagValues.errorFormatInput, "error_format", string(commons.OutputFormatText), "Set format of error printed on failure ('text' or 'json')")
 	command.Flags().StringVarP(&commonFlagValues.Resource, "resource", "R", "", "Set resource server")
 
 	command.MarkFlagsMutuallyExclusive("quiet", "version")
@@ -63,6 +66,7 @@ func SetCommonFlagsWithoutResource(command *cobra.Command) {
 	command.Flags().BoolVarP(&commonFlagValues.Quiet, "quiet", "q", false, "Suppress usual output messages")
 	command.Flags().StringVar(&commonFlagValues.logLevelInput, "log_level", "", "Set log level")
 	command.Flags().IntVarP(&commonFlagValues.SessionID, "session", "s", os.Getppid(), "Set session ID")
+	command.Flags().StringVar(&commonFlagValues.errorFormatInput, "error_format", string(commons.OutputFormatText), "Set format of error printed on failure ('text' or 'json')")
 
 	command.MarkFlagsMutuallyExclusive("quiet", "version")
 	command.MarkFlagsMutuallyExclusive("log_level", "version")
@@ -85,9 +89,25 @@ func GetCommonFlagValues(command *cobra.Command) *CommonFlagValues {
 		commonFlagValues.ResourceUpdated = true
 	}
 
+	// only text and json are supported for errors, print in text until the format is checked
+	commonFlagValues.ErrorFormat = commons.OutputFormatText
+	if commons.GetOutputFormat(commonFlagValues.errorFormatInput) == commons.OutputFormatJSON {
+		commonFlagValues.ErrorFormat = commons.OutputFormatJSON
+	}
+
 	return &commonFlagValues
 }
 
+// CheckErrorFormat returns an error if the error format is not 'text' or 'json'
+func (values *CommonFlagValues) CheckErrorFormat() error {
+	switch commons.GetOutputFormat(values.errorFormatInput) {
+	case commons.OutputFormatText, commons.OutputFormatJSON:
+		return nil
+	default:
+		return xerrors.Errorf("unknown error format %q, must be one of 'text' or 'json'", values.errorFormatInput)
+	}
+}
+
 func getLogrusLogLevel(irodsLogLevel int) log.Level {
 	switch irodsLogLevel {
 	case 0:
@@ -138,6 +158,11 @@ func ProcessCommonFlags(command *cobra.Command) (bool, error) {
 
 	setLogLevel(command)
 
+	err := myCommonFlagValues.CheckErrorFormat()
+	if err != nil {
+		return false, err
+	}
+
 	if myCommonFlagValues.ShowHelp {
 		command.Usage()
 		return false, nil // stop here
@@ -149,7 +174,7 @@ func ProcessCommonFlags(command *cobra.Command) (bool, error) {
 	}
 
 	// init config
-	err := commons.InitEnvironmentManager()
+	err = commons.InitEnvironmentManager()
 	if err != nil {
 		return false, xerrors.Errorf("failed to init environment manager: %w", err)
 	}
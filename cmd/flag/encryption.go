@@ -15,15 +15,20 @@ type EncryptionFlagValues struct {
 	modeInput            string
 	Key                  string
 	PublicPrivateKeyPath string
+	Recipients           []string
+	Identities           []string
+	DefaultIdentities    bool
 	TempPath             string
 }
 
 type DecryptionFlagValues struct {
-	Decryption     bool
-	NoDecryption   bool
-	Key            string
-	PrivateKeyPath string
-	TempPath       string
+	Decryption        bool
+	NoDecryption      bool
+	Key               string
+	PrivateKeyPath    string
+	Identities        []string
+	DefaultIdentities bool
+	TempPath          string
 }
 
 var (
@@ -35,18 +40,21 @@ func SetEncryptionFlags(command *cobra.Command) {
 	command.Flags().BoolVar(&encryptionFlagValues.Encryption, "encrypt", false, "Encrypt files")
 	command.Flags().BoolVar(&encryptionFlagValues.NoEncryption, "no_encrypt", false, "Disable encryption forcefully")
 	command.Flags().BoolVar(&encryptionFlagValues.IgnoreMeta, "ignore_meta", false, "Ignore encryption config via metadata")
-	command.Flags().StringVar(&encryptionFlagValues.modeInput, "encrypt_mode", "ssh", "Encryption mode ('winscp', 'pgp', or 'ssh')")
-	command.Flags().StringVar(&encryptionFlagValues.Key, "encrypt_key", "", "Encryption key for 'winscp' and 'pgp' mode")
-	command.Flags().StringVar(&encryptionFlagValues.PublicPrivateKeyPath, "encrypt_pub_key", commons.GetDefaultPublicKeyPath(), "Encryption public (or private) key for 'ssh' mode")
-	command.Flags().StringVar(&encryptionFlagValues.TempPath, "encrypt_temp", os.TempDir(), "Specify temp directory path for encrypting files")
+	command.Flags().StringVar(&encryptionFlagValues.modeInput, "encrypt_mode", "ssh", "Encryption mode ('winscp', 'pgp', 'ssh', or 'age')")
+	command.Flags().StringVar(&encryptionFlagValues.Key, "encrypt_key", "", "Encryption key for 'winscp' and 'pgp' mode, or passphrase for 'age' mode")
+	command.Flags().StringVar(&encryptionFlagValues.PublicPrivateKeyPath, "encrypt_pub_key", commons.GetDefaultPublicKeyPath(), "Encryption public (or private) key for 'ssh' mode, also used for 'age' mode if no recipients are given")
+	command.Flags().StringArrayVar(&encryptionFlagValues.Recipients, "encrypt_recipient", []string{}, "Recipient for 'age' mode, an age or SSH public key, or a file having them line by line (can be given multiple times)")
+	command.Flags().StringArrayVar(&encryptionFlagValues.Identities, "encrypt_identity", commons.GetDefaultAgeIdentityPaths(), "Identity (age secret key or SSH private key) file for 'age' mode to read filename keys of existing collections")
+	command.Flags().StringVar(&encryptionFlagValues.TempPath, "encrypt_temp", os.TempDir(), "Specify temp directory path for encrypting files, used only if files cannot be encrypted while uploading (e.g., with --verify_checksum)")
 }
 
 func SetDecryptionFlags(command *cobra.Command) {
 	command.Flags().BoolVar(&decryptionFlagValues.Decryption, "decrypt", true, "Decrypt files")
 	command.Flags().BoolVar(&decryptionFlagValues.NoDecryption, "no_decrypt", false, "Disable decryption forcefully")
-	command.Flags().StringVar(&decryptionFlagValues.Key, "decrypt_key", "", "Decryption key for 'winscp' and 'pgp' mode")
+	command.Flags().StringVar(&decryptionFlagValues.Key, "decrypt_key", "", "Decryption key for 'winscp' and 'pgp' mode, or passphrase for 'age' mode")
 	command.Flags().StringVar(&decryptionFlagValues.PrivateKeyPath, "decrypt_priv_key", commons.GetDefaultPrivateKeyPath(), "Decryption private key for 'ssh' mode")
-	command.Flags().StringVar(&decryptionFlagValues.TempPath, "decrypt_temp", os.TempDir(), "Specify temp directory path for decrypting files")
+	command.Flags().StringArrayVar(&decryptionFlagValues.Identities, "decrypt_identity", commons.GetDefaultAgeIdentityPaths(), "Identity (age secret key or SSH private key) file for 'age' mode (can be given multiple times)")
+	command.Flags().StringVar(&decryptionFlagValues.TempPath, "decrypt_temp", os.TempDir(), "Specify temp directory path for decrypting files, used only if files cannot be decrypted while downloading (e.g., with --verify_checksum)")
 }
 
 func GetEncryptionFlagValues(command *cobra.Command) *EncryptionFlagValues {
@@ -55,6 +63,9 @@ func GetEncryptionFlagValues(command *cobra.Command) *EncryptionFlagValues {
 		encryptionFlagValues.Encryption = true
 	}
 
+	// default SSH keys may be encrypted or not usable, only given identities must be readable
+	encryptionFlagValues.DefaultIdentities = !command.Flags().Changed("encrypt_identity")
+
 	if encryptionFlagValues.NoEncryption {
 		encryptionFlagValues.Encryption = false
 	}
@@ -67,6 +78,9 @@ func GetDecryptionFlagValues(command *cobra.Command) *DecryptionFlagValues {
 		decryptionFlagValues.Decryption = true
 	}
 
+	// default SSH keys may be encrypted or not usable, only given identities must be readable
+	decryptionFlagValues.DefaultIdentities = !command.Flags().Changed("decrypt_identity")
+
 	if decryptionFlagValues.NoDecryption {
 		decryptionFlagValues.Decryption = false
 	}
@@ -0,0 +1,45 @@
+package flag
+
+import (
+	"fmt"
+
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/cyverse/gocommands/commons"
+	"github.com/spf13/cobra"
+)
+
+type EncryptionKeyFlagValues struct {
+	KeyFile    string
+	KeyCommand string
+	Keyring    bool
+	Prompt     bool
+}
+
+var (
+	encryptionKeyFlagValues EncryptionKeyFlagValues
+)
+
+func SetEncryptionKeyFlags(command *cobra.Command) {
+	command.Flags().StringVar(&encryptionKeyFlagValues.KeyFile, "key_file", "", "Read encryption key for 'winscp' and 'pgp' mode, or passphrase for 'age' mode from the file")
+	command.Flags().StringVar(&encryptionKeyFlagValues.KeyCommand, "key_command", "", "Run the command (key helper) printing encryption key to stdout")
+	command.Flags().BoolVar(&encryptionKeyFlagValues.Keyring, "key_keyring", false, "Get encryption key from OS keyring, use with --key_prompt to store the key typed in")
+	command.Flags().BoolVar(&encryptionKeyFlagValues.Prompt, "key_prompt", false, "Ask encryption key interactively")
+}
+
+func GetEncryptionKeyFlagValues() *EncryptionKeyFlagValues {
+	return &encryptionKeyFlagValues
+}
+
+// GetEncryptionKeyConfig returns sources to get encryption key from, the key given by flag comes first.
+// The key is stored in OS keyring per iRODS user. The key is asked twice to confirm if confirm is true.
+func (values *EncryptionKeyFlagValues) GetEncryptionKeyConfig(key string, account *irodsclient_types.IRODSAccount, confirm bool) *commons.EncryptionKeyConfig {
+	return &commons.EncryptionKeyConfig{
+		Key:           key,
+		KeyFile:       values.KeyFile,
+		KeyCommand:    values.KeyCommand,
+		Keyring:       values.Keyring,
+		Prompt:        values.Prompt,
+		KeyringUser:   fmt.Sprintf("%s@%s", account.ClientUser, account.ClientZone),
+		ConfirmPrompt: confirm,
+	}
+}
@@ -0,0 +1,21 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type EventFlagValues struct {
+	Target string
+}
+
+var (
+	eventFlagValues EventFlagValues
+)
+
+func SetEventFlags(command *cobra.Command) {
+	command.Flags().StringVar(&eventFlagValues.Target, "events", "", "Emit progress and lifecycle events in newline-delimited JSON to a file path, 'fd:<number>', or 'unix:<socket path>'")
+}
+
+func GetEventFlagValues() *EventFlagValues {
+	return &eventFlagValues
+}
@@ -0,0 +1,60 @@
+package flag
+
+import (
+	"strings"
+
+	"github.com/cyverse/gocommands/commons"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+)
+
+type FromReportFlagValues struct {
+	ReportPath  string
+	Statuses    []commons.TransferReportStatus
+	statusInput []string
+}
+
+var (
+	fromReportFlagValues FromReportFlagValues
+)
+
+func SetFromReportFlags(command *cobra.Command) {
+	command.Flags().StringVar(&fromReportFlagValues.ReportPath, "from_report", "", "Retry transfers in the given transfer report instead of transferring source paths")
+	command.Flags().StringSliceVar(&fromReportFlagValues.statusInput, "only", []string{string(commons.TransferReportStatusFailed)}, "Retry transfers in the given status with '--from_report' ('failed', 'skipped', or 'transferred')")
+}
+
+func GetFromReportFlagValues() *FromReportFlagValues {
+	fromReportFlagValues.Statuses = []commons.TransferReportStatus{}
+	for _, status := range fromReportFlagValues.statusInput {
+		fromReportFlagValues.Statuses = append(fromReportFlagValues.Statuses, commons.TransferReportStatus(strings.ToLower(status)))
+	}
+
+	return &fromReportFlagValues
+}
+
+// CheckStatuses returns an error if a status given can't be retried
+func (values *FromReportFlagValues) CheckStatuses() error {
+	for _, status := range values.Statuses {
+		switch status {
+		case commons.TransferReportStatusFailed, commons.TransferReportStatusSkipped, commons.TransferReportStatusTransferred:
+		default:
+			return xerrors.Errorf("unknown status %q to retry, must be one of 'failed', 'skipped', or 'transferred'", status)
+		}
+	}
+
+	return nil
+}
+
+// MinimumNArgsOrFromReport requires at least n args, or no args if '--from_report' is given
+func MinimumNArgsOrFromReport(n int) cobra.PositionalArgs {
+	return func(command *cobra.Command, args []string) error {
+		if command.Flags().Changed("from_report") {
+			if len(args) > 0 {
+				return xerrors.Errorf("source and target paths can't be given with '--from_report'")
+			}
+			return nil
+		}
+
+		return cobra.MinimumNArgs(n)(command, args)
+	}
+}
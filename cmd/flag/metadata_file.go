@@ -0,0 +1,51 @@
+package flag
+
+import (
+	"github.com/cyverse/gocommands/commons"
+	"github.com/spf13/cobra"
+)
+
+type MetadataImportFlagValues struct {
+	Format          commons.MetadataFileFormat
+	formatInput     string
+	ThreadNumber    int
+	ErrorReportPath string
+}
+
+type MetadataExportFlagValues struct {
+	Format      commons.MetadataFileFormat
+	formatInput string
+	FilePath    string
+}
+
+var (
+	metadataImportFlagValues MetadataImportFlagValues
+	metadataExportFlagValues MetadataExportFlagValues
+)
+
+func SetMetadataImportFlags(command *cobra.Command) {
+	command.Flags().StringVar(&metadataImportFlagValues.formatInput, "format", "", "Specify input format ('csv', 'json', or 'yaml'), detected from file extension if not given")
+	command.Flags().IntVar(&metadataImportFlagValues.ThreadNumber, "thread_num", commons.TransferThreadNumDefault, "Specify the number of threads adding metadata")
+	command.Flags().StringVar(&metadataImportFlagValues.ErrorReportPath, "error_report", "", "Write rows failed to import to the given file in CSV")
+}
+
+func GetMetadataImportFlagValues() *MetadataImportFlagValues {
+	metadataImportFlagValues.Format = commons.GetMetadataFileFormat(metadataImportFlagValues.formatInput)
+
+	if metadataImportFlagValues.ThreadNumber < 1 {
+		metadataImportFlagValues.ThreadNumber = 1
+	}
+
+	return &metadataImportFlagValues
+}
+
+func SetMetadataExportFlags(command *cobra.Command) {
+	command.Flags().StringVar(&metadataExportFlagValues.formatInput, "format", "", "Specify output format ('csv', 'json', or 'yaml'), detected from file extension if not given")
+	command.Flags().StringVarP(&metadataExportFlagValues.FilePath, "file", "f", "", "Write metadata to the given file instead of stdout")
+}
+
+func GetMetadataExportFlagValues() *MetadataExportFlagValues {
+	metadataExportFlagValues.Format = commons.GetMetadataFileFormat(metadataExportFlagValues.formatInput)
+
+	return &metadataExportFlagValues
+}
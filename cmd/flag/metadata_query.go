@@ -0,0 +1,27 @@
+package flag
+
+import (
+	"github.com/cyverse/gocommands/commons"
+	"github.com/spf13/cobra"
+)
+
+type MetadataQueryFlagValues struct {
+	Query           string
+	TargetType      commons.MetadataQueryTargetType
+	targetTypeInput string
+}
+
+var (
+	metadataQueryFlagValues MetadataQueryFlagValues
+)
+
+func SetMetadataQueryFlags(command *cobra.Command) {
+	command.Flags().StringVarP(&metadataQueryFlagValues.Query, "query", "Q", "", "Search items by metadata, e.g., \"project = X and run_date > 2024-01-01\" (operators: =, !=, like, not like, <, <=, >, >=, between)")
+	command.Flags().StringVar(&metadataQueryFlagValues.targetTypeInput, "query_type", string(commons.MetadataQueryTargetTypeDataObject), "Set type of items to search ('data_object', 'collection', 'user', or 'resource')")
+}
+
+func GetMetadataQueryFlagValues() *MetadataQueryFlagValues {
+	metadataQueryFlagValues.TargetType = commons.GetMetadataQueryTargetType(metadataQueryFlagValues.targetTypeInput)
+
+	return &metadataQueryFlagValues
+}
@@ -0,0 +1,26 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type MetadataSidecarFlagValues struct {
+	Export bool
+	Import bool
+}
+
+var (
+	metadataSidecarFlagValues MetadataSidecarFlagValues
+)
+
+func SetExportMetadataSidecarFlags(command *cobra.Command) {
+	command.Flags().BoolVar(&metadataSidecarFlagValues.Export, "export_meta", false, "Write metadata of each data object to a sidecar file ('<file>.meta.json')")
+}
+
+func SetImportMetadataSidecarFlags(command *cobra.Command) {
+	command.Flags().BoolVar(&metadataSidecarFlagValues.Import, "import_meta", false, "Set metadata of each data object to AVUs in its sidecar file ('<file>.meta.json'), sidecar files are not uploaded")
+}
+
+func GetMetadataSidecarFlagValues() *MetadataSidecarFlagValues {
+	return &metadataSidecarFlagValues
+}
@@ -0,0 +1,35 @@
+package flag
+
+import (
+	"github.com/cyverse/gocommands/commons"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+)
+
+type OutputFormatFlagValues struct {
+	Format      commons.OutputFormat
+	formatInput string
+}
+
+var (
+	outputFormatFlagValues OutputFormatFlagValues
+)
+
+func SetOutputFormatFlags(command *cobra.Command) {
+	command.Flags().StringVarP(&outputFormatFlagValues.formatInput, "output", "o", string(commons.OutputFormatText), "Set output format ('text', 'json', 'yaml', 'csv', or 'ndjson')")
+}
+
+func GetOutputFormatFlagValues() *OutputFormatFlagValues {
+	outputFormatFlagValues.Format = commons.GetOutputFormat(outputFormatFlagValues.formatInput)
+
+	return &outputFormatFlagValues
+}
+
+// CheckOutputFormat returns an error if the output format given is unknown
+func (values *OutputFormatFlagValues) CheckOutputFormat() error {
+	if values.Format == commons.OutputFormatUnknown {
+		return xerrors.Errorf("unknown output format %q, must be one of 'text', 'json', 'yaml', 'csv', or 'ndjson'", values.formatInput)
+	}
+
+	return nil
+}
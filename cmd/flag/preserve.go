@@ -0,0 +1,23 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type PreserveFlagValues struct {
+	PreserveMetadata bool
+	PreserveACL      bool
+}
+
+var (
+	preserveFlagValues PreserveFlagValues
+)
+
+func SetPreserveFlags(command *cobra.Command) {
+	command.Flags().BoolVar(&preserveFlagValues.PreserveMetadata, "preserve_meta", false, "Copy metadata to new data objects and collections")
+	command.Flags().BoolVar(&preserveFlagValues.PreserveACL, "preserve_acl", false, "Copy access permissions and ACL inheritance to new data objects and collections")
+}
+
+func GetPreserveFlagValues() *PreserveFlagValues {
+	return &preserveFlagValues
+}
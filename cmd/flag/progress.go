@@ -1,12 +1,19 @@
 package flag
 
 import (
+	"time"
+
+	"github.com/cyverse/gocommands/commons"
 	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
 )
 
 type ProgressFlagValues struct {
 	ShowProgress bool
 	ShowFullPath bool
+	Mode         commons.ProgressMode
+	modeInput    string
+	Interval     time.Duration
 }
 
 var (
@@ -16,8 +23,21 @@ var (
 func SetProgressFlags(command *cobra.Command) {
 	command.Flags().BoolVar(&progressFlagValues.ShowProgress, "progress", false, "Display progress bars")
 	command.Flags().BoolVar(&progressFlagValues.ShowFullPath, "show_path", false, "Display full path for progress bars")
+	command.Flags().StringVar(&progressFlagValues.modeInput, "progress_mode", "", "Set progress display ('full', 'compact', or 'plain'), defaults to 'full' for terminals and 'plain' otherwise")
+	command.Flags().DurationVar(&progressFlagValues.Interval, "progress_interval", commons.ProgressPlainIntervalDefault, "Set interval of progress lines in 'plain' progress mode")
 }
 
 func GetProgressFlagValues() *ProgressFlagValues {
+	progressFlagValues.Mode = commons.GetProgressMode(progressFlagValues.modeInput)
+
 	return &progressFlagValues
 }
+
+// CheckProgressMode returns an error if the progress mode given is unknown
+func (values *ProgressFlagValues) CheckProgressMode() error {
+	if len(values.modeInput) > 0 && values.Mode == commons.ProgressModeUnknown {
+		return xerrors.Errorf("unknown progress mode %q, must be one of 'full', 'compact', or 'plain'", values.modeInput)
+	}
+
+	return nil
+}
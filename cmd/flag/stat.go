@@ -0,0 +1,24 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type StatFlagValues struct {
+	JSONOutput bool
+}
+
+var (
+	statFlagValues StatFlagValues
+)
+
+func SetStatFlags(command *cobra.Command) {
+	command.Flags().BoolVar(&statFlagValues.JSONOutput, "json", false, "Print details in JSON format, same as '--output json'")
+
+	// replaced by --output
+	command.Flags().MarkHidden("json")
+}
+
+func GetStatFlagValues() *StatFlagValues {
+	return &statFlagValues
+}
@@ -0,0 +1,28 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type StatsFlagValues struct {
+	Stats           bool
+	OpenMetricsPath string
+}
+
+var (
+	statsFlagValues StatsFlagValues
+)
+
+func SetStatsFlags(command *cobra.Command) {
+	command.Flags().BoolVar(&statsFlagValues.Stats, "stats", false, "Display statistics of the run at the end")
+	command.Flags().StringVar(&statsFlagValues.OpenMetricsPath, "stats_file", "", "Write statistics of the run in OpenMetrics text format to the file, e.g., for node_exporter textfile collector")
+}
+
+func GetStatsFlagValues() *StatsFlagValues {
+	return &statsFlagValues
+}
+
+// IsStatsRequired returns true if statistics need to be collected
+func (values *StatsFlagValues) IsStatsRequired() bool {
+	return values.Stats || len(values.OpenMetricsPath) > 0
+}
@@ -1,21 +1,47 @@
 package flag
 
 import (
+	"strings"
+	"time"
+
+	"github.com/cyverse/gocommands/commons"
 	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
 )
 
 type TransferReportFlagValues struct {
 	ReportPath     string
 	Report         bool
 	ReportToStdout bool
+	Format         commons.TransferReportFormat
+	formatInput    string
+}
+
+type ReportSummarizeFlagValues struct {
+	SlowestCount int
+}
+
+type ReportFilterFlagValues struct {
+	Statuses    []commons.TransferReportStatus
+	statusInput []string
+	Methods     []commons.TransferMethod
+	methodInput []string
+	MinDuration time.Duration
 }
 
 var (
-	transferReportFlagValues TransferReportFlagValues
+	transferReportFlagValues  TransferReportFlagValues
+	reportSummarizeFlagValues ReportSummarizeFlagValues
+	reportFilterFlagValues    ReportFilterFlagValues
 )
 
 func SetTransferReportFlags(command *cobra.Command) {
 	command.Flags().StringVar(&transferReportFlagValues.ReportPath, "report", "", "Create a transfer report, give path to create a file, empty string or '-' will output to stdout")
+	SetTransferReportFormatFlags(command)
+}
+
+func SetTransferReportFormatFlags(command *cobra.Command) {
+	command.Flags().StringVar(&transferReportFlagValues.formatInput, "report_format", "", "Set transfer report format ('text', 'ndjson', 'csv', or 'summary'), defaults to 'text' for stdout and 'ndjson' for files")
 }
 
 func GetTransferReportFlagValues(command *cobra.Command) *TransferReportFlagValues {
@@ -29,5 +55,48 @@ func GetTransferReportFlagValues(command *cobra.Command) *TransferReportFlagValu
 		transferReportFlagValues.ReportToStdout = true
 	}
 
+	transferReportFlagValues.Format = commons.GetTransferReportFormat(transferReportFlagValues.formatInput)
+
 	return &transferReportFlagValues
 }
+
+// CheckReportFormat returns an error if the report format given is unknown
+func (values *TransferReportFlagValues) CheckReportFormat() error {
+	if len(values.formatInput) > 0 && values.Format == commons.TransferReportFormatUnknown {
+		return xerrors.Errorf("unknown report format %q, must be one of 'text', 'ndjson', 'csv', or 'summary'", values.formatInput)
+	}
+
+	return nil
+}
+
+func SetReportSummarizeFlags(command *cobra.Command) {
+	command.Flags().IntVar(&reportSummarizeFlagValues.SlowestCount, "slowest", 10, "Show the given number of slowest transfers")
+}
+
+func GetReportSummarizeFlagValues() *ReportSummarizeFlagValues {
+	if reportSummarizeFlagValues.SlowestCount < 0 {
+		reportSummarizeFlagValues.SlowestCount = 0
+	}
+
+	return &reportSummarizeFlagValues
+}
+
+func SetReportFilterFlags(command *cobra.Command) {
+	command.Flags().StringSliceVar(&reportFilterFlagValues.statusInput, "status", []string{}, "Keep transfers in the given status ('transferred', 'skipped', 'failed', 'deleted', or 'directory')")
+	command.Flags().StringSliceVar(&reportFilterFlagValues.methodInput, "method", []string{}, "Keep transfers of the given method ('get', 'put', 'bput', 'copy', or 'delete')")
+	command.Flags().DurationVar(&reportFilterFlagValues.MinDuration, "min_duration", 0, "Keep transfers taking the given duration or longer, e.g., '30s'")
+}
+
+func GetReportFilterFlagValues() *ReportFilterFlagValues {
+	reportFilterFlagValues.Statuses = []commons.TransferReportStatus{}
+	for _, status := range reportFilterFlagValues.statusInput {
+		reportFilterFlagValues.Statuses = append(reportFilterFlagValues.Statuses, commons.TransferReportStatus(strings.ToLower(status)))
+	}
+
+	reportFilterFlagValues.Methods = []commons.TransferMethod{}
+	for _, method := range reportFilterFlagValues.methodInput {
+		reportFilterFlagValues.Methods = append(reportFilterFlagValues.Methods, commons.GetTransferMethod(method))
+	}
+
+	return &reportFilterFlagValues
+}
@@ -0,0 +1,32 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type TreeFlagValues struct {
+	MaxDepth           int
+	HumanReadableSizes bool
+	JSONOutput         bool
+}
+
+var (
+	treeFlagValues TreeFlagValues
+)
+
+func SetTreeFlags(command *cobra.Command) {
+	command.Flags().IntVar(&treeFlagValues.MaxDepth, "max_depth", 0, "Descend at most the given levels of collections (0 for unlimited)")
+	command.Flags().BoolVarP(&treeFlagValues.HumanReadableSizes, "human_readable", "H", false, "Display sizes in human-readable format")
+	command.Flags().BoolVar(&treeFlagValues.JSONOutput, "json", false, "Print the tree in JSON format, same as '--output json'")
+
+	// replaced by --output
+	command.Flags().MarkHidden("json")
+}
+
+func GetTreeFlagValues() *TreeFlagValues {
+	if treeFlagValues.MaxDepth < 0 {
+		treeFlagValues.MaxDepth = 0
+	}
+
+	return &treeFlagValues
+}
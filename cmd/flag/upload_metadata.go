@@ -0,0 +1,23 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type UploadMetadataFlagValues struct {
+	Metadata         []string
+	MetadataFilePath string
+}
+
+var (
+	uploadMetadataFlagValues UploadMetadataFlagValues
+)
+
+func SetUploadMetadataFlags(command *cobra.Command) {
+	command.Flags().StringArrayVar(&uploadMetadataFlagValues.Metadata, "meta", []string{}, "Add metadata 'attr=value' or 'attr[unit]=value' to every uploaded data object")
+	command.Flags().StringVar(&uploadMetadataFlagValues.MetadataFilePath, "meta_file", "", "Add metadata to uploaded data objects as listed in a CSV, JSON, or YAML file (rows of local path, attribute, value, unit)")
+}
+
+func GetUploadMetadataFlagValues() *UploadMetadataFlagValues {
+	return &uploadMetadataFlagValues
+}
@@ -1,10 +1,9 @@
 package main
 
 import (
-	"errors"
+	"encoding/json"
 	"os"
 
-	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
 	"github.com/cyverse/gocommands/cmd/flag"
 	"github.com/cyverse/gocommands/cmd/subcmd"
 	"github.com/cyverse/gocommands/commons"
@@ -79,6 +78,9 @@ func main() {
 	subcmd.AddPwdCommand(rootCmd)
 	subcmd.AddCdCommand(rootCmd)
 	subcmd.AddLsCommand(rootCmd)
+	subcmd.AddTreeCommand(rootCmd)
+	subcmd.AddStatCommand(rootCmd)
+	subcmd.AddChmodCommand(rootCmd)
 	subcmd.AddTouchCommand(rootCmd)
 	subcmd.AddCpCommand(rootCmd)
 	subcmd.AddMvCommand(rootCmd)
@@ -91,17 +93,22 @@ func main() {
 	subcmd.AddRmdirCommand(rootCmd)
 	subcmd.AddBunCommand(rootCmd)
 	subcmd.AddBputCommand(rootCmd)
+	subcmd.AddBgetCommand(rootCmd)
 	subcmd.AddSvrinfoCommand(rootCmd)
 	subcmd.AddPsCommand(rootCmd)
 	subcmd.AddLsmetaCommand(rootCmd)
 	subcmd.AddAddmetaCommand(rootCmd)
+	subcmd.AddModmetaCommand(rootCmd)
+	subcmd.AddSetmetaCommand(rootCmd)
 	subcmd.AddRmmetaCommand(rootCmd)
+	subcmd.AddMetadataCommand(rootCmd)
 	subcmd.AddCopySftpIdCommand(rootCmd)
 	subcmd.AddLsticketCommand(rootCmd)
 	subcmd.AddRmticketCommand(rootCmd)
 	subcmd.AddMkticketCommand(rootCmd)
 	subcmd.AddModticketCommand(rootCmd)
 	subcmd.AddBcleanCommand(rootCmd)
+	subcmd.AddReportCommand(rootCmd)
 	subcmd.AddUpgradeCommand(rootCmd)
 
 	err := Execute()
@@ -112,91 +119,17 @@ func main() {
 			commons.PrintErrorf("%+v\n", err)
 		}
 
-		if os.IsNotExist(err) {
-			commons.PrintErrorf("File or directory not found!\n")
-		} else if irodsclient_types.IsConnectionConfigError(err) {
-			var connectionConfigError *irodsclient_types.ConnectionConfigError
-			if errors.As(err, &connectionConfigError) {
-				commons.PrintErrorf("Failed to establish a connection to iRODS server (host: %q, port: %d)!\n", connectionConfigError.Config.Host, connectionConfigError.Config.Port)
-			} else {
-				commons.PrintErrorf("Failed to establish a connection to iRODS server!\n")
-			}
-		} else if irodsclient_types.IsConnectionError(err) {
-			commons.PrintErrorf("Failed to establish a connection to iRODS server!\n")
-		} else if irodsclient_types.IsConnectionPoolFullError(err) {
-			var connectionPoolFullError *irodsclient_types.ConnectionPoolFullError
-			if errors.As(err, &connectionPoolFullError) {
-				commons.PrintErrorf("Failed to establish a new connection to iRODS server as connection pool is full (occupied: %d, max: %d)!\n", connectionPoolFullError.Occupied, connectionPoolFullError.Max)
-			} else {
-				commons.PrintErrorf("Failed to establish a new connection to iRODS server as connection pool is full!\n")
-			}
-		} else if irodsclient_types.IsAuthError(err) {
-			var authError *irodsclient_types.AuthError
-			if errors.As(err, &authError) {
-				commons.PrintErrorf("Authentication failed (auth scheme: %q, username: %q, zone: %q)!\n", authError.Config.AuthenticationScheme, authError.Config.ClientUser, authError.Config.ClientZone)
-			} else {
-				commons.PrintErrorf("Authentication failed!\n")
-			}
-		} else if irodsclient_types.IsFileNotFoundError(err) {
-			var fileNotFoundError *irodsclient_types.FileNotFoundError
-			if errors.As(err, &fileNotFoundError) {
-				commons.PrintErrorf("File or directory %q is not found!\n", fileNotFoundError.Path)
-			} else {
-				commons.PrintErrorf("File or directory is not found!\n")
-			}
-		} else if irodsclient_types.IsCollectionNotEmptyError(err) {
-			var collectionNotEmptyError *irodsclient_types.CollectionNotEmptyError
-			if errors.As(err, &collectionNotEmptyError) {
-				commons.PrintErrorf("Directory %q is not empty!\n", collectionNotEmptyError.Path)
-			} else {
-				commons.PrintErrorf("Directory is not empty!\n")
-			}
-		} else if irodsclient_types.IsFileAlreadyExistError(err) {
-			var fileAlreadyExistError *irodsclient_types.FileAlreadyExistError
-			if errors.As(err, &fileAlreadyExistError) {
-				commons.PrintErrorf("File or directory %q already exists!\n", fileAlreadyExistError.Path)
-			} else {
-				commons.PrintErrorf("File or directory already exists!\n")
-			}
-		} else if irodsclient_types.IsTicketNotFoundError(err) {
-			var ticketNotFoundError *irodsclient_types.TicketNotFoundError
-			if errors.As(err, &ticketNotFoundError) {
-				commons.PrintErrorf("Ticket %q is not found!\n", ticketNotFoundError.Ticket)
-			} else {
-				commons.PrintErrorf("Ticket is not found!\n")
-			}
-		} else if irodsclient_types.IsUserNotFoundError(err) {
-			var userNotFoundError *irodsclient_types.UserNotFoundError
-			if errors.As(err, &userNotFoundError) {
-				commons.PrintErrorf("User %q is not found!\n", userNotFoundError.Name)
-			} else {
-				commons.PrintErrorf("User is not found!\n")
-			}
-		} else if irodsclient_types.IsIRODSError(err) {
-			var irodsError *irodsclient_types.IRODSError
-			if errors.As(err, &irodsError) {
-				commons.PrintErrorf("iRODS Error (code: '%d', message: %q)\n", irodsError.Code, irodsError.Error())
-			} else {
-				commons.PrintErrorf("iRODS Error!\n")
-			}
-		} else if commons.IsNotDirError(err) {
-			var notDirError *commons.NotDirError
-			if errors.As(err, &notDirError) {
-				commons.PrintErrorf("Destination %q is not a directory!\n", notDirError.Path)
-			} else {
-				commons.PrintErrorf("Destination is not a directory!\n")
-			}
-		} else if commons.IsNotFileError(err) {
-			var notFileError *commons.NotFileError
-			if errors.As(err, &notFileError) {
-				commons.PrintErrorf("Destination %q is not a file!\n", notFileError.Path)
-			} else {
-				commons.PrintErrorf("Destination is not a file!\n")
-			}
-		} else {
+		classified := commons.ClassifyError(err)
+
+		if flag.GetCommonFlagValues(rootCmd).ErrorFormat == commons.OutputFormatJSON {
+			// no colors, to be parsed by other programs
+			json.NewEncoder(os.Stderr).Encode(classified)
+		} else if classified.ExitCode == commons.ExitCodeGeneralError {
 			commons.PrintErrorf("Unexpected error!\nError Trace:\n  - %+v\n", err)
+		} else {
+			commons.PrintErrorf("%s\n", classified.Message)
 		}
 
-		os.Exit(1)
+		os.Exit(int(classified.ExitCode))
 	}
 }
@@ -1,6 +1,9 @@
 package subcmd
 
 import (
+	"path/filepath"
+	"time"
+
 	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
 	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
 	"github.com/cyverse/gocommands/cmd/flag"
@@ -14,7 +17,7 @@ var bcleanCmd = &cobra.Command{
 	Use:     "bclean [collection]",
 	Aliases: []string{"bundle_clean"},
 	Short:   "Clean bundle staging directories",
-	Long:    `This cleans bundle files created by 'bput' or 'sync' for uploading data to the given iRODS collection.`,
+	Long:    `This cleans bundle files created by 'bput' or 'sync' for uploading data to the given iRODS collection. With --list, stale bundle files are listed instead of being deleted. With --scan, staging collections found anywhere under the given collection are cleaned.`,
 	RunE:    processBcleanCommand,
 }
 
@@ -24,6 +27,8 @@ func AddBcleanCommand(rootCmd *cobra.Command) {
 
 	flag.SetForceFlags(bcleanCmd, false)
 	flag.SetBundleTransferFlags(bcleanCmd, false, true)
+	flag.SetBulkRegistrationFlags(bcleanCmd, true)
+	flag.SetBundleCleanFlags(bcleanCmd)
 
 	rootCmd.AddCommand(bcleanCmd)
 }
@@ -43,6 +48,7 @@ type BcleanCommand struct {
 	commonFlagValues         *flag.CommonFlagValues
 	forceFlagValues          *flag.ForceFlagValues
 	bundleTransferFlagValues *flag.BundleTransferFlagValues
+	bundleCleanFlagValues    *flag.BundleCleanFlagValues
 
 	account    *irodsclient_types.IRODSAccount
 	filesystem *irodsclient_fs.FileSystem
@@ -57,6 +63,7 @@ func NewBcleanCommand(command *cobra.Command, args []string) (*BcleanCommand, er
 		commonFlagValues:         flag.GetCommonFlagValues(command),
 		forceFlagValues:          flag.GetForceFlagValues(),
 		bundleTransferFlagValues: flag.GetBundleTransferFlagValues(),
+		bundleCleanFlagValues:    flag.GetBundleCleanFlagValues(),
 	}
 
 	// path
@@ -87,6 +94,11 @@ func (bclean *BcleanCommand) Process() error {
 		return xerrors.Errorf("failed to input missing fields: %w", err)
 	}
 
+	err = bclean.bundleCleanFlagValues.CheckOlderThan()
+	if err != nil {
+		return err
+	}
+
 	// Create a file system
 	bclean.account = commons.GetSessionConfig().ToIRODSAccount()
 	bclean.filesystem, err = commons.GetIRODSFSClient(bclean.account)
@@ -97,27 +109,34 @@ func (bclean *BcleanCommand) Process() error {
 
 	// run
 	// clear local
-	commons.CleanUpOldLocalBundles(bclean.bundleTransferFlagValues.LocalTempPath, bclean.forceFlagValues.Force)
+	if bclean.bundleCleanFlagValues.List {
+		bclean.listLocal(bclean.bundleTransferFlagValues.LocalTempPath)
+	} else {
+		commons.CleanUpOldLocalBundles(bclean.bundleTransferFlagValues.LocalTempPath, bclean.bundleCleanFlagValues.OlderThan, bclean.forceFlagValues.Force)
+	}
 
 	// clear remote
 	if len(bclean.bundleTransferFlagValues.IRODSTempPath) > 0 {
 		logger.Debugf("clearing an irods temp directory %q", bclean.bundleTransferFlagValues.IRODSTempPath)
 
-		commons.CleanUpOldIRODSBundles(bclean.filesystem, bclean.bundleTransferFlagValues.IRODSTempPath, true, bclean.forceFlagValues.Force)
+		bclean.cleanStagingDir(bclean.bundleTransferFlagValues.IRODSTempPath)
 	} else {
 		userHome := commons.GetHomeDir()
 		homeStagingDir := commons.GetDefaultStagingDir(userHome)
-		commons.CleanUpOldIRODSBundles(bclean.filesystem, homeStagingDir, true, bclean.forceFlagValues.Force)
+		bclean.cleanStagingDir(homeStagingDir)
 	}
 
 	for _, targetPath := range bclean.targetPaths {
-		bclean.cleanOne(targetPath)
+		err = bclean.cleanOne(targetPath)
+		if err != nil {
+			return xerrors.Errorf("failed to clean %q: %w", targetPath, err)
+		}
 	}
 
 	return nil
 }
 
-func (bclean *BcleanCommand) cleanOne(targetPath string) {
+func (bclean *BcleanCommand) cleanOne(targetPath string) error {
 	logger := log.WithFields(log.Fields{
 		"package":  "subcmd",
 		"struct":   "BcleanCommand",
@@ -129,15 +148,85 @@ func (bclean *BcleanCommand) cleanOne(targetPath string) {
 	zone := bclean.account.ClientZone
 	targetPath = commons.MakeIRODSPath(cwd, home, zone, targetPath)
 
+	if bclean.bundleCleanFlagValues.Scan {
+		stagingDirPaths, err := commons.FindStagingDirs(bclean.filesystem, targetPath)
+		if err != nil {
+			return xerrors.Errorf("failed to find staging directories under %q: %w", targetPath, err)
+		}
+
+		logger.Debugf("found %d staging directories under %q", len(stagingDirPaths), targetPath)
+
+		for _, stagingDirPath := range stagingDirPaths {
+			bclean.cleanStagingDir(stagingDirPath)
+		}
+
+		return nil
+	}
+
 	if commons.IsStagingDirInTargetPath(targetPath) {
 		// target is staging dir
 		logger.Debugf("clearing an irods target directory %q", targetPath)
-		commons.CleanUpOldIRODSBundles(bclean.filesystem, targetPath, true, bclean.forceFlagValues.Force)
-		return
+		bclean.cleanStagingDir(targetPath)
+		return nil
 	}
 
 	stagingDirPath := commons.GetDefaultStagingDirInTargetPath(targetPath)
 	logger.Debugf("clearing an irods target directory %q", stagingDirPath)
 
-	commons.CleanUpOldIRODSBundles(bclean.filesystem, stagingDirPath, true, bclean.forceFlagValues.Force)
+	bclean.cleanStagingDir(stagingDirPath)
+	return nil
+}
+
+func (bclean *BcleanCommand) cleanStagingDir(stagingDirPath string) {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "BcleanCommand",
+		"function": "cleanStagingDir",
+	})
+
+	if bclean.bundleCleanFlagValues.List {
+		bundles, err := commons.ListOldIRODSBundles(bclean.filesystem, stagingDirPath, bclean.bundleCleanFlagValues.OlderThan)
+		if err != nil {
+			logger.WithError(err).Debugf("failed to list old irods bundles in %q", stagingDirPath)
+			return
+		}
+
+		bclean.printBundles(stagingDirPath, bundles)
+		return
+	}
+
+	err := commons.CleanUpOldIRODSBundles(bclean.filesystem, stagingDirPath, true, bclean.bundleCleanFlagValues.OlderThan, bclean.forceFlagValues.Force)
+	if err != nil {
+		logger.WithError(err).Debugf("failed to clean up old irods bundles in %q", stagingDirPath)
+	}
+}
+
+func (bclean *BcleanCommand) listLocal(localTempDirPath string) {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "BcleanCommand",
+		"function": "listLocal",
+	})
+
+	bundles, err := commons.ListOldLocalBundles(localTempDirPath, bclean.bundleCleanFlagValues.OlderThan)
+	if err != nil {
+		logger.WithError(err).Warnf("failed to list old local bundles in %q", localTempDirPath)
+		return
+	}
+
+	bclean.printBundles(localTempDirPath+" (local)", bundles)
+}
+
+func (bclean *BcleanCommand) printBundles(stagingDirName string, bundles []*commons.StaleBundle) {
+	if len(bundles) == 0 {
+		return
+	}
+
+	now := time.Now()
+
+	commons.Printf("%s:\n", stagingDirName)
+	for _, bundle := range bundles {
+		age := bundle.GetAge(now).Truncate(time.Second)
+		commons.Printf("  %d\t%s\t%s\t%s\n", bundle.Size, commons.MakeDateTimeString(bundle.ModTime), age.String(), filepath.Base(bundle.Path))
+	}
 }
@@ -0,0 +1,495 @@
+package subcmd
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/cyverse/gocommands/cmd/flag"
+	"github.com/cyverse/gocommands/commons"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+)
+
+var bgetCmd = &cobra.Command{
+	Use:     "bget [data-object1] [data-object2] [collection1] ... [local dir]",
+	Aliases: []string{"bundle_get"},
+	Short:   "Bundle-download iRODS data-objects or collections",
+	Long:    `This downloads iRODS data-objects or collections to the given local directory. The data-objects are bundled with TAR in the iRODS to maximize data transfer bandwidth, then extracted locally.`,
+	RunE:    processBgetCommand,
+	Args:    cobra.MinimumNArgs(1),
+}
+
+func AddBgetCommand(rootCmd *cobra.Command) {
+	// attach common flags
+	flag.SetCommonFlags(bgetCmd, false)
+
+	flag.SetBundleTransferFlags(bgetCmd, false, false)
+	flag.SetParallelTransferFlags(bgetCmd, false, false)
+	flag.SetForceFlags(bgetCmd, false)
+	flag.SetRecursiveFlags(bgetCmd, true)
+	flag.SetProgressFlags(bgetCmd)
+	flag.SetEventFlags(bgetCmd)
+	flag.SetStatsFlags(bgetCmd)
+	flag.SetRetryFlags(bgetCmd)
+	flag.SetDifferentialTransferFlags(bgetCmd, false)
+	flag.SetChecksumFlags(bgetCmd, true, false)
+	flag.SetNoRootFlags(bgetCmd)
+	flag.SetSyncFlags(bgetCmd, true)
+	flag.SetPostTransferFlagValues(bgetCmd)
+	flag.SetHiddenFileFlags(bgetCmd)
+	flag.SetTransferReportFlags(bgetCmd)
+
+	rootCmd.AddCommand(bgetCmd)
+}
+
+func processBgetCommand(command *cobra.Command, args []string) error {
+	bget, err := NewBgetCommand(command, args)
+	if err != nil {
+		return err
+	}
+
+	err = bget.Process()
+
+	statsErr := bget.transferStats.Finish(err)
+	if err != nil {
+		return err
+	}
+
+	return statsErr
+}
+
+type BgetCommand struct {
+	command *cobra.Command
+
+	commonFlagValues               *flag.CommonFlagValues
+	bundleTransferFlagValues       *flag.BundleTransferFlagValues
+	parallelTransferFlagValues     *flag.ParallelTransferFlagValues
+	forceFlagValues                *flag.ForceFlagValues
+	recursiveFlagValues            *flag.RecursiveFlagValues
+	progressFlagValues             *flag.ProgressFlagValues
+	eventFlagValues                *flag.EventFlagValues
+	statsFlagValues                *flag.StatsFlagValues
+	retryFlagValues                *flag.RetryFlagValues
+	differentialTransferFlagValues *flag.DifferentialTransferFlagValues
+	checksumFlagValues             *flag.ChecksumFlagValues
+	noRootFlagValues               *flag.NoRootFlagValues
+	syncFlagValues                 *flag.SyncFlagValues
+	postTransferFlagValues         *flag.PostTransferFlagValues
+	hiddenFileFlagValues           *flag.HiddenFileFlagValues
+	transferReportFlagValues       *flag.TransferReportFlagValues
+
+	maxConnectionNum int
+
+	account    *irodsclient_types.IRODSAccount
+	filesystem *irodsclient_fs.FileSystem
+
+	sourcePaths    []string
+	targetPath     string
+	stagingDirPath string
+
+	bundleDownloadManager *commons.BundleDownloadManager
+	transferReportManager *commons.TransferReportManager
+	eventWriter           *commons.EventWriter
+	transferStats         *commons.TransferStats
+	downloadTarget        *DownloadTarget
+}
+
+func NewBgetCommand(command *cobra.Command, args []string) (*BgetCommand, error) {
+	bget := &BgetCommand{
+		command: command,
+
+		commonFlagValues:               flag.GetCommonFlagValues(command),
+		bundleTransferFlagValues:       flag.GetBundleTransferFlagValues(),
+		parallelTransferFlagValues:     flag.GetParallelTransferFlagValues(),
+		forceFlagValues:                flag.GetForceFlagValues(),
+		recursiveFlagValues:            flag.GetRecursiveFlagValues(),
+		progressFlagValues:             flag.GetProgressFlagValues(),
+		eventFlagValues:                flag.GetEventFlagValues(),
+		statsFlagValues:                flag.GetStatsFlagValues(),
+		retryFlagValues:                flag.GetRetryFlagValues(),
+		differentialTransferFlagValues: flag.GetDifferentialTransferFlagValues(),
+		checksumFlagValues:             flag.GetChecksumFlagValues(),
+		noRootFlagValues:               flag.GetNoRootFlagValues(),
+		syncFlagValues:                 flag.GetSyncFlagValues(),
+		postTransferFlagValues:         flag.GetPostTransferFlagValues(),
+		hiddenFileFlagValues:           flag.GetHiddenFileFlagValues(),
+		transferReportFlagValues:       flag.GetTransferReportFlagValues(command),
+	}
+
+	bget.maxConnectionNum = bget.parallelTransferFlagValues.ThreadNumber + 2 // 2 for staging and bundling
+
+	// path
+	bget.targetPath = "./"
+	bget.sourcePaths = args
+
+	if len(args) >= 2 {
+		bget.targetPath = args[len(args)-1]
+		bget.sourcePaths = args[:len(args)-1]
+	}
+
+	if bget.noRootFlagValues.NoRoot && len(bget.sourcePaths) > 1 {
+		return nil, xerrors.Errorf("failed to get multiple source collections without creating root directory")
+	}
+
+	err := bget.transferReportFlagValues.CheckReportFormat()
+	if err != nil {
+		return nil, err
+	}
+
+	err = bget.progressFlagValues.CheckProgressMode()
+	if err != nil {
+		return nil, err
+	}
+
+	return bget, nil
+}
+
+func (bget *BgetCommand) Process() error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "BgetCommand",
+		"function": "Process",
+	})
+
+	cont, err := flag.ProcessCommonFlags(bget.command)
+	if err != nil {
+		return xerrors.Errorf("failed to process common flags: %w", err)
+	}
+
+	if !cont {
+		return nil
+	}
+
+	// handle local flags
+	_, err = commons.InputMissingFields()
+	if err != nil {
+		return xerrors.Errorf("failed to input missing fields: %w", err)
+	}
+
+	// clear local
+	// delete local bundles before entering to retry
+	if bget.bundleTransferFlagValues.ClearOld {
+		commons.CleanUpOldLocalBundles(bget.bundleTransferFlagValues.LocalTempPath, 0, true)
+	}
+
+	// handle retry
+	if bget.retryFlagValues.RetryNumber > 0 && !bget.retryFlagValues.RetryChild {
+		err = commons.RunWithRetry(bget.retryFlagValues.RetryNumber, bget.retryFlagValues.RetryIntervalSeconds)
+		if err != nil {
+			return xerrors.Errorf("failed to run with retry %d: %w", bget.retryFlagValues.RetryNumber, err)
+		}
+		return nil
+	}
+
+	// statistics, collected from here as errors in connecting are worth recording as well
+	if bget.statsFlagValues.IsStatsRequired() {
+		bget.transferStats = commons.NewTransferStats("bget", bget.statsFlagValues.Stats, bget.statsFlagValues.OpenMetricsPath)
+	}
+
+	// Create a file system
+	bget.account = commons.GetSessionConfig().ToIRODSAccount()
+	bget.filesystem, err = commons.GetIRODSFSClientForLargeFileIO(bget.account, bget.maxConnectionNum, bget.parallelTransferFlagValues.TCPBufferSize)
+	if err != nil {
+		return xerrors.Errorf("failed to get iRODS FS Client: %w", err)
+	}
+	defer bget.filesystem.Release()
+
+	// transfer report
+	bget.transferReportManager, err = commons.NewTransferReportManager(bget.transferReportFlagValues.Report, bget.transferReportFlagValues.ReportPath, bget.transferReportFlagValues.ReportToStdout, bget.transferReportFlagValues.Format)
+	if err != nil {
+		return xerrors.Errorf("failed to create transfer report manager: %w", err)
+	}
+	defer bget.transferReportManager.Release()
+
+	bget.downloadTarget = NewDownloadTarget(bget.forceFlagValues, bget.syncFlagValues, bget.differentialTransferFlagValues, bget.transferReportManager)
+
+	// events
+	if len(bget.eventFlagValues.Target) > 0 {
+		bget.eventWriter, err = commons.NewEventWriter(bget.eventFlagValues.Target)
+		if err != nil {
+			return xerrors.Errorf("failed to create event writer: %w", err)
+		}
+		defer bget.eventWriter.Release()
+	}
+	bget.transferReportManager.SetEventWriter(bget.eventWriter)
+	bget.transferReportManager.SetTransferStats(bget.transferStats)
+
+	// run
+	// target must be a dir
+	err = bget.ensureTargetIsDir(bget.targetPath)
+	if err != nil {
+		return xerrors.Errorf("target path %q is not a directory: %w", bget.targetPath, err)
+	}
+
+	// get staging path
+	bget.stagingDirPath, err = bget.getStagingDir()
+	if err != nil {
+		return xerrors.Errorf("failed to get staging path: %w", err)
+	}
+
+	// clear old irods bundles
+	if bget.bundleTransferFlagValues.ClearOld {
+		logger.Debugf("clearing an irods temp directory %q", bget.stagingDirPath)
+		err = commons.CleanUpOldIRODSBundles(bget.filesystem, bget.stagingDirPath, false, 0, true)
+		if err != nil {
+			return xerrors.Errorf("failed to clean up old irods bundle files in %q: %w", bget.stagingDirPath, err)
+		}
+	}
+
+	// bundle download manager
+	bget.bundleDownloadManager = commons.NewBundleDownloadManager(bget.account, bget.filesystem, bget.transferReportManager, bget.bundleTransferFlagValues.MinFileNum, bget.bundleTransferFlagValues.MaxFileNum, bget.bundleTransferFlagValues.MaxFileSize, bget.parallelTransferFlagValues.SingleThread, bget.parallelTransferFlagValues.ThreadNumber, bget.parallelTransferFlagValues.RedirectToResource, bget.parallelTransferFlagValues.Icat, bget.checksumFlagValues.VerifyChecksum, bget.bundleTransferFlagValues.LocalTempPath, bget.stagingDirPath, bget.progressFlagValues.ShowProgress, bget.progressFlagValues.ShowFullPath)
+	bget.bundleDownloadManager.SetProgressMode(bget.progressFlagValues.Mode, bget.progressFlagValues.Interval)
+	bget.bundleDownloadManager.SetEventWriter(bget.eventWriter)
+	bget.bundleDownloadManager.SetTransferStats(bget.transferStats)
+	bget.bundleDownloadManager.Start()
+
+	scheduling := true
+	defer func() {
+		if scheduling {
+			// wait for bundles scheduled, so their staging copies are removed
+			bget.bundleDownloadManager.DoneScheduling()
+			bget.bundleDownloadManager.Wait()
+		}
+	}()
+
+	// run
+	listingStartTime := time.Now()
+	for _, sourcePath := range bget.sourcePaths {
+		err = bget.bgetOne(sourcePath, bget.targetPath)
+		if err != nil {
+			return xerrors.Errorf("failed to bundle-get %q to %q: %w", sourcePath, bget.targetPath, err)
+		}
+	}
+
+	bget.transferStats.AddListingTime(time.Since(listingStartTime))
+	scheduling = false
+	bget.bundleDownloadManager.DoneScheduling()
+	err = bget.bundleDownloadManager.Wait()
+	if err != nil {
+		return xerrors.Errorf("failed to bundle-get: %w", err)
+	}
+
+	// delete on success
+	if bget.postTransferFlagValues.DeleteOnSuccess {
+		for _, sourcePath := range bget.sourcePaths {
+			logger.Infof("deleting source %q after successful data get", sourcePath)
+
+			err := bget.deleteOnSuccess(sourcePath)
+			if err != nil {
+				return xerrors.Errorf("failed to delete source %q: %w", sourcePath, err)
+			}
+		}
+	}
+
+	// delete extra
+	if bget.syncFlagValues.Delete {
+		logger.Infof("deleting extra files and directories under %q", bget.targetPath)
+
+		err = bget.downloadTarget.deleteExtra(bget.targetPath)
+		if err != nil {
+			return xerrors.Errorf("failed to delete extra files: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (bget *BgetCommand) ensureTargetIsDir(targetPath string) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "BgetCommand",
+		"function": "ensureTargetIsDir",
+	})
+
+	targetPath = commons.MakeLocalPath(targetPath)
+
+	targetStat, err := os.Stat(targetPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// not exist
+			logger.Debugf("creating a target directory %q", targetPath)
+			return os.MkdirAll(targetPath, 0766)
+		}
+
+		return xerrors.Errorf("failed to stat %q: %w", targetPath, err)
+	}
+
+	if !targetStat.IsDir() {
+		return commons.NewNotDirError(targetPath)
+	}
+
+	return nil
+}
+
+// getStagingDir returns a collection to bundle data objects in, the home collection is used by default
+// as data objects to download may be in collections the user can't write to
+func (bget *BgetCommand) getStagingDir() (string, error) {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "BgetCommand",
+		"function": "getStagingDir",
+	})
+
+	cwd := commons.GetCWD()
+	home := commons.GetHomeDir()
+	zone := bget.account.ClientZone
+
+	stagingPath := commons.GetDefaultStagingDir(home)
+	if len(bget.bundleTransferFlagValues.IRODSTempPath) > 0 {
+		stagingPath = commons.MakeIRODSPath(cwd, home, zone, bget.bundleTransferFlagValues.IRODSTempPath)
+	}
+
+	// is it safe?
+	logger.Debugf("validating staging directory %q", stagingPath)
+
+	err := commons.IsSafeStagingDir(stagingPath)
+	if err != nil {
+		logger.Debugf("staging path %q is not safe", stagingPath)
+
+		return "", xerrors.Errorf("staging path %q is not safe: %w", stagingPath, err)
+	}
+
+	tempEntry, err := bget.filesystem.Stat(stagingPath)
+	if err != nil {
+		if !irodsclient_types.IsFileNotFoundError(err) {
+			return "", xerrors.Errorf("failed to stat %q: %w", stagingPath, err)
+		}
+
+		// not exist
+		err = bget.filesystem.MakeDir(stagingPath, true)
+		if err != nil {
+			return "", xerrors.Errorf("failed to make a collection %q: %w", stagingPath, err)
+		}
+	} else if !tempEntry.IsDir() {
+		return "", xerrors.Errorf("staging path %q is a file", stagingPath)
+	}
+
+	logger.Debugf("use staging path %q", stagingPath)
+	return stagingPath, nil
+}
+
+func (bget *BgetCommand) bgetOne(sourcePath string, targetPath string) error {
+	cwd := commons.GetCWD()
+	home := commons.GetHomeDir()
+	zone := bget.account.ClientZone
+	sourcePath = commons.MakeIRODSPath(cwd, home, zone, sourcePath)
+	targetPath = commons.MakeLocalPath(targetPath)
+
+	sourceEntry, err := bget.filesystem.Stat(sourcePath)
+	if err != nil {
+		return xerrors.Errorf("failed to stat %q: %w", sourcePath, err)
+	}
+
+	if sourceEntry.IsDir() {
+		// dir
+		if !bget.noRootFlagValues.NoRoot {
+			targetPath = commons.MakeTargetLocalFilePath(sourcePath, targetPath)
+		}
+
+		return bget.getDir(sourceEntry, targetPath)
+	}
+
+	// file
+	targetPath = commons.MakeTargetLocalFilePath(sourcePath, targetPath)
+	return bget.getFile(sourceEntry, targetPath)
+}
+
+func (bget *BgetCommand) scheduleGet(sourceEntry *irodsclient_fs.Entry, targetPath string) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "BgetCommand",
+		"function": "scheduleGet",
+	})
+
+	err := bget.bundleDownloadManager.Schedule(sourceEntry, targetPath)
+	if err != nil {
+		return xerrors.Errorf("failed to schedule a data object %q: %w", sourceEntry.Path, err)
+	}
+
+	logger.Debugf("scheduled a data object download %q to %q", sourceEntry.Path, targetPath)
+
+	return nil
+}
+
+func (bget *BgetCommand) getFile(sourceEntry *irodsclient_fs.Entry, targetPath string) error {
+	targetStat, err := bget.downloadTarget.prepareFile(targetPath)
+	if err != nil {
+		return err
+	}
+
+	if targetStat != nil {
+		skip, err := bget.downloadTarget.skipFile(sourceEntry, targetPath, targetStat)
+		if err != nil || skip {
+			return err
+		}
+	}
+
+	// schedule
+	return bget.scheduleGet(sourceEntry, targetPath)
+}
+
+func (bget *BgetCommand) getDir(sourceEntry *irodsclient_fs.Entry, targetPath string) error {
+	err := bget.downloadTarget.prepareDir(sourceEntry, targetPath)
+	if err != nil {
+		return err
+	}
+
+	// get entries
+	entries, err := bget.filesystem.List(sourceEntry.Path)
+	if err != nil {
+		return xerrors.Errorf("failed to list a directory %q: %w", sourceEntry.Path, err)
+	}
+
+	for _, entry := range entries {
+		if bget.hiddenFileFlagValues.Exclude {
+			if strings.HasPrefix(entry.Name, ".") {
+				continue
+			}
+		}
+
+		newEntryPath := commons.MakeTargetLocalFilePath(entry.Path, targetPath)
+
+		if entry.IsDir() {
+			// dir
+			if entry.Path == bget.stagingDirPath {
+				// bundles being made for this download
+				continue
+			}
+
+			err = bget.getDir(entry, newEntryPath)
+			if err != nil {
+				return err
+			}
+		} else {
+			// file
+			err = bget.getFile(entry, newEntryPath)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (bget *BgetCommand) deleteOnSuccess(sourcePath string) error {
+	cwd := commons.GetCWD()
+	home := commons.GetHomeDir()
+	zone := bget.account.ClientZone
+	sourcePath = commons.MakeIRODSPath(cwd, home, zone, sourcePath)
+
+	sourceEntry, err := bget.filesystem.Stat(sourcePath)
+	if err != nil {
+		return xerrors.Errorf("failed to stat %q: %w", sourcePath, err)
+	}
+
+	if sourceEntry.IsDir() {
+		return bget.filesystem.RemoveDir(sourcePath, true, true)
+	}
+
+	return bget.filesystem.RemoveFile(sourcePath, true)
+}
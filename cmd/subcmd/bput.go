@@ -25,7 +25,7 @@ var bputCmd = &cobra.Command{
 	Use:     "bput [local file1] [local file2] [local dir1] ... [collection]",
 	Aliases: []string{"bundle_put"},
 	Short:   "Bundle-upload files or directories",
-	Long:    `This uploads files or directories to the given iRODS collection. The files or directories are bundled with TAR to maximize data transfer bandwidth, then extracted in the iRODS.`,
+	Long:    `This uploads files or directories to the given iRODS collection. The files or directories are bundled with TAR to maximize data transfer bandwidth, then extracted in the iRODS. Bundles can be compressed with gzip or bzip2, or made in ZIP format, to reduce data to transfer.`,
 	RunE:    processBputCommand,
 	Args:    cobra.MinimumNArgs(1),
 }
@@ -35,10 +35,15 @@ func AddBputCommand(rootCmd *cobra.Command) {
 	flag.SetCommonFlags(bputCmd, false)
 
 	flag.SetBundleTransferFlags(bputCmd, false, false)
+	flag.SetBulkRegistrationFlags(bputCmd, false)
+	flag.SetBundleFormatFlags(bputCmd)
+	flag.SetBundlePackingFlags(bputCmd)
 	flag.SetParallelTransferFlags(bputCmd, false, false)
 	flag.SetForceFlags(bputCmd, true)
 	flag.SetRecursiveFlags(bputCmd, true)
 	flag.SetProgressFlags(bputCmd)
+	flag.SetEventFlags(bputCmd)
+	flag.SetStatsFlags(bputCmd)
 	flag.SetRetryFlags(bputCmd)
 	flag.SetDifferentialTransferFlags(bputCmd, false)
 	flag.SetChecksumFlags(bputCmd, true, true)
@@ -47,6 +52,7 @@ func AddBputCommand(rootCmd *cobra.Command) {
 	flag.SetPostTransferFlagValues(bputCmd)
 	flag.SetHiddenFileFlags(bputCmd)
 	flag.SetTransferReportFlags(bputCmd)
+	flag.SetUploadMetadataFlags(bputCmd)
 
 	rootCmd.AddCommand(bputCmd)
 }
@@ -57,7 +63,14 @@ func processBputCommand(command *cobra.Command, args []string) error {
 		return err
 	}
 
-	return bput.Process()
+	err = bput.Process()
+
+	statsErr := bput.transferStats.Finish(err)
+	if err != nil {
+		return err
+	}
+
+	return statsErr
 }
 
 type BputCommand struct {
@@ -69,6 +82,8 @@ type BputCommand struct {
 	forceFlagValues                *flag.ForceFlagValues
 	recursiveFlagValues            *flag.RecursiveFlagValues
 	progressFlagValues             *flag.ProgressFlagValues
+	eventFlagValues                *flag.EventFlagValues
+	statsFlagValues                *flag.StatsFlagValues
 	retryFlagValues                *flag.RetryFlagValues
 	differentialTransferFlagValues *flag.DifferentialTransferFlagValues
 	checksumFlagValues             *flag.ChecksumFlagValues
@@ -77,6 +92,7 @@ type BputCommand struct {
 	postTransferFlagValues         *flag.PostTransferFlagValues
 	hiddenFileFlagValues           *flag.HiddenFileFlagValues
 	transferReportFlagValues       *flag.TransferReportFlagValues
+	uploadMetadataFlagValues       *flag.UploadMetadataFlagValues
 
 	maxConnectionNum int
 
@@ -88,6 +104,9 @@ type BputCommand struct {
 
 	bundleTransferManager *commons.BundleTransferManager
 	transferReportManager *commons.TransferReportManager
+	eventWriter           *commons.EventWriter
+	transferStats         *commons.TransferStats
+	uploadMetadataManager *commons.UploadMetadataManager
 	updatedPathMap        map[string]bool
 }
 
@@ -101,6 +120,8 @@ func NewBputCommand(command *cobra.Command, args []string) (*BputCommand, error)
 		forceFlagValues:                flag.GetForceFlagValues(),
 		recursiveFlagValues:            flag.GetRecursiveFlagValues(),
 		progressFlagValues:             flag.GetProgressFlagValues(),
+		eventFlagValues:                flag.GetEventFlagValues(),
+		statsFlagValues:                flag.GetStatsFlagValues(),
 		retryFlagValues:                flag.GetRetryFlagValues(),
 		differentialTransferFlagValues: flag.GetDifferentialTransferFlagValues(),
 		checksumFlagValues:             flag.GetChecksumFlagValues(),
@@ -109,6 +130,7 @@ func NewBputCommand(command *cobra.Command, args []string) (*BputCommand, error)
 		postTransferFlagValues:         flag.GetPostTransferFlagValues(),
 		hiddenFileFlagValues:           flag.GetHiddenFileFlagValues(),
 		transferReportFlagValues:       flag.GetTransferReportFlagValues(command),
+		uploadMetadataFlagValues:       flag.GetUploadMetadataFlagValues(),
 
 		updatedPathMap: map[string]bool{},
 	}
@@ -128,6 +150,26 @@ func NewBputCommand(command *cobra.Command, args []string) (*BputCommand, error)
 		return nil, xerrors.Errorf("failed to put multiple source collections without creating root directory")
 	}
 
+	err := bput.transferReportFlagValues.CheckReportFormat()
+	if err != nil {
+		return nil, err
+	}
+
+	err = bput.progressFlagValues.CheckProgressMode()
+	if err != nil {
+		return nil, err
+	}
+
+	err = bput.bundleTransferFlagValues.CheckBundleFormat()
+	if err != nil {
+		return nil, err
+	}
+
+	err = bput.bundleTransferFlagValues.CheckBundlePacking()
+	if err != nil {
+		return nil, err
+	}
+
 	return bput, nil
 }
 
@@ -156,7 +198,7 @@ func (bput *BputCommand) Process() error {
 	// clear local
 	// delete local bundles before entering to retry
 	if bput.bundleTransferFlagValues.ClearOld {
-		commons.CleanUpOldLocalBundles(bput.bundleTransferFlagValues.LocalTempPath, true)
+		commons.CleanUpOldLocalBundles(bput.bundleTransferFlagValues.LocalTempPath, 0, true)
 	}
 
 	// handle retry
@@ -168,6 +210,11 @@ func (bput *BputCommand) Process() error {
 		return nil
 	}
 
+	// statistics, collected from here as errors in connecting are worth recording as well
+	if bput.statsFlagValues.IsStatsRequired() {
+		bput.transferStats = commons.NewTransferStats("bput", bput.statsFlagValues.Stats, bput.statsFlagValues.OpenMetricsPath)
+	}
+
 	// Create a file system
 	bput.account = commons.GetSessionConfig().ToIRODSAccount()
 	bput.filesystem, err = commons.GetIRODSFSClientForLargeFileIO(bput.account, bput.maxConnectionNum, bput.parallelTransferFlagValues.TCPBufferSize)
@@ -177,12 +224,29 @@ func (bput *BputCommand) Process() error {
 	defer bput.filesystem.Release()
 
 	// transfer report
-	bput.transferReportManager, err = commons.NewTransferReportManager(bput.transferReportFlagValues.Report, bput.transferReportFlagValues.ReportPath, bput.transferReportFlagValues.ReportToStdout)
+	bput.transferReportManager, err = commons.NewTransferReportManager(bput.transferReportFlagValues.Report, bput.transferReportFlagValues.ReportPath, bput.transferReportFlagValues.ReportToStdout, bput.transferReportFlagValues.Format)
 	if err != nil {
 		return xerrors.Errorf("failed to create transfer report manager: %w", err)
 	}
 	defer bput.transferReportManager.Release()
 
+	// events
+	if len(bput.eventFlagValues.Target) > 0 {
+		bput.eventWriter, err = commons.NewEventWriter(bput.eventFlagValues.Target)
+		if err != nil {
+			return xerrors.Errorf("failed to create event writer: %w", err)
+		}
+		defer bput.eventWriter.Release()
+	}
+	bput.transferReportManager.SetEventWriter(bput.eventWriter)
+	bput.transferReportManager.SetTransferStats(bput.transferStats)
+
+	// metadata to attach
+	bput.uploadMetadataManager, err = commons.NewUploadMetadataManager(bput.uploadMetadataFlagValues.Metadata, bput.uploadMetadataFlagValues.MetadataFilePath)
+	if err != nil {
+		return xerrors.Errorf("failed to create upload metadata manager: %w", err)
+	}
+
 	// run
 	// target must be a dir
 	err = bput.ensureTargetIsDir(bput.targetPath)
@@ -199,7 +263,7 @@ func (bput *BputCommand) Process() error {
 	// clear old irods bundles
 	if bput.bundleTransferFlagValues.ClearOld {
 		logger.Debugf("clearing an irods temp directory %q", stagingDirPath)
-		err = commons.CleanUpOldIRODSBundles(bput.filesystem, stagingDirPath, false, true)
+		err = commons.CleanUpOldIRODSBundles(bput.filesystem, stagingDirPath, false, 0, true)
 		if err != nil {
 			return xerrors.Errorf("failed to clean up old irods bundle files in %q: %w", stagingDirPath, err)
 		}
@@ -219,9 +283,16 @@ func (bput *BputCommand) Process() error {
 
 	// bundle transfer manager
 	bput.bundleTransferManager = commons.NewBundleTransferManager(bput.account, bput.filesystem, bput.transferReportManager, bput.targetPath, localBundleRootPath, bput.bundleTransferFlagValues.MinFileNum, bput.bundleTransferFlagValues.MaxFileNum, bput.bundleTransferFlagValues.MaxFileSize, bput.parallelTransferFlagValues.SingleThread, bput.parallelTransferFlagValues.ThreadNumber, bput.parallelTransferFlagValues.RedirectToResource, bput.parallelTransferFlagValues.Icat, bput.bundleTransferFlagValues.LocalTempPath, stagingDirPath, bput.bundleTransferFlagValues.NoBulkRegistration, bput.progressFlagValues.ShowProgress, bput.progressFlagValues.ShowFullPath)
+	bput.bundleTransferManager.SetBundleFormat(bput.bundleTransferFlagValues.Format)
+	bput.bundleTransferManager.SetBundlePacking(bput.bundleTransferFlagValues.PackingStrategy, bput.bundleTransferFlagValues.DirectUploadSize)
+	bput.bundleTransferManager.SetUploadMetadataManager(bput.uploadMetadataManager)
+	bput.bundleTransferManager.SetProgressMode(bput.progressFlagValues.Mode, bput.progressFlagValues.Interval)
+	bput.bundleTransferManager.SetEventWriter(bput.eventWriter)
+	bput.bundleTransferManager.SetTransferStats(bput.transferStats)
 	bput.bundleTransferManager.Start()
 
 	// run
+	listingStartTime := time.Now()
 	for _, sourcePath := range bput.sourcePaths {
 		err = bput.bputOne(sourcePath)
 		if err != nil {
@@ -229,6 +300,7 @@ func (bput *BputCommand) Process() error {
 		}
 	}
 
+	bput.transferStats.AddListingTime(time.Since(listingStartTime))
 	bput.bundleTransferManager.DoneScheduling()
 	err = bput.bundleTransferManager.Wait()
 	if err != nil {
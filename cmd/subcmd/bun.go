@@ -13,12 +13,12 @@ import (
 )
 
 var bunCmd = &cobra.Command{
-	Use:     "bun [data-object1] [data-object2] ... [target collection]",
+	Use:     "bun [-x|-C|-l] [data-object or collection1] [data-object or collection2] ...",
 	Aliases: []string{"bundle", "ibun"},
-	Short:   "Extract iRODS data-objects in a structured file format to target collection",
-	Long:    `This extracts iRODS data-objects in a structured file format (e.g., zip and tar) to the given target collection.`,
+	Short:   "Extract, create, or list iRODS data-objects in a structured file format",
+	Long:    `This extracts iRODS data-objects in a structured file format (e.g., zip and tar) to the given target collection (bun -x [data-object1] [data-object2] ... [target collection]), creates a structured file from the given collection in iRODS (bun -C [structured file] [source collection]), or lists entries in structured files without extracting them (bun -l [data-object1] [data-object2] ...).`,
 	RunE:    processBunCommand,
-	Args:    cobra.MinimumNArgs(2),
+	Args:    cobra.MinimumNArgs(1),
 }
 
 func AddBunCommand(rootCmd *cobra.Command) {
@@ -67,11 +67,26 @@ func NewBunCommand(command *cobra.Command, args []string) (*BunCommand, error) {
 	}
 
 	// path
-	bun.targetPath = args[len(args)-1]
-	bun.sourcePaths = args[:len(args)-1]
+	switch {
+	case bun.bundleFlagValues.Extract:
+		if len(args) < 2 {
+			return nil, xerrors.Errorf("extract mode requires data objects and a target collection")
+		}
+
+		bun.targetPath = args[len(args)-1]
+		bun.sourcePaths = args[:len(args)-1]
+	case bun.bundleFlagValues.Create:
+		if len(args) != 2 {
+			return nil, xerrors.Errorf("create mode requires a structured file and a source collection")
+		}
 
-	if !bun.bundleFlagValues.Extract {
-		return nil, xerrors.Errorf("support only extract mode")
+		// same order as ibun, the structured file comes first
+		bun.targetPath = args[0]
+		bun.sourcePaths = args[1:]
+	case bun.bundleFlagValues.List:
+		bun.sourcePaths = args
+	default:
+		return nil, xerrors.Errorf("one of extract (-x), create (-C), or list (-l) mode must be given")
 	}
 
 	return bun, nil
@@ -103,7 +118,8 @@ func (bun *BunCommand) Process() error {
 
 	// Expand wildcards
 	if bun.wildcardSearchFlagValues.WildcardSearch {
-		bun.sourcePaths, err = commons.ExpandWildcards(bun.filesystem, bun.account, bun.sourcePaths, false, true)
+		// source of create mode is a collection
+		bun.sourcePaths, err = commons.ExpandWildcards(bun.filesystem, bun.account, bun.sourcePaths, bun.bundleFlagValues.Create, !bun.bundleFlagValues.Create)
 		if err != nil {
 			return xerrors.Errorf("failed to expand wildcards: %w", err)
 		}
@@ -116,6 +132,16 @@ func (bun *BunCommand) Process() error {
 			if err != nil {
 				return xerrors.Errorf("failed to extract bundle file %q to %q: %w", sourcePath, bun.targetPath, err)
 			}
+		} else if bun.bundleFlagValues.Create {
+			err = bun.createOne(sourcePath, bun.targetPath)
+			if err != nil {
+				return xerrors.Errorf("failed to create bundle file %q from %q: %w", bun.targetPath, sourcePath, err)
+			}
+		} else if bun.bundleFlagValues.List {
+			err = bun.listOne(sourcePath)
+			if err != nil {
+				return xerrors.Errorf("failed to list bundle file %q: %w", sourcePath, err)
+			}
 		}
 	}
 
@@ -202,3 +228,101 @@ func (bun *BunCommand) extractOne(sourcePath string, targetPath string) error {
 
 	return nil
 }
+
+func (bun *BunCommand) createOne(sourcePath string, targetPath string) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "BunCommand",
+		"function": "createOne",
+	})
+
+	cwd := commons.GetCWD()
+	home := commons.GetHomeDir()
+	zone := bun.account.ClientZone
+	sourcePath = commons.MakeIRODSPath(cwd, home, zone, sourcePath)
+	targetPath = commons.MakeIRODSPath(cwd, home, zone, targetPath)
+
+	sourceEntry, err := bun.filesystem.Stat(sourcePath)
+	if err != nil {
+		return xerrors.Errorf("failed to stat %q: %w", sourcePath, err)
+	}
+
+	if !sourceEntry.IsDir() {
+		return commons.NewNotDirError(sourcePath)
+	}
+
+	targetEntry, err := bun.filesystem.Stat(targetPath)
+	if err != nil {
+		if !irodsclient_types.IsFileNotFoundError(err) {
+			return xerrors.Errorf("failed to stat %q: %w", targetPath, err)
+		}
+	} else {
+		if targetEntry.IsDir() {
+			return commons.NewNotFileError(targetPath)
+		}
+
+		if !bun.forceFlagValues.Force {
+			return xerrors.Errorf("structured file %q already exists, use force to overwrite", targetPath)
+		}
+	}
+
+	logger.Debugf("creating a structured file %q from a collection %q", targetPath, sourcePath)
+
+	dt, err := bun.getDataType(targetPath, bun.bundleFlagValues.DataType)
+	if err != nil {
+		return xerrors.Errorf("failed to get type %q: %w", targetPath, err)
+	}
+
+	err = commons.BundleStructFile(bun.filesystem, targetPath, sourcePath, "", dt, bun.forceFlagValues.Force)
+	if err != nil {
+		return xerrors.Errorf("failed to create file %q from %q: %w", targetPath, sourcePath, err)
+	}
+
+	return nil
+}
+
+func (bun *BunCommand) listOne(sourcePath string) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "BunCommand",
+		"function": "listOne",
+	})
+
+	cwd := commons.GetCWD()
+	home := commons.GetHomeDir()
+	zone := bun.account.ClientZone
+	sourcePath = commons.MakeIRODSPath(cwd, home, zone, sourcePath)
+
+	sourceEntry, err := bun.filesystem.Stat(sourcePath)
+	if err != nil {
+		return xerrors.Errorf("failed to stat %q: %w", sourcePath, err)
+	}
+
+	if sourceEntry.IsDir() {
+		return xerrors.Errorf("source %q must be a data object", sourcePath)
+	}
+
+	logger.Debugf("listing entries in a data object %q", sourcePath)
+
+	dt, err := bun.getDataType(sourcePath, bun.bundleFlagValues.DataType)
+	if err != nil {
+		return xerrors.Errorf("failed to get type %q: %w", sourcePath, err)
+	}
+
+	entries, err := commons.ListStructFile(bun.filesystem, sourcePath, dt)
+	if err != nil {
+		return xerrors.Errorf("failed to list entries in %q: %w", sourcePath, err)
+	}
+
+	commons.Printf("%s:\n", sourcePath)
+	for _, entry := range entries {
+		if entry.Dir {
+			commons.Printf("  C- %s\n", entry.Name)
+			continue
+		}
+
+		commons.Printf("  %d\t%s\t%s\n", entry.Size, commons.MakeDateTimeString(entry.ModTime), entry.Name)
+	}
+
+	return nil
+}
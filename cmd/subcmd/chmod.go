@@ -0,0 +1,234 @@
+package subcmd
+
+import (
+	"strings"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_irodsfs "github.com/cyverse/go-irodsclient/irods/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/cyverse/gocommands/cmd/flag"
+	"github.com/cyverse/gocommands/commons"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+)
+
+var chmodCmd = &cobra.Command{
+	Use:     "chmod [null|read|write|own] [user or group] [data-object1] [collection1] ...",
+	Aliases: []string{"ichmod", "ch_mod", "change_mod", "change_access"},
+	Short:   "Change access to iRODS data-objects or collections",
+	Long:    `This grants or revokes access (null, read, write, or own) of a user or a group to iRODS data-objects or collections. Give 'user#zone' for users in other zones. Give 'inherit' or 'noinherit' followed by collections to change access inheritance.`,
+	RunE:    processChmodCommand,
+	Args:    cobra.MinimumNArgs(2),
+}
+
+func AddChmodCommand(rootCmd *cobra.Command) {
+	// attach common flags
+	flag.SetCommonFlags(chmodCmd, true)
+
+	flag.SetRecursiveFlags(chmodCmd, false)
+	flag.SetChmodFlags(chmodCmd)
+
+	rootCmd.AddCommand(chmodCmd)
+}
+
+func processChmodCommand(command *cobra.Command, args []string) error {
+	chmod, err := NewChmodCommand(command, args)
+	if err != nil {
+		return err
+	}
+
+	return chmod.Process()
+}
+
+type ChmodCommand struct {
+	command *cobra.Command
+
+	commonFlagValues    *flag.CommonFlagValues
+	recursiveFlagValues *flag.RecursiveFlagValues
+	chmodFlagValues     *flag.ChmodFlagValues
+
+	account    *irodsclient_types.IRODSAccount
+	filesystem *irodsclient_fs.FileSystem
+
+	inheritanceUpdate bool
+	inheritance       bool
+	accessLevel       irodsclient_types.IRODSAccessLevelType
+	userName          string
+	zoneName          string
+	targetPaths       []string
+}
+
+func NewChmodCommand(command *cobra.Command, args []string) (*ChmodCommand, error) {
+	chmod := &ChmodCommand{
+		command: command,
+
+		commonFlagValues:    flag.GetCommonFlagValues(command),
+		recursiveFlagValues: flag.GetRecursiveFlagValues(),
+		chmodFlagValues:     flag.GetChmodFlagValues(),
+	}
+
+	mode := strings.ToLower(args[0])
+	switch mode {
+	case "inherit", "noinherit":
+		chmod.inheritanceUpdate = true
+		chmod.inheritance = (mode == "inherit")
+		chmod.targetPaths = args[1:]
+	default:
+		accessLevel, err := chmod.getAccessLevel(mode)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(args) < 3 {
+			return nil, xerrors.Errorf("not enough input arguments, user (or group) and target paths must be given")
+		}
+
+		chmod.accessLevel = accessLevel
+		chmod.userName = args[1]
+		chmod.targetPaths = args[2:]
+
+		// user#zone
+		if idx := strings.LastIndex(chmod.userName, "#"); idx >= 0 {
+			chmod.zoneName = chmod.userName[idx+1:]
+			chmod.userName = chmod.userName[:idx]
+		}
+	}
+
+	return chmod, nil
+}
+
+func (chmod *ChmodCommand) getAccessLevel(mode string) (irodsclient_types.IRODSAccessLevelType, error) {
+	accessLevel := irodsclient_types.GetIRODSAccessLevelType(mode)
+	if accessLevel == irodsclient_types.IRODSAccessLevelNull && mode != string(irodsclient_types.IRODSAccessLevelNull) {
+		// unknown input is translated to null, which revokes access
+		return accessLevel, xerrors.Errorf("unknown access level %q, must be one of null, read, write, or own", mode)
+	}
+
+	return accessLevel, nil
+}
+
+func (chmod *ChmodCommand) Process() error {
+	cont, err := flag.ProcessCommonFlags(chmod.command)
+	if err != nil {
+		return xerrors.Errorf("failed to process common flags: %w", err)
+	}
+
+	if !cont {
+		return nil
+	}
+
+	// handle local flags
+	_, err = commons.InputMissingFields()
+	if err != nil {
+		return xerrors.Errorf("failed to input missing fields: %w", err)
+	}
+
+	// Create a file system
+	chmod.account = commons.GetSessionConfig().ToIRODSAccount()
+	chmod.filesystem, err = commons.GetIRODSFSClientForSingleOperation(chmod.account)
+	if err != nil {
+		return xerrors.Errorf("failed to get iRODS FS Client: %w", err)
+	}
+	defer chmod.filesystem.Release()
+
+	if len(chmod.zoneName) == 0 {
+		chmod.zoneName = chmod.account.ClientZone
+	}
+
+	// run
+	for _, targetPath := range chmod.targetPaths {
+		if chmod.inheritanceUpdate {
+			err = chmod.changeInheritance(targetPath)
+			if err != nil {
+				return xerrors.Errorf("failed to change access inheritance of %q: %w", targetPath, err)
+			}
+		} else {
+			err = chmod.changeAccess(targetPath)
+			if err != nil {
+				return xerrors.Errorf("failed to change access to %q: %w", targetPath, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (chmod *ChmodCommand) changeAccess(targetPath string) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "ChmodCommand",
+		"function": "changeAccess",
+	})
+
+	cwd := commons.GetCWD()
+	home := commons.GetHomeDir()
+	zone := chmod.account.ClientZone
+	targetPath = commons.MakeIRODSPath(cwd, home, zone, targetPath)
+
+	targetEntry, err := chmod.filesystem.Stat(targetPath)
+	if err != nil {
+		return xerrors.Errorf("failed to stat %q: %w", targetPath, err)
+	}
+
+	connection, err := chmod.filesystem.GetMetadataConnection()
+	if err != nil {
+		return xerrors.Errorf("failed to get connection: %w", err)
+	}
+	defer chmod.filesystem.ReturnMetadataConnection(connection)
+
+	logger.Debugf("changing access to %q, user %s#%s, access %q, recursive %t", targetPath, chmod.userName, chmod.zoneName, chmod.accessLevel, chmod.recursiveFlagValues.Recursive)
+
+	if targetEntry.IsDir() {
+		err = irodsclient_irodsfs.ChangeCollectionAccess(connection, targetPath, chmod.accessLevel, chmod.userName, chmod.zoneName, chmod.recursiveFlagValues.Recursive, chmod.chmodFlagValues.AdminMode)
+		if err != nil {
+			return xerrors.Errorf("failed to change access to collection %q: %w", targetPath, err)
+		}
+
+		return nil
+	}
+
+	err = irodsclient_irodsfs.ChangeDataObjectAccess(connection, targetPath, chmod.accessLevel, chmod.userName, chmod.zoneName, chmod.chmodFlagValues.AdminMode)
+	if err != nil {
+		return xerrors.Errorf("failed to change access to data-object %q: %w", targetPath, err)
+	}
+
+	return nil
+}
+
+func (chmod *ChmodCommand) changeInheritance(targetPath string) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "ChmodCommand",
+		"function": "changeInheritance",
+	})
+
+	cwd := commons.GetCWD()
+	home := commons.GetHomeDir()
+	zone := chmod.account.ClientZone
+	targetPath = commons.MakeIRODSPath(cwd, home, zone, targetPath)
+
+	targetEntry, err := chmod.filesystem.Stat(targetPath)
+	if err != nil {
+		return xerrors.Errorf("failed to stat %q: %w", targetPath, err)
+	}
+
+	if !targetEntry.IsDir() {
+		return commons.NewNotDirError(targetPath)
+	}
+
+	connection, err := chmod.filesystem.GetMetadataConnection()
+	if err != nil {
+		return xerrors.Errorf("failed to get connection: %w", err)
+	}
+	defer chmod.filesystem.ReturnMetadataConnection(connection)
+
+	logger.Debugf("changing access inheritance of %q to %t, recursive %t", targetPath, chmod.inheritance, chmod.recursiveFlagValues.Recursive)
+
+	err = irodsclient_irodsfs.SetAccessInherit(connection, targetPath, chmod.inheritance, chmod.recursiveFlagValues.Recursive, chmod.chmodFlagValues.AdminMode)
+	if err != nil {
+		return xerrors.Errorf("failed to set access inheritance of %q: %w", targetPath, err)
+	}
+
+	return nil
+}
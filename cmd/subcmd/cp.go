@@ -24,7 +24,7 @@ var cpCmd = &cobra.Command{
 	Short:   "Copy iRODS data-objects or collections to target collection",
 	Long:    `This copies iRODS data-objects or collections to the given target collection.`,
 	RunE:    processCpCommand,
-	Args:    cobra.MinimumNArgs(2),
+	Args:    flag.MinimumNArgsOrFromReport(2),
 }
 
 func AddCpCommand(rootCmd *cobra.Command) {
@@ -32,10 +32,13 @@ func AddCpCommand(rootCmd *cobra.Command) {
 	flag.SetCommonFlags(cpCmd, false)
 
 	flag.SetBundleTransferFlags(cpCmd, true, true)
+	flag.SetBulkRegistrationFlags(cpCmd, true)
 	flag.SetParallelTransferFlags(cpCmd, true, true)
 	flag.SetForceFlags(cpCmd, false)
 	flag.SetRecursiveFlags(cpCmd, false)
 	flag.SetProgressFlags(cpCmd)
+	flag.SetEventFlags(cpCmd)
+	flag.SetStatsFlags(cpCmd)
 	flag.SetRetryFlags(cpCmd)
 	flag.SetDifferentialTransferFlags(cpCmd, false)
 	flag.SetChecksumFlags(cpCmd, true, true)
@@ -43,7 +46,9 @@ func AddCpCommand(rootCmd *cobra.Command) {
 	flag.SetSyncFlags(cpCmd, true)
 	flag.SetHiddenFileFlags(cpCmd)
 	flag.SetTransferReportFlags(cpCmd)
+	flag.SetFromReportFlags(cpCmd)
 	flag.SetWildcardSearchFlags(cpCmd)
+	flag.SetPreserveFlags(cpCmd)
 
 	rootCmd.AddCommand(cpCmd)
 }
@@ -54,7 +59,14 @@ func processCpCommand(command *cobra.Command, args []string) error {
 		return err
 	}
 
-	return cp.Process()
+	err = cp.Process()
+
+	statsErr := cp.transferStats.Finish(err)
+	if err != nil {
+		return err
+	}
+
+	return statsErr
 }
 
 type CpCommand struct {
@@ -66,6 +78,8 @@ type CpCommand struct {
 	forceFlagValues                *flag.ForceFlagValues
 	recursiveFlagValues            *flag.RecursiveFlagValues
 	progressFlagValues             *flag.ProgressFlagValues
+	eventFlagValues                *flag.EventFlagValues
+	statsFlagValues                *flag.StatsFlagValues
 	retryFlagValues                *flag.RetryFlagValues
 	differentialTransferFlagValues *flag.DifferentialTransferFlagValues
 	checksumFlagValues             *flag.ChecksumFlagValues
@@ -73,7 +87,9 @@ type CpCommand struct {
 	syncFlagValues                 *flag.SyncFlagValues
 	hiddenFileFlagValues           *flag.HiddenFileFlagValues
 	transferReportFlagValues       *flag.TransferReportFlagValues
+	fromReportFlagValues           *flag.FromReportFlagValues
 	wildcardSearchFlagValues       *flag.WildcardSearchFlagValues
+	preserveFlagValues             *flag.PreserveFlagValues
 
 	account    *irodsclient_types.IRODSAccount
 	filesystem *irodsclient_fs.FileSystem
@@ -83,6 +99,8 @@ type CpCommand struct {
 
 	parallelJobManager    *commons.ParallelJobManager
 	transferReportManager *commons.TransferReportManager
+	eventWriter           *commons.EventWriter
+	transferStats         *commons.TransferStats
 	updatedPathMap        map[string]bool
 }
 
@@ -96,6 +114,8 @@ func NewCpCommand(command *cobra.Command, args []string) (*CpCommand, error) {
 		forceFlagValues:                flag.GetForceFlagValues(),
 		recursiveFlagValues:            flag.GetRecursiveFlagValues(),
 		progressFlagValues:             flag.GetProgressFlagValues(),
+		eventFlagValues:                flag.GetEventFlagValues(),
+		statsFlagValues:                flag.GetStatsFlagValues(),
 		retryFlagValues:                flag.GetRetryFlagValues(),
 		differentialTransferFlagValues: flag.GetDifferentialTransferFlagValues(),
 		checksumFlagValues:             flag.GetChecksumFlagValues(),
@@ -103,19 +123,44 @@ func NewCpCommand(command *cobra.Command, args []string) (*CpCommand, error) {
 		syncFlagValues:                 flag.GetSyncFlagValues(),
 		hiddenFileFlagValues:           flag.GetHiddenFileFlagValues(),
 		transferReportFlagValues:       flag.GetTransferReportFlagValues(command),
+		fromReportFlagValues:           flag.GetFromReportFlagValues(),
 		wildcardSearchFlagValues:       flag.GetWildcardSearchFlagValues(),
+		preserveFlagValues:             flag.GetPreserveFlagValues(),
 
 		updatedPathMap: map[string]bool{},
 	}
 
 	// path
-	cp.targetPath = args[len(args)-1]
-	cp.sourcePaths = args[:len(args)-1]
+	if len(args) >= 2 {
+		cp.targetPath = args[len(args)-1]
+		cp.sourcePaths = args[:len(args)-1]
+	}
 
 	if cp.noRootFlagValues.NoRoot && len(cp.sourcePaths) > 1 {
 		return nil, xerrors.Errorf("failed to copy multiple source collections without creating root directory")
 	}
 
+	err := cp.transferReportFlagValues.CheckReportFormat()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cp.progressFlagValues.CheckProgressMode()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cp.fromReportFlagValues.ReportPath) > 0 {
+		err = cp.fromReportFlagValues.CheckStatuses()
+		if err != nil {
+			return nil, err
+		}
+
+		if cp.syncFlagValues.Delete {
+			return nil, xerrors.Errorf("failed to copy with '--from_report', deleting files is not supported")
+		}
+	}
+
 	return cp, nil
 }
 
@@ -150,6 +195,11 @@ func (cp *CpCommand) Process() error {
 		return nil
 	}
 
+	// statistics, collected from here as errors in connecting are worth recording as well
+	if cp.statsFlagValues.IsStatsRequired() {
+		cp.transferStats = commons.NewTransferStats("cp", cp.statsFlagValues.Stats, cp.statsFlagValues.OpenMetricsPath)
+	}
+
 	// Create a file system
 	cp.account = commons.GetSessionConfig().ToIRODSAccount()
 	cp.filesystem, err = commons.GetIRODSFSClient(cp.account)
@@ -159,15 +209,31 @@ func (cp *CpCommand) Process() error {
 	defer cp.filesystem.Release()
 
 	// transfer report
-	cp.transferReportManager, err = commons.NewTransferReportManager(cp.transferReportFlagValues.Report, cp.transferReportFlagValues.ReportPath, cp.transferReportFlagValues.ReportToStdout)
+	cp.transferReportManager, err = commons.NewTransferReportManager(cp.transferReportFlagValues.Report, cp.transferReportFlagValues.ReportPath, cp.transferReportFlagValues.ReportToStdout, cp.transferReportFlagValues.Format)
 	if err != nil {
 		return xerrors.Errorf("failed to create transfer report manager: %w", err)
 	}
 	defer cp.transferReportManager.Release()
 
+	// events
+	if len(cp.eventFlagValues.Target) > 0 {
+		cp.eventWriter, err = commons.NewEventWriter(cp.eventFlagValues.Target)
+		if err != nil {
+			return xerrors.Errorf("failed to create event writer: %w", err)
+		}
+		defer cp.eventWriter.Release()
+	}
+	cp.transferReportManager.SetEventWriter(cp.eventWriter)
+	cp.transferReportManager.SetTransferStats(cp.transferStats)
+
 	// parallel job manager
 	cp.parallelJobManager = commons.NewParallelJobManager(cp.filesystem, commons.TransferThreadNumDefault, cp.progressFlagValues.ShowProgress, cp.progressFlagValues.ShowFullPath)
+	cp.parallelJobManager.SetProgressMode(cp.progressFlagValues.Mode, cp.progressFlagValues.Interval)
+	cp.parallelJobManager.SetEventWriter(cp.eventWriter)
 	cp.parallelJobManager.Start()
+	cp.transferReportManager.SetParallelJobManager(cp.parallelJobManager)
+
+	listingStartTime := time.Now()
 
 	// Expand wildcards
 	if cp.wildcardSearchFlagValues.WildcardSearch {
@@ -178,21 +244,33 @@ func (cp *CpCommand) Process() error {
 	}
 
 	// run
-	if len(cp.sourcePaths) >= 2 {
-		// multi-source, target must be a dir
-		err = cp.ensureTargetIsDir(cp.targetPath)
+	if len(cp.fromReportFlagValues.ReportPath) > 0 {
+		err = cp.copyFromReport(cp.fromReportFlagValues.ReportPath)
 		if err != nil {
-			return err
+			return xerrors.Errorf("failed to copy files in report %q: %w", cp.fromReportFlagValues.ReportPath, err)
+		}
+	} else {
+		if len(cp.sourcePaths) >= 2 {
+			// multi-source, target must be a dir
+			err = cp.ensureTargetIsDir(cp.targetPath)
+			if err != nil {
+				return err
+			}
 		}
-	}
 
-	for _, sourcePath := range cp.sourcePaths {
-		err = cp.copyOne(sourcePath, cp.targetPath)
-		if err != nil {
-			return xerrors.Errorf("failed to copy %q to %q: %w", sourcePath, cp.targetPath, err)
+		if cp.progressFlagValues.ShowProgress {
+			go cp.scanSources()
+		}
+
+		for _, sourcePath := range cp.sourcePaths {
+			err = cp.copyOne(sourcePath, cp.targetPath)
+			if err != nil {
+				return xerrors.Errorf("failed to copy %q to %q: %w", sourcePath, cp.targetPath, err)
+			}
 		}
 	}
 
+	cp.transferStats.AddListingTime(time.Since(listingStartTime))
 	cp.parallelJobManager.DoneScheduling()
 	err = cp.parallelJobManager.Wait()
 	if err != nil {
@@ -265,6 +343,80 @@ func (cp *CpCommand) copyOne(sourcePath string, targetPath string) error {
 	return cp.copyFile(sourceEntry, targetPath)
 }
 
+// scanSources counts data objects to copy to estimate ETA of progress
+func (cp *CpCommand) scanSources() {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "CpCommand",
+		"function": "scanSources",
+	})
+
+	cwd := commons.GetCWD()
+	home := commons.GetHomeDir()
+	zone := cp.account.ClientZone
+
+	sourcePaths := []string{}
+	for _, sourcePath := range cp.sourcePaths {
+		sourcePaths = append(sourcePaths, commons.MakeIRODSPath(cwd, home, zone, sourcePath))
+	}
+
+	files, _, err := commons.ScanIRODSPaths(cp.filesystem, sourcePaths, cp.hiddenFileFlagValues.Exclude)
+	if err != nil {
+		logger.WithError(err).Debug("failed to scan sources for progress")
+		return
+	}
+
+	// copies are tracked in files, not in bytes
+	cp.parallelJobManager.SetExpectedTotal(files, 0)
+}
+
+func (cp *CpCommand) copyFromReport(reportPath string) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "CpCommand",
+		"function": "copyFromReport",
+	})
+
+	reportFiles, err := commons.ReadTransferReportFilesFromPath(reportPath)
+	if err != nil {
+		return err
+	}
+
+	retryFiles := commons.GetTransferReportFilesToRetry(reportFiles, []commons.TransferMethod{commons.TransferMethodCopy}, cp.fromReportFlagValues.Statuses)
+	logger.Infof("retrying %d of %d transfers in report %q", len(retryFiles), len(reportFiles), reportPath)
+
+	for _, retryFile := range retryFiles {
+		err = cp.copyRetry(retryFile.SourcePath, retryFile.DestPath)
+		if err != nil {
+			return xerrors.Errorf("failed to copy %q to %q: %w", retryFile.SourcePath, retryFile.DestPath, err)
+		}
+	}
+
+	return nil
+}
+
+// copyRetry copies a data object to the exact target path recorded in a transfer report
+func (cp *CpCommand) copyRetry(sourcePath string, targetPath string) error {
+	sourceEntry, err := cp.filesystem.Stat(sourcePath)
+	if err != nil {
+		return xerrors.Errorf("failed to stat %q: %w", sourcePath, err)
+	}
+
+	if sourceEntry.IsDir() {
+		return commons.NewNotFileError(sourcePath)
+	}
+
+	targetDir := commons.GetDir(targetPath)
+	if !cp.filesystem.ExistsDir(targetDir) {
+		err = cp.filesystem.MakeDir(targetDir, true)
+		if err != nil {
+			return xerrors.Errorf("failed to make a collection %q: %w", targetDir, err)
+		}
+	}
+
+	return cp.copyFile(sourceEntry, targetPath)
+}
+
 func (cp *CpCommand) scheduleCopy(sourceEntry *irodsclient_fs.Entry, targetPath string, targetEntry *irodsclient_fs.Entry) error {
 	logger := log.WithFields(log.Fields{
 		"package":  "subcmd",
@@ -279,24 +431,60 @@ func (cp *CpCommand) scheduleCopy(sourceEntry *irodsclient_fs.Entry, targetPath
 		job.Progress(0, 1, false)
 
 		logger.Debugf("copying a data object %q to %q", sourceEntry.Path, targetPath)
+		startTime := time.Now()
 		err := fs.CopyFileToFile(sourceEntry.Path, targetPath, true)
 		if err != nil {
 			job.Progress(-1, 1, true)
+
+			reportFile := &commons.TransferReportFile{
+				Method:                  commons.TransferMethodCopy,
+				StartAt:                 startTime,
+				EndAt:                   time.Now(),
+				SourcePath:              sourceEntry.Path,
+				SourceSize:              sourceEntry.Size,
+				SourceChecksumAlgorithm: string(sourceEntry.CheckSumAlgorithm),
+				SourceChecksum:          hex.EncodeToString(sourceEntry.CheckSum),
+				DestPath:                targetPath,
+				Error:                   err,
+			}
+
+			cp.transferReportManager.AddFile(reportFile)
+
 			return xerrors.Errorf("failed to copy %q to %q: %w", sourceEntry.Path, targetPath, err)
 		}
 
-		now := time.Now()
+		notes, err := cp.preserve(fs, sourceEntry, targetPath)
+		if err != nil {
+			job.Progress(-1, 1, true)
+
+			reportFile := &commons.TransferReportFile{
+				Method:                  commons.TransferMethodCopy,
+				StartAt:                 startTime,
+				EndAt:                   time.Now(),
+				SourcePath:              sourceEntry.Path,
+				SourceSize:              sourceEntry.Size,
+				SourceChecksumAlgorithm: string(sourceEntry.CheckSumAlgorithm),
+				SourceChecksum:          hex.EncodeToString(sourceEntry.CheckSum),
+				DestPath:                targetPath,
+				Error:                   err,
+				Notes:                   notes,
+			}
+
+			cp.transferReportManager.AddFile(reportFile)
+			return err
+		}
+
 		reportFile := &commons.TransferReportFile{
 			Method:                  commons.TransferMethodCopy,
-			StartAt:                 now,
-			EndAt:                   now,
+			StartAt:                 startTime,
+			EndAt:                   time.Now(),
 			SourcePath:              sourceEntry.Path,
 			SourceSize:              sourceEntry.Size,
 			SourceChecksumAlgorithm: string(sourceEntry.CheckSumAlgorithm),
 			SourceChecksum:          hex.EncodeToString(sourceEntry.CheckSum),
 			DestPath:                targetPath,
 
-			Notes: []string{},
+			Notes: notes,
 		}
 
 		if targetEntry != nil {
@@ -316,7 +504,24 @@ func (cp *CpCommand) scheduleCopy(sourceEntry *irodsclient_fs.Entry, targetPath
 
 	err := cp.parallelJobManager.Schedule(sourceEntry.Path, copyTask, 1, progress.UnitsDefault)
 	if err != nil {
-		return xerrors.Errorf("failed to schedule copy %q to %q: %w", sourceEntry.Path, targetPath, err)
+		scheduleErr := xerrors.Errorf("failed to schedule copy %q to %q: %w", sourceEntry.Path, targetPath, err)
+
+		now := time.Now()
+		reportFile := &commons.TransferReportFile{
+			Method:                  commons.TransferMethodCopy,
+			StartAt:                 now,
+			EndAt:                   now,
+			SourcePath:              sourceEntry.Path,
+			SourceSize:              sourceEntry.Size,
+			SourceChecksumAlgorithm: string(sourceEntry.CheckSumAlgorithm),
+			SourceChecksum:          hex.EncodeToString(sourceEntry.CheckSum),
+			DestPath:                targetPath,
+			Error:                   scheduleErr,
+			Notes:                   []string{"not scheduled"},
+		}
+
+		cp.transferReportManager.AddFile(reportFile)
+		return scheduleErr
 	}
 
 	logger.Debugf("scheduled a data object copy %q to %q", sourceEntry.Path, targetPath)
@@ -324,6 +529,31 @@ func (cp *CpCommand) scheduleCopy(sourceEntry *irodsclient_fs.Entry, targetPath
 	return nil
 }
 
+// preserve copies metadata and ACLs of the source to the target if requested, returns notes for transfer report
+func (cp *CpCommand) preserve(fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, targetPath string) ([]string, error) {
+	notes := []string{}
+
+	if cp.preserveFlagValues.PreserveMetadata {
+		err := commons.CopyMetadata(fs, sourceEntry.Path, targetPath)
+		if err != nil {
+			return notes, xerrors.Errorf("failed to preserve metadata of %q: %w", sourceEntry.Path, err)
+		}
+
+		notes = append(notes, "preserve_meta")
+	}
+
+	if cp.preserveFlagValues.PreserveACL {
+		err := commons.CopyACLs(fs, sourceEntry, targetPath, cp.account.ClientUser, cp.account.ClientZone)
+		if err != nil {
+			return notes, xerrors.Errorf("failed to preserve ACLs of %q: %w", sourceEntry.Path, err)
+		}
+
+		notes = append(notes, "preserve_acl")
+	}
+
+	return notes, nil
+}
+
 func (cp *CpCommand) copyFile(sourceEntry *irodsclient_fs.Entry, targetPath string) error {
 	logger := log.WithFields(log.Fields{
 		"package":  "subcmd",
@@ -350,13 +580,13 @@ func (cp *CpCommand) copyFile(sourceEntry *irodsclient_fs.Entry, targetPath stri
 		if cp.syncFlagValues.Sync {
 			// if it is sync, remove
 			if cp.forceFlagValues.Force {
+				startTime := time.Now()
 				removeErr := cp.filesystem.RemoveDir(targetPath, true, true)
 
-				now := time.Now()
 				reportFile := &commons.TransferReportFile{
 					Method:     commons.TransferMethodDelete,
-					StartAt:    now,
-					EndAt:      now,
+					StartAt:    startTime,
+					EndAt:      time.Now(),
 					SourcePath: targetPath,
 					Error:      removeErr,
 					Notes:      []string{"overwrite", "cp", "dir"},
@@ -371,13 +601,13 @@ func (cp *CpCommand) copyFile(sourceEntry *irodsclient_fs.Entry, targetPath stri
 				// ask
 				overwrite := commons.InputYN(fmt.Sprintf("overwriting a file %q, but directory exists. Overwrite?", targetPath))
 				if overwrite {
+					startTime := time.Now()
 					removeErr := cp.filesystem.RemoveDir(targetPath, true, true)
 
-					now := time.Now()
 					reportFile := &commons.TransferReportFile{
 						Method:     commons.TransferMethodDelete,
-						StartAt:    now,
-						EndAt:      now,
+						StartAt:    startTime,
+						EndAt:      time.Now(),
 						SourcePath: targetPath,
 						Error:      removeErr,
 						Notes:      []string{"overwrite", "cp", "dir"},
@@ -494,22 +724,30 @@ func (cp *CpCommand) copyDir(sourceEntry *irodsclient_fs.Entry, targetPath strin
 		if irodsclient_types.IsFileNotFoundError(err) {
 			// target does not exist
 			// target must be a directory with new name
+			startTime := time.Now()
 			err = cp.filesystem.MakeDir(targetPath, true)
 			if err != nil {
 				return xerrors.Errorf("failed to make a directory %q: %w", targetPath, err)
 			}
 
-			now := time.Now()
 			reportFile := &commons.TransferReportFile{
 				Method:     commons.TransferMethodCopy,
-				StartAt:    now,
-				EndAt:      now,
+				StartAt:    startTime,
 				SourcePath: sourceEntry.Path,
 				DestPath:   targetPath,
 				Notes:      []string{"directory"},
 			}
 
+			notes, err := cp.preserve(cp.filesystem, sourceEntry, targetPath)
+			reportFile.EndAt = time.Now()
+			reportFile.Notes = append(reportFile.Notes, notes...)
+			reportFile.Error = err
+
 			cp.transferReportManager.AddFile(reportFile)
+
+			if err != nil {
+				return err
+			}
 		} else {
 			return xerrors.Errorf("failed to stat %q: %w", targetPath, err)
 		}
@@ -519,13 +757,13 @@ func (cp *CpCommand) copyDir(sourceEntry *irodsclient_fs.Entry, targetPath strin
 			if cp.syncFlagValues.Sync {
 				// if it is sync, remove
 				if cp.forceFlagValues.Force {
+					startTime := time.Now()
 					removeErr := cp.filesystem.RemoveFile(targetPath, true)
 
-					now := time.Now()
 					reportFile := &commons.TransferReportFile{
 						Method:     commons.TransferMethodDelete,
-						StartAt:    now,
-						EndAt:      now,
+						StartAt:    startTime,
+						EndAt:      time.Now(),
 						SourcePath: targetPath,
 						Error:      removeErr,
 						Notes:      []string{"overwrite", "cp"},
@@ -540,13 +778,13 @@ func (cp *CpCommand) copyDir(sourceEntry *irodsclient_fs.Entry, targetPath strin
 					// ask
 					overwrite := commons.InputYN(fmt.Sprintf("overwriting a directory %q, but file exists. Overwrite?", targetPath))
 					if overwrite {
+						startTime := time.Now()
 						removeErr := cp.filesystem.RemoveFile(targetPath, true)
 
-						now := time.Now()
 						reportFile := &commons.TransferReportFile{
 							Method:     commons.TransferMethodDelete,
-							StartAt:    now,
-							EndAt:      now,
+							StartAt:    startTime,
+							EndAt:      time.Now(),
 							SourcePath: targetPath,
 							Error:      removeErr,
 							Notes:      []string{"overwrite", "cp"},
@@ -627,13 +865,13 @@ func (cp *CpCommand) deleteExtraInternal(targetPath string) error {
 			// extra file
 			logger.Debugf("removing an extra data object %q", targetPath)
 
+			startTime := time.Now()
 			removeErr := cp.filesystem.RemoveFile(targetPath, true)
 
-			now := time.Now()
 			reportFile := &commons.TransferReportFile{
 				Method:     commons.TransferMethodDelete,
-				StartAt:    now,
-				EndAt:      now,
+				StartAt:    startTime,
+				EndAt:      time.Now(),
 				SourcePath: targetPath,
 				Error:      removeErr,
 				Notes:      []string{"extra", "cp"},
@@ -654,13 +892,13 @@ func (cp *CpCommand) deleteExtraInternal(targetPath string) error {
 		// extra dir
 		logger.Debugf("removing an extra collection %q", targetPath)
 
+		startTime := time.Now()
 		removeErr := cp.filesystem.RemoveDir(targetPath, true, true)
 
-		now := time.Now()
 		reportFile := &commons.TransferReportFile{
 			Method:     commons.TransferMethodDelete,
-			StartAt:    now,
-			EndAt:      now,
+			StartAt:    startTime,
+			EndAt:      time.Now(),
 			SourcePath: targetPath,
 			Error:      removeErr,
 			Notes:      []string{"extra", "cp", "dir"},
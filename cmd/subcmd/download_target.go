@@ -0,0 +1,322 @@
+package subcmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	irodsclient_util "github.com/cyverse/go-irodsclient/irods/util"
+	"github.com/cyverse/gocommands/cmd/flag"
+	"github.com/cyverse/gocommands/commons"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+// DownloadTarget prepares local files and directories to download data objects and collections to, shared by get and bget
+type DownloadTarget struct {
+	forceFlagValues                *flag.ForceFlagValues
+	syncFlagValues                 *flag.SyncFlagValues
+	differentialTransferFlagValues *flag.DifferentialTransferFlagValues
+
+	transferReportManager *commons.TransferReportManager
+	updatedPathMap        map[string]bool
+}
+
+// NewDownloadTarget creates a new DownloadTarget
+func NewDownloadTarget(forceFlagValues *flag.ForceFlagValues, syncFlagValues *flag.SyncFlagValues, differentialTransferFlagValues *flag.DifferentialTransferFlagValues, transferReportManager *commons.TransferReportManager) *DownloadTarget {
+	return &DownloadTarget{
+		forceFlagValues:                forceFlagValues,
+		syncFlagValues:                 syncFlagValues,
+		differentialTransferFlagValues: differentialTransferFlagValues,
+
+		transferReportManager: transferReportManager,
+		updatedPathMap:        map[string]bool{},
+	}
+}
+
+// markUpdated marks the local path as downloaded, not to be deleted as extra
+func (target *DownloadTarget) markUpdated(targetPath string) {
+	commons.MarkLocalPathMap(target.updatedPathMap, targetPath)
+}
+
+// prepareFile makes the target path ready to download a data object to, removing a directory at the path if sync is enabled.
+// Returns stat of the existing file, or nil if the file does not exist.
+func (target *DownloadTarget) prepareFile(targetPath string) (fs.FileInfo, error) {
+	target.markUpdated(targetPath)
+
+	targetStat, err := os.Stat(targetPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, xerrors.Errorf("failed to stat %q: %w", targetPath, err)
+	}
+
+	if !targetStat.IsDir() {
+		return targetStat, nil
+	}
+
+	// target must be a file
+	if !target.syncFlagValues.Sync {
+		return nil, commons.NewNotFileError(targetPath)
+	}
+
+	// if it is sync, remove
+	if !target.forceFlagValues.Force {
+		// ask
+		overwrite := commons.InputYN(fmt.Sprintf("overwriting a file %q, but directory exists. Overwrite?", targetPath))
+		if !overwrite {
+			return nil, commons.NewNotFileError(targetPath)
+		}
+	}
+
+	removeErr := os.RemoveAll(targetPath)
+
+	now := time.Now()
+	reportFile := &commons.TransferReportFile{
+		Method:     commons.TransferMethodDelete,
+		StartAt:    now,
+		EndAt:      now,
+		SourcePath: targetPath,
+		Error:      removeErr,
+		Notes:      []string{"overwrite", "get", "dir"},
+	}
+
+	target.transferReportManager.AddFile(reportFile)
+
+	if removeErr != nil {
+		return nil, removeErr
+	}
+
+	return nil, nil
+}
+
+// skipFile returns true if downloading the data object over the existing file is not required, reporting the skip
+func (target *DownloadTarget) skipFile(sourceEntry *irodsclient_fs.Entry, targetPath string, targetStat fs.FileInfo) (bool, error) {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "DownloadTarget",
+		"function": "skipFile",
+	})
+
+	reportFile := &commons.TransferReportFile{
+		Method:                  commons.TransferMethodGet,
+		SourcePath:              sourceEntry.Path,
+		SourceSize:              sourceEntry.Size,
+		SourceChecksumAlgorithm: string(sourceEntry.CheckSumAlgorithm),
+		SourceChecksum:          hex.EncodeToString(sourceEntry.CheckSum),
+		DestPath:                targetPath,
+		DestSize:                targetStat.Size(),
+	}
+
+	message := "The file already exists!"
+
+	if target.differentialTransferFlagValues.DifferentialTransfer {
+		if targetStat.Size() != sourceEntry.Size {
+			return false, nil
+		}
+
+		if target.differentialTransferFlagValues.NoHash {
+			reportFile.Notes = []string{"differential", "no_hash", "same file size", "skip"}
+		} else {
+			// compare hash
+			if len(sourceEntry.CheckSum) == 0 {
+				return false, nil
+			}
+
+			localChecksum, err := irodsclient_util.HashLocalFile(targetPath, string(sourceEntry.CheckSumAlgorithm))
+			if err != nil {
+				return false, xerrors.Errorf("failed to get hash of %q: %w", targetPath, err)
+			}
+
+			if !bytes.Equal(sourceEntry.CheckSum, localChecksum) {
+				return false, nil
+			}
+
+			reportFile.DestChecksum = hex.EncodeToString(localChecksum)
+			reportFile.DestChecksumAlgorithm = string(sourceEntry.CheckSumAlgorithm)
+			reportFile.Notes = []string{"differential", "same checksum", "skip"}
+			message = "The file with the same hash already exists!"
+		}
+	} else {
+		if target.forceFlagValues.Force {
+			return false, nil
+		}
+
+		// ask
+		overwrite := commons.InputYN(fmt.Sprintf("file %q already exists. Overwrite?", targetPath))
+		if overwrite {
+			return false, nil
+		}
+
+		reportFile.Notes = []string{"no_overwrite", "skip"}
+	}
+
+	now := time.Now()
+	reportFile.StartAt = now
+	reportFile.EndAt = now
+
+	target.transferReportManager.AddFile(reportFile)
+
+	commons.Printf("skip downloading a data object %q to %q. %s\n", sourceEntry.Path, targetPath, message)
+	logger.Debugf("skip downloading a data object %q to %q. %s", sourceEntry.Path, targetPath, message)
+	return true, nil
+}
+
+// prepareDir makes a directory at the target path to download a collection to, removing a file at the path if sync is enabled
+func (target *DownloadTarget) prepareDir(sourceEntry *irodsclient_fs.Entry, targetPath string) error {
+	target.markUpdated(targetPath)
+
+	targetStat, err := os.Stat(targetPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return xerrors.Errorf("failed to stat %q: %w", targetPath, err)
+		}
+
+		// target does not exist
+		err = os.MkdirAll(targetPath, 0766)
+		if err != nil {
+			return xerrors.Errorf("failed to make a directory %q: %w", targetPath, err)
+		}
+
+		now := time.Now()
+		reportFile := &commons.TransferReportFile{
+			Method:     commons.TransferMethodGet,
+			StartAt:    now,
+			EndAt:      now,
+			SourcePath: sourceEntry.Path,
+			DestPath:   targetPath,
+			Notes:      []string{"directory"},
+		}
+
+		target.transferReportManager.AddFile(reportFile)
+		return nil
+	}
+
+	if targetStat.IsDir() {
+		return nil
+	}
+
+	// target must be a directory
+	if !target.syncFlagValues.Sync {
+		return commons.NewNotDirError(targetPath)
+	}
+
+	// if it is sync, remove
+	if !target.forceFlagValues.Force {
+		// ask
+		overwrite := commons.InputYN(fmt.Sprintf("overwriting a directory %q, but file exists. Overwrite?", targetPath))
+		if !overwrite {
+			return commons.NewNotDirError(targetPath)
+		}
+	}
+
+	removeErr := os.Remove(targetPath)
+
+	now := time.Now()
+	reportFile := &commons.TransferReportFile{
+		Method:     commons.TransferMethodDelete,
+		StartAt:    now,
+		EndAt:      now,
+		SourcePath: targetPath,
+		Error:      removeErr,
+		Notes:      []string{"overwrite", "get"},
+	}
+
+	target.transferReportManager.AddFile(reportFile)
+
+	if removeErr != nil {
+		return removeErr
+	}
+
+	err = os.MkdirAll(targetPath, 0766)
+	if err != nil {
+		return xerrors.Errorf("failed to make a directory %q: %w", targetPath, err)
+	}
+
+	return nil
+}
+
+// deleteExtra deletes local files and directories under the target path not downloaded
+func (target *DownloadTarget) deleteExtra(targetPath string) error {
+	targetPath = commons.MakeLocalPath(targetPath)
+
+	return target.deleteExtraInternal(targetPath)
+}
+
+func (target *DownloadTarget) deleteExtraInternal(targetPath string) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "DownloadTarget",
+		"function": "deleteExtraInternal",
+	})
+
+	targetStat, err := os.Stat(targetPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return irodsclient_types.NewFileNotFoundError(targetPath)
+		}
+
+		return xerrors.Errorf("failed to stat %q: %w", targetPath, err)
+	}
+
+	_, updated := target.updatedPathMap[targetPath]
+
+	if updated && targetStat.IsDir() {
+		// non extra dir
+		// scan recursively
+		entries, err := os.ReadDir(targetPath)
+		if err != nil {
+			return xerrors.Errorf("failed to list a directory %q: %w", targetPath, err)
+		}
+
+		for _, entry := range entries {
+			newTargetPath := filepath.Join(targetPath, entry.Name())
+			err = target.deleteExtraInternal(newTargetPath)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if updated {
+		return nil
+	}
+
+	notes := []string{"extra", "get"}
+
+	var removeErr error
+	if targetStat.IsDir() {
+		// extra dir
+		logger.Debugf("removing an extra directory %q", targetPath)
+		removeErr = os.RemoveAll(targetPath)
+		notes = append(notes, "dir")
+	} else {
+		// extra file
+		logger.Debugf("removing an extra file %q", targetPath)
+		removeErr = os.Remove(targetPath)
+	}
+
+	now := time.Now()
+	reportFile := &commons.TransferReportFile{
+		Method:     commons.TransferMethodDelete,
+		StartAt:    now,
+		EndAt:      now,
+		SourcePath: targetPath,
+		Error:      removeErr,
+		Notes:      notes,
+	}
+
+	target.transferReportManager.AddFile(reportFile)
+
+	return removeErr
+}
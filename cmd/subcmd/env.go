@@ -23,6 +23,8 @@ func AddEnvCommand(rootCmd *cobra.Command) {
 	// attach common flags
 	flag.SetCommonFlags(envCmd, true)
 
+	flag.SetOutputFormatFlags(envCmd)
+
 	rootCmd.AddCommand(envCmd)
 }
 
@@ -38,14 +40,21 @@ func processEnvCommand(command *cobra.Command, args []string) error {
 type EnvCommand struct {
 	command *cobra.Command
 
-	commonFlagValues *flag.CommonFlagValues
+	commonFlagValues       *flag.CommonFlagValues
+	outputFormatFlagValues *flag.OutputFormatFlagValues
 }
 
 func NewEnvCommand(command *cobra.Command, args []string) (*EnvCommand, error) {
 	env := &EnvCommand{
 		command: command,
 
-		commonFlagValues: flag.GetCommonFlagValues(command),
+		commonFlagValues:       flag.GetCommonFlagValues(command),
+		outputFormatFlagValues: flag.GetOutputFormatFlagValues(),
+	}
+
+	err := env.outputFormatFlagValues.CheckOutputFormat()
+	if err != nil {
+		return nil, err
 	}
 
 	return env, nil
@@ -75,14 +84,26 @@ func (env *EnvCommand) printEnvironment() error {
 		return xerrors.Errorf("environment is not set")
 	}
 
-	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
-
 	sessionConfig, err := envMgr.GetSessionConfig()
 	if err != nil {
 		return err
 	}
 
+	if env.outputFormatFlagValues.Format.IsStructured() {
+		// do not print secrets
+		outputConfig := *sessionConfig
+		outputConfig.Password = ""
+		outputConfig.PAMToken = ""
+		outputConfig.Ticket = ""
+		outputConfig.CurrentWorkingDir = commons.GetCWD()
+		outputConfig.Home = commons.GetHomeDir()
+
+		return commons.WriteOutput(os.Stdout, env.outputFormatFlagValues.Format, &outputConfig)
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+
 	t.AppendRows([]table.Row{
 		{
 			"Session Environment File",
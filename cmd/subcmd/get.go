@@ -1,11 +1,9 @@
 package subcmd
 
 import (
-	"bytes"
 	"encoding/hex"
-	"fmt"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -27,7 +25,7 @@ var getCmd = &cobra.Command{
 	Short:   "Download iRODS data-objects or collections",
 	Long:    `This downloads iRODS data-objects or collections to the given local path.`,
 	RunE:    processGetCommand,
-	Args:    cobra.MinimumNArgs(1),
+	Args:    flag.MinimumNArgsOrFromReport(1),
 }
 
 func AddGetCommand(rootCmd *cobra.Command) {
@@ -35,21 +33,27 @@ func AddGetCommand(rootCmd *cobra.Command) {
 	flag.SetCommonFlags(getCmd, false)
 
 	flag.SetBundleTransferFlags(getCmd, true, true)
+	flag.SetBulkRegistrationFlags(getCmd, true)
 	flag.SetParallelTransferFlags(getCmd, false, false)
 	flag.SetForceFlags(getCmd, false)
 	flag.SetRecursiveFlags(getCmd, true)
 	flag.SetTicketAccessFlags(getCmd)
 	flag.SetProgressFlags(getCmd)
+	flag.SetEventFlags(getCmd)
+	flag.SetStatsFlags(getCmd)
 	flag.SetRetryFlags(getCmd)
 	flag.SetDifferentialTransferFlags(getCmd, false)
 	flag.SetChecksumFlags(getCmd, true, false)
 	flag.SetNoRootFlags(getCmd)
 	flag.SetSyncFlags(getCmd, true)
 	flag.SetDecryptionFlags(getCmd)
+	flag.SetEncryptionKeyFlags(getCmd)
 	flag.SetPostTransferFlagValues(getCmd)
 	flag.SetHiddenFileFlags(getCmd)
 	flag.SetTransferReportFlags(getCmd)
+	flag.SetFromReportFlags(getCmd)
 	flag.SetWildcardSearchFlags(getCmd)
+	flag.SetExportMetadataSidecarFlags(getCmd)
 
 	rootCmd.AddCommand(getCmd)
 }
@@ -60,7 +64,14 @@ func processGetCommand(command *cobra.Command, args []string) error {
 		return err
 	}
 
-	return get.Process()
+	err = get.Process()
+
+	statsErr := get.transferStats.Finish(err)
+	if err != nil {
+		return err
+	}
+
+	return statsErr
 }
 
 type GetCommand struct {
@@ -73,28 +84,37 @@ type GetCommand struct {
 	recursiveFlagValues            *flag.RecursiveFlagValues
 	ticketAccessFlagValues         *flag.TicketAccessFlagValues
 	progressFlagValues             *flag.ProgressFlagValues
+	eventFlagValues                *flag.EventFlagValues
+	statsFlagValues                *flag.StatsFlagValues
 	retryFlagValues                *flag.RetryFlagValues
 	differentialTransferFlagValues *flag.DifferentialTransferFlagValues
 	checksumFlagValues             *flag.ChecksumFlagValues
 	noRootFlagValues               *flag.NoRootFlagValues
 	syncFlagValues                 *flag.SyncFlagValues
 	decryptionFlagValues           *flag.DecryptionFlagValues
+	encryptionKeyFlagValues        *flag.EncryptionKeyFlagValues
 	postTransferFlagValues         *flag.PostTransferFlagValues
 	hiddenFileFlagValues           *flag.HiddenFileFlagValues
 	transferReportFlagValues       *flag.TransferReportFlagValues
+	fromReportFlagValues           *flag.FromReportFlagValues
 	wildcardSearchFlagValues       *flag.WildcardSearchFlagValues
+	metadataSidecarFlagValues      *flag.MetadataSidecarFlagValues
 
 	maxConnectionNum int
 
 	account    *irodsclient_types.IRODSAccount
 	filesystem *irodsclient_fs.FileSystem
 
+	encryptionKeySource commons.EncryptionKeySource
+
 	sourcePaths []string
 	targetPath  string
 
 	parallelJobManager    *commons.ParallelJobManager
 	transferReportManager *commons.TransferReportManager
-	updatedPathMap        map[string]bool
+	eventWriter           *commons.EventWriter
+	transferStats         *commons.TransferStats
+	downloadTarget        *DownloadTarget
 }
 
 func NewGetCommand(command *cobra.Command, args []string) (*GetCommand, error) {
@@ -108,18 +128,21 @@ func NewGetCommand(command *cobra.Command, args []string) (*GetCommand, error) {
 		recursiveFlagValues:            flag.GetRecursiveFlagValues(),
 		ticketAccessFlagValues:         flag.GetTicketAccessFlagValues(),
 		progressFlagValues:             flag.GetProgressFlagValues(),
+		eventFlagValues:                flag.GetEventFlagValues(),
+		statsFlagValues:                flag.GetStatsFlagValues(),
 		retryFlagValues:                flag.GetRetryFlagValues(),
 		differentialTransferFlagValues: flag.GetDifferentialTransferFlagValues(),
 		checksumFlagValues:             flag.GetChecksumFlagValues(),
 		noRootFlagValues:               flag.GetNoRootFlagValues(),
 		syncFlagValues:                 flag.GetSyncFlagValues(),
 		decryptionFlagValues:           flag.GetDecryptionFlagValues(command),
+		encryptionKeyFlagValues:        flag.GetEncryptionKeyFlagValues(),
 		postTransferFlagValues:         flag.GetPostTransferFlagValues(),
 		hiddenFileFlagValues:           flag.GetHiddenFileFlagValues(),
 		transferReportFlagValues:       flag.GetTransferReportFlagValues(command),
+		fromReportFlagValues:           flag.GetFromReportFlagValues(),
 		wildcardSearchFlagValues:       flag.GetWildcardSearchFlagValues(),
-
-		updatedPathMap: map[string]bool{},
+		metadataSidecarFlagValues:      flag.GetMetadataSidecarFlagValues(),
 	}
 
 	get.maxConnectionNum = get.parallelTransferFlagValues.ThreadNumber
@@ -137,6 +160,27 @@ func NewGetCommand(command *cobra.Command, args []string) (*GetCommand, error) {
 		return nil, xerrors.Errorf("failed to get multiple source collections without creating root directory")
 	}
 
+	err := get.transferReportFlagValues.CheckReportFormat()
+	if err != nil {
+		return nil, err
+	}
+
+	err = get.progressFlagValues.CheckProgressMode()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(get.fromReportFlagValues.ReportPath) > 0 {
+		err = get.fromReportFlagValues.CheckStatuses()
+		if err != nil {
+			return nil, err
+		}
+
+		if get.syncFlagValues.Delete || get.postTransferFlagValues.DeleteOnSuccess {
+			return nil, xerrors.Errorf("failed to get with '--from_report', deleting files is not supported")
+		}
+	}
+
 	return get, nil
 }
 
@@ -171,6 +215,11 @@ func (get *GetCommand) Process() error {
 		return nil
 	}
 
+	// statistics, collected from here as errors in connecting are worth recording as well
+	if get.statsFlagValues.IsStatsRequired() {
+		get.transferStats = commons.NewTransferStats("get", get.statsFlagValues.Stats, get.statsFlagValues.OpenMetricsPath)
+	}
+
 	// Create a file system
 	get.account = commons.GetSessionConfig().ToIRODSAccount()
 	if len(get.ticketAccessFlagValues.Name) > 0 {
@@ -186,45 +235,81 @@ func (get *GetCommand) Process() error {
 	defer get.filesystem.Release()
 
 	// transfer report
-	get.transferReportManager, err = commons.NewTransferReportManager(get.transferReportFlagValues.Report, get.transferReportFlagValues.ReportPath, get.transferReportFlagValues.ReportToStdout)
+	get.transferReportManager, err = commons.NewTransferReportManager(get.transferReportFlagValues.Report, get.transferReportFlagValues.ReportPath, get.transferReportFlagValues.ReportToStdout, get.transferReportFlagValues.Format)
 	if err != nil {
 		return xerrors.Errorf("failed to create transfer report manager: %w", err)
 	}
 	defer get.transferReportManager.Release()
 
-	// set default key for decryption
-	if len(get.decryptionFlagValues.Key) == 0 {
+	get.downloadTarget = NewDownloadTarget(get.forceFlagValues, get.syncFlagValues, get.differentialTransferFlagValues, get.transferReportManager)
+
+	// events
+	if len(get.eventFlagValues.Target) > 0 {
+		get.eventWriter, err = commons.NewEventWriter(get.eventFlagValues.Target)
+		if err != nil {
+			return xerrors.Errorf("failed to create event writer: %w", err)
+		}
+		defer get.eventWriter.Release()
+	}
+	get.transferReportManager.SetEventWriter(get.eventWriter)
+	get.transferReportManager.SetTransferStats(get.transferStats)
+
+	// get key for decryption, fall back to the iRODS password
+	keyConfig := get.encryptionKeyFlagValues.GetEncryptionKeyConfig(get.decryptionFlagValues.Key, get.account, false)
+	get.decryptionFlagValues.Key, get.encryptionKeySource, err = commons.ResolveEncryptionKey(keyConfig)
+	if err != nil {
+		return xerrors.Errorf("failed to get decryption key: %w", err)
+	}
+
+	if get.encryptionKeySource == commons.EncryptionKeySourceUnknown {
 		get.decryptionFlagValues.Key = get.account.Password
+		get.encryptionKeySource = commons.EncryptionKeySourcePassword
 	}
 
 	// parallel job manager
 	get.parallelJobManager = commons.NewParallelJobManager(get.filesystem, get.parallelTransferFlagValues.ThreadNumber, get.progressFlagValues.ShowProgress, get.progressFlagValues.ShowFullPath)
+	get.parallelJobManager.SetProgressMode(get.progressFlagValues.Mode, get.progressFlagValues.Interval)
+	get.parallelJobManager.SetEventWriter(get.eventWriter)
 	get.parallelJobManager.Start()
+	get.transferReportManager.SetParallelJobManager(get.parallelJobManager)
 
 	// run
-	if len(get.sourcePaths) >= 2 {
-		// multi-source, target must be a dir
-		err = get.ensureTargetIsDir(get.targetPath)
+	listingStartTime := time.Now()
+	if len(get.fromReportFlagValues.ReportPath) > 0 {
+		err = get.getFromReport(get.fromReportFlagValues.ReportPath)
 		if err != nil {
-			return err
+			return xerrors.Errorf("failed to get files in report %q: %w", get.fromReportFlagValues.ReportPath, err)
+		}
+	} else {
+		if len(get.sourcePaths) >= 2 {
+			// multi-source, target must be a dir
+			err = get.ensureTargetIsDir(get.targetPath)
+			if err != nil {
+				return err
+			}
 		}
-	}
 
-	// Expand wildcards
-	if get.wildcardSearchFlagValues.WildcardSearch {
-		get.sourcePaths, err = commons.ExpandWildcards(get.filesystem, get.account, get.sourcePaths, true, true)
-		if err != nil {
-			return xerrors.Errorf("failed to expand wildcards:  %w", err)
+		// Expand wildcards
+		if get.wildcardSearchFlagValues.WildcardSearch {
+			get.sourcePaths, err = commons.ExpandWildcards(get.filesystem, get.account, get.sourcePaths, true, true)
+			if err != nil {
+				return xerrors.Errorf("failed to expand wildcards:  %w", err)
+			}
 		}
-	}
 
-	for _, sourcePath := range get.sourcePaths {
-		err = get.getOne(sourcePath, get.targetPath)
-		if err != nil {
-			return xerrors.Errorf("failed to get %q to %q: %w", sourcePath, get.targetPath, err)
+		if get.progressFlagValues.ShowProgress {
+			go get.scanSources()
+		}
+
+		for _, sourcePath := range get.sourcePaths {
+			err = get.getOne(sourcePath, get.targetPath)
+			if err != nil {
+				return xerrors.Errorf("failed to get %q to %q: %w", sourcePath, get.targetPath, err)
+			}
 		}
 	}
 
+	get.transferStats.AddListingTime(time.Since(listingStartTime))
 	get.parallelJobManager.DoneScheduling()
 	err = get.parallelJobManager.Wait()
 	if err != nil {
@@ -247,7 +332,7 @@ func (get *GetCommand) Process() error {
 	if get.syncFlagValues.Delete {
 		logger.Infof("deleting extra files and directories under %q", get.targetPath)
 
-		err := get.deleteExtra(get.targetPath)
+		err := get.downloadTarget.deleteExtra(get.targetPath)
 		if err != nil {
 			return xerrors.Errorf("failed to delete extra files: %w", err)
 		}
@@ -337,6 +422,87 @@ func (get *GetCommand) getOne(sourcePath string, targetPath string) error {
 	return get.getFile(sourceEntry, "", targetPath)
 }
 
+// scanSources counts data objects to download to estimate ETA of progress
+func (get *GetCommand) scanSources() {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "GetCommand",
+		"function": "scanSources",
+	})
+
+	cwd := commons.GetCWD()
+	home := commons.GetHomeDir()
+	zone := get.account.ClientZone
+
+	sourcePaths := []string{}
+	for _, sourcePath := range get.sourcePaths {
+		sourcePaths = append(sourcePaths, commons.MakeIRODSPath(cwd, home, zone, sourcePath))
+	}
+
+	files, bytes, err := commons.ScanIRODSPaths(get.filesystem, sourcePaths, get.hiddenFileFlagValues.Exclude)
+	if err != nil {
+		logger.WithError(err).Debug("failed to scan sources for progress")
+		return
+	}
+
+	get.parallelJobManager.SetExpectedTotal(files, bytes)
+}
+
+func (get *GetCommand) getFromReport(reportPath string) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "GetCommand",
+		"function": "getFromReport",
+	})
+
+	reportFiles, err := commons.ReadTransferReportFilesFromPath(reportPath)
+	if err != nil {
+		return err
+	}
+
+	retryFiles := commons.GetTransferReportFilesToRetry(reportFiles, []commons.TransferMethod{commons.TransferMethodGet}, get.fromReportFlagValues.Statuses)
+	logger.Infof("retrying %d of %d transfers in report %q", len(retryFiles), len(reportFiles), reportPath)
+
+	for _, retryFile := range retryFiles {
+		err = get.getRetry(retryFile.SourcePath, retryFile.DestPath)
+		if err != nil {
+			return xerrors.Errorf("failed to get %q to %q: %w", retryFile.SourcePath, retryFile.DestPath, err)
+		}
+	}
+
+	return nil
+}
+
+// getRetry downloads a data object to the exact target path recorded in a transfer report
+func (get *GetCommand) getRetry(sourcePath string, targetPath string) error {
+	sourceEntry, err := get.filesystem.Stat(sourcePath)
+	if err != nil {
+		return xerrors.Errorf("failed to stat %q: %w", sourcePath, err)
+	}
+
+	if sourceEntry.IsDir() {
+		return commons.NewNotFileError(sourcePath)
+	}
+
+	targetDir := filepath.Dir(targetPath)
+	err = os.MkdirAll(targetDir, 0766)
+	if err != nil {
+		return xerrors.Errorf("failed to make a directory %q: %w", targetDir, err)
+	}
+
+	if get.requireDecryption(sourceEntry.Path) {
+		// decrypt filename
+		tempPath, newTargetPath, err := get.getPathsForDecryption(sourceEntry.Path, targetDir)
+		if err != nil {
+			return xerrors.Errorf("failed to get decryption path for %q: %w", sourceEntry.Path, err)
+		}
+
+		return get.getFile(sourceEntry, tempPath, newTargetPath)
+	}
+
+	return get.getFile(sourceEntry, "", targetPath)
+}
+
 func (get *GetCommand) scheduleGet(sourceEntry *irodsclient_fs.Entry, tempPath string, targetPath string, resume bool) error {
 	logger := log.WithFields(log.Fields{
 		"package":  "subcmd",
@@ -344,6 +510,8 @@ func (get *GetCommand) scheduleGet(sourceEntry *irodsclient_fs.Entry, tempPath s
 		"function": "scheduleGet",
 	})
 
+	encryptionMode := commons.DetectEncryptionMode(sourceEntry.Path)
+
 	getTask := func(job *commons.ParallelJob) error {
 		manager := job.GetManager()
 		fs := manager.GetFilesystem()
@@ -356,17 +524,61 @@ func (get *GetCommand) scheduleGet(sourceEntry *irodsclient_fs.Entry, tempPath s
 
 		logger.Debugf("downloading a data object %q to %q", sourceEntry.Path, targetPath)
 
+		startTime := time.Now()
+
 		var downloadErr error
 		var downloadResult *irodsclient_fs.FileTransferResult
 		notes := []string{}
 
+		failGet := func(err error) error {
+			job.Progress(-1, sourceEntry.Size, true)
+
+			reportFile := &commons.TransferReportFile{
+				Method:                  commons.TransferMethodGet,
+				StartAt:                 startTime,
+				EndAt:                   time.Now(),
+				SourcePath:              sourceEntry.Path,
+				SourceSize:              sourceEntry.Size,
+				SourceChecksumAlgorithm: string(sourceEntry.CheckSumAlgorithm),
+				SourceChecksum:          hex.EncodeToString(sourceEntry.CheckSum),
+				DestPath:                targetPath,
+				Error:                   err,
+				Notes:                   notes,
+			}
+
+			get.transferReportManager.AddFile(reportFile)
+			return err
+		}
+
+		// check key fingerprint before downloading, a mismatch only fails this file
+		if get.requireDecryption(sourceEntry.Path) && commons.UseEncryptionKey(encryptionMode) {
+			err := commons.CheckEncryptionKeyFingerprintMeta(fs, sourceEntry.Path, []byte(get.decryptionFlagValues.Key))
+			if err != nil {
+				notes = append(notes, "key_mismatch")
+				logger.Error(failGet(err))
+				return nil
+			}
+		}
+
 		downloadPath := targetPath
 		if len(tempPath) > 0 {
 			downloadPath = tempPath
 		}
 
+		// decrypt while downloading if possible, otherwise download to a temp file to decrypt
+		streamDecryption := get.requireDecryption(sourceEntry.Path) && encryptionMode != commons.EncryptionModeUnknown && get.canStreamDecryption()
+
 		// determine how to download
-		if get.parallelTransferFlagValues.SingleThread || get.parallelTransferFlagValues.ThreadNumber == 1 {
+		if streamDecryption {
+			taskNum := 0
+			if get.parallelTransferFlagValues.SingleThread || get.parallelTransferFlagValues.ThreadNumber == 1 {
+				taskNum = 1
+			}
+
+			encryptManager := get.getEncryptionManagerForDecryption(encryptionMode)
+			downloadResult, downloadErr = commons.DownloadFileDecrypted(fs, encryptManager, sourceEntry, targetPath, taskNum, callbackGet)
+			notes = append(notes, "decrypted", targetPath, "stream")
+		} else if get.parallelTransferFlagValues.SingleThread || get.parallelTransferFlagValues.ThreadNumber == 1 {
 			downloadResult, downloadErr = fs.DownloadFileResumable(sourceEntry.Path, "", downloadPath, get.checksumFlagValues.VerifyChecksum, callbackGet)
 			notes = append(notes, "icat", "single-thread")
 		} else if get.parallelTransferFlagValues.RedirectToResource {
@@ -410,16 +622,14 @@ func (get *GetCommand) scheduleGet(sourceEntry *irodsclient_fs.Entry, tempPath s
 		}
 
 		if downloadErr != nil {
-			job.Progress(-1, sourceEntry.Size, true)
-			return xerrors.Errorf("failed to download %q to %q: %w", sourceEntry.Path, targetPath, downloadErr)
+			return failGet(xerrors.Errorf("failed to download %q to %q: %w", sourceEntry.Path, targetPath, downloadErr))
 		}
 
 		// decrypt
-		if get.requireDecryption(sourceEntry.Path) {
+		if get.requireDecryption(sourceEntry.Path) && !streamDecryption {
 			decrypted, err := get.decryptFile(sourceEntry.Path, tempPath, targetPath)
 			if err != nil {
-				job.Progress(-1, sourceEntry.Size, true)
-				return xerrors.Errorf("failed to decrypt file: %w", err)
+				return failGet(xerrors.Errorf("failed to decrypt file: %w", err))
 			}
 
 			if decrypted {
@@ -427,6 +637,16 @@ func (get *GetCommand) scheduleGet(sourceEntry *irodsclient_fs.Entry, tempPath s
 			}
 		}
 
+		// export metadata
+		if get.metadataSidecarFlagValues.Export {
+			err := commons.ExportMetadataSidecar(fs, sourceEntry.Path, targetPath)
+			if err != nil {
+				return failGet(xerrors.Errorf("failed to export metadata of %q: %w", sourceEntry.Path, err))
+			}
+
+			notes = append(notes, "meta_sidecar")
+		}
+
 		err := get.transferReportManager.AddTransfer(downloadResult, commons.TransferMethodGet, downloadErr, notes)
 		if err != nil {
 			job.Progress(-1, sourceEntry.Size, true)
@@ -443,7 +663,24 @@ func (get *GetCommand) scheduleGet(sourceEntry *irodsclient_fs.Entry, tempPath s
 	threadsRequired := irodsclient_util.GetNumTasksForParallelTransfer(sourceEntry.Size)
 	err := get.parallelJobManager.Schedule(sourceEntry.Path, getTask, threadsRequired, progress.UnitsBytes)
 	if err != nil {
-		return xerrors.Errorf("failed to schedule download %q to %q: %w", sourceEntry.Path, targetPath, err)
+		scheduleErr := xerrors.Errorf("failed to schedule download %q to %q: %w", sourceEntry.Path, targetPath, err)
+
+		now := time.Now()
+		reportFile := &commons.TransferReportFile{
+			Method:                  commons.TransferMethodGet,
+			StartAt:                 now,
+			EndAt:                   now,
+			SourcePath:              sourceEntry.Path,
+			SourceSize:              sourceEntry.Size,
+			SourceChecksumAlgorithm: string(sourceEntry.CheckSumAlgorithm),
+			SourceChecksum:          hex.EncodeToString(sourceEntry.CheckSum),
+			DestPath:                targetPath,
+			Error:                   scheduleErr,
+			Notes:                   []string{"not scheduled"},
+		}
+
+		get.transferReportManager.AddFile(reportFile)
+		return scheduleErr
 	}
 
 	logger.Debugf("scheduled a data object download %q to %q", sourceEntry.Path, targetPath)
@@ -458,70 +695,19 @@ func (get *GetCommand) getFile(sourceEntry *irodsclient_fs.Entry, tempPath strin
 		"function": "getFile",
 	})
 
-	commons.MarkLocalPathMap(get.updatedPathMap, targetPath)
+	if get.metadataSidecarFlagValues.Export {
+		get.downloadTarget.markUpdated(commons.GetMetadataSidecarPath(targetPath))
+	}
 
-	targetStat, err := os.Stat(targetPath)
+	targetStat, err := get.downloadTarget.prepareFile(targetPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			// target does not exist
-			// target must be a file with new name
-			return get.scheduleGet(sourceEntry, tempPath, targetPath, false)
-		}
-
-		return xerrors.Errorf("failed to stat %q: %w", targetPath, err)
+		return err
 	}
 
-	// target exists
-	// target must be a file
-	if targetStat.IsDir() {
-		if get.syncFlagValues.Sync {
-			// if it is sync, remove
-			if get.forceFlagValues.Force {
-				removeErr := os.RemoveAll(targetPath)
-
-				now := time.Now()
-				reportFile := &commons.TransferReportFile{
-					Method:     commons.TransferMethodDelete,
-					StartAt:    now,
-					EndAt:      now,
-					SourcePath: targetPath,
-					Error:      removeErr,
-					Notes:      []string{"overwrite", "get", "dir"},
-				}
-
-				get.transferReportManager.AddFile(reportFile)
-
-				if removeErr != nil {
-					return removeErr
-				}
-			} else {
-				// ask
-				overwrite := commons.InputYN(fmt.Sprintf("overwriting a file %q, but directory exists. Overwrite?", targetPath))
-				if overwrite {
-					removeErr := os.RemoveAll(targetPath)
-
-					now := time.Now()
-					reportFile := &commons.TransferReportFile{
-						Method:     commons.TransferMethodDelete,
-						StartAt:    now,
-						EndAt:      now,
-						SourcePath: targetPath,
-						Error:      removeErr,
-						Notes:      []string{"overwrite", "get", "dir"},
-					}
-
-					get.transferReportManager.AddFile(reportFile)
-
-					if removeErr != nil {
-						return removeErr
-					}
-				} else {
-					return commons.NewNotFileError(targetPath)
-				}
-			}
-		} else {
-			return commons.NewNotFileError(targetPath)
-		}
+	if targetStat == nil {
+		// target does not exist
+		// target must be a file with new name
+		return get.scheduleGet(sourceEntry, tempPath, targetPath, false)
 	}
 
 	// check transfer status file
@@ -533,94 +719,9 @@ func (get *GetCommand) getFile(sourceEntry *irodsclient_fs.Entry, tempPath strin
 		return get.scheduleGet(sourceEntry, tempPath, targetPath, true)
 	}
 
-	if get.differentialTransferFlagValues.DifferentialTransfer {
-		if get.differentialTransferFlagValues.NoHash {
-			if targetStat.Size() == sourceEntry.Size {
-				// skip
-				now := time.Now()
-				reportFile := &commons.TransferReportFile{
-					Method:                  commons.TransferMethodGet,
-					StartAt:                 now,
-					EndAt:                   now,
-					SourcePath:              sourceEntry.Path,
-					SourceSize:              sourceEntry.Size,
-					SourceChecksumAlgorithm: string(sourceEntry.CheckSumAlgorithm),
-					SourceChecksum:          hex.EncodeToString(sourceEntry.CheckSum),
-
-					DestPath: targetPath,
-					DestSize: targetStat.Size(),
-					Notes:    []string{"differential", "no_hash", "same file size", "skip"},
-				}
-
-				get.transferReportManager.AddFile(reportFile)
-
-				commons.Printf("skip downloading a data object %q to %q. The file already exists!\n", sourceEntry.Path, targetPath)
-				logger.Debugf("skip downloading a data object %q to %q. The file already exists!", sourceEntry.Path, targetPath)
-				return nil
-			}
-		} else {
-			if targetStat.Size() == sourceEntry.Size {
-				// compare hash
-				if len(sourceEntry.CheckSum) > 0 {
-					localChecksum, err := irodsclient_util.HashLocalFile(targetPath, string(sourceEntry.CheckSumAlgorithm))
-					if err != nil {
-						return xerrors.Errorf("failed to get hash of %q: %w", targetPath, err)
-					}
-
-					if bytes.Equal(sourceEntry.CheckSum, localChecksum) {
-						// skip
-						now := time.Now()
-						reportFile := &commons.TransferReportFile{
-							Method:                  commons.TransferMethodGet,
-							StartAt:                 now,
-							EndAt:                   now,
-							SourcePath:              sourceEntry.Path,
-							SourceSize:              sourceEntry.Size,
-							SourceChecksumAlgorithm: string(sourceEntry.CheckSumAlgorithm),
-							SourceChecksum:          hex.EncodeToString(sourceEntry.CheckSum),
-							DestPath:                targetPath,
-							DestSize:                targetStat.Size(),
-							DestChecksum:            hex.EncodeToString(localChecksum),
-							DestChecksumAlgorithm:   string(sourceEntry.CheckSumAlgorithm),
-							Notes:                   []string{"differential", "same checksum", "skip"},
-						}
-
-						get.transferReportManager.AddFile(reportFile)
-
-						commons.Printf("skip downloading a data object %q to %q. The file with the same hash already exists!\n", sourceEntry.Path, targetPath)
-						logger.Debugf("skip downloading a data object %q to %q. The file with the same hash already exists!", sourceEntry.Path, targetPath)
-						return nil
-					}
-				}
-			}
-		}
-	} else {
-		if !get.forceFlagValues.Force {
-			// ask
-			overwrite := commons.InputYN(fmt.Sprintf("file %q already exists. Overwrite?", targetPath))
-			if !overwrite {
-				// skip
-				now := time.Now()
-				reportFile := &commons.TransferReportFile{
-					Method:                  commons.TransferMethodGet,
-					StartAt:                 now,
-					EndAt:                   now,
-					SourcePath:              sourceEntry.Path,
-					SourceSize:              sourceEntry.Size,
-					SourceChecksumAlgorithm: string(sourceEntry.CheckSumAlgorithm),
-					SourceChecksum:          hex.EncodeToString(sourceEntry.CheckSum),
-					DestPath:                targetPath,
-					DestSize:                targetStat.Size(),
-					Notes:                   []string{"no_overwrite", "skip"},
-				}
-
-				get.transferReportManager.AddFile(reportFile)
-
-				commons.Printf("skip downloading a data object %q to %q. The file already exists!\n", sourceEntry.Path, targetPath)
-				logger.Debugf("skip downloading a data object %q to %q. The file already exists!", sourceEntry.Path, targetPath)
-				return nil
-			}
-		}
+	skip, err := get.downloadTarget.skipFile(sourceEntry, targetPath, targetStat)
+	if err != nil || skip {
+		return err
 	}
 
 	// schedule
@@ -628,84 +729,9 @@ func (get *GetCommand) getFile(sourceEntry *irodsclient_fs.Entry, tempPath strin
 }
 
 func (get *GetCommand) getDir(sourceEntry *irodsclient_fs.Entry, targetPath string) error {
-	commons.MarkLocalPathMap(get.updatedPathMap, targetPath)
-
-	targetStat, err := os.Stat(targetPath)
+	err := get.downloadTarget.prepareDir(sourceEntry, targetPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			// target does not exist
-			// target must be a directorywith new name
-			err = os.MkdirAll(targetPath, 0766)
-			if err != nil {
-				return xerrors.Errorf("failed to make a directory %q: %w", targetPath, err)
-			}
-
-			now := time.Now()
-			reportFile := &commons.TransferReportFile{
-				Method:     commons.TransferMethodGet,
-				StartAt:    now,
-				EndAt:      now,
-				SourcePath: sourceEntry.Path,
-				DestPath:   targetPath,
-				Notes:      []string{"directory"},
-			}
-
-			get.transferReportManager.AddFile(reportFile)
-		} else {
-			return xerrors.Errorf("failed to stat %q: %w", targetPath, err)
-		}
-	} else {
-		// target exists
-		if !targetStat.IsDir() {
-			if get.syncFlagValues.Sync {
-				// if it is sync, remove
-				if get.forceFlagValues.Force {
-					removeErr := os.Remove(targetPath)
-
-					now := time.Now()
-					reportFile := &commons.TransferReportFile{
-						Method:     commons.TransferMethodDelete,
-						StartAt:    now,
-						EndAt:      now,
-						SourcePath: targetPath,
-						Error:      removeErr,
-						Notes:      []string{"overwrite", "get"},
-					}
-
-					get.transferReportManager.AddFile(reportFile)
-
-					if removeErr != nil {
-						return removeErr
-					}
-				} else {
-					// ask
-					overwrite := commons.InputYN(fmt.Sprintf("overwriting a directory %q, but file exists. Overwrite?", targetPath))
-					if overwrite {
-						removeErr := os.Remove(targetPath)
-
-						now := time.Now()
-						reportFile := &commons.TransferReportFile{
-							Method:     commons.TransferMethodDelete,
-							StartAt:    now,
-							EndAt:      now,
-							SourcePath: targetPath,
-							Error:      removeErr,
-							Notes:      []string{"overwrite", "put"},
-						}
-
-						get.transferReportManager.AddFile(reportFile)
-
-						if removeErr != nil {
-							return removeErr
-						}
-					} else {
-						return commons.NewNotDirError(targetPath)
-					}
-				}
-			} else {
-				return commons.NewNotDirError(targetPath)
-			}
-		}
+		return err
 	}
 
 	// load encryption config
@@ -770,106 +796,29 @@ func (get *GetCommand) deleteOnSuccess(sourcePath string) error {
 	return get.filesystem.RemoveFile(sourcePath, true)
 }
 
-func (get *GetCommand) deleteExtra(targetPath string) error {
-	targetPath = commons.MakeLocalPath(targetPath)
-
-	return get.deleteExtraInternal(targetPath)
-}
-
-func (get *GetCommand) deleteExtraInternal(targetPath string) error {
-	logger := log.WithFields(log.Fields{
-		"package":  "subcmd",
-		"struct":   "GetCommand",
-		"function": "deleteExtraInternal",
-	})
-
-	targetStat, err := os.Stat(targetPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return irodsclient_types.NewFileNotFoundError(targetPath)
-		}
-
-		return xerrors.Errorf("failed to stat %q: %w", targetPath, err)
-	}
-
-	// target is file
-	if !targetStat.IsDir() {
-		if _, ok := get.updatedPathMap[targetPath]; !ok {
-			// extra file
-			logger.Debugf("removing an extra file %q", targetPath)
-
-			removeErr := os.Remove(targetPath)
-
-			now := time.Now()
-			reportFile := &commons.TransferReportFile{
-				Method:     commons.TransferMethodDelete,
-				StartAt:    now,
-				EndAt:      now,
-				SourcePath: targetPath,
-				Error:      removeErr,
-				Notes:      []string{"extra", "get"},
-			}
-
-			get.transferReportManager.AddFile(reportFile)
-
-			if removeErr != nil {
-				return removeErr
-			}
-		}
-
-		return nil
-	}
-
-	// target is dir
-	if _, ok := get.updatedPathMap[targetPath]; !ok {
-		// extra dir
-		logger.Debugf("removing an extra directory %q", targetPath)
-
-		removeErr := os.RemoveAll(targetPath)
-
-		now := time.Now()
-		reportFile := &commons.TransferReportFile{
-			Method:     commons.TransferMethodDelete,
-			StartAt:    now,
-			EndAt:      now,
-			SourcePath: targetPath,
-			Error:      removeErr,
-			Notes:      []string{"extra", "get", "dir"},
-		}
-
-		get.transferReportManager.AddFile(reportFile)
-
-		if removeErr != nil {
-			return removeErr
-		}
-	} else {
-		// non extra dir
-		// scan recursively
-		entries, err := os.ReadDir(targetPath)
-		if err != nil {
-			return xerrors.Errorf("failed to list a directory %q: %w", targetPath, err)
-		}
-
-		for _, entry := range entries {
-			newTargetPath := path.Join(targetPath, entry.Name())
-			err = get.deleteExtraInternal(newTargetPath)
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
-
 func (get *GetCommand) getEncryptionManagerForDecryption(mode commons.EncryptionMode) *commons.EncryptionManager {
 	manager := commons.NewEncryptionManager(mode)
 
 	switch mode {
 	case commons.EncryptionModeWinSCP, commons.EncryptionModePGP:
+		if get.encryptionKeySource == commons.EncryptionKeySourcePassword {
+			commons.WarnPasswordEncryptionKey()
+		}
+
 		manager.SetKey([]byte(get.decryptionFlagValues.Key))
 	case commons.EncryptionModeSSH:
 		manager.SetPublicPrivateKey(get.decryptionFlagValues.PrivateKeyPath)
+	case commons.EncryptionModeAge:
+		// use passphrase only if given, not the default key
+		if get.encryptionKeySource != commons.EncryptionKeySourcePassword {
+			manager.SetKey([]byte(get.decryptionFlagValues.Key))
+		}
+
+		if get.decryptionFlagValues.DefaultIdentities {
+			manager.SetDefaultIdentities(get.decryptionFlagValues.Identities)
+		} else {
+			manager.SetIdentities(get.decryptionFlagValues.Identities)
+		}
 	}
 
 	return manager
@@ -885,6 +834,11 @@ func (get *GetCommand) getPathsForDecryption(sourcePath string, targetPath strin
 
 		tempFilePath := commons.MakeTargetLocalFilePath(sourcePath, get.decryptionFlagValues.TempPath)
 
+		err := encryptManager.LoadFilenameKey(get.filesystem, commons.GetDir(sourcePath), false)
+		if err != nil {
+			return "", "", xerrors.Errorf("failed to load filename key for %q: %w", sourcePath, err)
+		}
+
 		decryptedFilename, err := encryptManager.DecryptFilename(sourceFilename)
 		if err != nil {
 			return "", "", xerrors.Errorf("failed to decrypt filename %q: %w", sourcePath, err)
@@ -900,6 +854,12 @@ func (get *GetCommand) getPathsForDecryption(sourcePath string, targetPath strin
 	return "", targetFilePath, nil
 }
 
+// canStreamDecryption returns true if files can be decrypted while downloading.
+// Temp files are needed to verify checksum of encrypted files or to download from resource servers directly.
+func (get *GetCommand) canStreamDecryption() bool {
+	return !get.checksumFlagValues.VerifyChecksum && !get.parallelTransferFlagValues.RedirectToResource
+}
+
 func (get *GetCommand) decryptFile(sourcePath string, encryptedFilePath string, targetPath string) (bool, error) {
 	logger := log.WithFields(log.Fields{
 		"package":  "subcmd",
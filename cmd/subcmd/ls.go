@@ -2,12 +2,14 @@ package subcmd
 
 import (
 	"fmt"
+	"os"
 	"path"
 	"sort"
 	"strings"
 	"time"
 
 	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_conn "github.com/cyverse/go-irodsclient/irods/connection"
 	irodsclient_irodsfs "github.com/cyverse/go-irodsclient/irods/fs"
 	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
 	"github.com/cyverse/gocommands/cmd/flag"
@@ -41,8 +43,11 @@ func AddLsCommand(rootCmd *cobra.Command) {
 	flag.SetListFlags(lsCmd)
 	flag.SetTicketAccessFlags(lsCmd)
 	flag.SetDecryptionFlags(lsCmd)
+	flag.SetEncryptionKeyFlags(lsCmd)
 	flag.SetHiddenFileFlags(lsCmd)
 	flag.SetWildcardSearchFlags(lsCmd)
+	flag.SetACLFlags(lsCmd)
+	flag.SetOutputFormatFlags(lsCmd)
 
 	rootCmd.AddCommand(lsCmd)
 }
@@ -63,13 +68,24 @@ type LsCommand struct {
 	listFlagValues           *flag.ListFlagValues
 	ticketAccessFlagValues   *flag.TicketAccessFlagValues
 	decryptionFlagValues     *flag.DecryptionFlagValues
+	encryptionKeyFlagValues  *flag.EncryptionKeyFlagValues
 	hiddenFileFlagValues     *flag.HiddenFileFlagValues
 	wildcardSearchFlagValues *flag.WildcardSearchFlagValues
+	aclFlagValues            *flag.ACLFlagValues
+	outputFormatFlagValues   *flag.OutputFormatFlagValues
 
 	account    *irodsclient_types.IRODSAccount
 	filesystem *irodsclient_fs.FileSystem
 
+	encryptionKeySource commons.EncryptionKeySource
+
 	sourcePaths []string
+
+	// accesses of entries being printed, keyed by path
+	accesses map[string][]*irodsclient_types.IRODSAccess
+
+	// writes entries in machine-readable format, nil for text output
+	outputWriter *commons.OutputWriter
 }
 
 func NewLsCommand(command *cobra.Command, args []string) (*LsCommand, error) {
@@ -80,8 +96,13 @@ func NewLsCommand(command *cobra.Command, args []string) (*LsCommand, error) {
 		listFlagValues:           flag.GetListFlagValues(),
 		ticketAccessFlagValues:   flag.GetTicketAccessFlagValues(),
 		decryptionFlagValues:     flag.GetDecryptionFlagValues(command),
+		encryptionKeyFlagValues:  flag.GetEncryptionKeyFlagValues(),
 		hiddenFileFlagValues:     flag.GetHiddenFileFlagValues(),
 		wildcardSearchFlagValues: flag.GetWildcardSearchFlagValues(),
+		aclFlagValues:            flag.GetACLFlagValues(),
+		outputFormatFlagValues:   flag.GetOutputFormatFlagValues(),
+
+		accesses: map[string][]*irodsclient_types.IRODSAccess{},
 	}
 
 	// path
@@ -91,6 +112,11 @@ func NewLsCommand(command *cobra.Command, args []string) (*LsCommand, error) {
 		ls.sourcePaths = []string{"."}
 	}
 
+	err := ls.outputFormatFlagValues.CheckOutputFormat()
+	if err != nil {
+		return nil, err
+	}
+
 	return ls, nil
 }
 
@@ -129,9 +155,16 @@ func (ls *LsCommand) Process() error {
 	}
 	defer ls.filesystem.Release()
 
-	// set default key for decryption
-	if len(ls.decryptionFlagValues.Key) == 0 {
+	// get key for decryption, fall back to the iRODS password
+	keyConfig := ls.encryptionKeyFlagValues.GetEncryptionKeyConfig(ls.decryptionFlagValues.Key, ls.account, false)
+	ls.decryptionFlagValues.Key, ls.encryptionKeySource, err = commons.ResolveEncryptionKey(keyConfig)
+	if err != nil {
+		return xerrors.Errorf("failed to get decryption key: %w", err)
+	}
+
+	if ls.encryptionKeySource == commons.EncryptionKeySourceUnknown {
 		ls.decryptionFlagValues.Key = ls.account.Password
+		ls.encryptionKeySource = commons.EncryptionKeySourcePassword
 	}
 
 	// Expand wildcards
@@ -143,6 +176,10 @@ func (ls *LsCommand) Process() error {
 		ls.sourcePaths = expanded_results
 	}
 
+	if ls.outputFormatFlagValues.Format.IsStructured() {
+		ls.outputWriter = commons.NewOutputWriter(os.Stdout, ls.outputFormatFlagValues.Format)
+	}
+
 	// run
 	for _, sourcePath := range ls.sourcePaths {
 		err = ls.listDataObject(sourcePath)
@@ -158,6 +195,13 @@ func (ls *LsCommand) Process() error {
 		}
 	}
 
+	if ls.outputWriter != nil {
+		err = ls.outputWriter.Flush()
+		if err != nil {
+			return xerrors.Errorf("failed to write output: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -201,8 +245,6 @@ func (ls *LsCommand) listCollection(sourcePath string) error {
 	}
 
 	// collection
-	ls.printCurrentCollection(sourcePath)
-
 	collection, err := irodsclient_irodsfs.GetCollection(connection, sourcePath)
 	if err != nil {
 		return xerrors.Errorf("failed to get collection %q: %w", sourcePath, err)
@@ -218,6 +260,24 @@ func (ls *LsCommand) listCollection(sourcePath string) error {
 		return xerrors.Errorf("failed to list data-objects in %q: %w", sourcePath, err)
 	}
 
+	if ls.outputWriter != nil {
+		return ls.writeEntries(ls.filterHiddenCollections(colls), ls.filterHiddenDataObjects(objs))
+	}
+
+	ls.printCurrentCollection(sourcePath)
+
+	if ls.aclFlagValues.ShowACL {
+		err = ls.printCollectionACLs(connection, sourcePath)
+		if err != nil {
+			return err
+		}
+
+		err = ls.loadACLsForEntries(connection, collection)
+		if err != nil {
+			return err
+		}
+	}
+
 	// filter out hidden files
 	filtered_colls := ls.filterHiddenCollections(colls)
 	filtered_objs := ls.filterHiddenDataObjects(objs)
@@ -262,6 +322,17 @@ func (ls *LsCommand) listDataObject(sourcePath string) error {
 		return xerrors.Errorf("failed to get data-object %q: %w", sourcePath, err)
 	}
 
+	if ls.outputWriter != nil {
+		return ls.writeEntries(nil, []*irodsclient_types.IRODSDataObject{entry})
+	}
+
+	if ls.aclFlagValues.ShowACL {
+		err = ls.loadACLsForDataObject(connection, entry)
+		if err != nil {
+			return err
+		}
+	}
+
 	entries := []*irodsclient_types.IRODSDataObject{entry}
 	ls.printDataObjects(entries, true)
 
@@ -302,6 +373,27 @@ func (ls *LsCommand) filterHiddenDataObjects(entries []*irodsclient_types.IRODSD
 	return filteredEntries
 }
 
+// writeEntries writes collections and data objects in machine-readable format
+func (ls *LsCommand) writeEntries(colls []*irodsclient_types.IRODSCollection, objs []*irodsclient_types.IRODSDataObject) error {
+	sort.SliceStable(colls, ls.getCollectionSortFunction(colls, ls.listFlagValues.SortOrder, ls.listFlagValues.SortReverse))
+	for _, coll := range colls {
+		err := ls.outputWriter.Write(coll)
+		if err != nil {
+			return xerrors.Errorf("failed to write collection %q: %w", coll.Path, err)
+		}
+	}
+
+	sort.SliceStable(objs, ls.getDataObjectSortFunction(objs, ls.listFlagValues.SortOrder, ls.listFlagValues.SortReverse))
+	for _, obj := range objs {
+		err := ls.outputWriter.Write(obj)
+		if err != nil {
+			return xerrors.Errorf("failed to write data-object %q: %w", obj.Path, err)
+		}
+	}
+
+	return nil
+}
+
 func (ls *LsCommand) printCurrentCollection(sourcePath string) {
 	commons.Printf("%s:\n", sourcePath)
 }
@@ -310,6 +402,7 @@ func (ls *LsCommand) printCollections(entries []*irodsclient_types.IRODSCollecti
 	sort.SliceStable(entries, ls.getCollectionSortFunction(entries, ls.listFlagValues.SortOrder, ls.listFlagValues.SortReverse))
 	for _, entry := range entries {
 		commons.Printf("  C- %s\n", entry.Path)
+		ls.printACLs(entry.Path)
 	}
 }
 
@@ -318,6 +411,7 @@ func (ls *LsCommand) printDataObjects(entries []*irodsclient_types.IRODSDataObje
 		sort.SliceStable(entries, ls.getDataObjectSortFunction(entries, ls.listFlagValues.SortOrder, ls.listFlagValues.SortReverse))
 		for _, entry := range entries {
 			ls.printDataObjectShort(entry, showFullPath)
+			ls.printACLs(entry.Path)
 		}
 	} else {
 		replicas := ls.flattenReplicas(entries)
@@ -493,7 +587,7 @@ func (ls *LsCommand) printDataObjectShort(entry *irodsclient_types.IRODSDataObje
 		if encryptionMode != commons.EncryptionModeUnknown {
 			encryptManager := ls.getEncryptionManagerForDecryption(encryptionMode)
 
-			decryptedFilename, err := encryptManager.DecryptFilename(newName)
+			decryptedFilename, err := ls.decryptFilename(encryptManager, entry.Path)
 			if err != nil {
 				logger.Debugf("%+v", err)
 				newName = fmt.Sprintf("%s\t(decryption_failed)", newName)
@@ -507,8 +601,15 @@ func (ls *LsCommand) printDataObjectShort(entry *irodsclient_types.IRODSDataObje
 }
 
 func (ls *LsCommand) printReplicas(flatReplicas []*FlatReplica) {
+	aclPrinted := map[string]bool{}
 	for _, flatReplica := range flatReplicas {
 		ls.printReplica(*flatReplica)
+
+		// print ACLs once per data object
+		if _, ok := aclPrinted[flatReplica.DataObject.Path]; !ok {
+			aclPrinted[flatReplica.DataObject.Path] = true
+			ls.printACLs(flatReplica.DataObject.Path)
+		}
 	}
 }
 
@@ -517,14 +618,39 @@ func (ls *LsCommand) getEncryptionManagerForDecryption(mode commons.EncryptionMo
 
 	switch mode {
 	case commons.EncryptionModeWinSCP, commons.EncryptionModePGP:
+		if ls.encryptionKeySource == commons.EncryptionKeySourcePassword {
+			commons.WarnPasswordEncryptionKey()
+		}
+
 		manager.SetKey([]byte(ls.decryptionFlagValues.Key))
 	case commons.EncryptionModeSSH:
 		manager.SetPublicPrivateKey(ls.decryptionFlagValues.PrivateKeyPath)
+	case commons.EncryptionModeAge:
+		// use passphrase only if given, not the default key
+		if ls.encryptionKeySource != commons.EncryptionKeySourcePassword {
+			manager.SetKey([]byte(ls.decryptionFlagValues.Key))
+		}
+
+		if ls.decryptionFlagValues.DefaultIdentities {
+			manager.SetDefaultIdentities(ls.decryptionFlagValues.Identities)
+		} else {
+			manager.SetIdentities(ls.decryptionFlagValues.Identities)
+		}
 	}
 
 	return manager
 }
 
+// decryptFilename decrypts the name of the data object, loading the filename key of its collection if needed
+func (ls *LsCommand) decryptFilename(encryptManager *commons.EncryptionManager, dataObjectPath string) (string, error) {
+	err := encryptManager.LoadFilenameKey(ls.filesystem, commons.GetDir(dataObjectPath), false)
+	if err != nil {
+		return "", err
+	}
+
+	return encryptManager.DecryptFilename(commons.GetBasename(dataObjectPath))
+}
+
 func (ls *LsCommand) printReplica(flatReplica FlatReplica) {
 	logger := log.WithFields(log.Fields{
 		"package":  "subcmd",
@@ -540,7 +666,7 @@ func (ls *LsCommand) printReplica(flatReplica FlatReplica) {
 		if encryptionMode != commons.EncryptionModeUnknown {
 			encryptManager := ls.getEncryptionManagerForDecryption(encryptionMode)
 
-			decryptedFilename, err := encryptManager.DecryptFilename(newName)
+			decryptedFilename, err := ls.decryptFilename(encryptManager, flatReplica.DataObject.Path)
 			if err != nil {
 				logger.Debugf("%+v", err)
 				newName = fmt.Sprintf("%s\tdecryption_failed", newName)
@@ -623,3 +749,78 @@ func (ls *LsCommand) getStatusMark(status string) string {
 		return "?"
 	}
 }
+
+func (ls *LsCommand) loadACLsForEntries(connection *irodsclient_conn.IRODSConnection, collection *irodsclient_types.IRODSCollection) error {
+	collAccesses, err := irodsclient_irodsfs.ListAccessesForSubCollections(connection, collection.Path)
+	if err != nil {
+		return xerrors.Errorf("failed to list accesses for sub-collections in %q: %w", collection.Path, err)
+	}
+
+	objAccesses, err := irodsclient_irodsfs.ListAccessesForDataObjects(connection, collection)
+	if err != nil {
+		return xerrors.Errorf("failed to list accesses for data-objects in %q: %w", collection.Path, err)
+	}
+
+	for _, access := range append(collAccesses, objAccesses...) {
+		ls.accesses[access.Path] = append(ls.accesses[access.Path], access)
+	}
+
+	return nil
+}
+
+func (ls *LsCommand) loadACLsForDataObject(connection *irodsclient_conn.IRODSConnection, entry *irodsclient_types.IRODSDataObject) error {
+	collectionPath := path.Dir(entry.Path)
+	collection, err := irodsclient_irodsfs.GetCollection(connection, collectionPath)
+	if err != nil {
+		return xerrors.Errorf("failed to get collection %q: %w", collectionPath, err)
+	}
+
+	accesses, err := irodsclient_irodsfs.ListDataObjectAccesses(connection, collection, entry.Name)
+	if err != nil {
+		return xerrors.Errorf("failed to list accesses for data-object %q: %w", entry.Path, err)
+	}
+
+	ls.accesses[entry.Path] = accesses
+
+	return nil
+}
+
+func (ls *LsCommand) printCollectionACLs(connection *irodsclient_conn.IRODSConnection, collectionPath string) error {
+	accesses, err := irodsclient_irodsfs.ListCollectionAccesses(connection, collectionPath)
+	if err != nil {
+		return xerrors.Errorf("failed to list accesses for collection %q: %w", collectionPath, err)
+	}
+
+	inheritance, err := irodsclient_irodsfs.GetCollectionAccessInheritance(connection, collectionPath)
+	if err != nil {
+		return xerrors.Errorf("failed to get access inheritance for collection %q: %w", collectionPath, err)
+	}
+
+	commons.Printf("        ACL - %s\n", ls.getACLsString(accesses))
+
+	if inheritance.Inheritance {
+		commons.Print("        Inheritance - Enabled\n")
+	} else {
+		commons.Print("        Inheritance - Disabled\n")
+	}
+
+	return nil
+}
+
+func (ls *LsCommand) printACLs(entryPath string) {
+	if !ls.aclFlagValues.ShowACL {
+		return
+	}
+
+	commons.Printf("        ACL - %s\n", ls.getACLsString(ls.accesses[entryPath]))
+}
+
+func (ls *LsCommand) getACLsString(accesses []*irodsclient_types.IRODSAccess) string {
+	accessStrings := []string{}
+	for _, access := range accesses {
+		accessStrings = append(accessStrings, fmt.Sprintf("%s#%s:%s", access.UserName, access.UserZone, access.AccessLevel.ChmodString()))
+	}
+
+	sort.Strings(accessStrings)
+	return strings.Join(accessStrings, "   ")
+}
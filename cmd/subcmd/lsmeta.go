@@ -2,21 +2,30 @@ package subcmd
 
 import (
 	"fmt"
+	"os"
 	"sort"
 
 	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
 	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
 	"github.com/cyverse/gocommands/cmd/flag"
 	"github.com/cyverse/gocommands/commons"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"golang.org/x/xerrors"
 )
 
+// MetadataQueryMatch is an item found by metadata query, written in machine-readable output
+type MetadataQueryMatch struct {
+	Type commons.MetadataQueryTargetType `json:"type"`
+	// Path is a path of data object or collection, or a name of user or resource
+	Path string `json:"path"`
+}
+
 var lsmetaCmd = &cobra.Command{
 	Use:     "lsmeta",
 	Aliases: []string{"ls_meta", "ls_metadata", "list_meta", "list_metadata"},
 	Short:   "List metadata",
-	Long:    `This lists metadata for the given collection, data object, user, or a resource.`,
+	Long:    `This lists metadata for the given collection, data object, user, or a resource, or searches items by metadata with a query.`,
 	RunE:    processLsmetaCommand,
 	Args:    cobra.NoArgs,
 }
@@ -27,6 +36,8 @@ func AddLsmetaCommand(rootCmd *cobra.Command) {
 
 	flag.SetListFlags(lsmetaCmd)
 	flag.SetTargetObjectFlags(lsmetaCmd)
+	flag.SetMetadataQueryFlags(lsmetaCmd)
+	flag.SetOutputFormatFlags(lsmetaCmd)
 
 	rootCmd.AddCommand(lsmetaCmd)
 }
@@ -43,9 +54,11 @@ func processLsmetaCommand(command *cobra.Command, args []string) error {
 type LsMetaCommand struct {
 	command *cobra.Command
 
-	commonFlagValues       *flag.CommonFlagValues
-	listFlagValues         *flag.ListFlagValues
-	targetObjectFlagValues *flag.TargetObjectFlagValues
+	commonFlagValues        *flag.CommonFlagValues
+	listFlagValues          *flag.ListFlagValues
+	targetObjectFlagValues  *flag.TargetObjectFlagValues
+	metadataQueryFlagValues *flag.MetadataQueryFlagValues
+	outputFormatFlagValues  *flag.OutputFormatFlagValues
 
 	account    *irodsclient_types.IRODSAccount
 	filesystem *irodsclient_fs.FileSystem
@@ -55,9 +68,16 @@ func NewLsMetaCommand(command *cobra.Command, args []string) (*LsMetaCommand, er
 	lsMeta := &LsMetaCommand{
 		command: command,
 
-		commonFlagValues:       flag.GetCommonFlagValues(command),
-		listFlagValues:         flag.GetListFlagValues(),
-		targetObjectFlagValues: flag.GetTargetObjectFlagValues(command),
+		commonFlagValues:        flag.GetCommonFlagValues(command),
+		listFlagValues:          flag.GetListFlagValues(),
+		targetObjectFlagValues:  flag.GetTargetObjectFlagValues(command),
+		metadataQueryFlagValues: flag.GetMetadataQueryFlagValues(),
+		outputFormatFlagValues:  flag.GetOutputFormatFlagValues(),
+	}
+
+	err := lsMeta.outputFormatFlagValues.CheckOutputFormat()
+	if err != nil {
+		return nil, err
 	}
 
 	return lsMeta, nil
@@ -87,6 +107,10 @@ func (lsMeta *LsMetaCommand) Process() error {
 	}
 	defer lsMeta.filesystem.Release()
 
+	if len(lsMeta.metadataQueryFlagValues.Query) > 0 {
+		return lsMeta.queryMeta(lsMeta.metadataQueryFlagValues.Query)
+	}
+
 	if lsMeta.targetObjectFlagValues.PathUpdated {
 		return lsMeta.listMetaForPath(lsMeta.targetObjectFlagValues.Path)
 	} else if lsMeta.targetObjectFlagValues.UserUpdated {
@@ -110,7 +134,7 @@ func (lsMeta *LsMetaCommand) listMetaForPath(targetPath string) error {
 		return xerrors.Errorf("failed to list meta for path %q: %w", targetPath, err)
 	}
 
-	if len(metas) == 0 {
+	if len(metas) == 0 && !lsMeta.outputFormatFlagValues.Format.IsStructured() {
 		commons.Printf("Found no metadata\n")
 		return nil
 	}
@@ -124,7 +148,7 @@ func (lsMeta *LsMetaCommand) listMetaForUser(username string) error {
 		return xerrors.Errorf("failed to list meta for user %q: %w", username, err)
 	}
 
-	if len(metas) == 0 {
+	if len(metas) == 0 && !lsMeta.outputFormatFlagValues.Format.IsStructured() {
 		commons.Printf("Found no metadata\n")
 		return nil
 	}
@@ -138,7 +162,7 @@ func (lsMeta *LsMetaCommand) listMetaForResource(resource string) error {
 		return xerrors.Errorf("failed to list meta for resource %q: %w", resource, err)
 	}
 
-	if len(metas) == 0 {
+	if len(metas) == 0 && !lsMeta.outputFormatFlagValues.Format.IsStructured() {
 		commons.Printf("Found no metadata\n")
 		return nil
 	}
@@ -146,9 +170,82 @@ func (lsMeta *LsMetaCommand) listMetaForResource(resource string) error {
 	return lsMeta.printMetas(metas)
 }
 
+func (lsMeta *LsMetaCommand) queryMeta(query string) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "LsMetaCommand",
+		"function": "queryMeta",
+	})
+
+	conditions, err := commons.ParseMetadataQuery(query)
+	if err != nil {
+		return xerrors.Errorf("failed to parse query %q: %w", query, err)
+	}
+
+	targetType := lsMeta.metadataQueryFlagValues.TargetType
+	if targetType == commons.MetadataQueryTargetTypeUnknown {
+		return xerrors.Errorf("unknown query type, must be one of 'data_object', 'collection', 'user', or 'resource'")
+	}
+
+	// path limits the search to the subtree
+	scopePath := ""
+	if lsMeta.targetObjectFlagValues.PathUpdated {
+		cwd := commons.GetCWD()
+		home := commons.GetHomeDir()
+		zone := lsMeta.account.ClientZone
+		scopePath = commons.MakeIRODSPath(cwd, home, zone, lsMeta.targetObjectFlagValues.Path)
+	}
+
+	logger.Debugf("search %s by metadata query %q under %q", targetType, query, scopePath)
+
+	matches, err := commons.SearchByMetadata(lsMeta.filesystem, targetType, conditions, scopePath)
+	if err != nil {
+		return xerrors.Errorf("failed to search %s by metadata query %q: %w", targetType, query, err)
+	}
+
+	sort.Strings(matches)
+	if lsMeta.listFlagValues.SortReverse {
+		sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+	}
+
+	if lsMeta.outputFormatFlagValues.Format.IsStructured() {
+		outputWriter := commons.NewOutputWriter(os.Stdout, lsMeta.outputFormatFlagValues.Format)
+		for _, match := range matches {
+			err = outputWriter.Write(&MetadataQueryMatch{
+				Type: targetType,
+				Path: match,
+			})
+			if err != nil {
+				return xerrors.Errorf("failed to write output: %w", err)
+			}
+		}
+
+		return outputWriter.Flush()
+	}
+
+	// print one per line so the output can be passed to other commands
+	for _, match := range matches {
+		commons.Printf("%s\n", match)
+	}
+
+	return nil
+}
+
 func (lsMeta *LsMetaCommand) printMetas(metas []*irodsclient_types.IRODSMeta) error {
 	sort.SliceStable(metas, lsMeta.getMetaSortFunction(metas, lsMeta.listFlagValues.SortOrder, lsMeta.listFlagValues.SortReverse))
 
+	if lsMeta.outputFormatFlagValues.Format.IsStructured() {
+		outputWriter := commons.NewOutputWriter(os.Stdout, lsMeta.outputFormatFlagValues.Format)
+		for _, meta := range metas {
+			err := outputWriter.Write(meta)
+			if err != nil {
+				return xerrors.Errorf("failed to write output: %w", err)
+			}
+		}
+
+		return outputWriter.Flush()
+	}
+
 	for _, meta := range metas {
 		lsMeta.printMetaInternal(meta)
 	}
@@ -183,7 +280,6 @@ func (lsMeta *LsMetaCommand) printMetaInternal(meta *irodsclient_types.IRODSMeta
 
 	switch lsMeta.listFlagValues.Format {
 	case commons.ListFormatLong, commons.ListFormatVeryLong:
-		commons.Printf("[%s]\n", meta.Name)
 		commons.Printf("[%s]\n", meta.Name)
 		commons.Printf("  id: %d\n", meta.AVUID)
 		commons.Printf("  attribute: %s\n", name)
@@ -1,6 +1,7 @@
 package subcmd
 
 import (
+	"os"
 	"sort"
 
 	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
@@ -26,6 +27,7 @@ func AddLsticketCommand(rootCmd *cobra.Command) {
 	flag.SetCommonFlags(lsticketCmd, true)
 
 	flag.SetListFlags(lsticketCmd)
+	flag.SetOutputFormatFlags(lsticketCmd)
 
 	rootCmd.AddCommand(lsticketCmd)
 }
@@ -42,8 +44,9 @@ func processLsticketCommand(command *cobra.Command, args []string) error {
 type LsTicketCommand struct {
 	command *cobra.Command
 
-	commonFlagValues *flag.CommonFlagValues
-	listFlagValues   *flag.ListFlagValues
+	commonFlagValues       *flag.CommonFlagValues
+	listFlagValues         *flag.ListFlagValues
+	outputFormatFlagValues *flag.OutputFormatFlagValues
 
 	account    *irodsclient_types.IRODSAccount
 	filesystem *irodsclient_fs.FileSystem
@@ -55,13 +58,19 @@ func NewLsTicketCommand(command *cobra.Command, args []string) (*LsTicketCommand
 	lsTicket := &LsTicketCommand{
 		command: command,
 
-		commonFlagValues: flag.GetCommonFlagValues(command),
-		listFlagValues:   flag.GetListFlagValues(),
+		commonFlagValues:       flag.GetCommonFlagValues(command),
+		listFlagValues:         flag.GetListFlagValues(),
+		outputFormatFlagValues: flag.GetOutputFormatFlagValues(),
 	}
 
 	// tickets
 	lsTicket.tickets = args
 
+	err := lsTicket.outputFormatFlagValues.CheckOutputFormat()
+	if err != nil {
+		return nil, err
+	}
+
 	return lsTicket, nil
 }
 
@@ -109,7 +118,7 @@ func (lsTicket *LsTicketCommand) listTickets() error {
 		return xerrors.Errorf("failed to list tickets: %w", err)
 	}
 
-	if len(tickets) == 0 {
+	if len(tickets) == 0 && !lsTicket.outputFormatFlagValues.Format.IsStructured() {
 		commons.Printf("Found no tickets\n")
 	}
 
@@ -137,6 +146,18 @@ func (lsTicket *LsTicketCommand) printTicket(ticketName string) error {
 func (lsTicket *LsTicketCommand) printTickets(tickets []*irodsclient_types.IRODSTicket) error {
 	sort.SliceStable(tickets, lsTicket.getTicketSortFunction(tickets, lsTicket.listFlagValues.SortOrder, lsTicket.listFlagValues.SortReverse))
 
+	if lsTicket.outputFormatFlagValues.Format.IsStructured() {
+		outputWriter := commons.NewOutputWriter(os.Stdout, lsTicket.outputFormatFlagValues.Format)
+		for _, ticket := range tickets {
+			err := outputWriter.Write(ticket)
+			if err != nil {
+				return xerrors.Errorf("failed to write output: %w", err)
+			}
+		}
+
+		return outputWriter.Flush()
+	}
+
 	for _, ticket := range tickets {
 		err := lsTicket.printTicketInternal(ticket)
 		if err != nil {
@@ -0,0 +1,24 @@
+package subcmd
+
+import (
+	"github.com/cyverse/gocommands/cmd/flag"
+	"github.com/spf13/cobra"
+)
+
+var metadataCmd = &cobra.Command{
+	Use:     "meta [subcommand]",
+	Aliases: []string{"metadata"},
+	Short:   "Import or export metadata in bulk",
+	Long:    `This imports or exports metadata of many data objects and collections at once, using CSV, JSON, or YAML files.`,
+	Args:    cobra.NoArgs,
+}
+
+func AddMetadataCommand(rootCmd *cobra.Command) {
+	// attach common flags
+	flag.SetCommonFlagsWithoutResource(metadataCmd)
+
+	AddMetaImportCommand(metadataCmd)
+	AddMetaExportCommand(metadataCmd)
+
+	rootCmd.AddCommand(metadataCmd)
+}
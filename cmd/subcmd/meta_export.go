@@ -0,0 +1,206 @@
+package subcmd
+
+import (
+	"io"
+	"os"
+	"sort"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/cyverse/gocommands/cmd/flag"
+	"github.com/cyverse/gocommands/commons"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+)
+
+var metaExportCmd = &cobra.Command{
+	Use:     "export [data-object1] [collection1] ...",
+	Aliases: []string{"exp"},
+	Short:   "Export metadata to a file",
+	Long:    `This writes metadata of data objects and collections in CSV (path,attribute,value,unit), JSON, or YAML format.`,
+	RunE:    processMetaExportCommand,
+	Args:    cobra.MinimumNArgs(1),
+}
+
+func AddMetaExportCommand(metadataCmd *cobra.Command) {
+	// attach common flags
+	flag.SetCommonFlagsWithoutResource(metaExportCmd)
+
+	flag.SetMetadataExportFlags(metaExportCmd)
+	flag.SetRecursiveFlags(metaExportCmd, false)
+
+	metadataCmd.AddCommand(metaExportCmd)
+}
+
+func processMetaExportCommand(command *cobra.Command, args []string) error {
+	metaExport, err := NewMetaExportCommand(command, args)
+	if err != nil {
+		return err
+	}
+
+	return metaExport.Process()
+}
+
+type MetaExportCommand struct {
+	command *cobra.Command
+
+	commonFlagValues         *flag.CommonFlagValues
+	metadataExportFlagValues *flag.MetadataExportFlagValues
+	recursiveFlagValues      *flag.RecursiveFlagValues
+
+	account    *irodsclient_types.IRODSAccount
+	filesystem *irodsclient_fs.FileSystem
+
+	sourcePaths []string
+}
+
+func NewMetaExportCommand(command *cobra.Command, args []string) (*MetaExportCommand, error) {
+	metaExport := &MetaExportCommand{
+		command: command,
+
+		commonFlagValues:         flag.GetCommonFlagValues(command),
+		metadataExportFlagValues: flag.GetMetadataExportFlagValues(),
+		recursiveFlagValues:      flag.GetRecursiveFlagValues(),
+	}
+
+	// paths
+	metaExport.sourcePaths = args
+
+	return metaExport, nil
+}
+
+func (metaExport *MetaExportCommand) Process() error {
+	cont, err := flag.ProcessCommonFlags(metaExport.command)
+	if err != nil {
+		return xerrors.Errorf("failed to process common flags: %w", err)
+	}
+
+	if !cont {
+		return nil
+	}
+
+	// handle local flags
+	_, err = commons.InputMissingFields()
+	if err != nil {
+		return xerrors.Errorf("failed to input missing fields: %w", err)
+	}
+
+	// Create a file system
+	metaExport.account = commons.GetSessionConfig().ToIRODSAccount()
+	metaExport.filesystem, err = commons.GetIRODSFSClientForSingleOperation(metaExport.account)
+	if err != nil {
+		return xerrors.Errorf("failed to get iRODS FS Client: %w", err)
+	}
+	defer metaExport.filesystem.Release()
+
+	// run
+	rows := []*commons.MetadataRow{}
+	for _, sourcePath := range metaExport.sourcePaths {
+		exportedRows, err := metaExport.exportOne(sourcePath)
+		if err != nil {
+			return xerrors.Errorf("failed to export metadata of %q: %w", sourcePath, err)
+		}
+
+		rows = append(rows, exportedRows...)
+	}
+
+	return metaExport.writeRows(rows)
+}
+
+func (metaExport *MetaExportCommand) exportOne(sourcePath string) ([]*commons.MetadataRow, error) {
+	cwd := commons.GetCWD()
+	home := commons.GetHomeDir()
+	zone := metaExport.account.ClientZone
+	sourcePath = commons.MakeIRODSPath(cwd, home, zone, sourcePath)
+
+	sourceEntry, err := metaExport.filesystem.Stat(sourcePath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to stat %q: %w", sourcePath, err)
+	}
+
+	return metaExport.exportEntry(sourceEntry)
+}
+
+func (metaExport *MetaExportCommand) exportEntry(entry *irodsclient_fs.Entry) ([]*commons.MetadataRow, error) {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "MetaExportCommand",
+		"function": "exportEntry",
+	})
+
+	logger.Debugf("export metadata of %q", entry.Path)
+
+	metas, err := metaExport.filesystem.ListMetadata(entry.Path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to list metadata of %q: %w", entry.Path, err)
+	}
+
+	sort.SliceStable(metas, func(i int, j int) bool {
+		return metas[i].Name < metas[j].Name
+	})
+
+	rows := []*commons.MetadataRow{}
+	for _, meta := range metas {
+		rows = append(rows, &commons.MetadataRow{
+			Path:      entry.Path,
+			Attribute: meta.Name,
+			Value:     meta.Value,
+			Unit:      meta.Units,
+		})
+	}
+
+	if !entry.IsDir() || !metaExport.recursiveFlagValues.Recursive {
+		return rows, nil
+	}
+
+	entries, err := metaExport.filesystem.List(entry.Path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to list dir %q: %w", entry.Path, err)
+	}
+
+	sort.SliceStable(entries, func(i int, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	for _, childEntry := range entries {
+		childRows, err := metaExport.exportEntry(childEntry)
+		if err != nil {
+			return nil, err
+		}
+
+		rows = append(rows, childRows...)
+	}
+
+	return rows, nil
+}
+
+func (metaExport *MetaExportCommand) writeRows(rows []*commons.MetadataRow) error {
+	format := metaExport.metadataExportFlagValues.Format
+	if format == commons.MetadataFileFormatUnknown {
+		format = commons.DetectMetadataFileFormat(metaExport.metadataExportFlagValues.FilePath)
+		if format == commons.MetadataFileFormatUnknown {
+			format = commons.MetadataFileFormatCSV
+		}
+	}
+
+	var writer io.Writer = os.Stdout
+	if len(metaExport.metadataExportFlagValues.FilePath) > 0 {
+		targetPath := commons.MakeLocalPath(metaExport.metadataExportFlagValues.FilePath)
+
+		file, err := os.Create(targetPath)
+		if err != nil {
+			return xerrors.Errorf("failed to create file %q: %w", targetPath, err)
+		}
+		defer file.Close()
+
+		writer = file
+	}
+
+	err := commons.WriteMetadataRows(writer, format, rows)
+	if err != nil {
+		return xerrors.Errorf("failed to write metadata: %w", err)
+	}
+
+	return nil
+}
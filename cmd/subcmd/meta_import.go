@@ -0,0 +1,256 @@
+package subcmd
+
+import (
+	"encoding/csv"
+	"os"
+	"sync"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/cyverse/gocommands/cmd/flag"
+	"github.com/cyverse/gocommands/commons"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+)
+
+var metaImportCmd = &cobra.Command{
+	Use:     "import [metadata file]",
+	Aliases: []string{"imp"},
+	Short:   "Import metadata from a file",
+	Long:    `This adds metadata listed in a CSV (path,attribute,value,unit), JSON, or YAML file to data objects and collections.`,
+	RunE:    processMetaImportCommand,
+	Args:    cobra.ExactArgs(1),
+}
+
+func AddMetaImportCommand(metadataCmd *cobra.Command) {
+	// attach common flags
+	flag.SetCommonFlagsWithoutResource(metaImportCmd)
+
+	flag.SetMetadataImportFlags(metaImportCmd)
+	flag.SetDryRunFlags(metaImportCmd)
+
+	metadataCmd.AddCommand(metaImportCmd)
+}
+
+func processMetaImportCommand(command *cobra.Command, args []string) error {
+	metaImport, err := NewMetaImportCommand(command, args)
+	if err != nil {
+		return err
+	}
+
+	return metaImport.Process()
+}
+
+type MetaImportCommand struct {
+	command *cobra.Command
+
+	commonFlagValues         *flag.CommonFlagValues
+	metadataImportFlagValues *flag.MetadataImportFlagValues
+	dryRunFlagValues         *flag.DryRunFlagValues
+
+	account    *irodsclient_types.IRODSAccount
+	filesystem *irodsclient_fs.FileSystem
+
+	sourcePath string
+}
+
+func NewMetaImportCommand(command *cobra.Command, args []string) (*MetaImportCommand, error) {
+	metaImport := &MetaImportCommand{
+		command: command,
+
+		commonFlagValues:         flag.GetCommonFlagValues(command),
+		metadataImportFlagValues: flag.GetMetadataImportFlagValues(),
+		dryRunFlagValues:         flag.GetDryRunFlagValues(),
+	}
+
+	// path
+	metaImport.sourcePath = args[0]
+
+	return metaImport, nil
+}
+
+func (metaImport *MetaImportCommand) Process() error {
+	cont, err := flag.ProcessCommonFlags(metaImport.command)
+	if err != nil {
+		return xerrors.Errorf("failed to process common flags: %w", err)
+	}
+
+	if !cont {
+		return nil
+	}
+
+	// handle local flags
+	_, err = commons.InputMissingFields()
+	if err != nil {
+		return xerrors.Errorf("failed to input missing fields: %w", err)
+	}
+
+	rows, err := metaImport.readRows()
+	if err != nil {
+		return err
+	}
+
+	// Create a file system
+	metaImport.account = commons.GetSessionConfig().ToIRODSAccount()
+	metaImport.filesystem, err = commons.GetIRODSFSClientForMetadataOperation(metaImport.account, metaImport.metadataImportFlagValues.ThreadNumber)
+	if err != nil {
+		return xerrors.Errorf("failed to get iRODS FS Client: %w", err)
+	}
+	defer metaImport.filesystem.Release()
+
+	// run
+	rowErrors := metaImport.importRows(rows)
+
+	failed := 0
+	for idx, rowErr := range rowErrors {
+		if rowErr != nil {
+			failed++
+			commons.PrintErrorf("row %d (%s, %q=%q): %s\n", idx+1, rows[idx].Path, rows[idx].Attribute, rows[idx].Value, rowErr.Error())
+		}
+	}
+
+	if len(metaImport.metadataImportFlagValues.ErrorReportPath) > 0 && failed > 0 {
+		err = metaImport.writeErrorReport(rows, rowErrors)
+		if err != nil {
+			return err
+		}
+	}
+
+	if metaImport.dryRunFlagValues.DryRun {
+		commons.Printf("checked %d metadata rows, %d would fail\n", len(rows), failed)
+	} else {
+		commons.Printf("imported %d metadata rows, %d failed\n", len(rows)-failed, failed)
+	}
+
+	if failed > 0 {
+		return xerrors.Errorf("failed to import %d of %d metadata rows", failed, len(rows))
+	}
+
+	return nil
+}
+
+func (metaImport *MetaImportCommand) readRows() ([]*commons.MetadataRow, error) {
+	sourcePath := commons.MakeLocalPath(metaImport.sourcePath)
+
+	format := metaImport.metadataImportFlagValues.Format
+	if format == commons.MetadataFileFormatUnknown {
+		format = commons.DetectMetadataFileFormat(sourcePath)
+		if format == commons.MetadataFileFormatUnknown {
+			format = commons.MetadataFileFormatCSV
+		}
+	}
+
+	file, err := os.Open(sourcePath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to open file %q: %w", sourcePath, err)
+	}
+	defer file.Close()
+
+	rows, err := commons.ReadMetadataRows(file, format)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read metadata from %q: %w", sourcePath, err)
+	}
+
+	return rows, nil
+}
+
+// importRows adds metadata rows concurrently, returns errors indexed by row
+func (metaImport *MetaImportCommand) importRows(rows []*commons.MetadataRow) []error {
+	rowErrors := make([]error, len(rows))
+
+	rowIndexes := make(chan int)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < metaImport.metadataImportFlagValues.ThreadNumber; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for idx := range rowIndexes {
+				rowErrors[idx] = metaImport.importRow(rows[idx])
+			}
+		}()
+	}
+
+	for idx := range rows {
+		rowIndexes <- idx
+	}
+	close(rowIndexes)
+
+	wg.Wait()
+
+	return rowErrors
+}
+
+func (metaImport *MetaImportCommand) importRow(row *commons.MetadataRow) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "MetaImportCommand",
+		"function": "importRow",
+	})
+
+	err := row.Validate()
+	if err != nil {
+		return err
+	}
+
+	cwd := commons.GetCWD()
+	home := commons.GetHomeDir()
+	zone := metaImport.account.ClientZone
+	targetPath := commons.MakeIRODSPath(cwd, home, zone, row.Path)
+
+	if metaImport.dryRunFlagValues.DryRun {
+		_, err = metaImport.filesystem.Stat(targetPath)
+		if err != nil {
+			return xerrors.Errorf("failed to stat %q: %w", targetPath, err)
+		}
+
+		commons.Printf("would add metadata %q=%q (unit %q) to %q\n", row.Attribute, row.Value, row.Unit, targetPath)
+		return nil
+	}
+
+	logger.Debugf("add metadata to path %q - attr %q, value %q, unit %q", targetPath, row.Attribute, row.Value, row.Unit)
+
+	err = metaImport.filesystem.AddMetadata(targetPath, row.Attribute, row.Value, row.Unit)
+	if err != nil {
+		return xerrors.Errorf("failed to add metadata to path %q: %w", targetPath, err)
+	}
+
+	return nil
+}
+
+func (metaImport *MetaImportCommand) writeErrorReport(rows []*commons.MetadataRow, rowErrors []error) error {
+	reportPath := commons.MakeLocalPath(metaImport.metadataImportFlagValues.ErrorReportPath)
+
+	file, err := os.Create(reportPath)
+	if err != nil {
+		return xerrors.Errorf("failed to create error report %q: %w", reportPath, err)
+	}
+	defer file.Close()
+
+	csvWriter := csv.NewWriter(file)
+	err = csvWriter.Write([]string{"path", "attribute", "value", "unit", "error"})
+	if err != nil {
+		return xerrors.Errorf("failed to write error report %q: %w", reportPath, err)
+	}
+
+	for idx, rowErr := range rowErrors {
+		if rowErr == nil {
+			continue
+		}
+
+		row := rows[idx]
+		err = csvWriter.Write([]string{row.Path, row.Attribute, row.Value, row.Unit, rowErr.Error()})
+		if err != nil {
+			return xerrors.Errorf("failed to write error report %q: %w", reportPath, err)
+		}
+	}
+
+	csvWriter.Flush()
+	if err = csvWriter.Error(); err != nil {
+		return xerrors.Errorf("failed to write error report %q: %w", reportPath, err)
+	}
+
+	return nil
+}
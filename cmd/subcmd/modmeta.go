@@ -0,0 +1,277 @@
+package subcmd
+
+import (
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/cyverse/gocommands/cmd/flag"
+	"github.com/cyverse/gocommands/commons"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+)
+
+var modmetaCmd = &cobra.Command{
+	Use:     "modmeta [AVU ID|attribute name] [new attribute value] [new attribute unit (optional)]",
+	Aliases: []string{"mod_meta", "mod_metadata", "modify_meta", "modify_metadata"},
+	Short:   "Modify a metadata",
+	Long:    `This changes the value and unit of a metadata of the given collection, data object, user, or a resource. The unit is kept if not given.`,
+	RunE:    processModmetaCommand,
+	Args:    cobra.RangeArgs(2, 3),
+}
+
+func AddModmetaCommand(rootCmd *cobra.Command) {
+	// attach common flags
+	flag.SetCommonFlagsWithoutResource(modmetaCmd)
+
+	flag.SetTargetObjectFlags(modmetaCmd)
+	flag.SetRecursiveFlags(modmetaCmd, false)
+
+	rootCmd.AddCommand(modmetaCmd)
+}
+
+func processModmetaCommand(command *cobra.Command, args []string) error {
+	modMeta, err := NewModMetaCommand(command, args)
+	if err != nil {
+		return err
+	}
+
+	return modMeta.Process()
+}
+
+type ModMetaCommand struct {
+	command *cobra.Command
+
+	commonFlagValues       *flag.CommonFlagValues
+	targetObjectFlagValues *flag.TargetObjectFlagValues
+	recursiveFlagValues    *flag.RecursiveFlagValues
+
+	account    *irodsclient_types.IRODSAccount
+	filesystem *irodsclient_fs.FileSystem
+
+	avuIDOrName string
+	value       string
+	unit        string
+	unitUpdated bool
+
+	modified int
+}
+
+func NewModMetaCommand(command *cobra.Command, args []string) (*ModMetaCommand, error) {
+	modMeta := &ModMetaCommand{
+		command: command,
+
+		commonFlagValues:       flag.GetCommonFlagValues(command),
+		targetObjectFlagValues: flag.GetTargetObjectFlagValues(command),
+		recursiveFlagValues:    flag.GetRecursiveFlagValues(),
+	}
+
+	// get avu
+	modMeta.avuIDOrName = args[0]
+	modMeta.value = args[1]
+	modMeta.unit = ""
+	if len(args) >= 3 {
+		modMeta.unit = args[2]
+		modMeta.unitUpdated = true
+	}
+
+	return modMeta, nil
+}
+
+func (modMeta *ModMetaCommand) Process() error {
+	cont, err := flag.ProcessCommonFlags(modMeta.command)
+	if err != nil {
+		return xerrors.Errorf("failed to process common flags: %w", err)
+	}
+
+	if !cont {
+		return nil
+	}
+
+	// handle local flags
+	_, err = commons.InputMissingFields()
+	if err != nil {
+		return xerrors.Errorf("failed to input missing fields: %w", err)
+	}
+
+	// Create a file system
+	modMeta.account = commons.GetSessionConfig().ToIRODSAccount()
+	modMeta.filesystem, err = commons.GetIRODSFSClientForSingleOperation(modMeta.account)
+	if err != nil {
+		return xerrors.Errorf("failed to get iRODS FS Client: %w", err)
+	}
+	defer modMeta.filesystem.Release()
+
+	// modify meta
+	if modMeta.targetObjectFlagValues.PathUpdated {
+		cwd := commons.GetCWD()
+		home := commons.GetHomeDir()
+		zone := modMeta.account.ClientZone
+		targetPath := commons.MakeIRODSPath(cwd, home, zone, modMeta.targetObjectFlagValues.Path)
+
+		targetEntry, err := modMeta.filesystem.Stat(targetPath)
+		if err != nil {
+			return xerrors.Errorf("failed to stat %q: %w", targetPath, err)
+		}
+
+		err = modMeta.modifyMetaOfPath(targetEntry)
+		if err != nil {
+			return err
+		}
+	} else if modMeta.targetObjectFlagValues.UserUpdated {
+		err = modMeta.modifyMetaOfUser(modMeta.targetObjectFlagValues.User)
+		if err != nil {
+			return err
+		}
+	} else if modMeta.targetObjectFlagValues.ResourceUpdated {
+		err = modMeta.modifyMetaOfResource(modMeta.targetObjectFlagValues.Resource)
+		if err != nil {
+			return err
+		}
+	} else {
+		// nothing updated
+		return xerrors.Errorf("path, user, or resource must be given")
+	}
+
+	if modMeta.modified == 0 {
+		return xerrors.Errorf("failed to find metadata for avuid (or name) %q", modMeta.avuIDOrName)
+	}
+
+	return nil
+}
+
+// selectMeta returns the metadata to modify, nil if not found
+func (modMeta *ModMetaCommand) selectMeta(metas []*irodsclient_types.IRODSMeta, target string) (*irodsclient_types.IRODSMeta, error) {
+	selected := commons.SelectMetadata(metas, modMeta.avuIDOrName)
+	if len(selected) == 0 {
+		return nil, nil
+	}
+
+	if len(selected) > 1 {
+		return nil, xerrors.Errorf("found %d metadata with name %q in %s, use AVU ID or setmeta instead", len(selected), modMeta.avuIDOrName, target)
+	}
+
+	return selected[0], nil
+}
+
+func (modMeta *ModMetaCommand) makeNewMeta(oldMeta *irodsclient_types.IRODSMeta) *irodsclient_types.IRODSMeta {
+	newMeta := &irodsclient_types.IRODSMeta{
+		Name:  oldMeta.Name,
+		Value: modMeta.value,
+		Units: oldMeta.Units,
+	}
+
+	if modMeta.unitUpdated {
+		newMeta.Units = modMeta.unit
+	}
+
+	return newMeta
+}
+
+func (modMeta *ModMetaCommand) modifyMetaOfPath(entry *irodsclient_fs.Entry) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "ModMetaCommand",
+		"function": "modifyMetaOfPath",
+	})
+
+	metas, err := modMeta.filesystem.ListMetadata(entry.Path)
+	if err != nil {
+		return xerrors.Errorf("failed to list metadata of path %q: %w", entry.Path, err)
+	}
+
+	oldMeta, err := modMeta.selectMeta(metas, entry.Path)
+	if err != nil {
+		return err
+	}
+
+	if oldMeta != nil {
+		newMeta := modMeta.makeNewMeta(oldMeta)
+
+		logger.Debugf("modify metadata %d of path %q (attr %q, value %q, unit %q)", oldMeta.AVUID, entry.Path, newMeta.Name, newMeta.Value, newMeta.Units)
+
+		err = commons.ModifyPathMetadata(modMeta.filesystem, entry, oldMeta, newMeta)
+		if err != nil {
+			return xerrors.Errorf("failed to modify metadata %d of path %q: %w", oldMeta.AVUID, entry.Path, err)
+		}
+
+		modMeta.modified++
+	}
+
+	if !entry.IsDir() || !modMeta.recursiveFlagValues.Recursive {
+		return nil
+	}
+
+	entries, err := modMeta.filesystem.List(entry.Path)
+	if err != nil {
+		return xerrors.Errorf("failed to list dir %q: %w", entry.Path, err)
+	}
+
+	for _, childEntry := range entries {
+		err = modMeta.modifyMetaOfPath(childEntry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (modMeta *ModMetaCommand) modifyMetaOfUser(username string) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "ModMetaCommand",
+		"function": "modifyMetaOfUser",
+	})
+
+	metas, err := modMeta.filesystem.ListUserMetadata(username, modMeta.account.ClientZone)
+	if err != nil {
+		return xerrors.Errorf("failed to list metadata of user %q: %w", username, err)
+	}
+
+	oldMeta, err := modMeta.selectMeta(metas, username)
+	if err != nil || oldMeta == nil {
+		return err
+	}
+
+	newMeta := modMeta.makeNewMeta(oldMeta)
+
+	logger.Debugf("modify metadata %d of user %q (attr %q, value %q, unit %q)", oldMeta.AVUID, username, newMeta.Name, newMeta.Value, newMeta.Units)
+
+	err = commons.ModifyUserMetadata(modMeta.filesystem, username, modMeta.account.ClientZone, oldMeta, newMeta)
+	if err != nil {
+		return xerrors.Errorf("failed to modify metadata %d of user %q: %w", oldMeta.AVUID, username, err)
+	}
+
+	modMeta.modified++
+	return nil
+}
+
+func (modMeta *ModMetaCommand) modifyMetaOfResource(resource string) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "ModMetaCommand",
+		"function": "modifyMetaOfResource",
+	})
+
+	metas, err := modMeta.filesystem.ListResourceMetadata(resource)
+	if err != nil {
+		return xerrors.Errorf("failed to list metadata of resource %q: %w", resource, err)
+	}
+
+	oldMeta, err := modMeta.selectMeta(metas, resource)
+	if err != nil || oldMeta == nil {
+		return err
+	}
+
+	newMeta := modMeta.makeNewMeta(oldMeta)
+
+	logger.Debugf("modify metadata %d of resource %q (attr %q, value %q, unit %q)", oldMeta.AVUID, resource, newMeta.Name, newMeta.Value, newMeta.Units)
+
+	err = commons.ModifyResourceMetadata(modMeta.filesystem, resource, oldMeta, newMeta)
+	if err != nil {
+		return xerrors.Errorf("failed to modify metadata %d of resource %q: %w", oldMeta.AVUID, resource, err)
+	}
+
+	modMeta.modified++
+	return nil
+}
@@ -15,6 +15,19 @@ import (
 	"golang.org/x/xerrors"
 )
 
+// ProcessUserCount is a count of processes per user, written in machine-readable output
+type ProcessUserCount struct {
+	ProxyUser    string `json:"proxy_user"`
+	ClientUser   string `json:"client_user"`
+	ProcessCount int    `json:"process_count"`
+}
+
+// ProcessProgramCount is a count of processes per client program, written in machine-readable output
+type ProcessProgramCount struct {
+	ClientProgram string `json:"client_program"`
+	ProcessCount  int    `json:"process_count"`
+}
+
 var psCmd = &cobra.Command{
 	Use:     "ps",
 	Aliases: []string{"ips"},
@@ -29,6 +42,7 @@ func AddPsCommand(rootCmd *cobra.Command) {
 	flag.SetCommonFlags(psCmd, true)
 
 	flag.SetProcessFilterFlags(psCmd)
+	flag.SetOutputFormatFlags(psCmd)
 
 	rootCmd.AddCommand(psCmd)
 }
@@ -47,6 +61,7 @@ type PsCommand struct {
 
 	commonFlagValues        *flag.CommonFlagValues
 	processFilterFlagValues *flag.ProcessFilterFlagValues
+	outputFormatFlagValues  *flag.OutputFormatFlagValues
 
 	account    *irodsclient_types.IRODSAccount
 	filesystem *irodsclient_fs.FileSystem
@@ -58,6 +73,12 @@ func NewPsCommand(command *cobra.Command, args []string) (*PsCommand, error) {
 
 		commonFlagValues:        flag.GetCommonFlagValues(command),
 		processFilterFlagValues: flag.GetProcessFilterFlagValues(),
+		outputFormatFlagValues:  flag.GetOutputFormatFlagValues(),
+	}
+
+	err := ps.outputFormatFlagValues.CheckOutputFormat()
+	if err != nil {
+		return nil, err
 	}
 
 	return ps, nil
@@ -115,6 +136,10 @@ func (ps *PsCommand) listProcesses() error {
 		return xerrors.Errorf("failed to stat process addr %q, zone %q: %w", ps.processFilterFlagValues.Address, ps.processFilterFlagValues.Zone, err)
 	}
 
+	if ps.outputFormatFlagValues.Format.IsStructured() {
+		return ps.writeProcesses(processes)
+	}
+
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
 
@@ -207,3 +232,58 @@ func (ps *PsCommand) listProcesses() error {
 
 	return nil
 }
+
+// writeProcesses writes processes, or process counts if grouped, in machine-readable format
+func (ps *PsCommand) writeProcesses(processes []*irodsclient_types.IRODSProcess) error {
+	outputWriter := commons.NewOutputWriter(os.Stdout, ps.outputFormatFlagValues.Format)
+
+	records := []interface{}{}
+	switch ps.processFilterFlagValues.GroupBy {
+	case flag.ProcessGroupByNone:
+		for _, process := range processes {
+			records = append(records, process)
+		}
+	case flag.ProcessGroupByUser:
+		userCounts := map[string]*ProcessUserCount{}
+		for _, process := range processes {
+			proxyUser := fmt.Sprintf("%s#%s", process.ProxyUser, process.ProxyZone)
+			clientUser := fmt.Sprintf("%s#%s", process.ClientUser, process.ClientZone)
+			key := fmt.Sprintf("%s,%s", proxyUser, clientUser)
+
+			if userCount, ok := userCounts[key]; ok {
+				userCount.ProcessCount++
+				continue
+			}
+
+			userCounts[key] = &ProcessUserCount{
+				ProxyUser:    proxyUser,
+				ClientUser:   clientUser,
+				ProcessCount: 1,
+			}
+			records = append(records, userCounts[key])
+		}
+	case flag.ProcessGroupByProgram:
+		programCounts := map[string]*ProcessProgramCount{}
+		for _, process := range processes {
+			if programCount, ok := programCounts[process.ClientProgram]; ok {
+				programCount.ProcessCount++
+				continue
+			}
+
+			programCounts[process.ClientProgram] = &ProcessProgramCount{
+				ClientProgram: process.ClientProgram,
+				ProcessCount:  1,
+			}
+			records = append(records, programCounts[process.ClientProgram])
+		}
+	}
+
+	for _, record := range records {
+		err := outputWriter.Write(record)
+		if err != nil {
+			return xerrors.Errorf("failed to write output: %w", err)
+		}
+	}
+
+	return outputWriter.Flush()
+}
@@ -28,7 +28,7 @@ var putCmd = &cobra.Command{
 	Short:   "Upload files or directories",
 	Long:    `This uploads files or directories to the given iRODS collection.`,
 	RunE:    processPutCommand,
-	Args:    cobra.MinimumNArgs(1),
+	Args:    flag.MinimumNArgsOrFromReport(1),
 }
 
 func AddPutCommand(rootCmd *cobra.Command) {
@@ -36,20 +36,27 @@ func AddPutCommand(rootCmd *cobra.Command) {
 	flag.SetCommonFlags(putCmd, false)
 
 	flag.SetBundleTransferFlags(putCmd, true, true)
+	flag.SetBulkRegistrationFlags(putCmd, true)
 	flag.SetParallelTransferFlags(putCmd, false, false)
 	flag.SetForceFlags(putCmd, false)
 	flag.SetRecursiveFlags(putCmd, true)
 	flag.SetTicketAccessFlags(putCmd)
 	flag.SetProgressFlags(putCmd)
+	flag.SetEventFlags(putCmd)
+	flag.SetStatsFlags(putCmd)
 	flag.SetRetryFlags(putCmd)
 	flag.SetDifferentialTransferFlags(putCmd, false)
 	flag.SetChecksumFlags(putCmd, false, false)
 	flag.SetNoRootFlags(putCmd)
 	flag.SetSyncFlags(putCmd, false)
 	flag.SetEncryptionFlags(putCmd)
+	flag.SetEncryptionKeyFlags(putCmd)
 	flag.SetHiddenFileFlags(putCmd)
 	flag.SetPostTransferFlagValues(putCmd)
 	flag.SetTransferReportFlags(putCmd)
+	flag.SetFromReportFlags(putCmd)
+	flag.SetUploadMetadataFlags(putCmd)
+	flag.SetImportMetadataSidecarFlags(putCmd)
 
 	rootCmd.AddCommand(putCmd)
 }
@@ -60,7 +67,14 @@ func processPutCommand(command *cobra.Command, args []string) error {
 		return err
 	}
 
-	return put.Process()
+	err = put.Process()
+
+	statsErr := put.transferStats.Finish(err)
+	if err != nil {
+		return err
+	}
+
+	return statsErr
 }
 
 type PutCommand struct {
@@ -73,26 +87,37 @@ type PutCommand struct {
 	recursiveFlagValues            *flag.RecursiveFlagValues
 	ticketAccessFlagValues         *flag.TicketAccessFlagValues
 	progressFlagValues             *flag.ProgressFlagValues
+	eventFlagValues                *flag.EventFlagValues
+	statsFlagValues                *flag.StatsFlagValues
 	retryFlagValues                *flag.RetryFlagValues
 	differentialTransferFlagValues *flag.DifferentialTransferFlagValues
 	checksumFlagValues             *flag.ChecksumFlagValues
 	noRootFlagValues               *flag.NoRootFlagValues
 	syncFlagValues                 *flag.SyncFlagValues
 	encryptionFlagValues           *flag.EncryptionFlagValues
+	encryptionKeyFlagValues        *flag.EncryptionKeyFlagValues
 	hiddenFileFlagValues           *flag.HiddenFileFlagValues
 	postTransferFlagValues         *flag.PostTransferFlagValues
 	transferReportFlagValues       *flag.TransferReportFlagValues
+	fromReportFlagValues           *flag.FromReportFlagValues
+	uploadMetadataFlagValues       *flag.UploadMetadataFlagValues
+	metadataSidecarFlagValues      *flag.MetadataSidecarFlagValues
 
 	maxConnectionNum int
 
 	account    *irodsclient_types.IRODSAccount
 	filesystem *irodsclient_fs.FileSystem
 
+	encryptionKeySource commons.EncryptionKeySource
+
 	sourcePaths []string
 	targetPath  string
 
 	parallelJobManager    *commons.ParallelJobManager
 	transferReportManager *commons.TransferReportManager
+	eventWriter           *commons.EventWriter
+	transferStats         *commons.TransferStats
+	uploadMetadataManager *commons.UploadMetadataManager
 	updatedPathMap        map[string]bool
 }
 
@@ -107,15 +132,21 @@ func NewPutCommand(command *cobra.Command, args []string) (*PutCommand, error) {
 		recursiveFlagValues:            flag.GetRecursiveFlagValues(),
 		ticketAccessFlagValues:         flag.GetTicketAccessFlagValues(),
 		progressFlagValues:             flag.GetProgressFlagValues(),
+		eventFlagValues:                flag.GetEventFlagValues(),
+		statsFlagValues:                flag.GetStatsFlagValues(),
 		retryFlagValues:                flag.GetRetryFlagValues(),
 		differentialTransferFlagValues: flag.GetDifferentialTransferFlagValues(),
 		checksumFlagValues:             flag.GetChecksumFlagValues(),
 		noRootFlagValues:               flag.GetNoRootFlagValues(),
 		syncFlagValues:                 flag.GetSyncFlagValues(),
 		encryptionFlagValues:           flag.GetEncryptionFlagValues(command),
+		encryptionKeyFlagValues:        flag.GetEncryptionKeyFlagValues(),
 		hiddenFileFlagValues:           flag.GetHiddenFileFlagValues(),
 		postTransferFlagValues:         flag.GetPostTransferFlagValues(),
 		transferReportFlagValues:       flag.GetTransferReportFlagValues(command),
+		fromReportFlagValues:           flag.GetFromReportFlagValues(),
+		uploadMetadataFlagValues:       flag.GetUploadMetadataFlagValues(),
+		metadataSidecarFlagValues:      flag.GetMetadataSidecarFlagValues(),
 
 		updatedPathMap: map[string]bool{},
 	}
@@ -135,6 +166,27 @@ func NewPutCommand(command *cobra.Command, args []string) (*PutCommand, error) {
 		return nil, xerrors.Errorf("failed to put multiple source collections without creating root directory")
 	}
 
+	err := put.transferReportFlagValues.CheckReportFormat()
+	if err != nil {
+		return nil, err
+	}
+
+	err = put.progressFlagValues.CheckProgressMode()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(put.fromReportFlagValues.ReportPath) > 0 {
+		err = put.fromReportFlagValues.CheckStatuses()
+		if err != nil {
+			return nil, err
+		}
+
+		if put.syncFlagValues.Delete || put.postTransferFlagValues.DeleteOnSuccess {
+			return nil, xerrors.Errorf("failed to put with '--from_report', deleting files is not supported")
+		}
+	}
+
 	return put, nil
 }
 
@@ -169,6 +221,11 @@ func (put *PutCommand) Process() error {
 		return nil
 	}
 
+	// statistics, collected from here as errors in connecting are worth recording as well
+	if put.statsFlagValues.IsStatsRequired() {
+		put.transferStats = commons.NewTransferStats("put", put.statsFlagValues.Stats, put.statsFlagValues.OpenMetricsPath)
+	}
+
 	// Create a file system
 	put.account = commons.GetSessionConfig().ToIRODSAccount()
 	if len(put.ticketAccessFlagValues.Name) > 0 {
@@ -183,37 +240,78 @@ func (put *PutCommand) Process() error {
 	defer put.filesystem.Release()
 
 	// transfer report
-	put.transferReportManager, err = commons.NewTransferReportManager(put.transferReportFlagValues.Report, put.transferReportFlagValues.ReportPath, put.transferReportFlagValues.ReportToStdout)
+	put.transferReportManager, err = commons.NewTransferReportManager(put.transferReportFlagValues.Report, put.transferReportFlagValues.ReportPath, put.transferReportFlagValues.ReportToStdout, put.transferReportFlagValues.Format)
 	if err != nil {
 		return xerrors.Errorf("failed to create transfer report manager: %w", err)
 	}
 	defer put.transferReportManager.Release()
 
-	// set default key for encryption
-	if len(put.encryptionFlagValues.Key) == 0 {
+	// events
+	if len(put.eventFlagValues.Target) > 0 {
+		put.eventWriter, err = commons.NewEventWriter(put.eventFlagValues.Target)
+		if err != nil {
+			return xerrors.Errorf("failed to create event writer: %w", err)
+		}
+		defer put.eventWriter.Release()
+	}
+	put.transferReportManager.SetEventWriter(put.eventWriter)
+	put.transferReportManager.SetTransferStats(put.transferStats)
+
+	// metadata to attach
+	put.uploadMetadataManager, err = commons.NewUploadMetadataManager(put.uploadMetadataFlagValues.Metadata, put.uploadMetadataFlagValues.MetadataFilePath)
+	if err != nil {
+		return xerrors.Errorf("failed to create upload metadata manager: %w", err)
+	}
+	put.uploadMetadataManager.SetImportSidecar(put.metadataSidecarFlagValues.Import)
+
+	// get key for encryption, fall back to the iRODS password
+	keyConfig := put.encryptionKeyFlagValues.GetEncryptionKeyConfig(put.encryptionFlagValues.Key, put.account, true)
+	put.encryptionFlagValues.Key, put.encryptionKeySource, err = commons.ResolveEncryptionKey(keyConfig)
+	if err != nil {
+		return xerrors.Errorf("failed to get encryption key: %w", err)
+	}
+
+	if put.encryptionKeySource == commons.EncryptionKeySourceUnknown {
 		put.encryptionFlagValues.Key = put.account.Password
+		put.encryptionKeySource = commons.EncryptionKeySourcePassword
 	}
 
 	// parallel job manager
 	put.parallelJobManager = commons.NewParallelJobManager(put.filesystem, put.parallelTransferFlagValues.ThreadNumber, put.progressFlagValues.ShowProgress, put.progressFlagValues.ShowFullPath)
+	put.parallelJobManager.SetProgressMode(put.progressFlagValues.Mode, put.progressFlagValues.Interval)
+	put.parallelJobManager.SetEventWriter(put.eventWriter)
 	put.parallelJobManager.Start()
+	put.transferReportManager.SetParallelJobManager(put.parallelJobManager)
 
 	// run
-	if len(put.sourcePaths) >= 2 {
-		// multi-source, target must be a dir
-		err = put.ensureTargetIsDir(put.targetPath)
+	listingStartTime := time.Now()
+	if len(put.fromReportFlagValues.ReportPath) > 0 {
+		err = put.putFromReport(put.fromReportFlagValues.ReportPath)
 		if err != nil {
-			return err
+			return xerrors.Errorf("failed to put files in report %q: %w", put.fromReportFlagValues.ReportPath, err)
+		}
+	} else {
+		if len(put.sourcePaths) >= 2 {
+			// multi-source, target must be a dir
+			err = put.ensureTargetIsDir(put.targetPath)
+			if err != nil {
+				return err
+			}
 		}
-	}
 
-	for _, sourcePath := range put.sourcePaths {
-		err = put.putOne(sourcePath, put.targetPath)
-		if err != nil {
-			return xerrors.Errorf("failed to put %q to %q: %w", sourcePath, put.targetPath, err)
+		if put.progressFlagValues.ShowProgress {
+			go put.scanSources()
+		}
+
+		for _, sourcePath := range put.sourcePaths {
+			err = put.putOne(sourcePath, put.targetPath)
+			if err != nil {
+				return xerrors.Errorf("failed to put %q to %q: %w", sourcePath, put.targetPath, err)
+			}
 		}
 	}
 
+	put.transferStats.AddListingTime(time.Since(listingStartTime))
 	put.parallelJobManager.DoneScheduling()
 	err = put.parallelJobManager.Wait()
 	if err != nil {
@@ -351,6 +449,85 @@ func (put *PutCommand) putOne(sourcePath string, targetPath string) error {
 	return put.putFile(sourceStat, sourcePath, "", targetPath, requireEncryption, commons.EncryptionModeUnknown)
 }
 
+// scanSources counts files to upload to estimate ETA of progress
+func (put *PutCommand) scanSources() {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "PutCommand",
+		"function": "scanSources",
+	})
+
+	files, bytes, err := commons.ScanLocalPaths(put.sourcePaths, put.hiddenFileFlagValues.Exclude)
+	if err != nil {
+		logger.WithError(err).Debug("failed to scan sources for progress")
+		return
+	}
+
+	put.parallelJobManager.SetExpectedTotal(files, bytes)
+}
+
+func (put *PutCommand) putFromReport(reportPath string) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "PutCommand",
+		"function": "putFromReport",
+	})
+
+	reportFiles, err := commons.ReadTransferReportFilesFromPath(reportPath)
+	if err != nil {
+		return err
+	}
+
+	retryFiles := commons.GetTransferReportFilesToRetry(reportFiles, []commons.TransferMethod{commons.TransferMethodPut, commons.TransferMethodBput}, put.fromReportFlagValues.Statuses)
+	logger.Infof("retrying %d of %d transfers in report %q", len(retryFiles), len(reportFiles), reportPath)
+
+	for _, retryFile := range retryFiles {
+		err = put.putRetry(retryFile.SourcePath, retryFile.DestPath)
+		if err != nil {
+			return xerrors.Errorf("failed to put %q to %q: %w", retryFile.SourcePath, retryFile.DestPath, err)
+		}
+	}
+
+	return nil
+}
+
+// putRetry uploads a file to the exact target path recorded in a transfer report
+func (put *PutCommand) putRetry(sourcePath string, targetPath string) error {
+	sourceStat, err := os.Stat(sourcePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return irodsclient_types.NewFileNotFoundError(sourcePath)
+		}
+
+		return xerrors.Errorf("failed to stat %q: %w", sourcePath, err)
+	}
+
+	if sourceStat.IsDir() {
+		return commons.NewNotFileError(sourcePath)
+	}
+
+	targetDir := commons.GetDir(targetPath)
+	if !put.filesystem.ExistsDir(targetDir) {
+		err = put.filesystem.MakeDir(targetDir, true)
+		if err != nil {
+			return xerrors.Errorf("failed to make a collection %q: %w", targetDir, err)
+		}
+	}
+
+	requireEncryption, encryptionMode := put.requireEncryption(targetPath, false, commons.EncryptionModeUnknown)
+	if requireEncryption {
+		// the report has the encrypted filename, encrypt it again from source
+		tempPath, newTargetPath, err := put.getPathsForEncryption(sourcePath, targetDir)
+		if err != nil {
+			return xerrors.Errorf("failed to get encryption path for %q: %w", sourcePath, err)
+		}
+
+		return put.putFile(sourceStat, sourcePath, tempPath, newTargetPath, requireEncryption, encryptionMode)
+	}
+
+	return put.putFile(sourceStat, sourcePath, "", targetPath, requireEncryption, commons.EncryptionModeUnknown)
+}
+
 func (put *PutCommand) schedulePut(sourceStat fs.FileInfo, sourcePath string, tempPath string, targetPath string, requireDecryption bool, encryptionMode commons.EncryptionMode) error {
 	logger := log.WithFields(log.Fields{
 		"package":  "subcmd",
@@ -370,16 +547,44 @@ func (put *PutCommand) schedulePut(sourceStat fs.FileInfo, sourcePath string, te
 
 		logger.Debugf("uploading a file %q to %q", sourcePath, targetPath)
 
+		startTime := time.Now()
+
 		var uploadErr error
 		var uploadResult *irodsclient_fs.FileTransferResult
 		notes := []string{}
 
-		// encrypt
-		if requireDecryption {
+		if requireDecryption && len(tempPath) > 0 {
+			defer func() {
+				logger.Debugf("removing a temp file %q", tempPath)
+				os.Remove(tempPath)
+			}()
+		}
+
+		// reports the failure so the file can be retried
+		failPut := func(err error) error {
+			job.Progress(-1, sourceStat.Size(), true)
+
+			reportFile := &commons.TransferReportFile{
+				Method:     commons.TransferMethodPut,
+				StartAt:    startTime,
+				EndAt:      time.Now(),
+				SourcePath: sourcePath,
+				SourceSize: sourceStat.Size(),
+				DestPath:   targetPath,
+				Error:      err,
+				Notes:      notes,
+			}
+
+			put.transferReportManager.AddFile(reportFile)
+			return err
+		}
+
+		// encrypt while uploading if possible, otherwise encrypt to a temp file
+		streamEncryption := requireDecryption && encryptionMode != commons.EncryptionModeUnknown && put.canStreamEncryption()
+		if requireDecryption && !streamEncryption {
 			encrypted, err := put.encryptFile(sourcePath, tempPath, encryptionMode)
 			if err != nil {
-				job.Progress(-1, sourceStat.Size(), true)
-				return xerrors.Errorf("failed to decrypt file: %w", err)
+				return failPut(xerrors.Errorf("failed to encrypt file %q: %w", sourcePath, err))
 			}
 
 			if encrypted {
@@ -393,7 +598,16 @@ func (put *PutCommand) schedulePut(sourceStat fs.FileInfo, sourcePath string, te
 		}
 
 		// determine how to upload
-		if put.parallelTransferFlagValues.SingleThread || put.parallelTransferFlagValues.ThreadNumber == 1 {
+		if streamEncryption {
+			taskNum := 0
+			if put.parallelTransferFlagValues.SingleThread || put.parallelTransferFlagValues.ThreadNumber == 1 {
+				taskNum = 1
+			}
+
+			encryptManager := put.getEncryptionManagerForEncryption(encryptionMode)
+			uploadResult, uploadErr = commons.UploadFileEncrypted(fs, encryptManager, sourcePath, targetPath, taskNum, put.checksumFlagValues.CalculateChecksum, callbackPut)
+			notes = append(notes, "encrypted", targetPath, "stream")
+		} else if put.parallelTransferFlagValues.SingleThread || put.parallelTransferFlagValues.ThreadNumber == 1 {
 			uploadResult, uploadErr = fs.UploadFile(uploadSourcePath, targetPath, "", false, put.checksumFlagValues.CalculateChecksum, put.checksumFlagValues.VerifyChecksum, false, callbackPut)
 			notes = append(notes, "icat", "single-thread")
 		} else if put.parallelTransferFlagValues.RedirectToResource {
@@ -415,19 +629,28 @@ func (put *PutCommand) schedulePut(sourceStat fs.FileInfo, sourcePath string, te
 		}
 
 		if uploadErr != nil {
-			job.Progress(-1, sourceStat.Size(), true)
-			return xerrors.Errorf("failed to upload %q to %q: %w", sourcePath, targetPath, uploadErr)
+			return failPut(xerrors.Errorf("failed to upload %q to %q: %w", sourcePath, targetPath, uploadErr))
 		}
 
-		err := put.transferReportManager.AddTransfer(uploadResult, commons.TransferMethodPut, uploadErr, notes)
+		// attach metadata
+		metadataNotes, err := put.uploadMetadataManager.Apply(fs, sourcePath, targetPath)
+		notes = append(notes, metadataNotes...)
 		if err != nil {
-			job.Progress(-1, sourceStat.Size(), true)
-			return xerrors.Errorf("failed to add transfer report: %w", err)
+			return failPut(xerrors.Errorf("failed to add metadata to %q: %w", targetPath, err))
 		}
 
-		if requireDecryption {
-			logger.Debugf("removing a temp file %q", tempPath)
-			os.Remove(tempPath)
+		// store key fingerprint to detect wrong keys before downloading, never for the iRODS password as it is readable by others
+		if requireDecryption && commons.UseEncryptionKey(encryptionMode) && put.encryptionKeySource != commons.EncryptionKeySourcePassword {
+			err = commons.SetEncryptionKeyFingerprintMeta(fs, targetPath, []byte(put.encryptionFlagValues.Key))
+			if err != nil {
+				return failPut(xerrors.Errorf("failed to add key fingerprint to %q: %w", targetPath, err))
+			}
+		}
+
+		err = put.transferReportManager.AddTransfer(uploadResult, commons.TransferMethodPut, uploadErr, notes)
+		if err != nil {
+			job.Progress(-1, sourceStat.Size(), true)
+			return xerrors.Errorf("failed to add transfer report: %w", err)
 		}
 
 		logger.Debugf("uploaded a file %q to %q", sourcePath, targetPath)
@@ -440,7 +663,26 @@ func (put *PutCommand) schedulePut(sourceStat fs.FileInfo, sourcePath string, te
 	threadsRequired := put.computeThreadsRequired(sourceStat.Size())
 	err := put.parallelJobManager.Schedule(sourcePath, putTask, threadsRequired, progress.UnitsBytes)
 	if err != nil {
-		return xerrors.Errorf("failed to schedule upload %q to %q: %w", sourcePath, targetPath, err)
+		scheduleErr := xerrors.Errorf("failed to schedule upload %q to %q: %w", sourcePath, targetPath, err)
+
+		reportFile := &commons.TransferReportFile{
+			Method:     commons.TransferMethodPut,
+			StartAt:    time.Now(),
+			EndAt:      time.Now(),
+			SourcePath: sourcePath,
+			SourceSize: sourceStat.Size(),
+			DestPath:   targetPath,
+			Error:      scheduleErr,
+			Notes:      []string{"not scheduled"},
+		}
+
+		put.transferReportManager.AddFile(reportFile)
+
+		if len(tempPath) > 0 {
+			os.Remove(tempPath)
+		}
+
+		return scheduleErr
 	}
 
 	logger.Debugf("scheduled a file upload %q to %q", sourcePath, targetPath)
@@ -714,6 +956,11 @@ func (put *PutCommand) putDir(_ fs.FileInfo, sourcePath string, targetPath strin
 
 		entryPath := filepath.Join(sourcePath, entry.Name())
 
+		if put.metadataSidecarFlagValues.Import && commons.IsMetadataSidecarPath(entryPath) {
+			// sidecar is imported as metadata of the data object
+			continue
+		}
+
 		entryStat, err := os.Stat(entryPath)
 		if err != nil {
 			if os.IsNotExist(err) {
@@ -880,9 +1127,29 @@ func (put *PutCommand) getEncryptionManagerForEncryption(mode commons.Encryption
 
 	switch mode {
 	case commons.EncryptionModeWinSCP, commons.EncryptionModePGP:
+		if put.encryptionKeySource == commons.EncryptionKeySourcePassword {
+			commons.WarnPasswordEncryptionKey()
+		}
+
 		manager.SetKey([]byte(put.encryptionFlagValues.Key))
 	case commons.EncryptionModeSSH:
 		manager.SetPublicPrivateKey(put.encryptionFlagValues.PublicPrivateKeyPath)
+	case commons.EncryptionModeAge:
+		recipients := put.encryptionFlagValues.Recipients
+
+		// use passphrase only if given, not the default key
+		if put.encryptionKeySource != commons.EncryptionKeySourcePassword {
+			manager.SetKey([]byte(put.encryptionFlagValues.Key))
+		} else if len(recipients) == 0 {
+			recipients = []string{put.encryptionFlagValues.PublicPrivateKeyPath}
+		}
+
+		manager.SetRecipients(recipients)
+		if put.encryptionFlagValues.DefaultIdentities {
+			manager.SetDefaultIdentities(put.encryptionFlagValues.Identities)
+		} else {
+			manager.SetIdentities(put.encryptionFlagValues.Identities)
+		}
 	}
 
 	return manager
@@ -893,6 +1160,16 @@ func (put *PutCommand) getPathsForEncryption(sourcePath string, targetPath strin
 		encryptManager := put.getEncryptionManagerForEncryption(put.encryptionFlagValues.Mode)
 		sourceFilename := commons.GetBasename(sourcePath)
 
+		targetDir := targetPath
+		if !put.filesystem.ExistsDir(targetDir) {
+			targetDir = commons.GetDir(targetPath)
+		}
+
+		err := encryptManager.LoadFilenameKey(put.filesystem, targetDir, true)
+		if err != nil {
+			return "", "", xerrors.Errorf("failed to load filename key for %q: %w", targetDir, err)
+		}
+
 		encryptedFilename, err := encryptManager.EncryptFilename(sourceFilename)
 		if err != nil {
 			return "", "", xerrors.Errorf("failed to encrypt filename %q: %w", sourcePath, err)
@@ -910,6 +1187,12 @@ func (put *PutCommand) getPathsForEncryption(sourcePath string, targetPath strin
 	return "", targetFilePath, nil
 }
 
+// canStreamEncryption returns true if files can be encrypted while uploading.
+// Temp files are needed to verify checksum of encrypted files or to upload to resource servers directly.
+func (put *PutCommand) canStreamEncryption() bool {
+	return !put.checksumFlagValues.VerifyChecksum && !put.parallelTransferFlagValues.RedirectToResource
+}
+
 func (put *PutCommand) encryptFile(sourcePath string, encryptedFilePath string, encryptionMode commons.EncryptionMode) (bool, error) {
 	logger := log.WithFields(log.Fields{
 		"package":  "subcmd",
@@ -0,0 +1,23 @@
+package subcmd
+
+import (
+	"github.com/cyverse/gocommands/cmd/flag"
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report [subcommand]",
+	Short: "Summarize or filter transfer reports",
+	Long:  `This reads transfer reports created with '--report' in JSON lines or CSV, and prints totals or selected transfers.`,
+	Args:  cobra.NoArgs,
+}
+
+func AddReportCommand(rootCmd *cobra.Command) {
+	// attach common flags
+	flag.SetCommonFlagsWithoutResource(reportCmd)
+
+	AddReportSummarizeCommand(reportCmd)
+	AddReportFilterCommand(reportCmd)
+
+	rootCmd.AddCommand(reportCmd)
+}
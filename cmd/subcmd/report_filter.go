@@ -0,0 +1,150 @@
+package subcmd
+
+import (
+	"github.com/cyverse/gocommands/cmd/flag"
+	"github.com/cyverse/gocommands/commons"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+)
+
+var reportFilterCmd = &cobra.Command{
+	Use:   "filter [report1] [report2] ...",
+	Short: "Print selected transfers in transfer reports",
+	Long:  `This prints transfers in transfer reports matching the given status, method and duration, in a transfer report format.`,
+	RunE:  processReportFilterCommand,
+	Args:  cobra.MinimumNArgs(1),
+}
+
+func AddReportFilterCommand(reportCmd *cobra.Command) {
+	// attach common flags
+	flag.SetCommonFlagsWithoutResource(reportFilterCmd)
+
+	flag.SetReportFilterFlags(reportFilterCmd)
+	flag.SetTransferReportFormatFlags(reportFilterCmd)
+
+	reportCmd.AddCommand(reportFilterCmd)
+}
+
+func processReportFilterCommand(command *cobra.Command, args []string) error {
+	reportFilter, err := NewReportFilterCommand(command, args)
+	if err != nil {
+		return err
+	}
+
+	return reportFilter.Process()
+}
+
+type ReportFilterCommand struct {
+	command *cobra.Command
+
+	commonFlagValues         *flag.CommonFlagValues
+	reportFilterFlagValues   *flag.ReportFilterFlagValues
+	transferReportFlagValues *flag.TransferReportFlagValues
+
+	reportPaths []string
+}
+
+func NewReportFilterCommand(command *cobra.Command, args []string) (*ReportFilterCommand, error) {
+	reportFilter := &ReportFilterCommand{
+		command: command,
+
+		commonFlagValues:         flag.GetCommonFlagValues(command),
+		reportFilterFlagValues:   flag.GetReportFilterFlagValues(),
+		transferReportFlagValues: flag.GetTransferReportFlagValues(command),
+	}
+
+	// paths
+	reportFilter.reportPaths = args
+
+	err := reportFilter.transferReportFlagValues.CheckReportFormat()
+	if err != nil {
+		return nil, err
+	}
+
+	return reportFilter, nil
+}
+
+func (reportFilter *ReportFilterCommand) Process() error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "ReportFilterCommand",
+		"function": "Process",
+	})
+
+	cont, err := flag.ProcessCommonFlags(reportFilter.command)
+	if err != nil {
+		return xerrors.Errorf("failed to process common flags: %w", err)
+	}
+
+	if !cont {
+		return nil
+	}
+
+	// JSON lines by default, to be read again
+	format := reportFilter.transferReportFlagValues.Format
+	if format == commons.TransferReportFormatUnknown {
+		format = commons.TransferReportFormatNDJSON
+	}
+
+	reportManager, err := commons.NewTransferReportManager(true, "-", true, format)
+	if err != nil {
+		return xerrors.Errorf("failed to create transfer report manager: %w", err)
+	}
+	defer reportManager.Release()
+
+	// run
+	for _, reportPath := range reportFilter.reportPaths {
+		logger.Debugf("filter report %q", reportPath)
+
+		files, err := commons.ReadTransferReportFilesFromPath(reportPath)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			if !reportFilter.matches(file) {
+				continue
+			}
+
+			err = reportManager.AddFile(file)
+			if err != nil {
+				return xerrors.Errorf("failed to write transfer of %q: %w", file.SourcePath, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (reportFilter *ReportFilterCommand) matches(file *commons.TransferReportFile) bool {
+	if len(reportFilter.reportFilterFlagValues.Statuses) > 0 {
+		matched := false
+		for _, status := range reportFilter.reportFilterFlagValues.Statuses {
+			if file.GetStatus() == status {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	if len(reportFilter.reportFilterFlagValues.Methods) > 0 {
+		matched := false
+		for _, method := range reportFilter.reportFilterFlagValues.Methods {
+			if file.Method == method {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	return file.GetDuration() >= reportFilter.reportFilterFlagValues.MinDuration
+}
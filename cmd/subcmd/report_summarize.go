@@ -0,0 +1,108 @@
+package subcmd
+
+import (
+	"os"
+
+	"github.com/cyverse/gocommands/cmd/flag"
+	"github.com/cyverse/gocommands/commons"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+)
+
+var reportSummarizeCmd = &cobra.Command{
+	Use:     "summarize [report1] [report2] ...",
+	Aliases: []string{"summary", "sum"},
+	Short:   "Print totals of transfer reports",
+	Long:    `This prints numbers of transferred, skipped and failed files, bytes and throughput, failures, and slowest transfers in transfer reports.`,
+	RunE:    processReportSummarizeCommand,
+	Args:    cobra.MinimumNArgs(1),
+}
+
+func AddReportSummarizeCommand(reportCmd *cobra.Command) {
+	// attach common flags
+	flag.SetCommonFlagsWithoutResource(reportSummarizeCmd)
+
+	flag.SetReportSummarizeFlags(reportSummarizeCmd)
+	flag.SetOutputFormatFlags(reportSummarizeCmd)
+
+	reportCmd.AddCommand(reportSummarizeCmd)
+}
+
+func processReportSummarizeCommand(command *cobra.Command, args []string) error {
+	reportSummarize, err := NewReportSummarizeCommand(command, args)
+	if err != nil {
+		return err
+	}
+
+	return reportSummarize.Process()
+}
+
+type ReportSummarizeCommand struct {
+	command *cobra.Command
+
+	commonFlagValues          *flag.CommonFlagValues
+	reportSummarizeFlagValues *flag.ReportSummarizeFlagValues
+	outputFormatFlagValues    *flag.OutputFormatFlagValues
+
+	reportPaths []string
+}
+
+func NewReportSummarizeCommand(command *cobra.Command, args []string) (*ReportSummarizeCommand, error) {
+	reportSummarize := &ReportSummarizeCommand{
+		command: command,
+
+		commonFlagValues:          flag.GetCommonFlagValues(command),
+		reportSummarizeFlagValues: flag.GetReportSummarizeFlagValues(),
+		outputFormatFlagValues:    flag.GetOutputFormatFlagValues(),
+	}
+
+	// paths
+	reportSummarize.reportPaths = args
+
+	err := reportSummarize.outputFormatFlagValues.CheckOutputFormat()
+	if err != nil {
+		return nil, err
+	}
+
+	return reportSummarize, nil
+}
+
+func (reportSummarize *ReportSummarizeCommand) Process() error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "ReportSummarizeCommand",
+		"function": "Process",
+	})
+
+	cont, err := flag.ProcessCommonFlags(reportSummarize.command)
+	if err != nil {
+		return xerrors.Errorf("failed to process common flags: %w", err)
+	}
+
+	if !cont {
+		return nil
+	}
+
+	// run
+	summary := commons.NewTransferReportSummary(reportSummarize.reportSummarizeFlagValues.SlowestCount)
+	for _, reportPath := range reportSummarize.reportPaths {
+		logger.Debugf("summarize report %q", reportPath)
+
+		files, err := commons.ReadTransferReportFilesFromPath(reportPath)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			summary.Add(file)
+		}
+	}
+
+	if reportSummarize.outputFormatFlagValues.Format.IsStructured() {
+		return commons.WriteOutput(os.Stdout, reportSummarize.outputFormatFlagValues.Format, summary)
+	}
+
+	summary.Write(commons.GetTerminalWriter())
+	return nil
+}
@@ -0,0 +1,205 @@
+package subcmd
+
+import (
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/cyverse/gocommands/cmd/flag"
+	"github.com/cyverse/gocommands/commons"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+)
+
+var setmetaCmd = &cobra.Command{
+	Use:     "setmeta [attribute name] [attribute value] [attribute unit (optional)]",
+	Aliases: []string{"set_meta", "set_metadata"},
+	Short:   "Set a metadata",
+	Long:    `This replaces all metadata having the attribute name with the given metadata for the given collection, data object, user, or a resource.`,
+	RunE:    processSetmetaCommand,
+	Args:    cobra.RangeArgs(2, 3),
+}
+
+func AddSetmetaCommand(rootCmd *cobra.Command) {
+	// attach common flags
+	flag.SetCommonFlagsWithoutResource(setmetaCmd)
+
+	flag.SetTargetObjectFlags(setmetaCmd)
+	flag.SetRecursiveFlags(setmetaCmd, false)
+
+	rootCmd.AddCommand(setmetaCmd)
+}
+
+func processSetmetaCommand(command *cobra.Command, args []string) error {
+	setMeta, err := NewSetMetaCommand(command, args)
+	if err != nil {
+		return err
+	}
+
+	return setMeta.Process()
+}
+
+type SetMetaCommand struct {
+	command *cobra.Command
+
+	commonFlagValues       *flag.CommonFlagValues
+	targetObjectFlagValues *flag.TargetObjectFlagValues
+	recursiveFlagValues    *flag.RecursiveFlagValues
+
+	account    *irodsclient_types.IRODSAccount
+	filesystem *irodsclient_fs.FileSystem
+
+	attribute string
+	value     string
+	unit      string
+}
+
+func NewSetMetaCommand(command *cobra.Command, args []string) (*SetMetaCommand, error) {
+	setMeta := &SetMetaCommand{
+		command: command,
+
+		commonFlagValues:       flag.GetCommonFlagValues(command),
+		targetObjectFlagValues: flag.GetTargetObjectFlagValues(command),
+		recursiveFlagValues:    flag.GetRecursiveFlagValues(),
+	}
+
+	// get avu
+	setMeta.attribute = args[0]
+	setMeta.value = args[1]
+	setMeta.unit = ""
+	if len(args) >= 3 {
+		setMeta.unit = args[2]
+	}
+
+	return setMeta, nil
+}
+
+func (setMeta *SetMetaCommand) Process() error {
+	cont, err := flag.ProcessCommonFlags(setMeta.command)
+	if err != nil {
+		return xerrors.Errorf("failed to process common flags: %w", err)
+	}
+
+	if !cont {
+		return nil
+	}
+
+	// handle local flags
+	_, err = commons.InputMissingFields()
+	if err != nil {
+		return xerrors.Errorf("failed to input missing fields: %w", err)
+	}
+
+	// Create a file system
+	setMeta.account = commons.GetSessionConfig().ToIRODSAccount()
+	setMeta.filesystem, err = commons.GetIRODSFSClientForSingleOperation(setMeta.account)
+	if err != nil {
+		return xerrors.Errorf("failed to get iRODS FS Client: %w", err)
+	}
+	defer setMeta.filesystem.Release()
+
+	meta := &irodsclient_types.IRODSMeta{
+		Name:  setMeta.attribute,
+		Value: setMeta.value,
+		Units: setMeta.unit,
+	}
+
+	// set meta
+	if setMeta.targetObjectFlagValues.PathUpdated {
+		cwd := commons.GetCWD()
+		home := commons.GetHomeDir()
+		zone := setMeta.account.ClientZone
+		targetPath := commons.MakeIRODSPath(cwd, home, zone, setMeta.targetObjectFlagValues.Path)
+
+		targetEntry, err := setMeta.filesystem.Stat(targetPath)
+		if err != nil {
+			return xerrors.Errorf("failed to stat %q: %w", targetPath, err)
+		}
+
+		err = setMeta.setMetaToPath(targetEntry, meta)
+		if err != nil {
+			return err
+		}
+	} else if setMeta.targetObjectFlagValues.UserUpdated {
+		err = setMeta.setMetaToUser(setMeta.targetObjectFlagValues.User, meta)
+		if err != nil {
+			return err
+		}
+	} else if setMeta.targetObjectFlagValues.ResourceUpdated {
+		err = setMeta.setMetaToResource(setMeta.targetObjectFlagValues.Resource, meta)
+		if err != nil {
+			return err
+		}
+	} else {
+		// nothing updated
+		return xerrors.Errorf("path, user, or resource must be given")
+	}
+
+	return nil
+}
+
+func (setMeta *SetMetaCommand) setMetaToPath(entry *irodsclient_fs.Entry, meta *irodsclient_types.IRODSMeta) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "SetMetaCommand",
+		"function": "setMetaToPath",
+	})
+
+	logger.Debugf("set metadata to path %q (attr %q, value %q, unit %q)", entry.Path, meta.Name, meta.Value, meta.Units)
+
+	err := commons.SetPathMetadata(setMeta.filesystem, entry, meta)
+	if err != nil {
+		return xerrors.Errorf("failed to set metadata to path %q (attr %q, value %q, unit %q): %w", entry.Path, meta.Name, meta.Value, meta.Units, err)
+	}
+
+	if !entry.IsDir() || !setMeta.recursiveFlagValues.Recursive {
+		return nil
+	}
+
+	entries, err := setMeta.filesystem.List(entry.Path)
+	if err != nil {
+		return xerrors.Errorf("failed to list dir %q: %w", entry.Path, err)
+	}
+
+	for _, childEntry := range entries {
+		err = setMeta.setMetaToPath(childEntry, meta)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (setMeta *SetMetaCommand) setMetaToUser(username string, meta *irodsclient_types.IRODSMeta) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "SetMetaCommand",
+		"function": "setMetaToUser",
+	})
+
+	logger.Debugf("set metadata to user %q (attr %q, value %q, unit %q)", username, meta.Name, meta.Value, meta.Units)
+
+	err := commons.SetUserMetadata(setMeta.filesystem, username, setMeta.account.ClientZone, meta)
+	if err != nil {
+		return xerrors.Errorf("failed to set metadata to user %q (attr %q, value %q, unit %q): %w", username, meta.Name, meta.Value, meta.Units, err)
+	}
+
+	return nil
+}
+
+func (setMeta *SetMetaCommand) setMetaToResource(resource string, meta *irodsclient_types.IRODSMeta) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "SetMetaCommand",
+		"function": "setMetaToResource",
+	})
+
+	logger.Debugf("set metadata to resource %q (attr %q, value %q, unit %q)", resource, meta.Name, meta.Value, meta.Units)
+
+	err := commons.SetResourceMetadata(setMeta.filesystem, resource, meta)
+	if err != nil {
+		return xerrors.Errorf("failed to set metadata to resource %q (attr %q, value %q, unit %q): %w", resource, meta.Name, meta.Value, meta.Units, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,527 @@
+package subcmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_irodsfs "github.com/cyverse/go-irodsclient/irods/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/cyverse/gocommands/cmd/flag"
+	"github.com/cyverse/gocommands/commons"
+	"github.com/jedib0t/go-pretty/v6/table"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+)
+
+// StatReplica is a replica of a data object in stat output
+type StatReplica struct {
+	Number            int64     `json:"number"`
+	Owner             string    `json:"owner"`
+	ResourceName      string    `json:"resource_name"`
+	ResourceHierarchy string    `json:"resource_hierarchy"`
+	Status            string    `json:"status"`
+	Checksum          string    `json:"checksum,omitempty"`
+	Path              string    `json:"path"`
+	CreateTime        time.Time `json:"create_time"`
+	ModifyTime        time.Time `json:"modify_time"`
+}
+
+// StatAccess is an ACL entry in stat output
+type StatAccess struct {
+	UserName    string `json:"user_name"`
+	UserZone    string `json:"user_zone"`
+	UserType    string `json:"user_type"`
+	AccessLevel string `json:"access_level"`
+}
+
+// StatMeta is an AVU in stat output
+type StatMeta struct {
+	AVUID      int64     `json:"avu_id"`
+	Name       string    `json:"name"`
+	Value      string    `json:"value"`
+	Units      string    `json:"units"`
+	CreateTime time.Time `json:"create_time"`
+	ModifyTime time.Time `json:"modify_time"`
+}
+
+// StatTicket is a ticket pointing at a data object or a collection in stat output
+type StatTicket struct {
+	Name           string    `json:"name"`
+	Type           string    `json:"type"`
+	Owner          string    `json:"owner"`
+	OwnerZone      string    `json:"owner_zone"`
+	ExpirationTime time.Time `json:"expiration_time"`
+}
+
+// StatResult has everything known about a data object or a collection
+type StatResult struct {
+	ID                int64          `json:"id"`
+	Path              string         `json:"path"`
+	Name              string         `json:"name"`
+	Type              string         `json:"type"`
+	Owner             string         `json:"owner"`
+	Size              int64          `json:"size"`
+	DataType          string         `json:"data_type,omitempty"`
+	ChecksumAlgorithm string         `json:"checksum_algorithm,omitempty"`
+	ACLInheritance    *bool          `json:"acl_inheritance,omitempty"`
+	CreateTime        time.Time      `json:"create_time"`
+	ModifyTime        time.Time      `json:"modify_time"`
+	Replicas          []*StatReplica `json:"replicas,omitempty"`
+	ACLs              []*StatAccess  `json:"acls"`
+	Metadata          []*StatMeta    `json:"metadata"`
+	Tickets           []*StatTicket  `json:"tickets,omitempty"`
+}
+
+var statCmd = &cobra.Command{
+	Use:     "stat [data-object1] [collection1] ...",
+	Aliases: []string{"istat"},
+	Short:   "Display details of iRODS data-objects or collections",
+	Long:    `This displays all details of iRODS data-objects or collections, including replicas, ACLs, metadata and tickets.`,
+	RunE:    processStatCommand,
+	Args:    cobra.MinimumNArgs(1),
+}
+
+func AddStatCommand(rootCmd *cobra.Command) {
+	// attach common flags
+	flag.SetCommonFlags(statCmd, true)
+
+	flag.SetStatFlags(statCmd)
+	flag.SetACLFlags(statCmd)
+	flag.SetOutputFormatFlags(statCmd)
+
+	rootCmd.AddCommand(statCmd)
+}
+
+func processStatCommand(command *cobra.Command, args []string) error {
+	stat, err := NewStatCommand(command, args)
+	if err != nil {
+		return err
+	}
+
+	return stat.Process()
+}
+
+type StatCommand struct {
+	command *cobra.Command
+
+	commonFlagValues       *flag.CommonFlagValues
+	statFlagValues         *flag.StatFlagValues
+	aclFlagValues          *flag.ACLFlagValues
+	outputFormatFlagValues *flag.OutputFormatFlagValues
+
+	account    *irodsclient_types.IRODSAccount
+	filesystem *irodsclient_fs.FileSystem
+
+	targetPaths []string
+}
+
+func NewStatCommand(command *cobra.Command, args []string) (*StatCommand, error) {
+	stat := &StatCommand{
+		command: command,
+
+		commonFlagValues:       flag.GetCommonFlagValues(command),
+		statFlagValues:         flag.GetStatFlagValues(),
+		aclFlagValues:          flag.GetACLFlagValues(),
+		outputFormatFlagValues: flag.GetOutputFormatFlagValues(),
+	}
+
+	// path
+	stat.targetPaths = args
+
+	if stat.statFlagValues.JSONOutput {
+		stat.outputFormatFlagValues.Format = commons.OutputFormatJSON
+	}
+
+	err := stat.outputFormatFlagValues.CheckOutputFormat()
+	if err != nil {
+		return nil, err
+	}
+
+	return stat, nil
+}
+
+func (stat *StatCommand) Process() error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "StatCommand",
+		"function": "Process",
+	})
+
+	cont, err := flag.ProcessCommonFlags(stat.command)
+	if err != nil {
+		return xerrors.Errorf("failed to process common flags: %w", err)
+	}
+
+	if !cont {
+		return nil
+	}
+
+	// handle local flags
+	_, err = commons.InputMissingFields()
+	if err != nil {
+		return xerrors.Errorf("failed to input missing fields: %w", err)
+	}
+
+	// Create a file system
+	stat.account = commons.GetSessionConfig().ToIRODSAccount()
+	stat.filesystem, err = commons.GetIRODSFSClientForSingleOperation(stat.account)
+	if err != nil {
+		return xerrors.Errorf("failed to get iRODS FS Client: %w", err)
+	}
+	defer stat.filesystem.Release()
+
+	// tickets are searched by path, omitted if not permitted to list
+	tickets, err := stat.filesystem.ListTickets()
+	if err != nil {
+		logger.WithError(err).Debugf("failed to list tickets, omitting tickets")
+		tickets = nil
+	} else if tickets == nil {
+		tickets = []*irodsclient_types.IRODSTicket{}
+	}
+
+	// run
+	results := []*StatResult{}
+	for _, targetPath := range stat.targetPaths {
+		result, err := stat.statOne(targetPath, tickets)
+		if err != nil {
+			return xerrors.Errorf("failed to stat %q: %w", targetPath, err)
+		}
+
+		results = append(results, result)
+	}
+
+	if stat.outputFormatFlagValues.Format.IsStructured() {
+		outputWriter := commons.NewOutputWriter(os.Stdout, stat.outputFormatFlagValues.Format)
+		for _, result := range results {
+			err = outputWriter.Write(result)
+			if err != nil {
+				return xerrors.Errorf("failed to write stat result of %q: %w", result.Path, err)
+			}
+		}
+
+		return outputWriter.Flush()
+	}
+
+	for _, result := range results {
+		if stat.aclFlagValues.ShowACL {
+			stat.printStatResultACLs(result)
+			continue
+		}
+
+		stat.printStatResult(result)
+	}
+
+	return nil
+}
+
+func (stat *StatCommand) statOne(targetPath string, tickets []*irodsclient_types.IRODSTicket) (*StatResult, error) {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "StatCommand",
+		"function": "statOne",
+	})
+
+	cwd := commons.GetCWD()
+	home := commons.GetHomeDir()
+	zone := stat.account.ClientZone
+	targetPath = commons.MakeIRODSPath(cwd, home, zone, targetPath)
+
+	logger.Debugf("stat %q", targetPath)
+
+	entry, err := stat.filesystem.Stat(targetPath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to stat %q: %w", targetPath, err)
+	}
+
+	result := &StatResult{
+		ID:         entry.ID,
+		Path:       entry.Path,
+		Name:       entry.Name,
+		Owner:      entry.Owner,
+		Size:       entry.Size,
+		CreateTime: entry.CreateTime,
+		ModifyTime: entry.ModifyTime,
+		ACLs:       []*StatAccess{},
+		Metadata:   []*StatMeta{},
+	}
+
+	if entry.IsDir() {
+		result.Type = "collection"
+
+		inheritance, err := stat.filesystem.GetDirACLInheritance(entry.Path)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get access inheritance for %q: %w", entry.Path, err)
+		}
+
+		result.ACLInheritance = &inheritance.Inheritance
+	} else {
+		result.Type = "data_object"
+		result.DataType = entry.DataType
+		result.ChecksumAlgorithm = string(entry.CheckSumAlgorithm)
+
+		replicas, err := stat.getReplicas(entry.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Replicas = replicas
+	}
+
+	accesses, err := stat.filesystem.ListACLs(entry.Path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to list ACLs for %q: %w", entry.Path, err)
+	}
+
+	for _, access := range accesses {
+		result.ACLs = append(result.ACLs, &StatAccess{
+			UserName:    access.UserName,
+			UserZone:    access.UserZone,
+			UserType:    string(access.UserType),
+			AccessLevel: string(access.AccessLevel),
+		})
+	}
+
+	metas, err := stat.filesystem.ListMetadata(entry.Path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to list metadata for %q: %w", entry.Path, err)
+	}
+
+	sort.SliceStable(metas, func(i int, j int) bool {
+		return metas[i].Name < metas[j].Name
+	})
+
+	for _, meta := range metas {
+		result.Metadata = append(result.Metadata, &StatMeta{
+			AVUID:      meta.AVUID,
+			Name:       meta.Name,
+			Value:      meta.Value,
+			Units:      meta.Units,
+			CreateTime: meta.CreateTime,
+			ModifyTime: meta.ModifyTime,
+		})
+	}
+
+	if tickets != nil {
+		result.Tickets = []*StatTicket{}
+	}
+
+	for _, ticket := range tickets {
+		if ticket.Path == entry.Path {
+			result.Tickets = append(result.Tickets, &StatTicket{
+				Name:           ticket.Name,
+				Type:           string(ticket.Type),
+				Owner:          ticket.Owner,
+				OwnerZone:      ticket.OwnerZone,
+				ExpirationTime: ticket.ExpirationTime,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+func (stat *StatCommand) getReplicas(targetPath string) ([]*StatReplica, error) {
+	connection, err := stat.filesystem.GetMetadataConnection()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get connection: %w", err)
+	}
+	defer stat.filesystem.ReturnMetadataConnection(connection)
+
+	dataObject, err := irodsclient_irodsfs.GetDataObjectWithoutCollection(connection, targetPath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get data-object %q: %w", targetPath, err)
+	}
+
+	replicas := []*StatReplica{}
+	for _, replica := range dataObject.Replicas {
+		checksum := ""
+		if replica.Checksum != nil {
+			checksum = replica.Checksum.IRODSChecksumString
+		}
+
+		replicas = append(replicas, &StatReplica{
+			Number:            replica.Number,
+			Owner:             replica.Owner,
+			ResourceName:      replica.ResourceName,
+			ResourceHierarchy: replica.ResourceHierarchy,
+			Status:            stat.getReplicaStatusString(replica.Status),
+			Checksum:          checksum,
+			Path:              replica.Path,
+			CreateTime:        replica.CreateTime,
+			ModifyTime:        replica.ModifyTime,
+		})
+	}
+
+	return replicas, nil
+}
+
+func (stat *StatCommand) getReplicaStatusString(status string) string {
+	switch status {
+	case "0":
+		return "stale"
+	case "1":
+		return "good"
+	case "2":
+		return "intermediate"
+	case "3":
+		return "read-locked"
+	case "4":
+		return "write-locked"
+	default:
+		return "unknown"
+	}
+}
+
+func (stat *StatCommand) newTable() table.Writer {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	return t
+}
+
+func (stat *StatCommand) printStatResultACLs(result *StatResult) {
+	commons.Printf("[%s]\n", result.Path)
+	if result.ACLInheritance != nil {
+		commons.Printf("ACL Inheritance: %t\n", *result.ACLInheritance)
+	}
+
+	stat.printACLs(result)
+	commons.Print("\n")
+}
+
+func (stat *StatCommand) printACLs(result *StatResult) {
+	commons.Print("ACLs:\n")
+	if len(result.ACLs) == 0 {
+		commons.Print("  none\n")
+		return
+	}
+
+	t := stat.newTable()
+	t.AppendHeader(table.Row{
+		"User",
+		"Zone",
+		"User Type",
+		"Access Level",
+	}, table.RowConfig{})
+
+	for _, access := range result.ACLs {
+		t.AppendRow(table.Row{
+			access.UserName,
+			access.UserZone,
+			access.UserType,
+			access.AccessLevel,
+		}, table.RowConfig{})
+	}
+	t.Render()
+}
+
+func (stat *StatCommand) printStatResult(result *StatResult) {
+	commons.Printf("[%s]\n", result.Path)
+
+	t := stat.newTable()
+	t.AppendRow(table.Row{"ID", fmt.Sprintf("%d", result.ID)}, table.RowConfig{})
+	t.AppendRow(table.Row{"Path", result.Path}, table.RowConfig{})
+	t.AppendRow(table.Row{"Type", result.Type}, table.RowConfig{})
+	t.AppendRow(table.Row{"Owner", result.Owner}, table.RowConfig{})
+	t.AppendRow(table.Row{"Size", fmt.Sprintf("%d", result.Size)}, table.RowConfig{})
+	if result.Type == "data_object" {
+		t.AppendRow(table.Row{"Data Type", result.DataType}, table.RowConfig{})
+		t.AppendRow(table.Row{"Checksum Algorithm", result.ChecksumAlgorithm}, table.RowConfig{})
+	}
+	if result.ACLInheritance != nil {
+		t.AppendRow(table.Row{"ACL Inheritance", fmt.Sprintf("%t", *result.ACLInheritance)}, table.RowConfig{})
+	}
+	t.AppendRow(table.Row{"Create Time", commons.MakeDateTimeString(result.CreateTime)}, table.RowConfig{})
+	t.AppendRow(table.Row{"Modify Time", commons.MakeDateTimeString(result.ModifyTime)}, table.RowConfig{})
+	t.Render()
+
+	if len(result.Replicas) > 0 {
+		commons.Print("Replicas:\n")
+		t = stat.newTable()
+		t.AppendHeader(table.Row{
+			"Number",
+			"Owner",
+			"Resource Hierarchy",
+			"Status",
+			"Checksum",
+			"Path",
+			"Modify Time",
+		}, table.RowConfig{})
+
+		for _, replica := range result.Replicas {
+			t.AppendRow(table.Row{
+				fmt.Sprintf("%d", replica.Number),
+				replica.Owner,
+				replica.ResourceHierarchy,
+				replica.Status,
+				replica.Checksum,
+				replica.Path,
+				commons.MakeDateTimeString(replica.ModifyTime),
+			}, table.RowConfig{})
+		}
+		t.Render()
+	}
+
+	stat.printACLs(result)
+
+	commons.Print("Metadata:\n")
+	if len(result.Metadata) == 0 {
+		commons.Print("  none\n")
+	} else {
+		t = stat.newTable()
+		t.AppendHeader(table.Row{
+			"AVU ID",
+			"Attribute",
+			"Value",
+			"Units",
+			"Modify Time",
+		}, table.RowConfig{})
+
+		for _, meta := range result.Metadata {
+			t.AppendRow(table.Row{
+				fmt.Sprintf("%d", meta.AVUID),
+				meta.Name,
+				meta.Value,
+				meta.Units,
+				commons.MakeDateTimeString(meta.ModifyTime),
+			}, table.RowConfig{})
+		}
+		t.Render()
+	}
+
+	// tickets are not available if not permitted to list
+	if result.Tickets != nil {
+		commons.Print("Tickets:\n")
+		if len(result.Tickets) == 0 {
+			commons.Print("  none\n")
+		} else {
+			t = stat.newTable()
+			t.AppendHeader(table.Row{
+				"Name",
+				"Type",
+				"Owner",
+				"Expiry Time",
+			}, table.RowConfig{})
+
+			for _, ticket := range result.Tickets {
+				expiry := "none"
+				if !ticket.ExpirationTime.IsZero() {
+					expiry = commons.MakeDateTimeString(ticket.ExpirationTime)
+				}
+
+				t.AppendRow(table.Row{
+					ticket.Name,
+					ticket.Type,
+					fmt.Sprintf("%s#%s", ticket.Owner, ticket.OwnerZone),
+					expiry,
+				}, table.RowConfig{})
+			}
+			t.Render()
+		}
+	}
+
+	commons.Print("\n")
+}
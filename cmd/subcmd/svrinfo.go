@@ -26,6 +26,8 @@ func AddSvrinfoCommand(rootCmd *cobra.Command) {
 	// attach common flags
 	flag.SetCommonFlags(svrinfoCmd, true)
 
+	flag.SetOutputFormatFlags(svrinfoCmd)
+
 	rootCmd.AddCommand(svrinfoCmd)
 }
 
@@ -41,7 +43,8 @@ func processSvrinfoCommand(command *cobra.Command, args []string) error {
 type SvrInfoCommand struct {
 	command *cobra.Command
 
-	commonFlagValues *flag.CommonFlagValues
+	commonFlagValues       *flag.CommonFlagValues
+	outputFormatFlagValues *flag.OutputFormatFlagValues
 
 	account    *irodsclient_types.IRODSAccount
 	filesystem *irodsclient_fs.FileSystem
@@ -51,7 +54,13 @@ func NewSvrInfoCommand(command *cobra.Command, args []string) (*SvrInfoCommand,
 	svrInfo := &SvrInfoCommand{
 		command: command,
 
-		commonFlagValues: flag.GetCommonFlagValues(command),
+		commonFlagValues:       flag.GetCommonFlagValues(command),
+		outputFormatFlagValues: flag.GetOutputFormatFlagValues(),
+	}
+
+	err := svrInfo.outputFormatFlagValues.CheckOutputFormat()
+	if err != nil {
+		return nil, err
 	}
 
 	return svrInfo, nil
@@ -104,6 +113,10 @@ func (svrInfo *SvrInfoCommand) displayInfo() error {
 		return xerrors.Errorf("failed to get server version: %w", err)
 	}
 
+	if svrInfo.outputFormatFlagValues.Format.IsStructured() {
+		return commons.WriteOutput(os.Stdout, svrInfo.outputFormatFlagValues.Format, ver)
+	}
+
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
 
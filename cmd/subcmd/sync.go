@@ -25,9 +25,14 @@ func AddSyncCommand(rootCmd *cobra.Command) {
 	flag.SetCommonFlags(syncCmd, false)
 
 	flag.SetBundleTransferFlags(syncCmd, false, false)
+	flag.SetBulkRegistrationFlags(syncCmd, false)
+	flag.SetBundleFormatFlags(syncCmd)
+	flag.SetBundlePackingFlags(syncCmd)
 	flag.SetParallelTransferFlags(syncCmd, false, false)
 	flag.SetForceFlags(syncCmd, true)
 	flag.SetProgressFlags(syncCmd)
+	flag.SetEventFlags(syncCmd)
+	flag.SetStatsFlags(syncCmd)
 	flag.SetRetryFlags(syncCmd)
 	flag.SetDifferentialTransferFlags(syncCmd, false)
 	flag.SetChecksumFlags(syncCmd, false, false)
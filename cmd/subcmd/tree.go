@@ -0,0 +1,427 @@
+package subcmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/cyverse/gocommands/cmd/flag"
+	"github.com/cyverse/gocommands/commons"
+	"github.com/dustin/go-humanize"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+)
+
+const (
+	TreeNodeTypeCollection string = "collection"
+	TreeNodeTypeDataObject string = "data_object"
+)
+
+// TreeNode is a data object or a collection in a tree. For collections, counts and size cover direct children only.
+type TreeNode struct {
+	Name            string      `json:"name"`
+	Path            string      `json:"path"`
+	Type            string      `json:"type"`
+	DecryptedName   string      `json:"decrypted_name,omitempty"`
+	Size            int64       `json:"size"`
+	CollectionCount int         `json:"collection_count,omitempty"`
+	DataObjectCount int         `json:"data_object_count,omitempty"`
+	Children        []*TreeNode `json:"children,omitempty"`
+}
+
+// TreeLevelSummary accumulates the entries found at a depth of a tree
+type TreeLevelSummary struct {
+	Depth           int   `json:"depth"`
+	CollectionCount int   `json:"collection_count"`
+	DataObjectCount int   `json:"data_object_count"`
+	Size            int64 `json:"size"`
+}
+
+// TreeResult is a tree of a source path, with per-level summaries
+type TreeResult struct {
+	Root   *TreeNode           `json:"root"`
+	Levels []*TreeLevelSummary `json:"levels"`
+}
+
+var treeCmd = &cobra.Command{
+	Use:     "tree [collection1] [collection2] ...",
+	Aliases: []string{"itree"},
+	Short:   "Display iRODS collections in a tree",
+	Long:    `This displays data objects and collections under the given iRODS collections in a tree, with the number of entries and sizes at each level.`,
+	RunE:    processTreeCommand,
+	Args:    cobra.ArbitraryArgs,
+}
+
+func AddTreeCommand(rootCmd *cobra.Command) {
+	// attach common flags
+	flag.SetCommonFlags(treeCmd, true)
+
+	flag.SetTreeFlags(treeCmd)
+	flag.SetTicketAccessFlags(treeCmd)
+	flag.SetDecryptionFlags(treeCmd)
+	flag.SetEncryptionKeyFlags(treeCmd)
+	flag.SetHiddenFileFlags(treeCmd)
+	flag.SetOutputFormatFlags(treeCmd)
+
+	rootCmd.AddCommand(treeCmd)
+}
+
+func processTreeCommand(command *cobra.Command, args []string) error {
+	tree, err := NewTreeCommand(command, args)
+	if err != nil {
+		return err
+	}
+
+	return tree.Process()
+}
+
+type TreeCommand struct {
+	command *cobra.Command
+
+	commonFlagValues        *flag.CommonFlagValues
+	treeFlagValues          *flag.TreeFlagValues
+	ticketAccessFlagValues  *flag.TicketAccessFlagValues
+	decryptionFlagValues    *flag.DecryptionFlagValues
+	encryptionKeyFlagValues *flag.EncryptionKeyFlagValues
+	hiddenFileFlagValues    *flag.HiddenFileFlagValues
+	outputFormatFlagValues  *flag.OutputFormatFlagValues
+
+	account    *irodsclient_types.IRODSAccount
+	filesystem *irodsclient_fs.FileSystem
+
+	encryptionKeySource commons.EncryptionKeySource
+
+	sourcePaths []string
+}
+
+func NewTreeCommand(command *cobra.Command, args []string) (*TreeCommand, error) {
+	tree := &TreeCommand{
+		command: command,
+
+		commonFlagValues:        flag.GetCommonFlagValues(command),
+		treeFlagValues:          flag.GetTreeFlagValues(),
+		ticketAccessFlagValues:  flag.GetTicketAccessFlagValues(),
+		decryptionFlagValues:    flag.GetDecryptionFlagValues(command),
+		encryptionKeyFlagValues: flag.GetEncryptionKeyFlagValues(),
+		hiddenFileFlagValues:    flag.GetHiddenFileFlagValues(),
+		outputFormatFlagValues:  flag.GetOutputFormatFlagValues(),
+	}
+
+	// path
+	tree.sourcePaths = args[:]
+
+	if len(args) == 0 {
+		tree.sourcePaths = []string{"."}
+	}
+
+	if tree.treeFlagValues.JSONOutput {
+		tree.outputFormatFlagValues.Format = commons.OutputFormatJSON
+	}
+
+	err := tree.outputFormatFlagValues.CheckOutputFormat()
+	if err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+func (tree *TreeCommand) Process() error {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "TreeCommand",
+		"function": "Process",
+	})
+
+	cont, err := flag.ProcessCommonFlags(tree.command)
+	if err != nil {
+		return xerrors.Errorf("failed to process common flags: %w", err)
+	}
+
+	if !cont {
+		return nil
+	}
+
+	// handle local flags
+	_, err = commons.InputMissingFields()
+	if err != nil {
+		return xerrors.Errorf("failed to input missing fields: %w", err)
+	}
+
+	// Create a file system
+	tree.account = commons.GetSessionConfig().ToIRODSAccount()
+	if len(tree.ticketAccessFlagValues.Name) > 0 {
+		logger.Debugf("use ticket: %q", tree.ticketAccessFlagValues.Name)
+		tree.account.Ticket = tree.ticketAccessFlagValues.Name
+	}
+
+	tree.filesystem, err = commons.GetIRODSFSClientForSingleOperation(tree.account)
+	if err != nil {
+		return xerrors.Errorf("failed to get iRODS FS Client: %w", err)
+	}
+	defer tree.filesystem.Release()
+
+	// get key for decryption, fall back to the iRODS password
+	keyConfig := tree.encryptionKeyFlagValues.GetEncryptionKeyConfig(tree.decryptionFlagValues.Key, tree.account, false)
+	tree.decryptionFlagValues.Key, tree.encryptionKeySource, err = commons.ResolveEncryptionKey(keyConfig)
+	if err != nil {
+		return xerrors.Errorf("failed to get decryption key: %w", err)
+	}
+
+	if tree.encryptionKeySource == commons.EncryptionKeySourceUnknown {
+		tree.decryptionFlagValues.Key = tree.account.Password
+		tree.encryptionKeySource = commons.EncryptionKeySourcePassword
+	}
+
+	// run
+	results := []*TreeResult{}
+	for _, sourcePath := range tree.sourcePaths {
+		result, err := tree.makeTree(sourcePath)
+		if err != nil {
+			return xerrors.Errorf("failed to make a tree of path %q: %w", sourcePath, err)
+		}
+
+		results = append(results, result)
+	}
+
+	if tree.outputFormatFlagValues.Format.IsStructured() {
+		return tree.writeTrees(results)
+	}
+
+	for _, result := range results {
+		tree.printTree(result)
+	}
+
+	return nil
+}
+
+func (tree *TreeCommand) requireDecryption(sourcePath string) bool {
+	if tree.decryptionFlagValues.NoDecryption {
+		return false
+	}
+
+	if !tree.decryptionFlagValues.Decryption {
+		return false
+	}
+
+	mode := commons.DetectEncryptionMode(sourcePath)
+	return mode != commons.EncryptionModeUnknown
+}
+
+func (tree *TreeCommand) getEncryptionManagerForDecryption(mode commons.EncryptionMode) *commons.EncryptionManager {
+	manager := commons.NewEncryptionManager(mode)
+
+	switch mode {
+	case commons.EncryptionModeWinSCP, commons.EncryptionModePGP:
+		if tree.encryptionKeySource == commons.EncryptionKeySourcePassword {
+			commons.WarnPasswordEncryptionKey()
+		}
+
+		manager.SetKey([]byte(tree.decryptionFlagValues.Key))
+	case commons.EncryptionModeSSH:
+		manager.SetPublicPrivateKey(tree.decryptionFlagValues.PrivateKeyPath)
+	case commons.EncryptionModeAge:
+		// use passphrase only if given, not the default key
+		if tree.encryptionKeySource != commons.EncryptionKeySourcePassword {
+			manager.SetKey([]byte(tree.decryptionFlagValues.Key))
+		}
+
+		if tree.decryptionFlagValues.DefaultIdentities {
+			manager.SetDefaultIdentities(tree.decryptionFlagValues.Identities)
+		} else {
+			manager.SetIdentities(tree.decryptionFlagValues.Identities)
+		}
+	}
+
+	return manager
+}
+
+func (tree *TreeCommand) makeTree(sourcePath string) (*TreeResult, error) {
+	cwd := commons.GetCWD()
+	home := commons.GetHomeDir()
+	zone := tree.account.ClientZone
+	sourcePath = commons.MakeIRODSPath(cwd, home, zone, sourcePath)
+
+	sourceEntry, err := tree.filesystem.Stat(sourcePath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to stat %q: %w", sourcePath, err)
+	}
+
+	result := &TreeResult{
+		Levels: []*TreeLevelSummary{},
+	}
+
+	root, err := tree.makeTreeNode(sourceEntry, 0, result)
+	if err != nil {
+		return nil, err
+	}
+
+	// show the full path at the root
+	root.Name = sourceEntry.Path
+	result.Root = root
+
+	return result, nil
+}
+
+func (tree *TreeCommand) makeTreeNode(entry *irodsclient_fs.Entry, depth int, result *TreeResult) (*TreeNode, error) {
+	logger := log.WithFields(log.Fields{
+		"package":  "subcmd",
+		"struct":   "TreeCommand",
+		"function": "makeTreeNode",
+	})
+
+	node := &TreeNode{
+		Name: entry.Name,
+		Path: entry.Path,
+	}
+
+	if !entry.IsDir() {
+		node.Type = TreeNodeTypeDataObject
+		node.Size = entry.Size
+
+		if tree.requireDecryption(entry.Path) {
+			encryptionMode := commons.DetectEncryptionMode(entry.Name)
+			encryptManager := tree.getEncryptionManagerForDecryption(encryptionMode)
+
+			err := encryptManager.LoadFilenameKey(tree.filesystem, commons.GetDir(entry.Path), false)
+			if err != nil {
+				logger.Debugf("%+v", err)
+			} else {
+				decryptedFilename, err := encryptManager.DecryptFilename(entry.Name)
+				if err != nil {
+					logger.Debugf("%+v", err)
+				} else {
+					node.DecryptedName = decryptedFilename
+				}
+			}
+		}
+
+		return node, nil
+	}
+
+	node.Type = TreeNodeTypeCollection
+	node.Children = []*TreeNode{}
+
+	if tree.treeFlagValues.MaxDepth > 0 && depth >= tree.treeFlagValues.MaxDepth {
+		// reached depth limit
+		return node, nil
+	}
+
+	entries, err := tree.filesystem.List(entry.Path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to list dir %q: %w", entry.Path, err)
+	}
+
+	sort.SliceStable(entries, func(i int, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	level := tree.getLevelSummary(result, depth+1)
+
+	for _, childEntry := range entries {
+		if tree.hiddenFileFlagValues.Exclude && strings.HasPrefix(childEntry.Name, ".") {
+			// skip hidden
+			continue
+		}
+
+		childNode, err := tree.makeTreeNode(childEntry, depth+1, result)
+		if err != nil {
+			return nil, err
+		}
+
+		if childEntry.IsDir() {
+			node.CollectionCount++
+			level.CollectionCount++
+		} else {
+			node.DataObjectCount++
+			node.Size += childEntry.Size
+			level.DataObjectCount++
+			level.Size += childEntry.Size
+		}
+
+		node.Children = append(node.Children, childNode)
+	}
+
+	return node, nil
+}
+
+func (tree *TreeCommand) getLevelSummary(result *TreeResult, depth int) *TreeLevelSummary {
+	for len(result.Levels) < depth {
+		result.Levels = append(result.Levels, &TreeLevelSummary{
+			Depth: len(result.Levels) + 1,
+		})
+	}
+
+	return result.Levels[depth-1]
+}
+
+func (tree *TreeCommand) writeTrees(results []*TreeResult) error {
+	outputWriter := commons.NewOutputWriter(os.Stdout, tree.outputFormatFlagValues.Format)
+	for _, result := range results {
+		err := outputWriter.Write(result)
+		if err != nil {
+			return xerrors.Errorf("failed to write tree of %q: %w", result.Root.Path, err)
+		}
+	}
+
+	return outputWriter.Flush()
+}
+
+func (tree *TreeCommand) printTree(result *TreeResult) {
+	commons.Printf("%s\n", tree.getNodeLabel(result.Root))
+	tree.printTreeChildren(result.Root, "")
+
+	collections := 0
+	dataObjects := 0
+	size := int64(0)
+
+	commons.Print("\n")
+	for _, level := range result.Levels {
+		commons.Printf("level %d: %d collections, %d data objects, %s\n", level.Depth, level.CollectionCount, level.DataObjectCount, tree.getSizeString(level.Size))
+
+		collections += level.CollectionCount
+		dataObjects += level.DataObjectCount
+		size += level.Size
+	}
+
+	commons.Printf("total: %d collections, %d data objects, %s\n\n", collections, dataObjects, tree.getSizeString(size))
+}
+
+func (tree *TreeCommand) printTreeChildren(node *TreeNode, indent string) {
+	for idx, child := range node.Children {
+		branch := "├── "
+		childIndent := indent + "│   "
+		if idx == len(node.Children)-1 {
+			branch = "└── "
+			childIndent = indent + "    "
+		}
+
+		commons.Printf("%s%s%s\n", indent, branch, tree.getNodeLabel(child))
+		tree.printTreeChildren(child, childIndent)
+	}
+}
+
+func (tree *TreeCommand) getNodeLabel(node *TreeNode) string {
+	if node.Type == TreeNodeTypeCollection {
+		return fmt.Sprintf("%s/ (%d collections, %d data objects, %s)", strings.TrimSuffix(node.Name, "/"), node.CollectionCount, node.DataObjectCount, tree.getSizeString(node.Size))
+	}
+
+	label := fmt.Sprintf("%s (%s)", node.Name, tree.getSizeString(node.Size))
+	if len(node.DecryptedName) > 0 {
+		label = fmt.Sprintf("%s\t(encrypted: %q)", label, node.DecryptedName)
+	}
+
+	return label
+}
+
+func (tree *TreeCommand) getSizeString(size int64) string {
+	if tree.treeFlagValues.HumanReadableSizes {
+		return humanize.Bytes(uint64(size))
+	}
+
+	return fmt.Sprintf("%d", size)
+}
@@ -0,0 +1,142 @@
+package commons
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	"golang.org/x/xerrors"
+)
+
+// StaleBundle is a bundle file left in a staging directory
+type StaleBundle struct {
+	Path        string
+	Size        int64
+	ModTime     time.Time
+	StagingPath string
+	Local       bool
+}
+
+// GetAge returns how long ago the bundle file was last modified
+func (bundle *StaleBundle) GetAge(now time.Time) time.Duration {
+	return now.Sub(bundle.ModTime)
+}
+
+// isOlderThan returns true if the bundle file is older than the given age, zero age matches all
+func (bundle *StaleBundle) isOlderThan(now time.Time, olderThan time.Duration) bool {
+	if olderThan <= 0 {
+		return true
+	}
+
+	return bundle.GetAge(now) >= olderThan
+}
+
+// ListOldLocalBundles returns local bundle files and their states older than the given age
+func ListOldLocalBundles(localTempDirPath string, olderThan time.Duration) ([]*StaleBundle, error) {
+	entries, err := os.ReadDir(localTempDirPath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read a local temp directory %q: %w", localTempDirPath, err)
+	}
+
+	now := time.Now()
+	bundles := []*StaleBundle{}
+	for _, entry := range entries {
+		// filter only bundle files and their states
+		if entry.IsDir() || !(IsBundleFilename(entry.Name()) || IsBundleStateFilename(entry.Name())) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			// removed in the middle
+			continue
+		}
+
+		bundle := &StaleBundle{
+			Path:        filepath.Join(localTempDirPath, entry.Name()),
+			Size:        info.Size(),
+			ModTime:     info.ModTime(),
+			StagingPath: localTempDirPath,
+			Local:       true,
+		}
+
+		if bundle.isOlderThan(now, olderThan) {
+			bundles = append(bundles, bundle)
+		}
+	}
+
+	return bundles, nil
+}
+
+// ListOldIRODSBundles returns bundle files in the iRODS staging collection older than the given age
+func ListOldIRODSBundles(fs *irodsclient_fs.FileSystem, stagingPath string, olderThan time.Duration) ([]*StaleBundle, error) {
+	if !fs.ExistsDir(stagingPath) {
+		return nil, xerrors.Errorf("staging dir %q does not exist", stagingPath)
+	}
+
+	entries, err := fs.List(stagingPath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to list %q: %w", stagingPath, err)
+	}
+
+	now := time.Now()
+	bundles := []*StaleBundle{}
+	for _, entry := range entries {
+		// filter only bundle files
+		if entry.Type != irodsclient_fs.FileEntry || !IsBundleFilename(entry.Name) {
+			continue
+		}
+
+		bundle := &StaleBundle{
+			Path:        entry.Path,
+			Size:        entry.Size,
+			ModTime:     entry.ModifyTime,
+			StagingPath: stagingPath,
+			Local:       false,
+		}
+
+		if bundle.isOlderThan(now, olderThan) {
+			bundles = append(bundles, bundle)
+		}
+	}
+
+	return bundles, nil
+}
+
+// FindStagingDirs returns staging collections found anywhere under the given collection, including its own
+func FindStagingDirs(fs *irodsclient_fs.FileSystem, rootPath string) ([]string, error) {
+	if !fs.ExistsDir(rootPath) {
+		return nil, xerrors.Errorf("collection %q does not exist", rootPath)
+	}
+
+	stagingDirs := []string{}
+
+	rootStagingDir := GetDefaultStagingDirInTargetPath(rootPath)
+	if IsStagingDirInTargetPath(rootPath) {
+		rootStagingDir = rootPath
+	}
+
+	if fs.ExistsDir(rootStagingDir) {
+		stagingDirs = append(stagingDirs, rootStagingDir)
+	}
+
+	// search in the catalog rather than walking down the tree
+	entries, err := fs.SearchDirUnixWildcard(GetDefaultStagingDirInTargetPath(path.Join(rootPath, "*")))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to search staging dirs under %q: %w", rootPath, err)
+	}
+
+	for _, entry := range entries {
+		if entry.Path == rootStagingDir || !IsStagingDirInTargetPath(entry.Path) {
+			continue
+		}
+
+		stagingDirs = append(stagingDirs, entry.Path)
+	}
+
+	sort.Strings(stagingDirs)
+	return stagingDirs, nil
+}
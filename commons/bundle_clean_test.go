@@ -0,0 +1,48 @@
+package commons
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBundleClean(t *testing.T) {
+	t.Run("test ListOldLocalBundles", testListOldLocalBundles)
+}
+
+func testListOldLocalBundles(t *testing.T) {
+	oldBundleName := GetBundleFilename("old", BundleFormatTar)
+	newBundleName := GetBundleFilename("new", BundleFormatZip)
+
+	tempDir, paths := writeLocalTestFiles(t, map[string]string{
+		oldBundleName:                     "data",
+		GetBundleStatePath(oldBundleName): "data",
+		newBundleName:                     "data",
+		"other.tar":                       "data",
+	})
+
+	oldBundlePath := paths[oldBundleName]
+
+	oldTime := time.Now().Add(-3 * 24 * time.Hour)
+	for _, p := range []string{oldBundlePath, GetBundleStatePath(oldBundlePath)} {
+		err := os.Chtimes(p, oldTime, oldTime)
+		assert.NoError(t, err)
+	}
+
+	bundles, err := ListOldLocalBundles(tempDir, 0)
+	assert.NoError(t, err)
+	assert.Len(t, bundles, 3)
+
+	bundles, err = ListOldLocalBundles(tempDir, 2*24*time.Hour)
+	assert.NoError(t, err)
+	assert.Len(t, bundles, 2)
+
+	for _, bundle := range bundles {
+		assert.True(t, bundle.Local)
+		assert.Equal(t, tempDir, bundle.StagingPath)
+		assert.Equal(t, int64(4), bundle.Size)
+		assert.GreaterOrEqual(t, bundle.GetAge(time.Now()), 2*24*time.Hour)
+	}
+}
@@ -0,0 +1,937 @@
+package commons
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	irodsclient_util "github.com/cyverse/go-irodsclient/irods/util"
+	"github.com/jedib0t/go-pretty/v6/progress"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+const (
+	BundleTaskNameStage    string = "Staging"
+	BundleTaskNameDownload string = "Downloading"
+)
+
+// BundleDownloadManager downloads data objects in bundles, the reverse of BundleTransferManager.
+// Data objects of a bundle are copied to a staging collection and bundled with TAR on the server-side,
+// then the bundle is downloaded and extracted locally.
+type BundleDownloadManager struct {
+	// moved to top to avoid 64bit alignment issue
+	bundlesScheduledCounter int64
+	bundlesDoneCounter      int64
+
+	account                 *irodsclient_types.IRODSAccount
+	filesystem              *irodsclient_fs.FileSystem
+	transferReportManager   *TransferReportManager
+	currentBundle           *Bundle
+	nextBundleIndex         int64
+	pendingBundles          chan *Bundle
+	bundles                 []*Bundle
+	bundleStartTimes        map[int64]time.Time
+	minBundleFileNum        int
+	maxBundleFileNum        int
+	maxBundleFileSize       int64
+	singleThreaded          bool
+	downloadThreadNum       int
+	redirectToResource      bool
+	useIcat                 bool
+	verifyChecksum          bool
+	localTempDirPath        string
+	irodsTempDirPath        string
+	showProgress            bool
+	showFullPath            bool
+	progressMode            ProgressMode
+	progressInterval        time.Duration
+	progressWriter          *TransferProgressWriter
+	progressTrackerCallback ProgressTrackerCallback
+	eventWriter             *EventWriter
+	transferStats           *TransferStats
+	lastError               error
+	mutex                   sync.RWMutex
+
+	scheduleWait sync.WaitGroup
+	transferWait sync.WaitGroup
+}
+
+// NewBundleDownloadManager creates a new BundleDownloadManager
+func NewBundleDownloadManager(account *irodsclient_types.IRODSAccount, fs *irodsclient_fs.FileSystem, transferReportManager *TransferReportManager, minBundleFileNum int, maxBundleFileNum int, maxBundleFileSize int64, singleThreaded bool, downloadThreadNum int, redirectToResource bool, useIcat bool, verifyChecksum bool, localTempDirPath string, irodsTempDirPath string, showProgress bool, showFullPath bool) *BundleDownloadManager {
+	manager := &BundleDownloadManager{
+		account:                 account,
+		filesystem:              fs,
+		transferReportManager:   transferReportManager,
+		currentBundle:           nil,
+		nextBundleIndex:         0,
+		pendingBundles:          make(chan *Bundle, 100),
+		bundles:                 []*Bundle{},
+		bundleStartTimes:        map[int64]time.Time{},
+		minBundleFileNum:        minBundleFileNum,
+		maxBundleFileNum:        maxBundleFileNum,
+		maxBundleFileSize:       maxBundleFileSize,
+		singleThreaded:          singleThreaded,
+		downloadThreadNum:       downloadThreadNum,
+		redirectToResource:      redirectToResource,
+		useIcat:                 useIcat,
+		verifyChecksum:          verifyChecksum,
+		localTempDirPath:        localTempDirPath,
+		irodsTempDirPath:        irodsTempDirPath,
+		showProgress:            showProgress,
+		showFullPath:            showFullPath,
+		progressMode:            ProgressModeUnknown,
+		progressInterval:        ProgressPlainIntervalDefault,
+		progressWriter:          nil,
+		progressTrackerCallback: nil,
+		eventWriter:             nil,
+		transferStats:           nil,
+		lastError:               nil,
+		mutex:                   sync.RWMutex{},
+		scheduleWait:            sync.WaitGroup{},
+		transferWait:            sync.WaitGroup{},
+
+		bundlesScheduledCounter: 0,
+		bundlesDoneCounter:      0,
+	}
+
+	if manager.maxBundleFileNum <= 0 {
+		manager.maxBundleFileNum = MaxBundleFileNumDefault
+	}
+
+	if manager.minBundleFileNum <= 0 {
+		manager.minBundleFileNum = MinBundleFileNumDefault
+	}
+
+	if manager.downloadThreadNum <= 0 {
+		manager.downloadThreadNum = 1
+	}
+
+	if manager.downloadThreadNum > UploadThreadNumMax {
+		manager.downloadThreadNum = UploadThreadNumMax
+	}
+
+	manager.scheduleWait.Add(1)
+
+	return manager
+}
+
+func (manager *BundleDownloadManager) GetFilesystem() *irodsclient_fs.FileSystem {
+	return manager.filesystem
+}
+
+// SetEventWriter sets a writer to emit events of bundles, must be called before Start
+func (manager *BundleDownloadManager) SetEventWriter(eventWriter *EventWriter) {
+	manager.eventWriter = eventWriter
+}
+
+// SetTransferStats sets stats to collect time spent in making bundle files, must be called before Start
+func (manager *BundleDownloadManager) SetTransferStats(transferStats *TransferStats) {
+	manager.transferStats = transferStats
+}
+
+// SetProgressMode sets how progress is displayed, must be called before Start
+func (manager *BundleDownloadManager) SetProgressMode(mode ProgressMode, interval time.Duration) {
+	manager.progressMode = mode
+	manager.progressInterval = interval
+}
+
+func (manager *BundleDownloadManager) progress(name string, processed int64, total int64, progressUnit progress.Units, errored bool) {
+	if manager.progressTrackerCallback != nil {
+		manager.progressTrackerCallback(name, processed, total, progressUnit, errored)
+	}
+
+	manager.eventWriter.Progress(name, processed, total, progressUnit, errored)
+}
+
+// runBundlePhase runs a task of the bundle if no bundle has failed, recording the error of the task
+func (manager *BundleDownloadManager) runBundlePhase(bundle *Bundle, taskName string, task func(bundle *Bundle) error) {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"struct":   "BundleDownloadManager",
+		"function": "runBundlePhase",
+	})
+
+	manager.mutex.RLock()
+	cont := manager.lastError == nil
+	manager.mutex.RUnlock()
+
+	if !cont || len(bundle.Entries) == 0 {
+		return
+	}
+
+	manager.eventWriter.BundlePhase(bundle, taskName, EventTypeStarted, nil)
+
+	startTime := time.Now()
+	err := task(bundle)
+	if taskName == BundleTaskNameStage || taskName == BundleTaskNameTar {
+		manager.transferStats.AddBundlingTime(time.Since(startTime))
+	}
+
+	if err != nil {
+		manager.eventWriter.BundlePhase(bundle, taskName, EventTypeFailed, err)
+
+		// mark error
+		manager.mutex.Lock()
+		manager.lastError = err
+		manager.mutex.Unlock()
+
+		bundle.LastError = err
+		bundle.LastErrorTaskName = taskName
+
+		logger.Error(err)
+		return
+	}
+
+	if manager.progressWriter != nil {
+		// phases of empty bundles have nothing to progress
+		manager.progressWriter.Done(manager.getProgressName(bundle, taskName))
+	}
+
+	manager.eventWriter.BundlePhase(bundle, taskName, EventTypeCompleted, nil)
+}
+
+func (manager *BundleDownloadManager) newBundle() *Bundle {
+	bundle := &Bundle{
+		Index:   manager.nextBundleIndex,
+		Entries: []*BundleEntry{},
+		Format:  BundleFormatTar,
+	}
+
+	manager.nextBundleIndex++
+	return bundle
+}
+
+func (manager *BundleDownloadManager) isBundleFull(bundle *Bundle) bool {
+	return bundle.Size >= manager.maxBundleFileSize || len(bundle.Entries) >= manager.maxBundleFileNum
+}
+
+// requireTar returns true if the bundle has enough data objects to bundle, otherwise they are downloaded one by one
+func (manager *BundleDownloadManager) requireTar(bundle *Bundle) bool {
+	return len(bundle.Entries) >= manager.minBundleFileNum
+}
+
+// getStagingCollectionPath returns a collection path to copy data objects in the bundle to
+func (manager *BundleDownloadManager) getStagingCollectionPath(bundle *Bundle) string {
+	return strings.TrimSuffix(bundle.IRODSBundlePath, path.Ext(bundle.IRODSBundlePath))
+}
+
+// getStagingDataObjectName returns a name of the data object copied to the staging collection.
+// Names are indices of entries, so they never collide when data objects come from different collections.
+func (manager *BundleDownloadManager) getStagingDataObjectName(entryIndex int) string {
+	return strconv.Itoa(entryIndex)
+}
+
+// scheduleBundle queues the bundle, the mutex must be held and is released while queueing as the channel may block
+func (manager *BundleDownloadManager) scheduleBundle(bundle *Bundle) error {
+	filename, err := bundle.GetBundleFilename()
+	if err != nil {
+		return xerrors.Errorf("failed to get bundle filename: %w", err)
+	}
+
+	bundle.LocalBundlePath = filepath.Join(manager.localTempDirPath, filename)
+	bundle.IRODSBundlePath = path.Join(manager.irodsTempDirPath, filename)
+
+	manager.mutex.Unlock()
+
+	manager.pendingBundles <- bundle
+	manager.eventWriter.BundlePhase(bundle, "", EventTypeScheduled, nil)
+
+	manager.mutex.Lock()
+	manager.bundles = append(manager.bundles, bundle)
+	manager.transferWait.Add(1)
+	atomic.AddInt64(&manager.bundlesScheduledCounter, 1)
+	return nil
+}
+
+// Schedule adds a data object to download to the target path
+func (manager *BundleDownloadManager) Schedule(sourceEntry *irodsclient_fs.Entry, targetPath string) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"struct":   "BundleDownloadManager",
+		"function": "Schedule",
+	})
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	// do not accept new schedule if there's an error
+	if manager.lastError != nil {
+		entry := manager.newBundleEntry(sourceEntry, targetPath)
+		manager.reportFailedBundleEntry(entry, manager.lastError, "not_scheduled")
+		return manager.lastError
+	}
+
+	// if current bundle is full, prepare a new bundle
+	if manager.currentBundle != nil && manager.isBundleFull(manager.currentBundle) {
+		bundle := manager.currentBundle
+		manager.currentBundle = nil
+
+		err := manager.scheduleBundle(bundle)
+		if err != nil {
+			return xerrors.Errorf("failed to schedule bundle %d: %w", bundle.Index, err)
+		}
+	}
+
+	if manager.currentBundle == nil {
+		manager.currentBundle = manager.newBundle()
+		logger.Debugf("assigned a new bundle %d", manager.currentBundle.Index)
+	}
+
+	logger.Debugf("scheduling a data object bundle-download %q", sourceEntry.Path)
+
+	manager.currentBundle.Entries = append(manager.currentBundle.Entries, manager.newBundleEntry(sourceEntry, targetPath))
+	manager.currentBundle.Size += sourceEntry.Size
+
+	return nil
+}
+
+func (manager *BundleDownloadManager) newBundleEntry(sourceEntry *irodsclient_fs.Entry, targetPath string) *BundleEntry {
+	return &BundleEntry{
+		LocalPath:         targetPath,
+		IRODSPath:         sourceEntry.Path,
+		Size:              sourceEntry.Size,
+		ChecksumAlgorithm: sourceEntry.CheckSumAlgorithm,
+		Checksum:          sourceEntry.CheckSum,
+	}
+}
+
+func (manager *BundleDownloadManager) DoneScheduling() error {
+	manager.mutex.Lock()
+
+	var err error
+	if manager.currentBundle != nil {
+		bundle := manager.currentBundle
+		manager.currentBundle = nil
+
+		err = manager.scheduleBundle(bundle)
+		if err != nil {
+			err = xerrors.Errorf("failed to schedule bundle %d: %w", bundle.Index, err)
+		}
+	}
+	manager.mutex.Unlock()
+
+	close(manager.pendingBundles)
+	manager.scheduleWait.Done()
+	return err
+}
+
+func (manager *BundleDownloadManager) GetBundles() []*Bundle {
+	return manager.bundles
+}
+
+func (manager *BundleDownloadManager) Wait() error {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"struct":   "BundleDownloadManager",
+		"function": "Wait",
+	})
+
+	logger.Debug("waiting schedule-wait")
+	manager.scheduleWait.Wait()
+	logger.Debug("waiting transfer-wait")
+	manager.transferWait.Wait()
+
+	manager.CleanUpBundles()
+
+	manager.mutex.RLock()
+	err := manager.lastError
+	if err == nil && manager.bundlesDoneCounter != manager.bundlesScheduledCounter {
+		err = xerrors.Errorf("%d bundles were done out of %d! Some bundles failed!", manager.bundlesDoneCounter, manager.bundlesScheduledCounter)
+	}
+	manager.mutex.RUnlock()
+
+	if err != nil {
+		manager.reportFailedBundles(err)
+		return err
+	}
+
+	return nil
+}
+
+// reportFailedBundles adds failed transfers of data objects in bundles not completed to the report, so they can be retried
+func (manager *BundleDownloadManager) reportFailedBundles(err error) {
+	for _, bundle := range manager.bundles {
+		if bundle.Completed {
+			continue
+		}
+
+		bundleErr := err
+		notes := []string{"bundle_not_processed"}
+		if bundle.LastError != nil {
+			bundleErr = bundle.LastError
+			notes = []string{"bundle_failed", bundle.LastErrorTaskName}
+		}
+
+		for _, entry := range bundle.Entries {
+			manager.reportFailedBundleEntry(entry, bundleErr, notes...)
+		}
+	}
+}
+
+// reportFailedBundleEntry adds a failed transfer of the entry to the report, entries already reported are ignored
+func (manager *BundleDownloadManager) reportFailedBundleEntry(entry *BundleEntry, err error, notes ...string) {
+	if entry.reported || entry.Dir {
+		return
+	}
+
+	now := time.Now()
+	reportFile := &TransferReportFile{
+		Method:                  TransferMethodGet,
+		StartAt:                 now,
+		EndAt:                   now,
+		SourcePath:              entry.IRODSPath,
+		SourceSize:              entry.Size,
+		SourceChecksumAlgorithm: string(entry.ChecksumAlgorithm),
+		SourceChecksum:          hex.EncodeToString(entry.Checksum),
+		DestPath:                entry.LocalPath,
+		Error:                   err,
+		Notes:                   notes,
+	}
+
+	manager.transferReportManager.AddFile(reportFile)
+	entry.reported = true
+}
+
+// CleanUpBundles removes staging collections and bundle files left by failed bundles
+func (manager *BundleDownloadManager) CleanUpBundles() {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"struct":   "BundleDownloadManager",
+		"function": "CleanUpBundles",
+	})
+
+	logger.Debugf("clearing bundle files in %q", manager.irodsTempDirPath)
+
+	for _, bundle := range manager.bundles {
+		if !manager.requireTar(bundle) {
+			continue
+		}
+
+		stagingCollectionPath := manager.getStagingCollectionPath(bundle)
+		if manager.filesystem.ExistsDir(stagingCollectionPath) {
+			err := manager.filesystem.RemoveDir(stagingCollectionPath, true, true)
+			if err != nil {
+				logger.WithError(err).Warnf("failed to remove staging collection %q", stagingCollectionPath)
+			}
+		}
+
+		if manager.filesystem.ExistsFile(bundle.IRODSBundlePath) {
+			err := manager.filesystem.RemoveFile(bundle.IRODSBundlePath, true)
+			if err != nil {
+				logger.WithError(err).Warnf("failed to remove irods bundle %q", bundle.IRODSBundlePath)
+			}
+		}
+
+		os.Remove(bundle.LocalBundlePath)
+	}
+
+	if IsStagingDirInTargetPath(manager.irodsTempDirPath) {
+		// default staging collection is removed if nothing else is staged
+		entries, err := manager.filesystem.List(manager.irodsTempDirPath)
+		if err == nil && len(entries) == 0 {
+			manager.filesystem.RemoveDir(manager.irodsTempDirPath, false, true)
+		}
+	}
+}
+
+func (manager *BundleDownloadManager) startProgress() {
+	if manager.showProgress {
+		manager.progressWriter = NewTransferProgressWriter(manager.progressMode, manager.progressInterval, manager.showFullPath, false, "tasks")
+		manager.progressWriter.Start()
+
+		// add progress tracker callback
+		manager.progressTrackerCallback = manager.progressWriter.Progress
+	}
+}
+
+func (manager *BundleDownloadManager) endProgress() {
+	if manager.progressWriter != nil {
+		manager.mutex.RLock()
+		hasError := manager.lastError != nil
+		manager.mutex.RUnlock()
+
+		manager.progressWriter.Stop(hasError)
+	}
+}
+
+func (manager *BundleDownloadManager) Start() {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"struct":   "BundleDownloadManager",
+		"function": "Start",
+	})
+
+	processBundleDownloadChan := make(chan *Bundle, 5)
+	processBundleExtractChan := make(chan *Bundle, 5)
+
+	manager.startProgress()
+
+	// bundle --> stage --> tar on server --> download --> extract
+
+	// process bundle - stage and tar
+	go func() {
+		logger.Debug("start bundle thread")
+		defer logger.Debug("exit bundle thread")
+
+		defer close(processBundleDownloadChan)
+
+		err := manager.makeTempDirs()
+		if err != nil {
+			// mark error
+			manager.mutex.Lock()
+			manager.lastError = err
+			manager.mutex.Unlock()
+
+			logger.Error(err)
+			// don't stop here
+		}
+
+		for bundle := range manager.pendingBundles {
+			manager.runBundlePhase(bundle, BundleTaskNameStage, manager.processBundleStage)
+			manager.runBundlePhase(bundle, BundleTaskNameTar, manager.processBundleTar)
+
+			processBundleDownloadChan <- bundle
+		}
+	}()
+
+	// process bundle - download
+	funcAsyncDownload := func(id int, wg *sync.WaitGroup) {
+		logger.Debugf("start transfer thread %d", id)
+		defer logger.Debugf("exit transfer thread %d", id)
+
+		defer wg.Done()
+
+		for bundle := range processBundleDownloadChan {
+			manager.runBundlePhase(bundle, BundleTaskNameDownload, manager.processBundleDownload)
+
+			processBundleExtractChan <- bundle
+		}
+	}
+
+	waitAsyncDownload := sync.WaitGroup{}
+	for i := 0; i < manager.downloadThreadNum; i++ {
+		waitAsyncDownload.Add(1)
+		go funcAsyncDownload(i, &waitAsyncDownload)
+	}
+
+	go func() {
+		waitAsyncDownload.Wait()
+		close(processBundleExtractChan)
+	}()
+
+	// process bundle - extract
+	funcAsyncExtract := func(id int, wg *sync.WaitGroup) {
+		logger.Debugf("start extract thread %d", id)
+		defer logger.Debugf("exit extract thread %d", id)
+
+		defer wg.Done()
+
+		for bundle := range processBundleExtractChan {
+			manager.runBundlePhase(bundle, BundleTaskNameExtract, manager.processBundleExtract)
+
+			manager.transferWait.Done()
+		}
+	}
+
+	waitAsyncExtract := sync.WaitGroup{}
+	for i := 0; i < 3; i++ {
+		waitAsyncExtract.Add(1)
+		go funcAsyncExtract(i, &waitAsyncExtract)
+	}
+
+	go func() {
+		waitAsyncExtract.Wait()
+
+		manager.endProgress()
+	}()
+}
+
+func (manager *BundleDownloadManager) makeTempDirs() error {
+	if !manager.filesystem.ExistsDir(manager.irodsTempDirPath) {
+		err := manager.filesystem.MakeDir(manager.irodsTempDirPath, true)
+		if err != nil {
+			return xerrors.Errorf("failed to make a collection %q: %w", manager.irodsTempDirPath, err)
+		}
+	}
+
+	err := os.MkdirAll(manager.localTempDirPath, 0766)
+	if err != nil {
+		return xerrors.Errorf("failed to make a directory %q: %w", manager.localTempDirPath, err)
+	}
+
+	return nil
+}
+
+func (manager *BundleDownloadManager) processBundleStage(bundle *Bundle) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"struct":   "BundleDownloadManager",
+		"function": "processBundleStage",
+	})
+
+	progressName := manager.getProgressName(bundle, BundleTaskNameStage)
+
+	totalFileNum := int64(len(bundle.Entries))
+
+	manager.progress(progressName, 0, totalFileNum, progress.UnitsDefault, false)
+
+	if !manager.requireTar(bundle) {
+		// no tar, so pass this step
+		manager.progress(progressName, totalFileNum, totalFileNum, progress.UnitsDefault, false)
+		logger.Debugf("skip staging bundle %d", bundle.Index)
+		return nil
+	}
+
+	stagingCollectionPath := manager.getStagingCollectionPath(bundle)
+
+	logger.Debugf("copying data objects in bundle %d to %q", bundle.Index, stagingCollectionPath)
+
+	err := manager.filesystem.MakeDir(stagingCollectionPath, true)
+	if err != nil {
+		manager.progress(progressName, 0, totalFileNum, progress.UnitsDefault, true)
+		return xerrors.Errorf("failed to make a staging collection %q for bundle %d: %w", stagingCollectionPath, bundle.Index, err)
+	}
+
+	for idx, entry := range bundle.Entries {
+		stagingPath := path.Join(stagingCollectionPath, manager.getStagingDataObjectName(idx))
+
+		err = manager.filesystem.CopyFileToFile(entry.IRODSPath, stagingPath, true)
+		if err != nil {
+			manager.progress(progressName, int64(idx), totalFileNum, progress.UnitsDefault, true)
+			return xerrors.Errorf("failed to copy data object %q in bundle %d to %q: %w", entry.IRODSPath, bundle.Index, stagingPath, err)
+		}
+
+		manager.progress(progressName, int64(idx+1), totalFileNum, progress.UnitsDefault, false)
+	}
+
+	logger.Debugf("copied data objects in bundle %d to %q", bundle.Index, stagingCollectionPath)
+	return nil
+}
+
+func (manager *BundleDownloadManager) processBundleTar(bundle *Bundle) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"struct":   "BundleDownloadManager",
+		"function": "processBundleTar",
+	})
+
+	progressName := manager.getProgressName(bundle, BundleTaskNameTar)
+
+	totalFileNum := int64(len(bundle.Entries))
+
+	manager.progress(progressName, 0, totalFileNum, progress.UnitsDefault, false)
+
+	if !manager.requireTar(bundle) {
+		// no tar, so pass this step
+		manager.progress(progressName, totalFileNum, totalFileNum, progress.UnitsDefault, false)
+		logger.Debugf("skip creating a tarball for bundle %d", bundle.Index)
+		return nil
+	}
+
+	stagingCollectionPath := manager.getStagingCollectionPath(bundle)
+
+	logger.Debugf("creating a tarball for bundle %d to %q", bundle.Index, bundle.IRODSBundlePath)
+
+	err := BundleStructFile(manager.filesystem, bundle.IRODSBundlePath, stagingCollectionPath, "", bundle.Format.GetDataType(), true)
+	if err != nil {
+		manager.progress(progressName, 0, totalFileNum, progress.UnitsDefault, true)
+		return xerrors.Errorf("failed to create a tarball for bundle %d to %q from %q: %w", bundle.Index, bundle.IRODSBundlePath, stagingCollectionPath, err)
+	}
+
+	// staged copies are in the tarball now
+	err = manager.filesystem.RemoveDir(stagingCollectionPath, true, true)
+	if err != nil {
+		logger.WithError(err).Warnf("failed to remove staging collection %q", stagingCollectionPath)
+	}
+
+	manager.progress(progressName, totalFileNum, totalFileNum, progress.UnitsDefault, false)
+
+	logger.Debugf("created a tarball for bundle %d to %q", bundle.Index, bundle.IRODSBundlePath)
+	return nil
+}
+
+// downloadFile downloads a data object in the way requested, returning notes of the way
+func (manager *BundleDownloadManager) downloadFile(irodsPath string, localPath string, size int64, callback func(processed int64, total int64)) (*irodsclient_fs.FileTransferResult, []string, error) {
+	if manager.singleThreaded || manager.downloadThreadNum == 1 {
+		result, err := manager.filesystem.DownloadFile(irodsPath, "", localPath, manager.verifyChecksum, callback)
+		return result, []string{"icat", "single-thread"}, err
+	} else if manager.redirectToResource {
+		result, err := manager.filesystem.DownloadFileRedirectToResource(irodsPath, "", localPath, 0, manager.verifyChecksum, callback)
+		return result, []string{"redirect-to-resource"}, err
+	} else if manager.useIcat {
+		result, err := manager.filesystem.DownloadFileParallel(irodsPath, "", localPath, 0, manager.verifyChecksum, callback)
+		return result, []string{"icat", "multi-thread"}, err
+	}
+
+	// auto
+	if size >= RedirectToResourceMinSize {
+		// redirect-to-resource
+		result, err := manager.filesystem.DownloadFileRedirectToResource(irodsPath, "", localPath, 0, manager.verifyChecksum, callback)
+		return result, []string{"redirect-to-resource"}, err
+	}
+
+	result, err := manager.filesystem.DownloadFileParallel(irodsPath, "", localPath, 0, manager.verifyChecksum, callback)
+	return result, []string{"icat", "multi-thread"}, err
+}
+
+func (manager *BundleDownloadManager) processBundleDownload(bundle *Bundle) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"struct":   "BundleDownloadManager",
+		"function": "processBundleDownload",
+	})
+
+	logger.Debugf("downloading bundle %d from %q", bundle.Index, bundle.IRODSBundlePath)
+
+	manager.mutex.Lock()
+	manager.bundleStartTimes[bundle.Index] = time.Now()
+	manager.mutex.Unlock()
+
+	if manager.requireTar(bundle) {
+		return manager.processBundleDownloadWithTar(bundle)
+	}
+
+	return manager.processBundleDownloadWithoutTar(bundle)
+}
+
+func (manager *BundleDownloadManager) processBundleDownloadWithTar(bundle *Bundle) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"struct":   "BundleDownloadManager",
+		"function": "processBundleDownloadWithTar",
+	})
+
+	progressName := manager.getProgressName(bundle, BundleTaskNameDownload)
+
+	callbackGet := func(processed int64, total int64) {
+		manager.progress(progressName, processed, total, progress.UnitsBytes, false)
+	}
+
+	bundleEntry, err := manager.filesystem.StatFile(bundle.IRODSBundlePath)
+	if err != nil {
+		manager.progress(progressName, 0, bundle.Size, progress.UnitsBytes, true)
+		return xerrors.Errorf("failed to stat bundle %q: %w", bundle.IRODSBundlePath, err)
+	}
+
+	manager.progress(progressName, 0, bundleEntry.Size, progress.UnitsBytes, false)
+
+	logger.Debugf("downloading bundle %d from %q, size %d", bundle.Index, bundle.IRODSBundlePath, bundleEntry.Size)
+
+	_, _, err = manager.downloadFile(bundle.IRODSBundlePath, bundle.LocalBundlePath, bundleEntry.Size, callbackGet)
+	if err != nil {
+		manager.progress(progressName, 0, bundleEntry.Size, progress.UnitsBytes, true)
+		return xerrors.Errorf("failed to download bundle %d from %q: %w", bundle.Index, bundle.IRODSBundlePath, err)
+	}
+
+	// remove irods bundle file
+	err = manager.filesystem.RemoveFile(bundle.IRODSBundlePath, true)
+	if err != nil {
+		logger.WithError(err).Warnf("failed to remove irods bundle %q", bundle.IRODSBundlePath)
+	}
+
+	manager.progress(progressName, bundleEntry.Size, bundleEntry.Size, progress.UnitsBytes, false)
+
+	logger.Debugf("downloaded bundle %d from %q", bundle.Index, bundle.IRODSBundlePath)
+	return nil
+}
+
+func (manager *BundleDownloadManager) processBundleDownloadWithoutTar(bundle *Bundle) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"struct":   "BundleDownloadManager",
+		"function": "processBundleDownloadWithoutTar",
+	})
+
+	progressName := manager.getProgressName(bundle, BundleTaskNameDownload)
+
+	fileProgress := make([]int64, len(bundle.Entries))
+
+	manager.progress(progressName, 0, bundle.Size, progress.UnitsBytes, false)
+
+	for fileIdx, file := range bundle.Entries {
+		callbackGet := func(processed int64, total int64) {
+			fileProgress[fileIdx] = processed
+
+			progressSum := int64(0)
+			for _, progress := range fileProgress {
+				progressSum += progress
+			}
+
+			manager.progress(progressName, progressSum, bundle.Size, progress.UnitsBytes, false)
+		}
+
+		parentDir := filepath.Dir(file.LocalPath)
+		err := os.MkdirAll(parentDir, 0766)
+		if err != nil {
+			manager.progress(progressName, 0, bundle.Size, progress.UnitsBytes, true)
+			return xerrors.Errorf("failed to make a directory %q to download data object %q in bundle %d: %w", parentDir, file.IRODSPath, bundle.Index, err)
+		}
+
+		downloadResult, notes, downloadErr := manager.downloadFile(file.IRODSPath, file.LocalPath, file.Size, callbackGet)
+		if downloadErr != nil {
+			now := time.Now()
+			reportFile := &TransferReportFile{
+				Method:     TransferMethodGet,
+				StartAt:    now,
+				EndAt:      now,
+				SourcePath: file.IRODSPath,
+				SourceSize: file.Size,
+				DestPath:   file.LocalPath,
+				Error:      downloadErr,
+				Notes:      notes,
+			}
+
+			manager.transferReportManager.AddFile(reportFile)
+			file.reported = true
+
+			manager.progress(progressName, 0, bundle.Size, progress.UnitsBytes, true)
+			return xerrors.Errorf("failed to download data object %q in bundle %d to %q: %w", file.IRODSPath, bundle.Index, file.LocalPath, downloadErr)
+		}
+
+		err = manager.transferReportManager.AddTransfer(downloadResult, TransferMethodGet, nil, notes)
+		if err != nil {
+			manager.progress(progressName, 0, bundle.Size, progress.UnitsBytes, true)
+			return xerrors.Errorf("failed to add transfer report: %w", err)
+		}
+		file.reported = true
+
+		logger.Debugf("downloaded data object %q in bundle %d to %q", file.IRODSPath, bundle.Index, file.LocalPath)
+	}
+
+	manager.progress(progressName, bundle.Size, bundle.Size, progress.UnitsBytes, false)
+
+	logger.Debugf("downloaded data objects in bundle %d", bundle.Index)
+	return nil
+}
+
+func (manager *BundleDownloadManager) processBundleExtract(bundle *Bundle) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"struct":   "BundleDownloadManager",
+		"function": "processBundleExtract",
+	})
+
+	progressName := manager.getProgressName(bundle, BundleTaskNameExtract)
+
+	totalFileNum := int64(len(bundle.Entries))
+
+	manager.progress(progressName, 0, totalFileNum, progress.UnitsDefault, false)
+
+	if manager.requireTar(bundle) {
+		logger.Debugf("extracting bundle %d at %q", bundle.Index, bundle.LocalBundlePath)
+
+		callbackUntar := func(processed int64, total int64) {
+			manager.progress(progressName, processed, total, progress.UnitsDefault, false)
+		}
+
+		targets := map[string]string{}
+		for idx, entry := range bundle.Entries {
+			targets[manager.getStagingDataObjectName(idx)] = entry.LocalPath
+		}
+
+		err := UntarFiles(bundle.LocalBundlePath, targets, callbackUntar)
+
+		// remove local bundle file
+		os.Remove(bundle.LocalBundlePath)
+
+		if err != nil {
+			manager.progress(progressName, 0, totalFileNum, progress.UnitsDefault, true)
+			return xerrors.Errorf("failed to extract bundle %d at %q: %w", bundle.Index, bundle.LocalBundlePath, err)
+		}
+
+		manager.mutex.RLock()
+		startTime := manager.bundleStartTimes[bundle.Index]
+		manager.mutex.RUnlock()
+
+		for _, entry := range bundle.Entries {
+			err = manager.verifyAndReport(entry, startTime)
+			if err != nil {
+				manager.progress(progressName, 0, totalFileNum, progress.UnitsDefault, true)
+				return xerrors.Errorf("failed to verify data object %q in bundle %d: %w", entry.IRODSPath, bundle.Index, err)
+			}
+		}
+	} else {
+		// no tar, so pass this step
+		// files are already reported in download step
+		logger.Debugf("skip extracting bundle %d", bundle.Index)
+	}
+
+	manager.progress(progressName, totalFileNum, totalFileNum, progress.UnitsDefault, false)
+
+	// set it done
+	bundle.SetCompleted()
+	atomic.AddInt64(&manager.bundlesDoneCounter, 1)
+
+	logger.Debugf("extracted bundle %d", bundle.Index)
+	return nil
+}
+
+// verifyAndReport compares the extracted file with its data object, then adds it to the report
+func (manager *BundleDownloadManager) verifyAndReport(entry *BundleEntry, startTime time.Time) error {
+	reportFile := &TransferReportFile{
+		Method:                  TransferMethodGet,
+		StartAt:                 startTime,
+		SourcePath:              entry.IRODSPath,
+		SourceSize:              entry.Size,
+		SourceChecksumAlgorithm: string(entry.ChecksumAlgorithm),
+		SourceChecksum:          hex.EncodeToString(entry.Checksum),
+		DestPath:                entry.LocalPath,
+		Notes:                   []string{"bundle_extracted"},
+	}
+
+	err := manager.verifyExtractedFile(entry, reportFile)
+
+	reportFile.EndAt = time.Now()
+	reportFile.Error = err
+	manager.transferReportManager.AddFile(reportFile)
+	entry.reported = true
+
+	return err
+}
+
+func (manager *BundleDownloadManager) verifyExtractedFile(entry *BundleEntry, reportFile *TransferReportFile) error {
+	stat, err := os.Stat(entry.LocalPath)
+	if err != nil {
+		return xerrors.Errorf("failed to stat %q: %w", entry.LocalPath, err)
+	}
+
+	reportFile.DestSize = stat.Size()
+
+	if stat.Size() != entry.Size {
+		return xerrors.Errorf("size mismatch, %q has %d bytes but %q has %d bytes", entry.LocalPath, stat.Size(), entry.IRODSPath, entry.Size)
+	}
+
+	if !manager.verifyChecksum || len(entry.Checksum) == 0 {
+		return nil
+	}
+
+	localChecksum, err := irodsclient_util.HashLocalFile(entry.LocalPath, string(entry.ChecksumAlgorithm))
+	if err != nil {
+		return xerrors.Errorf("failed to get hash of %q: %w", entry.LocalPath, err)
+	}
+
+	reportFile.DestChecksumAlgorithm = string(entry.ChecksumAlgorithm)
+	reportFile.DestChecksum = hex.EncodeToString(localChecksum)
+
+	if !bytes.Equal(localChecksum, entry.Checksum) {
+		return xerrors.Errorf("checksum mismatch between %q and %q", entry.LocalPath, entry.IRODSPath)
+	}
+
+	reportFile.Notes = append(reportFile.Notes, "verified_checksum")
+	return nil
+}
+
+func (manager *BundleDownloadManager) getProgressName(bundle *Bundle, taskName string) string {
+	return fmt.Sprintf("bundle %d - %q", bundle.Index, taskName)
+}
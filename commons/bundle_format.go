@@ -0,0 +1,179 @@
+package commons
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"strings"
+
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/dsnet/compress/bzip2"
+	"golang.org/x/xerrors"
+)
+
+// BundleFormat determines file format of bundle files
+type BundleFormat string
+
+const (
+	// BundleFormatTar is for uncompressed tar
+	BundleFormatTar BundleFormat = "tar"
+	// BundleFormatTarGzip is for gzip-compressed tar
+	BundleFormatTarGzip BundleFormat = "tar.gz"
+	// BundleFormatTarBzip2 is for bzip2-compressed tar
+	BundleFormatTarBzip2 BundleFormat = "tar.bz2"
+	// BundleFormatZip is for zip
+	BundleFormatZip BundleFormat = "zip"
+	// BundleFormatUnknown is for unknown format
+	BundleFormatUnknown BundleFormat = ""
+)
+
+// GetBundleFormat returns BundleFormat from string
+func GetBundleFormat(format string) BundleFormat {
+	switch strings.ToLower(format) {
+	case string(BundleFormatTar):
+		return BundleFormatTar
+	case string(BundleFormatTarGzip), "tgz", "gzip":
+		return BundleFormatTarGzip
+	case string(BundleFormatTarBzip2), "tbz2", "bzip2":
+		return BundleFormatTarBzip2
+	case string(BundleFormatZip):
+		return BundleFormatZip
+	default:
+		return BundleFormatUnknown
+	}
+}
+
+// GetExtension returns a file extension of bundle files in the format
+func (format BundleFormat) GetExtension() string {
+	if format == BundleFormatUnknown {
+		return "." + string(BundleFormatTar)
+	}
+
+	return "." + string(format)
+}
+
+// GetDataType returns a data type to extract bundle files in the format on the server-side
+func (format BundleFormat) GetDataType() irodsclient_types.DataType {
+	switch format {
+	case BundleFormatTarGzip:
+		return irodsclient_types.GZIP_TAR_DT
+	case BundleFormatTarBzip2:
+		return irodsclient_types.BZIP2_TAR_DT
+	case BundleFormatZip:
+		return irodsclient_types.ZIP_FILE_DT
+	default:
+		return irodsclient_types.TAR_FILE_DT
+	}
+}
+
+// bundleFileWriter writes entries to a bundle file
+type bundleFileWriter interface {
+	// AddEntry adds an entry with the name, returns a writer for file content
+	AddEntry(name string, sourceStat fs.FileInfo) (io.Writer, error)
+	// Close flushes all entries, the underlying writer is not closed
+	Close() error
+}
+
+func newBundleFileWriter(writer io.Writer, format BundleFormat) (bundleFileWriter, error) {
+	switch format {
+	case BundleFormatTar, BundleFormatUnknown:
+		return &tarBundleFileWriter{
+			tarWriter: tar.NewWriter(writer),
+		}, nil
+	case BundleFormatTarGzip:
+		gzipWriter := gzip.NewWriter(writer)
+		return &tarBundleFileWriter{
+			tarWriter:        tar.NewWriter(gzipWriter),
+			compressorWriter: gzipWriter,
+		}, nil
+	case BundleFormatTarBzip2:
+		bzip2Writer, err := bzip2.NewWriter(writer, nil)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to create bzip2 writer: %w", err)
+		}
+
+		return &tarBundleFileWriter{
+			tarWriter:        tar.NewWriter(bzip2Writer),
+			compressorWriter: bzip2Writer,
+		}, nil
+	case BundleFormatZip:
+		return &zipBundleFileWriter{
+			zipWriter: zip.NewWriter(writer),
+		}, nil
+	default:
+		return nil, xerrors.Errorf("unknown bundle format %q", format)
+	}
+}
+
+type tarBundleFileWriter struct {
+	tarWriter        *tar.Writer
+	compressorWriter io.WriteCloser
+}
+
+func (writer *tarBundleFileWriter) AddEntry(name string, sourceStat fs.FileInfo) (io.Writer, error) {
+	header, err := tar.FileInfoHeader(sourceStat, sourceStat.Name())
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create tar file info header: %w", err)
+	}
+
+	header.Name = name
+
+	err = writer.tarWriter.WriteHeader(header)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to write tar header: %w", err)
+	}
+
+	return writer.tarWriter, nil
+}
+
+func (writer *tarBundleFileWriter) Close() error {
+	err := writer.tarWriter.Close()
+	if err != nil {
+		return xerrors.Errorf("failed to close tar writer: %w", err)
+	}
+
+	if writer.compressorWriter != nil {
+		err = writer.compressorWriter.Close()
+		if err != nil {
+			return xerrors.Errorf("failed to close compressor: %w", err)
+		}
+	}
+
+	return nil
+}
+
+type zipBundleFileWriter struct {
+	zipWriter *zip.Writer
+}
+
+func (writer *zipBundleFileWriter) AddEntry(name string, sourceStat fs.FileInfo) (io.Writer, error) {
+	header, err := zip.FileInfoHeader(sourceStat)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create zip file info header: %w", err)
+	}
+
+	header.Name = name
+	if sourceStat.IsDir() {
+		header.Name += "/"
+	} else {
+		header.Method = zip.Deflate
+	}
+
+	entryWriter, err := writer.zipWriter.CreateHeader(header)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to write zip header: %w", err)
+	}
+
+	return entryWriter, nil
+}
+
+func (writer *zipBundleFileWriter) Close() error {
+	err := writer.zipWriter.Close()
+	if err != nil {
+		return xerrors.Errorf("failed to close zip writer: %w", err)
+	}
+
+	return nil
+}
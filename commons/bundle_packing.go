@@ -0,0 +1,224 @@
+package commons
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+// BundlePackingStrategy determines how files are packed into bundles
+type BundlePackingStrategy string
+
+const (
+	// BundlePackingStrategyWalk fills bundles with files in walk order
+	BundlePackingStrategyWalk BundlePackingStrategy = "walk"
+	// BundlePackingStrategyCollection groups files by target collection, uploads large files directly, and balances bundle sizes
+	BundlePackingStrategyCollection BundlePackingStrategy = "collection"
+	// BundlePackingStrategyUnknown is for unknown strategy
+	BundlePackingStrategyUnknown BundlePackingStrategy = ""
+)
+
+// GetBundlePackingStrategy returns BundlePackingStrategy from string
+func GetBundlePackingStrategy(strategy string) BundlePackingStrategy {
+	switch strings.ToLower(strategy) {
+	case string(BundlePackingStrategyWalk):
+		return BundlePackingStrategyWalk
+	case string(BundlePackingStrategyCollection), "coll":
+		return BundlePackingStrategyCollection
+	default:
+		return BundlePackingStrategyUnknown
+	}
+}
+
+// bundleEntryGroup is entries to the same target collection, not packed into a bundle yet
+type bundleEntryGroup struct {
+	entries []*BundleEntry
+	size    int64
+}
+
+func (group *bundleEntryGroup) add(entry *BundleEntry) {
+	group.entries = append(group.entries, entry)
+	if !entry.Dir {
+		group.size += entry.Size
+	}
+}
+
+// scheduleByCollection adds the file to the group of its target collection, the mutex must be held
+func (manager *BundleTransferManager) scheduleByCollection(sourceStat fs.FileInfo, sourcePath string) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"struct":   "BundleTransferManager",
+		"function": "scheduleByCollection",
+	})
+
+	entry, err := manager.newBundleEntry(sourceStat, sourcePath)
+	if err != nil {
+		return err
+	}
+
+	if !entry.Dir && entry.Size >= manager.directUploadFileSize {
+		// large files gain nothing from tar
+		bundle, err := manager.newBundleWithEntries([]*BundleEntry{entry})
+		if err != nil {
+			return err
+		}
+
+		bundle.direct = true
+
+		logger.Debugf("scheduling a large file %q to upload directly in bundle %d", sourcePath, bundle.Index)
+		manager.scheduleBundle(bundle)
+		return nil
+	}
+
+	collection := path.Dir(entry.IRODSPath)
+	group, ok := manager.collectionGroups[collection]
+	if !ok {
+		group = &bundleEntryGroup{
+			entries: []*BundleEntry{},
+			size:    0,
+		}
+
+		manager.collectionGroups[collection] = group
+		manager.collectionGroupOrder = append(manager.collectionGroupOrder, collection)
+	}
+
+	group.add(entry)
+
+	if group.size >= manager.maxBundleFileSize || len(group.entries) >= manager.maxBundleFileNum {
+		delete(manager.collectionGroups, collection)
+
+		bundle, err := manager.newBundleWithEntries(group.entries)
+		if err != nil {
+			return err
+		}
+
+		logger.Debugf("scheduling bundle %d for collection %q", bundle.Index, collection)
+		manager.scheduleBundle(bundle)
+	}
+
+	return nil
+}
+
+// flushCollectionGroups packs remaining groups into bundles of similar sizes, the mutex must be held
+func (manager *BundleTransferManager) flushCollectionGroups() error {
+	// entries of the same collection stay next to each other
+	entries := []*BundleEntry{}
+	for _, collection := range manager.collectionGroupOrder {
+		if group, ok := manager.collectionGroups[collection]; ok {
+			entries = append(entries, group.entries...)
+			delete(manager.collectionGroups, collection)
+		}
+	}
+
+	manager.collectionGroupOrder = []string{}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	bundleNum := getBalancedBundleNum(entries, manager.uploadThreadNum, manager.minBundleFileNum, manager.maxBundleFileNum, manager.maxBundleFileSize)
+	for _, bundleEntries := range splitBundleEntries(entries, bundleNum, manager.maxBundleFileNum) {
+		bundle, err := manager.newBundleWithEntries(bundleEntries)
+		if err != nil {
+			return err
+		}
+
+		manager.scheduleBundle(bundle)
+	}
+
+	return nil
+}
+
+func (manager *BundleTransferManager) newBundleWithEntries(entries []*BundleEntry) (*Bundle, error) {
+	bundle, err := newBundle(manager)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create a new bundle: %w", err)
+	}
+
+	err = bundle.addEntries(entries)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to add entries to bundle %d: %w", bundle.Index, err)
+	}
+
+	return bundle, nil
+}
+
+// getBalancedBundleNum returns the number of bundles to split the entries into.
+// One bundle per upload thread is preferred as long as bundles have enough files to tar and do not exceed limits.
+func getBalancedBundleNum(entries []*BundleEntry, threadNum int, minFileNum int, maxFileNum int, maxFileSize int64) int {
+	totalSize := int64(0)
+	for _, entry := range entries {
+		totalSize += entry.Size
+	}
+
+	bundleNum := threadNum
+	if minFileNum > 0 && len(entries)/minFileNum < bundleNum {
+		bundleNum = len(entries) / minFileNum
+	}
+
+	if maxFileNum > 0 {
+		numByCount := (len(entries) + maxFileNum - 1) / maxFileNum
+		if numByCount > bundleNum {
+			bundleNum = numByCount
+		}
+	}
+
+	if maxFileSize > 0 {
+		numBySize := int((totalSize + maxFileSize - 1) / maxFileSize)
+		if numBySize > bundleNum {
+			bundleNum = numBySize
+		}
+	}
+
+	if bundleNum < 1 {
+		bundleNum = 1
+	}
+
+	return bundleNum
+}
+
+// splitBundleEntries splits the entries into the number of groups of similar sizes, keeping order of the entries.
+// Empty files are split by count instead. Groups never have more than maxFileNum entries, so more groups may be returned.
+func splitBundleEntries(entries []*BundleEntry, num int, maxFileNum int) [][]*BundleEntry {
+	totalSize := int64(0)
+	for _, entry := range entries {
+		totalSize += entry.Size
+	}
+
+	targetSize := totalSize / int64(num)
+	targetCount := (len(entries) + num - 1) / num
+
+	groups := [][]*BundleEntry{}
+	current := []*BundleEntry{}
+	currentSize := int64(0)
+
+	for _, entry := range entries {
+		current = append(current, entry)
+		currentSize += entry.Size
+
+		full := maxFileNum > 0 && len(current) >= maxFileNum
+		balanced := false
+		if len(groups) < num-1 {
+			if targetSize > 0 {
+				balanced = currentSize >= targetSize
+			} else {
+				balanced = len(current) >= targetCount
+			}
+		}
+
+		if full || balanced {
+			groups = append(groups, current)
+			current = []*BundleEntry{}
+			currentSize = 0
+		}
+	}
+
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+
+	return groups
+}
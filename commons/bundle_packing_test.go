@@ -0,0 +1,81 @@
+package commons
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBundlePacking(t *testing.T) {
+	t.Run("test GetBalancedBundleNum", testGetBalancedBundleNum)
+	t.Run("test SplitBundleEntries", testSplitBundleEntries)
+	t.Run("test SplitBundleEntriesMaxFileNum", testSplitBundleEntriesMaxFileNum)
+}
+
+func makeTestBundleEntries(sizes ...int64) []*BundleEntry {
+	entries := []*BundleEntry{}
+	for idx, size := range sizes {
+		entries = append(entries, &BundleEntry{
+			LocalPath: fmt.Sprintf("/data/%d", idx),
+			IRODSPath: fmt.Sprintf("/zone/home/user/%d", idx),
+			Size:      size,
+		})
+	}
+	return entries
+}
+
+func testGetBalancedBundleNum(t *testing.T) {
+	// one bundle per thread
+	entries := makeTestBundleEntries(10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10)
+	assert.Equal(t, 4, getBalancedBundleNum(entries, 4, 3, 50, 1000))
+
+	// bundles must have enough files to tar
+	assert.Equal(t, 2, getBalancedBundleNum(entries[:7], 4, 3, 50, 1000))
+	assert.Equal(t, 1, getBalancedBundleNum(entries[:2], 4, 3, 50, 1000))
+
+	// limits of bundles are kept
+	assert.Equal(t, 6, getBalancedBundleNum(entries, 4, 3, 2, 1000))
+	assert.Equal(t, 3, getBalancedBundleNum(entries, 1, 3, 50, 50))
+}
+
+func testSplitBundleEntries(t *testing.T) {
+	entries := makeTestBundleEntries(50, 10, 10, 10, 10, 10, 30, 30, 40)
+
+	groups := splitBundleEntries(entries, 2, 50)
+	assert.Len(t, groups, 2)
+
+	sizes := []int64{}
+	count := 0
+	for _, group := range groups {
+		size := int64(0)
+		for _, entry := range group {
+			size += entry.Size
+		}
+		sizes = append(sizes, size)
+		count += len(group)
+	}
+
+	assert.Equal(t, []int64{100, 100}, sizes)
+	assert.Equal(t, len(entries), count)
+
+	// order is kept
+	assert.Equal(t, entries[0], groups[0][0])
+	assert.Equal(t, entries[8], groups[1][len(groups[1])-1])
+
+	// empty files are split by count
+	groups = splitBundleEntries(makeTestBundleEntries(0, 0, 0, 0, 0), 2, 50)
+	assert.Len(t, groups, 2)
+	assert.Len(t, groups[0], 3)
+	assert.Len(t, groups[1], 2)
+}
+
+func testSplitBundleEntriesMaxFileNum(t *testing.T) {
+	entries := makeTestBundleEntries(1, 1, 1, 1, 1, 1, 1)
+
+	groups := splitBundleEntries(entries, 1, 3)
+	assert.Len(t, groups, 3)
+	for _, group := range groups {
+		assert.LessOrEqual(t, len(group), 3)
+	}
+}
@@ -0,0 +1,148 @@
+package commons
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	BundleStateExtension string = ".state.json"
+)
+
+// BundleStatus is a progress of a bundle persisted to resume uploads
+type BundleStatus string
+
+const (
+	// BundleStatusNone is for bundles not processed yet
+	BundleStatusNone BundleStatus = ""
+	// BundleStatusCreated is for bundles having a bundle file created at local
+	BundleStatusCreated BundleStatus = "created"
+	// BundleStatusUploaded is for bundles having a bundle file uploaded to iRODS
+	BundleStatusUploaded BundleStatus = "uploaded"
+	// BundleStatusExtracted is for bundles extracted in iRODS
+	BundleStatusExtracted BundleStatus = "extracted"
+)
+
+// BundleStateEntry is a file or a directory in a bundle manifest
+type BundleStateEntry struct {
+	LocalPath string    `json:"local_path"`
+	IRODSPath string    `json:"irods_path"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	Dir       bool      `json:"dir"`
+}
+
+// BundleState is a manifest of a bundle and its progress, persisted next to the local bundle file
+type BundleState struct {
+	Format          BundleFormat        `json:"format"`
+	IRODSBundlePath string              `json:"irods_bundle_path"`
+	BundleFileSize  int64               `json:"bundle_file_size"`
+	Status          BundleStatus        `json:"status"`
+	Entries         []*BundleStateEntry `json:"entries"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+}
+
+// NewBundleState creates a manifest of the bundle in the status
+func NewBundleState(bundle *Bundle, status BundleStatus, bundleFileSize int64) *BundleState {
+	entries := make([]*BundleStateEntry, len(bundle.Entries))
+	for idx, entry := range bundle.Entries {
+		entries[idx] = &BundleStateEntry{
+			LocalPath: entry.LocalPath,
+			IRODSPath: entry.IRODSPath,
+			Size:      entry.Size,
+			ModTime:   entry.ModTime,
+			Dir:       entry.Dir,
+		}
+	}
+
+	return &BundleState{
+		Format:          bundle.Format,
+		IRODSBundlePath: bundle.IRODSBundlePath,
+		BundleFileSize:  bundleFileSize,
+		Status:          status,
+		Entries:         entries,
+		UpdatedAt:       time.Now(),
+	}
+}
+
+// GetBundleStatePath returns path of the manifest of the local bundle file
+func GetBundleStatePath(localBundlePath string) string {
+	return localBundlePath + BundleStateExtension
+}
+
+// IsBundleStateFilename returns true if the filename is of a bundle manifest
+func IsBundleStateFilename(p string) bool {
+	return strings.HasPrefix(p, "bundle_") && strings.HasSuffix(p, BundleStateExtension)
+}
+
+// ReadBundleState reads a bundle manifest, returns nil if there is no manifest
+func ReadBundleState(statePath string) (*BundleState, error) {
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, xerrors.Errorf("failed to read bundle state %q: %w", statePath, err)
+	}
+
+	state := BundleState{}
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse bundle state %q: %w", statePath, err)
+	}
+
+	return &state, nil
+}
+
+// WriteBundleState writes a bundle manifest, replacing the old one at once so it is never partially written
+func WriteBundleState(statePath string, state *BundleState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return xerrors.Errorf("failed to marshal bundle state: %w", err)
+	}
+
+	tempPath := statePath + ".tmp"
+	err = os.WriteFile(tempPath, data, 0644)
+	if err != nil {
+		return xerrors.Errorf("failed to write bundle state %q: %w", tempPath, err)
+	}
+
+	err = os.Rename(tempPath, statePath)
+	if err != nil {
+		os.Remove(tempPath)
+		return xerrors.Errorf("failed to rename bundle state %q to %q: %w", tempPath, statePath, err)
+	}
+
+	return nil
+}
+
+// Matches returns true if the manifest describes the bundle, files changed since are not matched
+func (state *BundleState) Matches(bundle *Bundle) bool {
+	if state.Format != bundle.Format || state.IRODSBundlePath != bundle.IRODSBundlePath {
+		return false
+	}
+
+	if len(state.Entries) != len(bundle.Entries) {
+		return false
+	}
+
+	for idx, entry := range bundle.Entries {
+		stateEntry := state.Entries[idx]
+		if stateEntry.LocalPath != entry.LocalPath || stateEntry.IRODSPath != entry.IRODSPath || stateEntry.Dir != entry.Dir {
+			return false
+		}
+
+		if !entry.Dir {
+			if stateEntry.Size != entry.Size || !stateEntry.ModTime.Equal(entry.ModTime) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,71 @@
+package commons
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBundleState(t *testing.T) {
+	t.Run("test WriteAndReadBundleState", testWriteAndReadBundleState)
+	t.Run("test ReadBundleStateMissing", testReadBundleStateMissing)
+	t.Run("test BundleStateMatches", testBundleStateMatches)
+}
+
+func makeTestBundle() *Bundle {
+	modTime := time.Date(2024, 5, 1, 10, 30, 0, 123456789, time.Local)
+
+	return &Bundle{
+		Format:          BundleFormatTarGzip,
+		IRODSBundlePath: "/zone/home/user/.gocmd_staging/bundle_abc.tar.gz",
+		Entries: []*BundleEntry{
+			{LocalPath: "/data/dir", IRODSPath: "/zone/home/user/dir", Dir: true},
+			{LocalPath: "/data/dir/a.txt", IRODSPath: "/zone/home/user/dir/a.txt", Size: 10, ModTime: modTime},
+			{LocalPath: "/data/dir/b.txt", IRODSPath: "/zone/home/user/dir/b.txt", Size: 20, ModTime: modTime},
+		},
+	}
+}
+
+func testWriteAndReadBundleState(t *testing.T) {
+	bundle := makeTestBundle()
+	statePath := GetBundleStatePath(filepath.Join(t.TempDir(), "bundle_abc.tar.gz"))
+
+	err := WriteBundleState(statePath, NewBundleState(bundle, BundleStatusUploaded, 1234))
+	assert.NoError(t, err)
+
+	state, err := ReadBundleState(statePath)
+	assert.NoError(t, err)
+	assert.Equal(t, BundleStatusUploaded, state.Status)
+	assert.Equal(t, int64(1234), state.BundleFileSize)
+	assert.True(t, state.Matches(bundle))
+
+	assert.True(t, IsBundleStateFilename(filepath.Base(statePath)))
+	assert.False(t, IsBundleFilename(filepath.Base(statePath)))
+}
+
+func testReadBundleStateMissing(t *testing.T) {
+	state, err := ReadBundleState(filepath.Join(t.TempDir(), "bundle_abc.tar.state.json"))
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+}
+
+func testBundleStateMatches(t *testing.T) {
+	state := NewBundleState(makeTestBundle(), BundleStatusCreated, 100)
+
+	// a file is modified
+	bundle := makeTestBundle()
+	bundle.Entries[1].ModTime = bundle.Entries[1].ModTime.Add(time.Second)
+	assert.False(t, state.Matches(bundle))
+
+	// a file is added
+	bundle = makeTestBundle()
+	bundle.Entries = append(bundle.Entries, &BundleEntry{LocalPath: "/data/dir/c.txt", IRODSPath: "/zone/home/user/dir/c.txt", Size: 30})
+	assert.False(t, state.Matches(bundle))
+
+	// different bundle format
+	bundle = makeTestBundle()
+	bundle.Format = BundleFormatTar
+	assert.False(t, state.Matches(bundle))
+}
@@ -24,6 +24,8 @@ const (
 	MaxBundleFileNumDefault  int   = 50
 	MaxBundleFileSizeDefault int64 = 2 * 1024 * 1024 * 1024 // 2GB
 	MinBundleFileNumDefault  int   = 3
+
+	DirectUploadFileSizeDefault int64 = 256 * 1024 * 1024 // 256MB
 )
 
 const (
@@ -37,7 +39,15 @@ type BundleEntry struct {
 	LocalPath string
 	IRODSPath string
 	Size      int64
+	ModTime   time.Time
 	Dir       bool
+
+	// checksum of the data object, set for downloads only
+	ChecksumAlgorithm irodsclient_types.ChecksumAlgorithm
+	Checksum          []byte
+
+	// set once the entry is in transfer report
+	reported bool
 }
 
 type Bundle struct {
@@ -46,12 +56,20 @@ type Bundle struct {
 	Index             int64
 	Entries           []*BundleEntry
 	Size              int64
+	Format            BundleFormat
 	LocalBundlePath   string
 	IRODSBundlePath   string
 	LastError         error
 	LastErrorTaskName string
 
 	Completed bool
+
+	// status and size of the bundle file, persisted to resume uploads
+	status         BundleStatus
+	bundleFileSize int64
+
+	// direct bundles are uploaded without tar regardless of the number of files
+	direct bool
 }
 
 func newBundle(manager *BundleTransferManager) (*Bundle, error) {
@@ -60,6 +78,7 @@ func newBundle(manager *BundleTransferManager) (*Bundle, error) {
 		Index:             manager.getNextBundleIndex(),
 		Entries:           []*BundleEntry{},
 		Size:              0,
+		Format:            manager.bundleFormat,
 		LocalBundlePath:   "",
 		IRODSBundlePath:   "",
 		LastError:         nil,
@@ -100,33 +119,27 @@ func (bundle *Bundle) GetBundleFilename() (string, error) {
 
 	hexhash := hex.EncodeToString(hash)
 
-	return GetBundleFilename(hexhash), nil
+	return GetBundleFilename(hexhash, bundle.Format), nil
 }
 
 func (bundle *Bundle) Add(sourceStat fs.FileInfo, sourcePath string) error {
-	irodsPath, err := bundle.manager.GetTargetPath(sourcePath)
+	e, err := bundle.manager.newBundleEntry(sourceStat, sourcePath)
 	if err != nil {
-		return xerrors.Errorf("failed to get target path for %q: %w", sourcePath, err)
-	}
-
-	e := &BundleEntry{
-		LocalPath: sourcePath,
-		IRODSPath: irodsPath,
-		Size:      sourceStat.Size(),
-		Dir:       sourceStat.IsDir(),
+		return err
 	}
 
-	bundle.Entries = append(bundle.Entries, e)
-	if !sourceStat.IsDir() {
-		bundle.Size += sourceStat.Size()
-	}
+	return bundle.addEntries([]*BundleEntry{e})
+}
 
-	err = bundle.updateBundlePath()
-	if err != nil {
-		return err
+func (bundle *Bundle) addEntries(entries []*BundleEntry) error {
+	for _, e := range entries {
+		bundle.Entries = append(bundle.Entries, e)
+		if !e.Dir {
+			bundle.Size += e.Size
+		}
 	}
 
-	return nil
+	return bundle.updateBundlePath()
 }
 
 func (bundle *Bundle) updateBundlePath() error {
@@ -156,7 +169,7 @@ func (bundle *Bundle) isFull() bool {
 }
 
 func (bundle *Bundle) RequireTar() bool {
-	return len(bundle.Entries) >= bundle.manager.minBundleFileNum
+	return !bundle.direct && len(bundle.Entries) >= bundle.manager.minBundleFileNum
 }
 
 func (bundle *Bundle) SetCompleted() {
@@ -171,6 +184,7 @@ type BundleTransferManager struct {
 	account                 *irodsclient_types.IRODSAccount
 	filesystem              *irodsclient_fs.FileSystem
 	transferReportManager   *TransferReportManager
+	uploadMetadataManager   *UploadMetadataManager
 	irodsDestPath           string
 	currentBundle           *Bundle
 	nextBundleIndex         int64
@@ -180,6 +194,11 @@ type BundleTransferManager struct {
 	minBundleFileNum        int
 	maxBundleFileNum        int
 	maxBundleFileSize       int64
+	bundleFormat            BundleFormat
+	packingStrategy         BundlePackingStrategy
+	directUploadFileSize    int64
+	collectionGroups        map[string]*bundleEntryGroup
+	collectionGroupOrder    []string
 	singleThreaded          bool
 	uploadThreadNum         int
 	redirectToResource      bool
@@ -189,9 +208,12 @@ type BundleTransferManager struct {
 	noBulkRegistration      bool
 	showProgress            bool
 	showFullPath            bool
-	progressWriter          progress.Writer
-	progressTrackers        map[string]*progress.Tracker
+	progressMode            ProgressMode
+	progressInterval        time.Duration
+	progressWriter          *TransferProgressWriter
 	progressTrackerCallback ProgressTrackerCallback
+	eventWriter             *EventWriter
+	transferStats           *TransferStats
 	lastError               error
 	mutex                   sync.RWMutex
 
@@ -210,6 +232,7 @@ func NewBundleTransferManager(account *irodsclient_types.IRODSAccount, fs *irods
 		account:                 account,
 		filesystem:              fs,
 		transferReportManager:   transferReportManager,
+		uploadMetadataManager:   nil,
 		irodsDestPath:           irodsDestPath,
 		currentBundle:           nil,
 		nextBundleIndex:         0,
@@ -219,6 +242,11 @@ func NewBundleTransferManager(account *irodsclient_types.IRODSAccount, fs *irods
 		minBundleFileNum:        minBundleFileNum,
 		maxBundleFileNum:        maxBundleFileNum,
 		maxBundleFileSize:       maxBundleFileSize,
+		bundleFormat:            BundleFormatTar,
+		packingStrategy:         BundlePackingStrategyWalk,
+		directUploadFileSize:    DirectUploadFileSizeDefault,
+		collectionGroups:        map[string]*bundleEntryGroup{},
+		collectionGroupOrder:    []string{},
 		singleThreaded:          singleThreaded,
 		uploadThreadNum:         uploadThreadNum,
 		redirectToResource:      redirectToResource,
@@ -228,9 +256,12 @@ func NewBundleTransferManager(account *irodsclient_types.IRODSAccount, fs *irods
 		noBulkRegistration:      noBulkReg,
 		showProgress:            showProgress,
 		showFullPath:            showFullPath,
+		progressMode:            ProgressModeUnknown,
+		progressInterval:        ProgressPlainIntervalDefault,
 		progressWriter:          nil,
-		progressTrackers:        map[string]*progress.Tracker{},
 		progressTrackerCallback: nil,
+		eventWriter:             nil,
+		transferStats:           nil,
 		lastError:               nil,
 		mutex:                   sync.RWMutex{},
 		scheduleWait:            sync.WaitGroup{},
@@ -261,6 +292,41 @@ func (manager *BundleTransferManager) GetFilesystem() *irodsclient_fs.FileSystem
 	return manager.filesystem
 }
 
+// SetUploadMetadataManager sets metadata to attach to data objects after upload
+func (manager *BundleTransferManager) SetUploadMetadataManager(uploadMetadataManager *UploadMetadataManager) {
+	manager.uploadMetadataManager = uploadMetadataManager
+}
+
+// SetBundleFormat sets file format of bundle files, must be called before Schedule
+func (manager *BundleTransferManager) SetBundleFormat(format BundleFormat) {
+	manager.bundleFormat = format
+}
+
+// SetBundlePacking sets how files are packed into bundles, must be called before Schedule.
+// Files of the directUploadFileSize or larger are uploaded directly with the collection strategy.
+func (manager *BundleTransferManager) SetBundlePacking(strategy BundlePackingStrategy, directUploadFileSize int64) {
+	manager.packingStrategy = strategy
+	if directUploadFileSize > 0 {
+		manager.directUploadFileSize = directUploadFileSize
+	}
+}
+
+// SetEventWriter sets a writer to emit events of bundles, must be called before Start
+func (manager *BundleTransferManager) SetEventWriter(eventWriter *EventWriter) {
+	manager.eventWriter = eventWriter
+}
+
+// SetTransferStats sets stats to collect time spent in making bundle files, must be called before Start
+func (manager *BundleTransferManager) SetTransferStats(transferStats *TransferStats) {
+	manager.transferStats = transferStats
+}
+
+// SetProgressMode sets how progress is displayed, must be called before Start
+func (manager *BundleTransferManager) SetProgressMode(mode ProgressMode, interval time.Duration) {
+	manager.progressMode = mode
+	manager.progressInterval = interval
+}
+
 func (manager *BundleTransferManager) getNextBundleIndex() int64 {
 	idx := manager.nextBundleIndex
 	manager.nextBundleIndex++
@@ -271,6 +337,101 @@ func (manager *BundleTransferManager) progress(name string, processed int64, tot
 	if manager.progressTrackerCallback != nil {
 		manager.progressTrackerCallback(name, processed, total, progressUnit, errored)
 	}
+
+	manager.eventWriter.Progress(name, processed, total, progressUnit, errored)
+}
+
+// runBundlePhase runs a task of the bundle, emitting events on entering and leaving the phase
+func (manager *BundleTransferManager) runBundlePhase(bundle *Bundle, taskName string, task func(bundle *Bundle) error) error {
+	manager.eventWriter.BundlePhase(bundle, taskName, EventTypeStarted, nil)
+
+	startTime := time.Now()
+	err := task(bundle)
+	if taskName == BundleTaskNameTar {
+		manager.transferStats.AddBundlingTime(time.Since(startTime))
+	}
+
+	if err != nil {
+		manager.eventWriter.BundlePhase(bundle, taskName, EventTypeFailed, err)
+		return err
+	}
+
+	if manager.progressWriter != nil {
+		// phases of empty bundles have nothing to progress
+		manager.progressWriter.Done(manager.getProgressName(bundle, taskName))
+	}
+
+	manager.eventWriter.BundlePhase(bundle, taskName, EventTypeCompleted, nil)
+	return nil
+}
+
+// resumeBundle restores status of the bundle from the state persisted by previous run.
+// The status is kept only if files in the bundle are not changed and the bundle file is still available.
+func (manager *BundleTransferManager) resumeBundle(bundle *Bundle) {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"struct":   "BundleTransferManager",
+		"function": "resumeBundle",
+	})
+
+	if !bundle.RequireTar() {
+		return
+	}
+
+	statePath := GetBundleStatePath(bundle.LocalBundlePath)
+	state, err := ReadBundleState(statePath)
+	if err != nil {
+		logger.WithError(err).Warnf("failed to read bundle state %q, ignoring", statePath)
+		return
+	}
+
+	if state == nil {
+		return
+	}
+
+	if !state.Matches(bundle) {
+		logger.Debugf("ignoring stale bundle state %q, files in bundle %d are changed", statePath, bundle.Index)
+		return
+	}
+
+	status := state.Status
+	if status == BundleStatusUploaded {
+		irodsBundleEntry, err := manager.filesystem.StatFile(bundle.IRODSBundlePath)
+		if err != nil || irodsBundleEntry.Size != state.BundleFileSize {
+			logger.Debugf("bundle %d is not found at %q, falling back to local bundle file", bundle.Index, bundle.IRODSBundlePath)
+			status = BundleStatusCreated
+		}
+	}
+
+	if status == BundleStatusCreated {
+		localBundleStat, err := os.Stat(bundle.LocalBundlePath)
+		if err != nil || localBundleStat.Size() != state.BundleFileSize {
+			logger.Debugf("bundle %d is not found at %q, recreating", bundle.Index, bundle.LocalBundlePath)
+			return
+		}
+	}
+
+	bundle.status = status
+	bundle.bundleFileSize = state.BundleFileSize
+
+	logger.Debugf("resuming bundle %d in %q status", bundle.Index, bundle.status)
+}
+
+// updateBundleStatus sets status of the bundle and persists it, failing to persist only makes the bundle not resumable
+func (manager *BundleTransferManager) updateBundleStatus(bundle *Bundle, status BundleStatus) {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"struct":   "BundleTransferManager",
+		"function": "updateBundleStatus",
+	})
+
+	bundle.status = status
+
+	statePath := GetBundleStatePath(bundle.LocalBundlePath)
+	err := WriteBundleState(statePath, NewBundleState(bundle, status, bundle.bundleFileSize))
+	if err != nil {
+		logger.WithError(err).Warnf("failed to persist status of bundle %d", bundle.Index)
+	}
 }
 
 func (manager *BundleTransferManager) GetTargetPath(localPath string) (string, error) {
@@ -282,6 +443,34 @@ func (manager *BundleTransferManager) GetTargetPath(localPath string) (string, e
 	return path.Join(manager.irodsDestPath, filepath.ToSlash(relPath)), nil
 }
 
+func (manager *BundleTransferManager) newBundleEntry(sourceStat fs.FileInfo, sourcePath string) (*BundleEntry, error) {
+	irodsPath, err := manager.GetTargetPath(sourcePath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get target path for %q: %w", sourcePath, err)
+	}
+
+	return &BundleEntry{
+		LocalPath: sourcePath,
+		IRODSPath: irodsPath,
+		Size:      sourceStat.Size(),
+		ModTime:   sourceStat.ModTime(),
+		Dir:       sourceStat.IsDir(),
+	}, nil
+}
+
+// scheduleBundle queues the bundle, the mutex must be held and is released while queueing as the channel may block
+func (manager *BundleTransferManager) scheduleBundle(bundle *Bundle) {
+	manager.mutex.Unlock()
+
+	manager.pendingBundles <- bundle
+	manager.eventWriter.BundlePhase(bundle, "", EventTypeScheduled, nil)
+
+	manager.mutex.Lock()
+	manager.bundles = append(manager.bundles, bundle)
+	manager.transferWait.Add(1)
+	atomic.AddInt64(&manager.bundlesScheduledCounter, 1)
+}
+
 func (manager *BundleTransferManager) Schedule(sourceStat fs.FileInfo, sourcePath string) error {
 	logger := log.WithFields(log.Fields{
 		"package":  "commons",
@@ -294,22 +483,27 @@ func (manager *BundleTransferManager) Schedule(sourceStat fs.FileInfo, sourcePat
 	// do not accept new schedule if there's an error
 	if manager.lastError != nil {
 		defer manager.mutex.Unlock()
+
+		entry, err := manager.newBundleEntry(sourceStat, sourcePath)
+		if err == nil {
+			manager.reportFailedBundleEntry(entry, manager.lastError, "not_scheduled")
+		}
+
 		return manager.lastError
 	}
 
+	if manager.packingStrategy == BundlePackingStrategyCollection {
+		defer manager.mutex.Unlock()
+
+		logger.Debugf("scheduling a local file/directory bundle-upload %q", sourcePath)
+		return manager.scheduleByCollection(sourceStat, sourcePath)
+	}
+
 	if manager.currentBundle != nil {
 		// if current bundle is full, prepare a new bundle
 		if manager.currentBundle.isFull() {
-			// temporarily release lock since adding to chan may block
-			manager.mutex.Unlock()
-
-			manager.pendingBundles <- manager.currentBundle
-			manager.bundles = append(manager.bundles, manager.currentBundle)
-
-			manager.mutex.Lock()
+			manager.scheduleBundle(manager.currentBundle)
 			manager.currentBundle = nil
-			manager.transferWait.Add(1)
-			atomic.AddInt64(&manager.bundlesScheduledCounter, 1)
 		}
 	}
 
@@ -331,13 +525,22 @@ func (manager *BundleTransferManager) Schedule(sourceStat fs.FileInfo, sourcePat
 }
 
 func (manager *BundleTransferManager) DoneScheduling() {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"struct":   "BundleTransferManager",
+		"function": "DoneScheduling",
+	})
+
 	manager.mutex.Lock()
 	if manager.currentBundle != nil {
-		manager.pendingBundles <- manager.currentBundle
-		manager.bundles = append(manager.bundles, manager.currentBundle)
+		manager.scheduleBundle(manager.currentBundle)
 		manager.currentBundle = nil
-		manager.transferWait.Add(1)
-		atomic.AddInt64(&manager.bundlesScheduledCounter, 1)
+	}
+
+	err := manager.flushCollectionGroups()
+	if err != nil {
+		manager.lastError = err
+		logger.Error(err)
 	}
 	manager.mutex.Unlock()
 
@@ -361,20 +564,65 @@ func (manager *BundleTransferManager) Wait() error {
 	logger.Debug("waiting transfer-wait")
 	manager.transferWait.Wait()
 
+	manager.mutex.RLock()
+	err := manager.lastError
+	if err == nil && manager.bundlesDoneCounter != manager.bundlesScheduledCounter {
+		err = xerrors.Errorf("%d bundles were done out of %d! Some bundles failed!", manager.bundlesDoneCounter, manager.bundlesScheduledCounter)
+	}
+	manager.mutex.RUnlock()
+
+	if err != nil {
+		manager.reportFailedBundles(err)
+
+		// keep bundle files and states, so the next run resumes
+		logger.Debugf("keeping bundle files in %q to resume", manager.irodsTempDirPath)
+		return err
+	}
+
 	manager.CleanUpBundles()
+	return nil
+}
 
-	manager.mutex.RLock()
-	defer manager.mutex.RUnlock()
+// reportFailedBundles adds failed transfers of files in bundles not completed to the report, so they can be retried
+func (manager *BundleTransferManager) reportFailedBundles(err error) {
+	for _, bundle := range manager.bundles {
+		if bundle.Completed {
+			continue
+		}
 
-	if manager.lastError != nil {
-		return manager.lastError
+		bundleErr := err
+		notes := []string{"bundle_not_processed"}
+		if bundle.LastError != nil {
+			bundleErr = bundle.LastError
+			notes = []string{"bundle_failed", bundle.LastErrorTaskName}
+		}
+
+		for _, entry := range bundle.Entries {
+			manager.reportFailedBundleEntry(entry, bundleErr, notes...)
+		}
+	}
+}
+
+// reportFailedBundleEntry adds a failed transfer of the entry to the report, entries already reported are ignored
+func (manager *BundleTransferManager) reportFailedBundleEntry(entry *BundleEntry, err error, notes ...string) {
+	if entry.reported || entry.Dir {
+		return
 	}
 
-	if manager.bundlesDoneCounter != manager.bundlesScheduledCounter {
-		return xerrors.Errorf("%d bundles were done out of %d! Some bundles failed!", manager.bundlesDoneCounter, manager.bundlesScheduledCounter)
+	now := time.Now()
+	reportFile := &TransferReportFile{
+		Method:     TransferMethodPut,
+		StartAt:    now,
+		EndAt:      now,
+		SourcePath: entry.LocalPath,
+		SourceSize: entry.Size,
+		DestPath:   entry.IRODSPath,
+		Error:      err,
+		Notes:      notes,
 	}
 
-	return nil
+	manager.transferReportManager.AddFile(reportFile)
+	entry.reported = true
 }
 
 func (manager *BundleTransferManager) CleanUpBundles() {
@@ -384,9 +632,19 @@ func (manager *BundleTransferManager) CleanUpBundles() {
 		"function": "CleanUpBundles",
 	})
 
+	for _, bundle := range manager.bundles {
+		if bundle.RequireTar() {
+			statePath := GetBundleStatePath(bundle.LocalBundlePath)
+			removeErr := os.Remove(statePath)
+			if removeErr != nil && !os.IsNotExist(removeErr) {
+				logger.WithError(removeErr).Warnf("failed to remove bundle state %q", statePath)
+			}
+		}
+	}
+
 	logger.Debugf("clearing bundle files in %q", manager.irodsTempDirPath)
 
-	err := CleanUpOldIRODSBundles(manager.filesystem, manager.irodsTempDirPath, true, true)
+	err := CleanUpOldIRODSBundles(manager.filesystem, manager.irodsTempDirPath, true, 0, true)
 	if err != nil {
 		logger.WithError(err).Warnf("failed to clear staging directory %q", manager.irodsTempDirPath)
 	} else {
@@ -396,66 +654,21 @@ func (manager *BundleTransferManager) CleanUpBundles() {
 
 func (manager *BundleTransferManager) startProgress() {
 	if manager.showProgress {
-		manager.progressWriter = GetProgressWriter(false)
-		messageWidth := getProgressMessageWidth(false)
-
-		go manager.progressWriter.Render()
+		manager.progressWriter = NewTransferProgressWriter(manager.progressMode, manager.progressInterval, manager.showFullPath, false, "tasks")
+		manager.progressWriter.Start()
 
 		// add progress tracker callback
-		manager.progressTrackerCallback = func(name string, processed int64, total int64, progressUnit progress.Units, errored bool) {
-			manager.mutex.Lock()
-			defer manager.mutex.Unlock()
-
-			var tracker *progress.Tracker
-			if t, ok := manager.progressTrackers[name]; !ok {
-				// created a new tracker if not exists
-				msg := name
-				if !manager.showFullPath {
-					msg = GetShortPathMessage(name, messageWidth)
-				}
-
-				tracker = &progress.Tracker{
-					Message: msg,
-					Total:   total,
-					Units:   progressUnit,
-				}
-
-				manager.progressWriter.AppendTracker(tracker)
-				manager.progressTrackers[name] = tracker
-			} else {
-				tracker = t
-			}
-
-			if processed >= 0 {
-				tracker.SetValue(processed)
-			}
-
-			if errored {
-				tracker.MarkAsErrored()
-			} else if processed >= total {
-				tracker.MarkAsDone()
-			}
-		}
+		manager.progressTrackerCallback = manager.progressWriter.Progress
 	}
 }
 
 func (manager *BundleTransferManager) endProgress() {
 	if manager.progressWriter != nil {
-		manager.mutex.Lock()
+		manager.mutex.RLock()
+		hasError := manager.lastError != nil
+		manager.mutex.RUnlock()
 
-		for _, tracker := range manager.progressTrackers {
-			if manager.lastError != nil {
-				tracker.MarkAsDone()
-			} else {
-				if !tracker.IsDone() {
-					tracker.MarkAsErrored()
-				}
-			}
-		}
-
-		manager.mutex.Unlock()
-
-		manager.progressWriter.Stop()
+		manager.progressWriter.Stop(hasError)
 	}
 }
 
@@ -511,6 +724,9 @@ func (manager *BundleTransferManager) Start() {
 		}
 
 		for bundle := range manager.pendingBundles {
+			// restore status of the bundle from previous run
+			manager.resumeBundle(bundle)
+
 			// send to tar and remove
 			processBundleTarChan <- bundle
 			processBundleRemoveFilesAndMakeDirsChan <- bundle
@@ -535,7 +751,7 @@ func (manager *BundleTransferManager) Start() {
 			manager.mutex.RUnlock()
 
 			if cont && len(bundle.Entries) > 0 {
-				err := manager.processBundleTar(bundle)
+				err := manager.runBundlePhase(bundle, BundleTaskNameTar, manager.processBundleTar)
 				if err != nil {
 					// mark error
 					manager.mutex.Lock()
@@ -573,7 +789,7 @@ func (manager *BundleTransferManager) Start() {
 				manager.mutex.RUnlock()
 
 				if cont && len(bundle.Entries) > 0 {
-					err := manager.processBundleUpload(bundle)
+					err := manager.runBundlePhase(bundle, BundleTaskNameUpload, manager.processBundleUpload)
 					if err != nil {
 						// mark error
 						manager.mutex.Lock()
@@ -623,7 +839,7 @@ func (manager *BundleTransferManager) Start() {
 			manager.mutex.RUnlock()
 
 			if cont && len(bundle.Entries) > 0 {
-				err := manager.processBundleRemoveFilesAndMakeDirs(bundle)
+				err := manager.runBundlePhase(bundle, BundleTaskNameRemoveFilesAndMakeDirs, manager.processBundleRemoveFilesAndMakeDirs)
 				if err != nil {
 					// mark error
 					manager.mutex.Lock()
@@ -672,7 +888,7 @@ func (manager *BundleTransferManager) Start() {
 						manager.mutex.RUnlock()
 
 						if cont && len(bundle1.Entries) > 0 {
-							err := manager.processBundleExtract(bundle1)
+							err := manager.runBundlePhase(bundle1, BundleTaskNameExtract, manager.processBundleExtract)
 							if err != nil {
 								// mark error
 								manager.mutex.Lock()
@@ -687,8 +903,8 @@ func (manager *BundleTransferManager) Start() {
 							}
 
 						} else {
-							if bundle1.RequireTar() {
-								// remove irods bundle file
+							if bundle1.RequireTar() && bundle1.status != BundleStatusUploaded {
+								// remove irods bundle file, uploaded ones are kept to resume
 								manager.filesystem.RemoveFile(bundle1.IRODSBundlePath, true)
 							}
 						}
@@ -721,7 +937,7 @@ func (manager *BundleTransferManager) Start() {
 						manager.mutex.RUnlock()
 
 						if cont && len(bundle2.Entries) > 0 {
-							err := manager.processBundleExtract(bundle2)
+							err := manager.runBundlePhase(bundle2, BundleTaskNameExtract, manager.processBundleExtract)
 							if err != nil {
 								// mark error
 								manager.mutex.Lock()
@@ -735,8 +951,8 @@ func (manager *BundleTransferManager) Start() {
 								// don't stop here
 							}
 						} else {
-							if bundle2.RequireTar() {
-								// remove irods bundle file
+							if bundle2.RequireTar() && bundle2.status != BundleStatusUploaded {
+								// remove irods bundle file, uploaded ones are kept to resume
 								manager.filesystem.RemoveFile(bundle2.IRODSBundlePath, true)
 							}
 						}
@@ -789,6 +1005,13 @@ func (manager *BundleTransferManager) processBundleRemoveFilesAndMakeDirs(bundle
 
 	manager.progress(progressName, 0, totalFileNum, progress.UnitsDefault, false)
 
+	if bundle.status == BundleStatusExtracted {
+		// extracted in previous run, so pass this step
+		manager.progress(progressName, totalFileNum, totalFileNum, progress.UnitsDefault, false)
+		logger.Debugf("skip deleting exising data objects in the bundle %d, already extracted", bundle.Index)
+		return nil
+	}
+
 	for _, bundleEntry := range bundle.Entries {
 		entry, err := manager.filesystem.Stat(bundleEntry.IRODSPath)
 		if err != nil {
@@ -854,17 +1077,33 @@ func (manager *BundleTransferManager) processBundleTar(bundle *Bundle) error {
 		return nil
 	}
 
+	if bundle.status != BundleStatusNone {
+		// resumed, bundle file is already created
+		manager.progress(progressName, totalFileNum, totalFileNum, progress.UnitsDefault, false)
+		logger.Debugf("skip creating a tarball for bundle %d to %q, resuming in %q status", bundle.Index, bundle.LocalBundlePath, bundle.status)
+		return nil
+	}
+
 	entries := make([]string, len(bundle.Entries))
 	for idx, entry := range bundle.Entries {
 		entries[idx] = entry.LocalPath
 	}
 
-	err := Tar(manager.localBundleRootPath, entries, bundle.LocalBundlePath, callbackTar)
+	err := MakeBundleFile(manager.localBundleRootPath, entries, bundle.LocalBundlePath, bundle.Format, callbackTar)
 	if err != nil {
 		manager.progress(progressName, 0, totalFileNum, progress.UnitsDefault, true)
 		return xerrors.Errorf("failed to create a tarball for bundle %d to %q (bundle root %q): %w", bundle.Index, bundle.LocalBundlePath, bundle.manager.localBundleRootPath, err)
 	}
 
+	localBundleStat, err := os.Stat(bundle.LocalBundlePath)
+	if err != nil {
+		manager.progress(progressName, 0, totalFileNum, progress.UnitsDefault, true)
+		return xerrors.Errorf("failed to stat %q: %w", bundle.LocalBundlePath, err)
+	}
+
+	bundle.bundleFileSize = localBundleStat.Size()
+	manager.updateBundleStatus(bundle, BundleStatusCreated)
+
 	manager.progress(progressName, totalFileNum, totalFileNum, progress.UnitsDefault, false)
 
 	logger.Debugf("created a tarball for bundle %d to %q", bundle.Index, bundle.LocalBundlePath)
@@ -900,6 +1139,14 @@ func (manager *BundleTransferManager) processBundleUploadWithTar(bundle *Bundle)
 		manager.progress(progressName, processed, total, progress.UnitsBytes, false)
 	}
 
+	if bundle.status == BundleStatusUploaded || bundle.status == BundleStatusExtracted {
+		// resumed, bundle file is already uploaded
+		manager.progress(progressName, bundle.Size, bundle.Size, progress.UnitsBytes, false)
+		os.Remove(bundle.LocalBundlePath)
+		logger.Debugf("skip uploading bundle %d to %q, resuming in %q status", bundle.Index, bundle.IRODSBundlePath, bundle.status)
+		return nil
+	}
+
 	// check local bundle file
 	localBundleStat, err := os.Stat(bundle.LocalBundlePath)
 	if err != nil {
@@ -922,6 +1169,7 @@ func (manager *BundleTransferManager) processBundleUploadWithTar(bundle *Bundle)
 		if bundleEntry.Size == localBundleStat.Size() {
 			// same file exist
 			manager.progress(progressName, bundle.Size, bundle.Size, progress.UnitsBytes, false)
+			manager.updateBundleStatus(bundle, BundleStatusUploaded)
 			// remove local bundle file
 			os.Remove(bundle.LocalBundlePath)
 			logger.Debugf("skip uploading bundle %d to %q, file already exists", bundle.Index, bundle.IRODSBundlePath)
@@ -953,6 +1201,8 @@ func (manager *BundleTransferManager) processBundleUploadWithTar(bundle *Bundle)
 		return xerrors.Errorf("failed to upload bundle %d to %q: %w", bundle.Index, bundle.IRODSBundlePath, err)
 	}
 
+	manager.updateBundleStatus(bundle, BundleStatusUploaded)
+
 	// remove local bundle file
 	os.Remove(bundle.LocalBundlePath)
 
@@ -1005,6 +1255,7 @@ func (manager *BundleTransferManager) processBundleUploadWithoutTar(bundle *Bund
 			}
 
 			manager.transferReportManager.AddFile(reportFile)
+			file.reported = true
 
 			manager.progress(progressName, 0, bundle.Size, progress.UnitsBytes, false)
 			logger.Debugf("uploaded a directory %q in bundle %d to %q", file.LocalPath, bundle.Index, file.IRODSPath)
@@ -1053,7 +1304,16 @@ func (manager *BundleTransferManager) processBundleUploadWithoutTar(bundle *Bund
 			return xerrors.Errorf("failed to upload file %q in bundle %d to %q: %w", file.LocalPath, bundle.Index, file.IRODSPath, err)
 		}
 
+		// attach metadata
+		metadataNotes, err := manager.uploadMetadataManager.Apply(manager.filesystem, file.LocalPath, file.IRODSPath)
+		notes = append(notes, metadataNotes...)
+		if err != nil {
+			manager.progress(progressName, 0, bundle.Size, progress.UnitsBytes, true)
+			return xerrors.Errorf("failed to add metadata to file %q in bundle %d: %w", file.IRODSPath, bundle.Index, err)
+		}
+
 		err = manager.transferReportManager.AddTransfer(uploadResult, TransferMethodPut, err, notes)
+		file.reported = true
 		if err != nil {
 			manager.progress(progressName, 0, bundle.Size, progress.UnitsBytes, true)
 			return xerrors.Errorf("failed to add transfer report: %w", err)
@@ -1082,8 +1342,30 @@ func (manager *BundleTransferManager) processBundleExtract(bundle *Bundle) error
 
 	manager.progress(progressName, 0, totalFileNum, progress.UnitsDefault, false)
 
-	if bundle.RequireTar() {
-		err := manager.filesystem.ExtractStructFile(bundle.IRODSBundlePath, manager.irodsDestPath, "", irodsclient_types.TAR_FILE_DT, true, !manager.noBulkRegistration)
+	if bundle.status == BundleStatusExtracted {
+		// extracted in previous run, so files are skipped
+		now := time.Now()
+
+		for _, file := range bundle.Entries {
+			reportFile := &TransferReportFile{
+				Method:     TransferMethodPut,
+				StartAt:    now,
+				EndAt:      now,
+				SourcePath: file.LocalPath,
+				SourceSize: file.Size,
+
+				DestPath: file.IRODSPath,
+				DestSize: file.Size,
+				Notes:    []string{"bundle_extracted", "resumed", "skip"},
+			}
+
+			manager.transferReportManager.AddFile(reportFile)
+			file.reported = true
+		}
+
+		logger.Debugf("skip extracting bundle %d at %q, already extracted", bundle.Index, bundle.IRODSBundlePath)
+	} else if bundle.RequireTar() {
+		err := manager.filesystem.ExtractStructFile(bundle.IRODSBundlePath, manager.irodsDestPath, "", bundle.Format.GetDataType(), true, !manager.noBulkRegistration)
 		if err != nil {
 			manager.progress(progressName, 0, totalFileNum, progress.UnitsDefault, true)
 			return xerrors.Errorf("failed to extract bundle %d at %q to %q: %w", bundle.Index, bundle.IRODSBundlePath, manager.irodsDestPath, err)
@@ -1092,8 +1374,42 @@ func (manager *BundleTransferManager) processBundleExtract(bundle *Bundle) error
 		// remove irods bundle file
 		logger.Debugf("removing bundle %d at %q", bundle.Index, bundle.IRODSBundlePath)
 		manager.filesystem.RemoveFile(bundle.IRODSBundlePath, true)
+
+		now := time.Now()
+
+		for _, file := range bundle.Entries {
+			notes := []string{"bundle_extracted"}
+
+			if !file.Dir {
+				// attach metadata
+				metadataNotes, err := manager.uploadMetadataManager.Apply(manager.filesystem, file.LocalPath, file.IRODSPath)
+				notes = append(notes, metadataNotes...)
+				if err != nil {
+					manager.progress(progressName, 0, totalFileNum, progress.UnitsDefault, true)
+					return xerrors.Errorf("failed to add metadata to file %q in bundle %d: %w", file.IRODSPath, bundle.Index, err)
+				}
+			}
+
+			reportFile := &TransferReportFile{
+				Method:     TransferMethodPut,
+				StartAt:    now,
+				EndAt:      now,
+				SourcePath: file.LocalPath,
+				SourceSize: file.Size,
+
+				DestPath: file.IRODSPath,
+				DestSize: file.Size,
+				Notes:    notes,
+			}
+
+			manager.transferReportManager.AddFile(reportFile)
+			file.reported = true
+		}
+
+		manager.updateBundleStatus(bundle, BundleStatusExtracted)
 	} else {
 		// no tar, so pass this step
+		// files are already reported and have metadata attached in upload step
 		manager.progress(progressName, totalFileNum, totalFileNum, progress.UnitsDefault, false)
 		logger.Debugf("skip extracting bundle %d at %q", bundle.Index, bundle.IRODSBundlePath)
 	}
@@ -1104,24 +1420,6 @@ func (manager *BundleTransferManager) processBundleExtract(bundle *Bundle) error
 	bundle.SetCompleted()
 	atomic.AddInt64(&manager.bundlesDoneCounter, 1)
 
-	now := time.Now()
-
-	for _, file := range bundle.Entries {
-		reportFile := &TransferReportFile{
-			Method:     TransferMethodPut,
-			StartAt:    now,
-			EndAt:      now,
-			SourcePath: file.LocalPath,
-			SourceSize: file.Size,
-
-			DestPath: file.IRODSPath,
-			DestSize: file.Size,
-			Notes:    []string{"bundle_extracted"},
-		}
-
-		manager.transferReportManager.AddFile(reportFile)
-	}
-
 	logger.Debugf("extracted bundle %d at %q to %q", bundle.Index, bundle.IRODSBundlePath, manager.irodsDestPath)
 	return nil
 }
@@ -1130,7 +1428,8 @@ func (manager *BundleTransferManager) getProgressName(bundle *Bundle, taskName s
 	return fmt.Sprintf("bundle %d - %q", bundle.Index, taskName)
 }
 
-func CleanUpOldLocalBundles(localTempDirPath string, force bool) {
+// CleanUpOldLocalBundles deletes local bundle files and their states older than the given age, zero age deletes all
+func CleanUpOldLocalBundles(localTempDirPath string, olderThan time.Duration, force bool) {
 	logger := log.WithFields(log.Fields{
 		"package":  "commons",
 		"struct":   "BundleTransferManager",
@@ -1139,23 +1438,15 @@ func CleanUpOldLocalBundles(localTempDirPath string, force bool) {
 
 	logger.Debugf("clearing local bundle files in %q", localTempDirPath)
 
-	entries, err := os.ReadDir(localTempDirPath)
+	bundles, err := ListOldLocalBundles(localTempDirPath, olderThan)
 	if err != nil {
-		logger.WithError(err).Warnf("failed to read a local temp directory %q", localTempDirPath)
+		logger.WithError(err).Warnf("failed to list old local bundles in %q", localTempDirPath)
 		return
 	}
 
-	bundleEntries := []string{}
-	for _, entry := range entries {
-		// filter only bundle files
-		if IsBundleFilename(entry.Name()) {
-			fullPath := filepath.Join(localTempDirPath, entry.Name())
-			bundleEntries = append(bundleEntries, fullPath)
-		}
-	}
-
 	deletedCount := 0
-	for _, entry := range bundleEntries {
+	for _, bundle := range bundles {
+		entry := bundle.Path
 		if force {
 			logger.Debugf("deleting old local bundle %q", entry)
 			removeErr := os.Remove(entry)
@@ -1182,7 +1473,9 @@ func CleanUpOldLocalBundles(localTempDirPath string, force bool) {
 	logger.Debugf("deleted %d old local bundles in %q", deletedCount, localTempDirPath)
 }
 
-func CleanUpOldIRODSBundles(fs *irodsclient_fs.FileSystem, stagingPath string, removeDir bool, force bool) error {
+// CleanUpOldIRODSBundles deletes bundle files older than the given age, zero age deletes all.
+// The staging dir is removed only if no bundle files are left.
+func CleanUpOldIRODSBundles(fs *irodsclient_fs.FileSystem, stagingPath string, removeDir bool, olderThan time.Duration, force bool) error {
 	logger := log.WithFields(log.Fields{
 		"package":  "commons",
 		"struct":   "BundleTransferManager",
@@ -1191,29 +1484,20 @@ func CleanUpOldIRODSBundles(fs *irodsclient_fs.FileSystem, stagingPath string, r
 
 	logger.Debugf("cleaning up old irods bundle files in %q", stagingPath)
 
-	if !fs.ExistsDir(stagingPath) {
-		return xerrors.Errorf("staging dir %q does not exist", stagingPath)
-	}
-
-	entries, err := fs.List(stagingPath)
+	bundles, err := ListOldIRODSBundles(fs, stagingPath, olderThan)
 	if err != nil {
-		return xerrors.Errorf("failed to list %q: %w", stagingPath, err)
+		return xerrors.Errorf("failed to list old irods bundles in %q: %w", stagingPath, err)
 	}
 
 	deletedCount := 0
-	for _, entry := range entries {
-		// filter only bundle files
-		if entry.Type == irodsclient_fs.FileEntry {
-			if IsBundleFilename(entry.Name) {
-				logger.Debugf("deleting old irods bundle %q", entry.Path)
-				removeErr := fs.RemoveFile(entry.Path, force)
-				if removeErr != nil {
-					return xerrors.Errorf("failed to remove bundle file %q: %w", entry.Path, removeErr)
-				} else {
-					deletedCount++
-				}
-			}
+	for _, bundle := range bundles {
+		logger.Debugf("deleting old irods bundle %q", bundle.Path)
+		removeErr := fs.RemoveFile(bundle.Path, force)
+		if removeErr != nil {
+			return xerrors.Errorf("failed to remove bundle file %q: %w", bundle.Path, removeErr)
 		}
+
+		deletedCount++
 	}
 
 	Printf("deleted %d old irods bundles in %q\n", deletedCount, stagingPath)
@@ -1221,6 +1505,19 @@ func CleanUpOldIRODSBundles(fs *irodsclient_fs.FileSystem, stagingPath string, r
 
 	if removeDir {
 		if IsStagingDirInTargetPath(stagingPath) {
+			if olderThan > 0 {
+				// keep the staging dir for newer bundles
+				remaining, listErr := ListOldIRODSBundles(fs, stagingPath, 0)
+				if listErr != nil {
+					return xerrors.Errorf("failed to list irods bundles in %q: %w", stagingPath, listErr)
+				}
+
+				if len(remaining) > 0 {
+					logger.Debugf("keeping staging directory %q having %d newer bundles", stagingPath, len(remaining))
+					return nil
+				}
+			}
+
 			rmdirErr := fs.RemoveDir(stagingPath, true, force)
 			if rmdirErr != nil {
 				return xerrors.Errorf("failed to remove staging directory %q: %w", stagingPath, rmdirErr)
@@ -1,9 +1,13 @@
 package commons
 
 import (
+	"bytes"
 	"crypto/rsa"
+	"io"
 	"strings"
 
+	"filippo.io/age"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
 	"golang.org/x/xerrors"
 )
 
@@ -17,6 +21,8 @@ const (
 	EncryptionModePGP EncryptionMode = "PGP"
 	// EncryptionModeSSH is for SSH key encryption
 	EncryptionModeSSH EncryptionMode = "SSH"
+	// EncryptionModeAge is for authenticated encryption to multiple recipients in age format
+	EncryptionModeAge EncryptionMode = "AGE"
 	// EncryptionModeUnknown is for unknown mode
 	EncryptionModeUnknown EncryptionMode = ""
 )
@@ -30,6 +36,8 @@ func GetEncryptionMode(mode string) EncryptionMode {
 		return EncryptionModePGP
 	case string(EncryptionModeSSH):
 		return EncryptionModeSSH
+	case string(EncryptionModeAge), "X25519":
+		return EncryptionModeAge
 	default:
 		return EncryptionModeUnknown
 	}
@@ -46,6 +54,9 @@ func DetectEncryptionMode(p string) EncryptionMode {
 	} else if strings.HasSuffix(p, SshEncryptedFileExtension) {
 		// ssh
 		return EncryptionModeSSH
+	} else if strings.HasSuffix(p, AgeEncryptedFileExtension) {
+		// age
+		return EncryptionModeAge
 	} else {
 		return EncryptionModeUnknown
 	}
@@ -65,6 +76,10 @@ type EncryptionManager struct {
 	mode                 EncryptionMode
 	key                  []byte
 	publicprivateKeyPath string
+	recipients           []string
+	identityPaths        []string
+	defaultIdentityPaths []string
+	filenameKey          []byte
 }
 
 // NewEncryptionManager creates a new EncryptionManager
@@ -86,6 +101,51 @@ func (manager *EncryptionManager) SetPublicPrivateKey(keyPath string) {
 	manager.publicprivateKeyPath = keyPath
 }
 
+// SetRecipients sets recipients for 'age' mode, age public keys, SSH public keys, or files having them
+func (manager *EncryptionManager) SetRecipients(recipients []string) {
+	manager.recipients = recipients
+}
+
+// SetIdentities sets identity files for 'age' mode, age secret keys or SSH private keys
+func (manager *EncryptionManager) SetIdentities(identityPaths []string) {
+	manager.identityPaths = identityPaths
+}
+
+// SetDefaultIdentities sets default identity files for 'age' mode, files not readable or encrypted are skipped
+func (manager *EncryptionManager) SetDefaultIdentities(identityPaths []string) {
+	manager.defaultIdentityPaths = identityPaths
+}
+
+// LoadFilenameKey loads the key to encrypt filenames in the given collection, only for 'age' mode.
+// If create is true and the collection and its parents have no key, a new key is stored in the collection,
+// otherwise the key found is encrypted again to include the recipients.
+func (manager *EncryptionManager) LoadFilenameKey(fs *irodsclient_fs.FileSystem, collPath string, create bool) error {
+	if manager.mode != EncryptionModeAge {
+		return nil
+	}
+
+	identities, err := manager.getAgeIdentities()
+	if err != nil {
+		return err
+	}
+
+	filenameKey, err := GetAgeFilenameKey(fs, collPath, manager.recipients, string(manager.key), identities, create)
+	if err != nil {
+		return err
+	}
+
+	manager.filenameKey = filenameKey
+	return nil
+}
+
+func (manager *EncryptionManager) getAgeRecipients() ([]age.Recipient, error) {
+	return ParseAgeRecipients(manager.recipients, string(manager.key))
+}
+
+func (manager *EncryptionManager) getAgeIdentities() ([]age.Identity, error) {
+	return ParseAgeIdentities(manager.identityPaths, manager.defaultIdentityPaths, string(manager.key))
+}
+
 func (manager *EncryptionManager) getPublicKey() (*rsa.PublicKey, error) {
 	if len(manager.publicprivateKeyPath) > 0 {
 		pub, err := DecodePublicKey(manager.publicprivateKeyPath)
@@ -127,6 +187,12 @@ func (manager *EncryptionManager) EncryptFilename(filename string) (string, erro
 		}
 
 		return EncryptFilenameSSH(filename, publicKey)
+	case EncryptionModeAge:
+		if manager.filenameKey == nil {
+			return "", xerrors.Errorf("failed to encrypt filename, filename key is not loaded")
+		}
+
+		return EncryptFilenameAge(filename, manager.filenameKey)
 	default:
 		return "", xerrors.Errorf("unknown encryption mode")
 	}
@@ -147,6 +213,12 @@ func (manager *EncryptionManager) DecryptFilename(filename string) (string, erro
 		}
 
 		return DecryptFilenameSSH(filename, privateKey)
+	case EncryptionModeAge:
+		if manager.filenameKey == nil {
+			return "", xerrors.Errorf("failed to decrypt filename, filename key is not loaded")
+		}
+
+		return DecryptFilenameAge(filename, manager.filenameKey)
 	default:
 		return "", xerrors.Errorf("unknown encryption mode")
 	}
@@ -167,6 +239,13 @@ func (manager *EncryptionManager) EncryptFile(source string, target string) erro
 		}
 
 		return EncryptFileSSH(source, target, publicKey)
+	case EncryptionModeAge:
+		recipients, err := manager.getAgeRecipients()
+		if err != nil {
+			return err
+		}
+
+		return EncryptFileAge(source, target, recipients)
 	default:
 		return xerrors.Errorf("unknown encryption mode")
 	}
@@ -187,7 +266,114 @@ func (manager *EncryptionManager) DecryptFile(source string, target string) erro
 		}
 
 		return DecryptFileSSH(source, target, privateKey)
+	case EncryptionModeAge:
+		identities, err := manager.getAgeIdentities()
+		if err != nil {
+			return err
+		}
+
+		return DecryptFileAge(source, target, identities)
 	default:
 		return xerrors.Errorf("unknown encryption mode")
 	}
 }
+
+// SupportRangeAccess returns true if any range of file content can be encrypted or decrypted independently, allowing parallel transfers
+func (manager *EncryptionManager) SupportRangeAccess() bool {
+	switch manager.mode {
+	case EncryptionModeWinSCP, EncryptionModeSSH:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewCTRCipher creates a cipher to encrypt file content, only for modes supporting range access
+func (manager *EncryptionManager) NewCTRCipher() (*CTRCipher, error) {
+	switch manager.mode {
+	case EncryptionModeWinSCP:
+		return NewWinSCPCipher(manager.key)
+	case EncryptionModeSSH:
+		// load publickey
+		publicKey, err := manager.getPublicKey()
+		if err != nil {
+			return nil, err
+		}
+
+		return NewSSHCipher(publicKey)
+	default:
+		return nil, xerrors.Errorf("encryption mode %q does not support range access", manager.mode)
+	}
+}
+
+// ReadCTRCipher reads the header of encrypted file content to create a cipher, only for modes supporting range access.
+// Returns nil for empty content.
+func (manager *EncryptionManager) ReadCTRCipher(reader io.Reader) (*CTRCipher, error) {
+	switch manager.mode {
+	case EncryptionModeWinSCP:
+		return ReadWinSCPCipher(reader, manager.key)
+	case EncryptionModeSSH:
+		// load privatekey
+		privateKey, err := manager.getPrivateKey()
+		if err != nil {
+			return nil, err
+		}
+
+		return ReadSSHCipher(reader, privateKey)
+	default:
+		return nil, xerrors.Errorf("encryption mode %q does not support range access", manager.mode)
+	}
+}
+
+// NewEncryptWriter returns a writer encrypting content to the writer, Close must be called to finish. The writer is not closed
+func (manager *EncryptionManager) NewEncryptWriter(writer io.Writer) (io.WriteCloser, error) {
+	switch manager.mode {
+	case EncryptionModeWinSCP, EncryptionModeSSH:
+		ctrCipher, err := manager.NewCTRCipher()
+		if err != nil {
+			return nil, err
+		}
+
+		return ctrCipher.NewEncryptWriter(writer), nil
+	case EncryptionModePGP:
+		return NewEncryptWriterPGP(writer, manager.key)
+	case EncryptionModeAge:
+		recipients, err := manager.getAgeRecipients()
+		if err != nil {
+			return nil, err
+		}
+
+		return NewEncryptWriterAge(writer, recipients)
+	default:
+		return nil, xerrors.Errorf("unknown encryption mode")
+	}
+}
+
+// NewDecryptReader returns a reader decrypting content from the reader
+func (manager *EncryptionManager) NewDecryptReader(reader io.Reader) (io.Reader, error) {
+	switch manager.mode {
+	case EncryptionModeWinSCP, EncryptionModeSSH:
+		ctrCipher, err := manager.ReadCTRCipher(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		if ctrCipher == nil {
+			// empty file
+			return bytes.NewReader(nil), nil
+		}
+
+		return ctrCipher.NewDecryptReader(reader)
+	case EncryptionModePGP:
+		return NewDecryptReaderPGP(reader, manager.key)
+	case EncryptionModeAge:
+		identities, err := manager.getAgeIdentities()
+		if err != nil {
+			return nil, err
+		}
+
+		return NewDecryptReaderAge(reader, identities)
+	default:
+		return nil, xerrors.Errorf("unknown encryption mode")
+	}
+}
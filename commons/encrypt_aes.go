@@ -5,6 +5,7 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"io"
+	"os"
 
 	"golang.org/x/xerrors"
 )
@@ -112,3 +113,286 @@ func DecryptAESCTRReaderWriter(reader io.Reader, writer io.Writer, salt []byte,
 		}
 	}
 }
+
+// CTRCipher encrypts or decrypts any range of file content independently, used by encryption modes based on AES-CTR
+type CTRCipher struct {
+	header []byte
+	salt   []byte
+	key    []byte
+}
+
+// NewCTRCipher creates a new CTRCipher, header is written in front of encrypted content
+func NewCTRCipher(header []byte, salt []byte, key []byte) (*CTRCipher, error) {
+	if len(salt) != aes.BlockSize {
+		return nil, xerrors.Errorf("salt must be %d bytes, but got %d bytes", aes.BlockSize, len(salt))
+	}
+
+	return &CTRCipher{
+		header: header,
+		salt:   salt,
+		key:    key,
+	}, nil
+}
+
+// GetHeaderSize returns size of the header in front of encrypted content
+func (ctrCipher *CTRCipher) GetHeaderSize() int64 {
+	return int64(len(ctrCipher.header))
+}
+
+// GetEncryptedSize returns size of encrypted file for the content size, empty content is not encrypted
+func (ctrCipher *CTRCipher) GetEncryptedSize(size int64) int64 {
+	if size == 0 {
+		return 0
+	}
+
+	return ctrCipher.GetHeaderSize() + size
+}
+
+// newStreamAt returns a key stream starting at the offset of content
+func (ctrCipher *CTRCipher) newStreamAt(offset int64) (cipher.Stream, error) {
+	paddedKey := PadPkcs7(ctrCipher.key, 32)
+	block, err := aes.NewCipher(paddedKey)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create AES cipher: %w", err)
+	}
+
+	// counter is the salt added with the block index, as a 128-bit big-endian integer
+	counter := make([]byte, aes.BlockSize)
+	copy(counter, ctrCipher.salt)
+
+	carry := uint64(offset / int64(aes.BlockSize))
+	for idx := aes.BlockSize - 1; idx >= 0 && carry > 0; idx-- {
+		sum := uint64(counter[idx]) + (carry & 0xff)
+		counter[idx] = byte(sum)
+		carry = (carry >> 8) + (sum >> 8)
+	}
+
+	stream := cipher.NewCTR(block, counter)
+
+	// skip to the offset in the block
+	skip := int(offset % int64(aes.BlockSize))
+	if skip > 0 {
+		discard := make([]byte, skip)
+		stream.XORKeyStream(discard, discard)
+	}
+
+	return stream, nil
+}
+
+// XORKeyStreamAt encrypts or decrypts src at the offset of content into dst
+func (ctrCipher *CTRCipher) XORKeyStreamAt(dst []byte, src []byte, offset int64) error {
+	stream, err := ctrCipher.newStreamAt(offset)
+	if err != nil {
+		return err
+	}
+
+	stream.XORKeyStream(dst, src)
+	return nil
+}
+
+// NewEncryptWriter returns a writer encrypting content to the writer.
+// The header is written with the first content, so empty content stays empty. The writer is not closed.
+func (ctrCipher *CTRCipher) NewEncryptWriter(writer io.Writer) io.WriteCloser {
+	return &ctrEncryptWriter{
+		ctrCipher: ctrCipher,
+		writer:    writer,
+	}
+}
+
+// NewDecryptReader returns a reader decrypting content from the reader, the header must be read already
+func (ctrCipher *CTRCipher) NewDecryptReader(reader io.Reader) (io.Reader, error) {
+	stream, err := ctrCipher.newStreamAt(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cipher.StreamReader{
+		S: stream,
+		R: reader,
+	}, nil
+}
+
+// NewEncryptReaderAt returns a reader of encrypted file, including the header, for the content of the size
+func (ctrCipher *CTRCipher) NewEncryptReaderAt(reader io.ReaderAt, size int64) io.ReaderAt {
+	return &ctrEncryptReaderAt{
+		ctrCipher: ctrCipher,
+		reader:    reader,
+		size:      size,
+	}
+}
+
+// NewDecryptReaderAt returns a reader of content for the encrypted file, offsets do not include the header
+func (ctrCipher *CTRCipher) NewDecryptReaderAt(reader io.ReaderAt) io.ReaderAt {
+	return &ctrDecryptReaderAt{
+		ctrCipher: ctrCipher,
+		reader:    reader,
+	}
+}
+
+type ctrEncryptWriter struct {
+	ctrCipher *CTRCipher
+	writer    io.Writer
+	stream    cipher.Stream
+	buffer    []byte
+}
+
+func (writer *ctrEncryptWriter) Write(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	if writer.stream == nil {
+		_, err := writer.writer.Write(writer.ctrCipher.header)
+		if err != nil {
+			return 0, xerrors.Errorf("failed to write header: %w", err)
+		}
+
+		stream, err := writer.ctrCipher.newStreamAt(0)
+		if err != nil {
+			return 0, err
+		}
+
+		writer.stream = stream
+	}
+
+	if len(writer.buffer) < len(data) {
+		writer.buffer = make([]byte, len(data))
+	}
+
+	encrypted := writer.buffer[:len(data)]
+	writer.stream.XORKeyStream(encrypted, data)
+
+	writeLen, err := writer.writer.Write(encrypted)
+	if err != nil {
+		return writeLen, err
+	}
+
+	if writeLen != len(data) {
+		return writeLen, io.ErrShortWrite
+	}
+
+	return writeLen, nil
+}
+
+func (writer *ctrEncryptWriter) Close() error {
+	return nil
+}
+
+type ctrEncryptReaderAt struct {
+	ctrCipher *CTRCipher
+	reader    io.ReaderAt
+	size      int64
+}
+
+func (reader *ctrEncryptReaderAt) ReadAt(buffer []byte, offset int64) (int, error) {
+	headerSize := reader.ctrCipher.GetHeaderSize()
+	if offset >= reader.ctrCipher.GetEncryptedSize(reader.size) {
+		return 0, io.EOF
+	}
+
+	readLen := 0
+	if offset < headerSize {
+		readLen = copy(buffer, reader.ctrCipher.header[offset:])
+	}
+
+	if readLen < len(buffer) {
+		contentOffset := offset + int64(readLen) - headerSize
+		contentBuffer := buffer[readLen:]
+
+		contentReadLen, err := reader.reader.ReadAt(contentBuffer, contentOffset)
+		if contentReadLen > 0 {
+			xorErr := reader.ctrCipher.XORKeyStreamAt(contentBuffer[:contentReadLen], contentBuffer[:contentReadLen], contentOffset)
+			if xorErr != nil {
+				return readLen, xorErr
+			}
+		}
+
+		readLen += contentReadLen
+		if err != nil {
+			return readLen, err
+		}
+	}
+
+	return readLen, nil
+}
+
+type ctrDecryptReaderAt struct {
+	ctrCipher *CTRCipher
+	reader    io.ReaderAt
+}
+
+func (reader *ctrDecryptReaderAt) ReadAt(buffer []byte, offset int64) (int, error) {
+	readLen, err := reader.reader.ReadAt(buffer, offset+reader.ctrCipher.GetHeaderSize())
+	if readLen > 0 {
+		xorErr := reader.ctrCipher.XORKeyStreamAt(buffer[:readLen], buffer[:readLen], offset)
+		if xorErr != nil {
+			return 0, xorErr
+		}
+	}
+
+	return readLen, err
+}
+
+// encryptFileCTR encrypts the source file to the target file
+func encryptFileCTR(source string, target string, ctrCipher *CTRCipher) error {
+	sourceFileHandle, err := os.Open(source)
+	if err != nil {
+		return xerrors.Errorf("failed to open file %q: %w", source, err)
+	}
+
+	defer sourceFileHandle.Close()
+
+	targetFileHandle, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return xerrors.Errorf("failed to create file %q: %w", target, err)
+	}
+
+	defer targetFileHandle.Close()
+
+	writer := ctrCipher.NewEncryptWriter(targetFileHandle)
+	_, err = io.Copy(writer, sourceFileHandle)
+	if err != nil {
+		return xerrors.Errorf("failed to encrypt file content: %w", err)
+	}
+
+	return writer.Close()
+}
+
+// decryptFileCTR decrypts the source file to the target file, readCipher reads the header of the source file
+func decryptFileCTR(source string, target string, readCipher func(reader io.Reader) (*CTRCipher, error)) error {
+	sourceFileHandle, err := os.Open(source)
+	if err != nil {
+		return xerrors.Errorf("failed to open file %q: %w", source, err)
+	}
+
+	defer sourceFileHandle.Close()
+
+	ctrCipher, err := readCipher(sourceFileHandle)
+	if err != nil {
+		return err
+	}
+
+	targetFileHandle, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return xerrors.Errorf("failed to create file %q: %w", target, err)
+	}
+
+	defer targetFileHandle.Close()
+
+	if ctrCipher == nil {
+		// empty file
+		return nil
+	}
+
+	reader, err := ctrCipher.NewDecryptReader(sourceFileHandle)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(targetFileHandle, reader)
+	if err != nil {
+		return xerrors.Errorf("failed to decrypt file content: %w", err)
+	}
+
+	return nil
+}
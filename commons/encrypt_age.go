@@ -0,0 +1,868 @@
+package commons
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/xerrors"
+)
+
+const (
+	AgeEncryptedFileExtension string = ".age.enc"
+
+	// AgeFilenameKeyMetaName is the name of the AVU holding the filename key of a collection, encrypted to the recipients
+	AgeFilenameKeyMetaName string = "gocommands::encryption::filename_key"
+
+	ageFilenameKeyLen int = 32
+)
+
+var (
+	ageFilenameKeyCache     map[string]*ageFilenameKey = map[string]*ageFilenameKey{}
+	ageFilenameKeyCacheLock sync.Mutex
+)
+
+// ageFilenameKey is a filename key found in the collection
+type ageFilenameKey struct {
+	key               []byte
+	recipients        []string
+	collPath          string
+	recipientsUpdated bool
+}
+
+// GetDefaultAgeIdentityPaths returns default SSH private key paths existing, used to decrypt in 'age' mode
+func GetDefaultAgeIdentityPaths() []string {
+	paths := []string{}
+	for _, keyPath := range []string{"~/.ssh/id_ed25519", "~/.ssh/id_rsa"} {
+		expandedPath, err := ExpandHomeDir(keyPath)
+		if err != nil {
+			continue
+		}
+
+		st, err := os.Stat(expandedPath)
+		if err == nil && !st.IsDir() {
+			paths = append(paths, expandedPath)
+		}
+	}
+
+	return paths
+}
+
+// ResolveAgeRecipients resolves recipients for 'age' mode to age public keys and SSH public keys without comments.
+// Each spec is an age public key (age1...), an SSH public key line (ssh-ed25519 or ssh-rsa),
+// or a path to a file having them line by line or having a private key to derive the recipient from.
+func ResolveAgeRecipients(specs []string) ([]string, error) {
+	recipients := []string{}
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if len(spec) == 0 {
+			continue
+		}
+
+		if isAgeRecipientString(spec) {
+			recipient, err := resolveAgeRecipientString(spec)
+			if err != nil {
+				return nil, err
+			}
+
+			recipients = append(recipients, recipient)
+			continue
+		}
+
+		fileRecipients, err := resolveAgeRecipientFile(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		recipients = append(recipients, fileRecipients...)
+	}
+
+	return recipients, nil
+}
+
+// ParseAgeRecipients parses recipients for 'age' mode, specs are the same as ResolveAgeRecipients.
+// The passphrase is used as the only recipient if given, as the age format does not allow mixing it with others.
+func ParseAgeRecipients(specs []string, passphrase string) ([]age.Recipient, error) {
+	recipientStrings, err := ResolveAgeRecipients(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := []age.Recipient{}
+	for _, recipientString := range recipientStrings {
+		recipient, err := parseAgeRecipientString(recipientString)
+		if err != nil {
+			return nil, err
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	if len(passphrase) > 0 {
+		// the age format allows a passphrase only as the sole recipient
+		if len(recipients) > 0 {
+			return nil, xerrors.Errorf("passphrase cannot be used with other recipients")
+		}
+
+		scryptRecipient, err := age.NewScryptRecipient(passphrase)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to create a passphrase recipient: %w", err)
+		}
+
+		recipients = append(recipients, scryptRecipient)
+	}
+
+	if len(recipients) == 0 {
+		return nil, xerrors.Errorf("no recipients are given")
+	}
+
+	return recipients, nil
+}
+
+// ParseAgeIdentities parses identities for 'age' mode.
+// Each path is a file having age secret keys (AGE-SECRET-KEY-...) or an unencrypted SSH private key (ed25519 or RSA).
+// Default paths are parsed the same, but skipped if not readable, as default SSH keys are often encrypted.
+// The passphrase is added as an identity if given.
+func ParseAgeIdentities(paths []string, defaultPaths []string, passphrase string) ([]age.Identity, error) {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"function": "ParseAgeIdentities",
+	})
+
+	identities := []age.Identity{}
+	for _, identityPath := range paths {
+		if len(identityPath) == 0 {
+			continue
+		}
+
+		fileIdentities, err := parseAgeIdentityFile(identityPath)
+		if err != nil {
+			return nil, err
+		}
+
+		identities = append(identities, fileIdentities...)
+	}
+
+	for _, identityPath := range defaultPaths {
+		if len(identityPath) == 0 {
+			continue
+		}
+
+		fileIdentities, err := parseAgeIdentityFile(identityPath)
+		if err != nil {
+			logger.Debugf("skipping default identity %q: %s", identityPath, err)
+			continue
+		}
+
+		identities = append(identities, fileIdentities...)
+	}
+
+	if len(passphrase) > 0 {
+		scryptIdentity, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to create a passphrase identity: %w", err)
+		}
+
+		identities = append(identities, scryptIdentity)
+	}
+
+	if len(identities) == 0 {
+		return nil, xerrors.Errorf("no identities are given")
+	}
+
+	return identities, nil
+}
+
+func isAgeRecipientString(spec string) bool {
+	return strings.HasPrefix(spec, "age1") || strings.HasPrefix(spec, "ssh-")
+}
+
+func parseAgeRecipientString(spec string) (age.Recipient, error) {
+	if strings.HasPrefix(spec, "age1") {
+		recipient, err := age.ParseX25519Recipient(spec)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse recipient %q: %w", spec, err)
+		}
+
+		return recipient, nil
+	}
+
+	recipient, err := agessh.ParseRecipient(spec)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse recipient %q: %w", spec, err)
+	}
+
+	return recipient, nil
+}
+
+func resolveAgeRecipientString(spec string) (string, error) {
+	if strings.HasPrefix(spec, "age1") {
+		recipient, err := age.ParseX25519Recipient(spec)
+		if err != nil {
+			return "", xerrors.Errorf("failed to parse recipient %q: %w", spec, err)
+		}
+
+		return recipient.String(), nil
+	}
+
+	// drop the comment, so the same key is always the same string
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(spec))
+	if err != nil {
+		return "", xerrors.Errorf("failed to parse recipient %q: %w", spec, err)
+	}
+
+	recipient := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey)))
+
+	// check if the key type is supported
+	_, err = parseAgeRecipientString(recipient)
+	if err != nil {
+		return "", err
+	}
+
+	return recipient, nil
+}
+
+func resolveAgeRecipientFile(recipientPath string) ([]string, error) {
+	expandedPath, err := ExpandHomeDir(recipientPath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to expand home dir for %q: %w", recipientPath, err)
+	}
+
+	content, err := os.ReadFile(expandedPath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read recipient file %q: %w", expandedPath, err)
+	}
+
+	if isAgeIdentityContent(content) {
+		// derive recipients from private keys
+		recipients, err := resolveAgeRecipientsFromPrivateKey(content)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse private key file %q: %w", expandedPath, err)
+		}
+
+		return recipients, nil
+	}
+
+	recipients := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		recipient, err := resolveAgeRecipientString(line)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse recipient file %q: %w", expandedPath, err)
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	if len(recipients) == 0 {
+		return nil, xerrors.Errorf("no recipients found in %q", expandedPath)
+	}
+
+	return recipients, nil
+}
+
+func resolveAgeRecipientsFromPrivateKey(content []byte) ([]string, error) {
+	if bytes.Contains(content, []byte("AGE-SECRET-KEY-")) {
+		identities, err := age.ParseIdentities(bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+
+		recipients := []string{}
+		for _, identity := range identities {
+			if x25519Identity, ok := identity.(*age.X25519Identity); ok {
+				recipients = append(recipients, x25519Identity.Recipient().String())
+			}
+		}
+
+		return recipients, nil
+	}
+
+	signer, err := ssh.ParsePrivateKey(content)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := resolveAgeRecipientString(strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey()))))
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{recipient}, nil
+}
+
+func parseAgeIdentityFile(identityPath string) ([]age.Identity, error) {
+	expandedPath, err := ExpandHomeDir(identityPath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to expand home dir for %q: %w", identityPath, err)
+	}
+
+	content, err := os.ReadFile(expandedPath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read identity file %q: %w", expandedPath, err)
+	}
+
+	identities, err := parseAgeIdentityContent(content)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse identity file %q: %w", expandedPath, err)
+	}
+
+	return identities, nil
+}
+
+func isAgeIdentityContent(content []byte) bool {
+	return bytes.Contains(content, []byte("AGE-SECRET-KEY-")) || bytes.Contains(content, []byte("PRIVATE KEY"))
+}
+
+func parseAgeIdentityContent(content []byte) ([]age.Identity, error) {
+	if bytes.Contains(content, []byte("AGE-SECRET-KEY-")) {
+		identities, err := age.ParseIdentities(bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+
+		return identities, nil
+	}
+
+	identity, err := agessh.ParseIdentity(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return []age.Identity{identity}, nil
+}
+
+// NewEncryptWriterAge returns a writer encrypting content to the writer, Close must be called to finish. The writer is not closed
+func NewEncryptWriterAge(writer io.Writer, recipients []age.Recipient) (io.WriteCloser, error) {
+	writeHandle, err := age.Encrypt(writer, recipients...)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create a encrypt writer: %w", err)
+	}
+
+	return writeHandle, nil
+}
+
+// NewDecryptReaderAge returns a reader decrypting content from the reader.
+// Reading returns an error if the content has been modified.
+func NewDecryptReaderAge(reader io.Reader, identities []age.Identity) (io.Reader, error) {
+	decryptReader, err := age.Decrypt(reader, identities...)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decrypt: %w", err)
+	}
+
+	return decryptReader, nil
+}
+
+func EncryptFileAge(source string, target string, recipients []age.Recipient) error {
+	sourceFileHandle, err := os.Open(source)
+	if err != nil {
+		return xerrors.Errorf("failed to open file %q: %w", source, err)
+	}
+
+	defer sourceFileHandle.Close()
+
+	targetFileHandle, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return xerrors.Errorf("failed to create file %q: %w", target, err)
+	}
+
+	defer targetFileHandle.Close()
+
+	writeHandle, err := NewEncryptWriterAge(targetFileHandle, recipients)
+	if err != nil {
+		return xerrors.Errorf("failed to create a encrypt writer for %q: %w", target, err)
+	}
+
+	_, err = io.Copy(writeHandle, sourceFileHandle)
+	if err != nil {
+		writeHandle.Close()
+		return xerrors.Errorf("failed to encrypt data: %w", err)
+	}
+
+	err = writeHandle.Close()
+	if err != nil {
+		return xerrors.Errorf("failed to finish encryption: %w", err)
+	}
+
+	return nil
+}
+
+func DecryptFileAge(source string, target string, identities []age.Identity) error {
+	sourceFileHandle, err := os.Open(source)
+	if err != nil {
+		return xerrors.Errorf("failed to open file %q: %w", source, err)
+	}
+
+	defer sourceFileHandle.Close()
+
+	targetFileHandle, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return xerrors.Errorf("failed to create file %q: %w", target, err)
+	}
+
+	defer targetFileHandle.Close()
+
+	reader, err := NewDecryptReaderAge(sourceFileHandle, identities)
+	if err != nil {
+		return xerrors.Errorf("failed to decrypt for %q: %w", source, err)
+	}
+
+	_, err = io.Copy(targetFileHandle, reader)
+	if err != nil {
+		return xerrors.Errorf("failed to decrypt data: %w", err)
+	}
+
+	return nil
+}
+
+// deriveAgeFilenameKeys derives a key for synthetic nonces and a key for encryption from the filename key
+func deriveAgeFilenameKeys(filenameKey []byte) ([]byte, []byte, error) {
+	if len(filenameKey) != ageFilenameKeyLen {
+		return nil, nil, xerrors.Errorf("filename key must be %d bytes", ageFilenameKeyLen)
+	}
+
+	keys := make([]byte, 64)
+	_, err := io.ReadFull(hkdf.New(sha256.New, filenameKey, nil, []byte("gocommands filename encryption")), keys)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("failed to derive keys: %w", err)
+	}
+
+	return keys[:32], keys[32:], nil
+}
+
+// EncryptFilenameAge encrypts filename deterministically, the same filename is always encrypted to the same name with the same key.
+// The nonce is derived from the filename (SIV), so the encrypted name also authenticates the filename.
+func EncryptFilenameAge(filename string, filenameKey []byte) (string, error) {
+	sivKey, encKey, err := deriveAgeFilenameKeys(filenameKey)
+	if err != nil {
+		return "", err
+	}
+
+	// convert to utf8
+	utf8Filename := []byte(strings.ToValidUTF8(filename, "_"))
+
+	mac := hmac.New(sha256.New, sivKey)
+	mac.Write(utf8Filename)
+	nonce := mac.Sum(nil)[:chacha20poly1305.NonceSize]
+
+	aead, err := chacha20poly1305.New(encKey)
+	if err != nil {
+		return "", xerrors.Errorf("failed to create cipher: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, utf8Filename, nil)
+
+	// url encoding does not have '/'
+	b64EncodedFilename := base64.RawURLEncoding.EncodeToString(sealed)
+
+	return fmt.Sprintf("%s%s", b64EncodedFilename, AgeEncryptedFileExtension), nil
+}
+
+// DecryptFilenameAge decrypts filename, returns an error if the filename has been modified or encrypted with other key
+func DecryptFilenameAge(filename string, filenameKey []byte) (string, error) {
+	sivKey, encKey, err := deriveAgeFilenameKeys(filenameKey)
+	if err != nil {
+		return "", err
+	}
+
+	// trim file ext
+	filename = strings.TrimSuffix(filename, AgeEncryptedFileExtension)
+
+	sealed, err := base64.RawURLEncoding.DecodeString(filename)
+	if err != nil {
+		return "", xerrors.Errorf("failed to base64 decode filename: %w", err)
+	}
+
+	if len(sealed) < chacha20poly1305.NonceSize {
+		return "", xerrors.Errorf("failed to extract nonce from filename")
+	}
+
+	aead, err := chacha20poly1305.New(encKey)
+	if err != nil {
+		return "", xerrors.Errorf("failed to create cipher: %w", err)
+	}
+
+	nonce := sealed[:chacha20poly1305.NonceSize]
+	decryptedFilename, err := aead.Open(nil, nonce, sealed[chacha20poly1305.NonceSize:], nil)
+	if err != nil {
+		return "", xerrors.Errorf("failed to decrypt filename: %w", err)
+	}
+
+	// check synthetic nonce
+	mac := hmac.New(sha256.New, sivKey)
+	mac.Write(decryptedFilename)
+	if !hmac.Equal(nonce, mac.Sum(nil)[:chacha20poly1305.NonceSize]) {
+		return "", xerrors.Errorf("failed to verify filename")
+	}
+
+	return string(decryptedFilename), nil
+}
+
+// NewAgeFilenameKey generates a new filename key
+func NewAgeFilenameKey() ([]byte, error) {
+	filenameKey := make([]byte, ageFilenameKeyLen)
+	_, err := rand.Read(filenameKey)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to generate filename key: %w", err)
+	}
+
+	return filenameKey, nil
+}
+
+// WrapAgeFilenameKey encrypts the filename key to the recipients to store in metadata.
+// Recipients are encrypted along with the key, so only those who can decrypt the key can read and change them.
+// The passphrase is used instead of recipients if given, then recipients are not known.
+func WrapAgeFilenameKey(filenameKey []byte, recipientStrings []string, passphrase string) (string, error) {
+	recipients, err := ParseAgeRecipients(recipientStrings, passphrase)
+	if err != nil {
+		return "", err
+	}
+
+	payload := bytes.Buffer{}
+	payload.Write(filenameKey)
+	for _, recipientString := range recipientStrings {
+		payload.WriteString(recipientString)
+		payload.WriteString("\n")
+	}
+
+	buffer := &bytes.Buffer{}
+	writer, err := NewEncryptWriterAge(buffer, recipients)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = writer.Write(payload.Bytes())
+	if err != nil {
+		writer.Close()
+		return "", xerrors.Errorf("failed to encrypt filename key: %w", err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return "", xerrors.Errorf("failed to finish encryption: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(buffer.Bytes()), nil
+}
+
+// UnwrapAgeFilenameKey decrypts the filename key stored in metadata, returns the key and the recipients it is encrypted to
+func UnwrapAgeFilenameKey(wrappedKey string, identities []age.Identity) ([]byte, []string, error) {
+	encryptedKey, err := base64.StdEncoding.DecodeString(wrappedKey)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("failed to base64 decode filename key: %w", err)
+	}
+
+	reader, err := NewDecryptReaderAge(bytes.NewReader(encryptedKey), identities)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payload, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("failed to decrypt filename key: %w", err)
+	}
+
+	if len(payload) < ageFilenameKeyLen {
+		return nil, nil, xerrors.Errorf("filename key must be %d bytes", ageFilenameKeyLen)
+	}
+
+	recipients := []string{}
+	for _, line := range strings.Split(string(payload[ageFilenameKeyLen:]), "\n") {
+		if len(line) > 0 {
+			recipients = append(recipients, line)
+		}
+	}
+
+	return payload[:ageFilenameKeyLen], recipients, nil
+}
+
+// selectAgeFilenameKeyMeta returns the AVU holding the filename key, the one with the lowest AVU ID if many are stored at the same time
+func selectAgeFilenameKeyMeta(metas []*irodsclient_types.IRODSMeta) *irodsclient_types.IRODSMeta {
+	var selected *irodsclient_types.IRODSMeta
+	for _, meta := range metas {
+		if meta.Name != AgeFilenameKeyMetaName {
+			continue
+		}
+
+		if selected == nil || meta.AVUID < selected.AVUID {
+			selected = meta
+		}
+	}
+
+	return selected
+}
+
+// getMissingAgeFilenameKeyRecipients returns the recipients not in the recorded recipients
+func getMissingAgeFilenameKeyRecipients(recipients []string, recordedRecipients []string) []string {
+	recorded := map[string]bool{}
+	for _, recipient := range recordedRecipients {
+		recorded[recipient] = true
+	}
+
+	missing := []string{}
+	for _, recipient := range recipients {
+		if !recorded[recipient] {
+			missing = append(missing, recipient)
+			recorded[recipient] = true
+		}
+	}
+
+	return missing
+}
+
+// findAgeFilenameKey returns the filename key stored in the collection or its closest parent, returns nil if not found
+func findAgeFilenameKey(fs *irodsclient_fs.FileSystem, collPath string, identities []age.Identity) (*ageFilenameKey, error) {
+	visited := []string{}
+
+	currentPath := collPath
+	for {
+		if filenameKey, ok := ageFilenameKeyCache[currentPath]; ok {
+			for _, visitedPath := range visited {
+				ageFilenameKeyCache[visitedPath] = filenameKey
+			}
+			return filenameKey, nil
+		}
+
+		visited = append(visited, currentPath)
+
+		// the collection may not exist yet
+		metas, err := fs.ListMetadata(currentPath)
+		if err == nil {
+			keyMeta := selectAgeFilenameKeyMeta(metas)
+			if keyMeta != nil {
+				key, recipients, err := UnwrapAgeFilenameKey(keyMeta.Value, identities)
+				if err != nil {
+					return nil, xerrors.Errorf("failed to decrypt filename key of %q, none of the identities is a recipient of it: %w", currentPath, err)
+				}
+
+				filenameKey := &ageFilenameKey{
+					key:        key,
+					recipients: recipients,
+					collPath:   currentPath,
+				}
+
+				for _, visitedPath := range visited {
+					ageFilenameKeyCache[visitedPath] = filenameKey
+				}
+				return filenameKey, nil
+			}
+		}
+
+		parentPath := path.Dir(currentPath)
+		if parentPath == currentPath {
+			return nil, nil
+		}
+
+		currentPath = parentPath
+	}
+}
+
+// createAgeFilenameKey stores a new filename key encrypted to the recipients in the collection.
+// If another key is stored at the same time, the one with the lowest AVU ID is used by all.
+func createAgeFilenameKey(fs *irodsclient_fs.FileSystem, collPath string, recipientStrings []string, passphrase string, identities []age.Identity) (*ageFilenameKey, error) {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"function": "createAgeFilenameKey",
+	})
+
+	key, err := NewAgeFilenameKey()
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := WrapAgeFilenameKey(key, recipientStrings, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fs.AddMetadata(collPath, AgeFilenameKeyMetaName, wrappedKey, "")
+	if err != nil {
+		return nil, xerrors.Errorf("failed to store filename key in %q: %w", collPath, err)
+	}
+
+	// re-read to find the key stored first
+	metas, err := fs.ListMetadata(collPath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to list metadata of %q: %w", collPath, err)
+	}
+
+	keyMeta := selectAgeFilenameKeyMeta(metas)
+	if keyMeta == nil {
+		return nil, xerrors.Errorf("failed to find filename key stored in %q", collPath)
+	}
+
+	if keyMeta.Value != wrappedKey {
+		logger.Debugf("another filename key is stored in %q at the same time, using it", collPath)
+
+		for _, meta := range metas {
+			if meta.Name == AgeFilenameKeyMetaName && meta.Value == wrappedKey {
+				err = fs.DeleteMetadata(collPath, meta.AVUID)
+				if err != nil {
+					return nil, xerrors.Errorf("failed to delete filename key in %q: %w", collPath, err)
+				}
+			}
+		}
+
+		key, recipients, err := UnwrapAgeFilenameKey(keyMeta.Value, identities)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decrypt filename key of %q stored at the same time, none of the identities is a recipient of it: %w", collPath, err)
+		}
+
+		return &ageFilenameKey{
+			key:        key,
+			recipients: recipients,
+			collPath:   collPath,
+		}, nil
+	}
+
+	return &ageFilenameKey{
+		key:               key,
+		recipients:        recipientStrings,
+		collPath:          collPath,
+		recipientsUpdated: true,
+	}, nil
+}
+
+// updateAgeFilenameKeyRecipients encrypts the filename key again to the recipients it is encrypted to and the given recipients.
+// Recipients are read only from the decrypted key, never from other metadata anyone having write access can add.
+func updateAgeFilenameKeyRecipients(fs *irodsclient_fs.FileSystem, filenameKey *ageFilenameKey, recipientStrings []string, identities []age.Identity) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"function": "updateAgeFilenameKeyRecipients",
+	})
+
+	if len(recipientStrings) == 0 {
+		// passphrase only, cannot be mixed with other recipients
+		return nil
+	}
+
+	if len(getMissingAgeFilenameKeyRecipients(recipientStrings, filenameKey.recipients)) == 0 {
+		return nil
+	}
+
+	if len(filenameKey.recipients) == 0 {
+		// encrypted with a passphrase, cannot be mixed with other recipients
+		logger.Debugf("filename key in %q is encrypted with a passphrase, not encrypting it to recipients", filenameKey.collPath)
+		return nil
+	}
+
+	// re-read, not to lose recipients added by others since the key was read
+	metas, err := fs.ListMetadata(filenameKey.collPath)
+	if err != nil {
+		return xerrors.Errorf("failed to list metadata of %q: %w", filenameKey.collPath, err)
+	}
+
+	keyMeta := selectAgeFilenameKeyMeta(metas)
+	if keyMeta == nil {
+		return xerrors.Errorf("failed to find filename key stored in %q", filenameKey.collPath)
+	}
+
+	key, recordedRecipients, err := UnwrapAgeFilenameKey(keyMeta.Value, identities)
+	if err != nil {
+		return xerrors.Errorf("failed to decrypt filename key of %q: %w", filenameKey.collPath, err)
+	}
+
+	if !bytes.Equal(key, filenameKey.key) {
+		return xerrors.Errorf("filename key of %q is changed while uploading", filenameKey.collPath)
+	}
+
+	missingRecipients := getMissingAgeFilenameKeyRecipients(recipientStrings, recordedRecipients)
+	recipients := append(recordedRecipients, missingRecipients...)
+	if len(missingRecipients) == 0 {
+		filenameKey.recipients = recipients
+		return nil
+	}
+
+	wrappedKey, err := WrapAgeFilenameKey(filenameKey.key, recipients, "")
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("encrypting filename key in %q to %d new recipients", filenameKey.collPath, len(missingRecipients))
+
+	newKeyMeta := &irodsclient_types.IRODSMeta{
+		Name:  AgeFilenameKeyMetaName,
+		Value: wrappedKey,
+	}
+
+	err = modifyMetadata(fs, irodsclient_types.IRODSCollectionMetaItemType, filenameKey.collPath, keyMeta, newKeyMeta)
+	if err != nil {
+		return xerrors.Errorf("failed to update filename key in %q: %w", filenameKey.collPath, err)
+	}
+
+	filenameKey.recipients = recipients
+	return nil
+}
+
+// GetAgeFilenameKey returns the filename key for files in the collection, stored in the collection or its parents.
+// If create is true, a new key encrypted to the recipients is stored in the collection if no key is found,
+// otherwise the key found is encrypted again to include the recipients.
+func GetAgeFilenameKey(fs *irodsclient_fs.FileSystem, collPath string, recipientSpecs []string, passphrase string, identities []age.Identity, create bool) ([]byte, error) {
+	ageFilenameKeyCacheLock.Lock()
+	defer ageFilenameKeyCacheLock.Unlock()
+
+	var recipientStrings []string
+	if create {
+		// validate recipients and the passphrase
+		_, err := ParseAgeRecipients(recipientSpecs, passphrase)
+		if err != nil {
+			return nil, err
+		}
+
+		recipientStrings, err = ResolveAgeRecipients(recipientSpecs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	filenameKey, err := findAgeFilenameKey(fs, collPath, identities)
+	if err != nil {
+		return nil, err
+	}
+
+	if filenameKey == nil {
+		if !create {
+			return nil, xerrors.Errorf("failed to find filename key in %q or its parents", collPath)
+		}
+
+		filenameKey, err = createAgeFilenameKey(fs, collPath, recipientStrings, passphrase, identities)
+		if err != nil {
+			return nil, err
+		}
+
+		ageFilenameKeyCache[collPath] = filenameKey
+	}
+
+	if create && !filenameKey.recipientsUpdated {
+		err = updateAgeFilenameKeyRecipients(fs, filenameKey, recipientStrings, identities)
+		if err != nil {
+			return nil, err
+		}
+
+		filenameKey.recipientsUpdated = true
+	}
+
+	return filenameKey.key, nil
+}
@@ -0,0 +1,395 @@
+package commons
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	shlex "github.com/anmitsu/go-shlex"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// EncryptionKeyEnvName is the environment variable giving the encryption key
+	EncryptionKeyEnvName string = "GOCMD_ENCRYPTION_KEY"
+	// EncryptionKeyFingerprintMetaName is the name of the AVU holding the fingerprint of the key a data object is encrypted with
+	EncryptionKeyFingerprintMetaName string = "gocommands::encryption::key_fingerprint"
+
+	encryptionKeyringService     string = "gocommands"
+	encryptionFingerprintSaltLen int    = 16
+)
+
+var (
+	encryptionKeyFingerprintCache     map[string]string = map[string]string{}
+	encryptionKeyFingerprintCacheLock sync.Mutex
+
+	// salt shared by fingerprints stored in a run, so the slow fingerprint is derived once
+	encryptionFingerprintRunSalt     []byte
+	encryptionFingerprintRunSaltErr  error
+	encryptionFingerprintRunSaltOnce sync.Once
+
+	passwordEncryptionKeyWarningOnce sync.Once
+)
+
+// EncryptionKeySource determines where the encryption key comes from
+type EncryptionKeySource string
+
+const (
+	// EncryptionKeySourceFlag is for the key given by flag
+	EncryptionKeySourceFlag EncryptionKeySource = "flag"
+	// EncryptionKeySourceFile is for the key read from a file
+	EncryptionKeySourceFile EncryptionKeySource = "file"
+	// EncryptionKeySourceCommand is for the key printed by an external command (key helper)
+	EncryptionKeySourceCommand EncryptionKeySource = "command"
+	// EncryptionKeySourceKeyring is for the key stored in OS keyring
+	EncryptionKeySourceKeyring EncryptionKeySource = "keyring"
+	// EncryptionKeySourcePrompt is for the key typed in by user
+	EncryptionKeySourcePrompt EncryptionKeySource = "prompt"
+	// EncryptionKeySourceEnv is for the key given by environment variable
+	EncryptionKeySourceEnv EncryptionKeySource = "env"
+	// EncryptionKeySourcePassword is for the iRODS password used as the key
+	EncryptionKeySourcePassword EncryptionKeySource = "password"
+	// EncryptionKeySourceUnknown is for no key found
+	EncryptionKeySourceUnknown EncryptionKeySource = ""
+)
+
+// EncryptionKeyConfig has sources to get the encryption key from
+type EncryptionKeyConfig struct {
+	Key        string
+	KeyFile    string
+	KeyCommand string
+	Keyring    bool
+	Prompt     bool
+	// KeyringUser identifies the key in OS keyring, e.g., iRODS user and zone
+	KeyringUser string
+	// ConfirmPrompt asks the key twice, for encryption
+	ConfirmPrompt bool
+}
+
+// ResolveEncryptionKey returns the key from the first source available, in order of
+// the key given by flag, a key file, a key helper command, OS keyring, a prompt, and the environment variable.
+// If keyring and prompt are both enabled, the key typed in is stored in OS keyring.
+// Returns EncryptionKeySourceUnknown if no key is found.
+func ResolveEncryptionKey(config *EncryptionKeyConfig) (string, EncryptionKeySource, error) {
+	if len(config.Key) > 0 {
+		return config.Key, EncryptionKeySourceFlag, nil
+	}
+
+	if len(config.KeyFile) > 0 {
+		key, err := ReadEncryptionKeyFile(config.KeyFile)
+		if err != nil {
+			return "", EncryptionKeySourceUnknown, err
+		}
+
+		return key, EncryptionKeySourceFile, nil
+	}
+
+	if len(config.KeyCommand) > 0 {
+		key, err := RunEncryptionKeyCommand(config.KeyCommand)
+		if err != nil {
+			return "", EncryptionKeySourceUnknown, err
+		}
+
+		return key, EncryptionKeySourceCommand, nil
+	}
+
+	if config.Keyring {
+		key, err := GetEncryptionKeyFromKeyring(config.KeyringUser)
+		if err != nil {
+			return "", EncryptionKeySourceUnknown, err
+		}
+
+		if len(key) > 0 {
+			return key, EncryptionKeySourceKeyring, nil
+		}
+
+		if !config.Prompt {
+			return "", EncryptionKeySourceUnknown, xerrors.Errorf("failed to find encryption key for %q in OS keyring", config.KeyringUser)
+		}
+	}
+
+	if config.Prompt {
+		key, err := InputEncryptionKey(config.ConfirmPrompt)
+		if err != nil {
+			return "", EncryptionKeySourceUnknown, err
+		}
+
+		if config.Keyring {
+			err = SetEncryptionKeyToKeyring(config.KeyringUser, key)
+			if err != nil {
+				return "", EncryptionKeySourceUnknown, err
+			}
+		}
+
+		return key, EncryptionKeySourcePrompt, nil
+	}
+
+	if key, ok := os.LookupEnv(EncryptionKeyEnvName); ok && len(key) > 0 {
+		return key, EncryptionKeySourceEnv, nil
+	}
+
+	return "", EncryptionKeySourceUnknown, nil
+}
+
+// ReadEncryptionKeyFile reads the key from the file, ignoring trailing newlines
+func ReadEncryptionKeyFile(keyPath string) (string, error) {
+	expandedPath, err := ExpandHomeDir(keyPath)
+	if err != nil {
+		return "", xerrors.Errorf("failed to expand home dir for %q: %w", keyPath, err)
+	}
+
+	content, err := os.ReadFile(expandedPath)
+	if err != nil {
+		return "", xerrors.Errorf("failed to read key file %q: %w", expandedPath, err)
+	}
+
+	key := strings.TrimRight(string(content), "\r\n")
+	if len(key) == 0 {
+		return "", xerrors.Errorf("key file %q is empty", expandedPath)
+	}
+
+	return key, nil
+}
+
+// RunEncryptionKeyCommand runs the key helper command and returns the key it prints, ignoring trailing newlines
+func RunEncryptionKeyCommand(command string) (string, error) {
+	args, err := shlex.Split(command, true)
+	if err != nil {
+		return "", xerrors.Errorf("failed to parse key command %q: %w", command, err)
+	}
+
+	if len(args) == 0 {
+		return "", xerrors.Errorf("key command is empty")
+	}
+
+	stdout := &bytes.Buffer{}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+
+	err = cmd.Run()
+	if err != nil {
+		return "", xerrors.Errorf("failed to run key command %q: %w", args[0], err)
+	}
+
+	key := strings.TrimRight(stdout.String(), "\r\n")
+	if len(key) == 0 {
+		return "", xerrors.Errorf("key command %q printed no key", args[0])
+	}
+
+	return key, nil
+}
+
+// GetEncryptionKeyFromKeyring returns the key stored in OS keyring, returns empty string if not found
+func GetEncryptionKeyFromKeyring(user string) (string, error) {
+	key, err := keyring.Get(encryptionKeyringService, user)
+	if err != nil {
+		if xerrors.Is(err, keyring.ErrNotFound) {
+			return "", nil
+		}
+
+		return "", xerrors.Errorf("failed to get encryption key for %q from OS keyring: %w", user, err)
+	}
+
+	return key, nil
+}
+
+// SetEncryptionKeyToKeyring stores the key in OS keyring
+func SetEncryptionKeyToKeyring(user string, key string) error {
+	err := keyring.Set(encryptionKeyringService, user, key)
+	if err != nil {
+		return xerrors.Errorf("failed to store encryption key for %q in OS keyring: %w", user, err)
+	}
+
+	return nil
+}
+
+// InputEncryptionKey asks the key to user, twice if confirm is true
+func InputEncryptionKey(confirm bool) (string, error) {
+	key := InputPassword("Encryption key")
+	if len(key) == 0 {
+		return "", xerrors.Errorf("encryption key is empty")
+	}
+
+	if confirm {
+		confirmKey := InputPassword("Confirm encryption key")
+		if key != confirmKey {
+			return "", xerrors.Errorf("encryption keys do not match")
+		}
+	}
+
+	return key, nil
+}
+
+// WarnPasswordEncryptionKey warns once that the iRODS password is used as the encryption key
+func WarnPasswordEncryptionKey() {
+	passwordEncryptionKeyWarningOnce.Do(func() {
+		PrintErrorf("WARN: using the iRODS password as the encryption key, give a key with --key_file, --key_command, --key_keyring, --key_prompt, or %s env\n", EncryptionKeyEnvName)
+	})
+}
+
+// UseEncryptionKey returns true if the mode encrypts with the encryption key rather than key files
+func UseEncryptionKey(mode EncryptionMode) bool {
+	switch mode {
+	case EncryptionModeWinSCP, EncryptionModePGP:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetEncryptionKeyFingerprint returns the fingerprint of the key derived with the salt, the key cannot be recovered from it easily.
+// Fingerprints are cached, so each salt is derived once.
+func GetEncryptionKeyFingerprint(key []byte, salt []byte) (string, error) {
+	cacheKey := hex.EncodeToString(salt) + ":" + string(key)
+
+	encryptionKeyFingerprintCacheLock.Lock()
+	defer encryptionKeyFingerprintCacheLock.Unlock()
+
+	if fingerprint, ok := encryptionKeyFingerprintCache[cacheKey]; ok {
+		return fingerprint, nil
+	}
+
+	// slow to derive, to make guessing the key hard
+	derived, err := scrypt.Key(key, salt, 1<<15, 8, 1, 16)
+	if err != nil {
+		return "", xerrors.Errorf("failed to compute key fingerprint: %w", err)
+	}
+
+	fingerprint := hex.EncodeToString(derived)
+	encryptionKeyFingerprintCache[cacheKey] = fingerprint
+	return fingerprint, nil
+}
+
+// getEncryptionKeyFingerprintRunSalt returns a random salt generated once per run
+func getEncryptionKeyFingerprintRunSalt() ([]byte, error) {
+	encryptionFingerprintRunSaltOnce.Do(func() {
+		salt := make([]byte, encryptionFingerprintSaltLen)
+		_, err := rand.Read(salt)
+		if err != nil {
+			encryptionFingerprintRunSaltErr = xerrors.Errorf("failed to generate salt: %w", err)
+			return
+		}
+
+		encryptionFingerprintRunSalt = salt
+	})
+
+	return encryptionFingerprintRunSalt, encryptionFingerprintRunSaltErr
+}
+
+// MakeEncryptionKeyFingerprintMetaValue returns 'salt:fingerprint' of the key to be stored in metadata.
+// The salt is random per run, so data objects uploaded together share the fingerprint and it is derived once.
+func MakeEncryptionKeyFingerprintMetaValue(key []byte) (string, error) {
+	salt, err := getEncryptionKeyFingerprintRunSalt()
+	if err != nil {
+		return "", err
+	}
+
+	fingerprint, err := GetEncryptionKeyFingerprint(key, salt)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(salt) + ":" + fingerprint, nil
+}
+
+// MatchEncryptionKeyFingerprintMetaValue returns true if the 'salt:fingerprint' value is made from the key.
+// Returns an error if the value is malformed.
+func MatchEncryptionKeyFingerprintMetaValue(value string, key []byte) (bool, error) {
+	saltHex, fingerprint, ok := strings.Cut(value, ":")
+	if !ok {
+		return false, xerrors.Errorf("malformed key fingerprint %q", value)
+	}
+
+	salt, err := hex.DecodeString(saltHex)
+	if err != nil || len(salt) == 0 {
+		return false, xerrors.Errorf("malformed salt in key fingerprint %q", value)
+	}
+
+	expected, err := GetEncryptionKeyFingerprint(key, salt)
+	if err != nil {
+		return false, err
+	}
+
+	return expected == fingerprint, nil
+}
+
+// SetEncryptionKeyFingerprintMeta stores the fingerprint of the key in metadata of the data object, replacing old one.
+// Must not be called with the iRODS password as the key, as the fingerprint is readable by others.
+func SetEncryptionKeyFingerprintMeta(fs *irodsclient_fs.FileSystem, irodsPath string, key []byte) error {
+	metas, err := fs.ListMetadata(irodsPath)
+	if err != nil {
+		return xerrors.Errorf("failed to list metadata of %q: %w", irodsPath, err)
+	}
+
+	for _, meta := range metas {
+		if meta.Name != EncryptionKeyFingerprintMetaName {
+			continue
+		}
+
+		if matched, _ := MatchEncryptionKeyFingerprintMetaValue(meta.Value, key); matched {
+			return nil
+		}
+
+		err = fs.DeleteMetadata(irodsPath, meta.AVUID)
+		if err != nil {
+			return xerrors.Errorf("failed to delete old key fingerprint of %q: %w", irodsPath, err)
+		}
+	}
+
+	value, err := MakeEncryptionKeyFingerprintMetaValue(key)
+	if err != nil {
+		return err
+	}
+
+	err = fs.AddMetadata(irodsPath, EncryptionKeyFingerprintMetaName, value, "")
+	if err != nil {
+		return xerrors.Errorf("failed to add key fingerprint to %q: %w", irodsPath, err)
+	}
+
+	return nil
+}
+
+// CheckEncryptionKeyFingerprintMeta returns an error if the data object has a key fingerprint in metadata not matching the key.
+// Data objects without a fingerprint or with a malformed one are not checked.
+func CheckEncryptionKeyFingerprintMeta(fs *irodsclient_fs.FileSystem, irodsPath string, key []byte) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"function": "CheckEncryptionKeyFingerprintMeta",
+	})
+
+	metas, err := fs.ListMetadata(irodsPath)
+	if err != nil {
+		return xerrors.Errorf("failed to list metadata of %q: %w", irodsPath, err)
+	}
+
+	for _, meta := range metas {
+		if meta.Name != EncryptionKeyFingerprintMetaName {
+			continue
+		}
+
+		matched, err := MatchEncryptionKeyFingerprintMetaValue(meta.Value, key)
+		if err != nil {
+			logger.Debugf("skipping key fingerprint check of %q: %s", irodsPath, err)
+			return nil
+		}
+
+		if !matched {
+			return xerrors.Errorf("wrong encryption key for %q, the key fingerprint does not match", irodsPath)
+		}
+
+		return nil
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package commons
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptKey(t *testing.T) {
+	t.Run("test ResolveEncryptionKey", testResolveEncryptionKey)
+	t.Run("test GetEncryptionKeyFingerprint", testGetEncryptionKeyFingerprint)
+}
+
+func testResolveEncryptionKey(t *testing.T) {
+	_, keyPaths := writeLocalTestFiles(t, map[string]string{"key_file": "file_key\n"})
+	keyPath := keyPaths["key_file"]
+
+	t.Setenv(EncryptionKeyEnvName, "env_key")
+
+	// flag comes first
+	key, source, err := ResolveEncryptionKey(&EncryptionKeyConfig{Key: "flag_key", KeyFile: keyPath})
+	assert.NoError(t, err)
+	assert.Equal(t, "flag_key", key)
+	assert.Equal(t, EncryptionKeySourceFlag, source)
+
+	key, source, err = ResolveEncryptionKey(&EncryptionKeyConfig{KeyFile: keyPath, KeyCommand: "echo command_key"})
+	assert.NoError(t, err)
+	assert.Equal(t, "file_key", key)
+	assert.Equal(t, EncryptionKeySourceFile, source)
+
+	key, source, err = ResolveEncryptionKey(&EncryptionKeyConfig{KeyCommand: "echo 'command key'"})
+	assert.NoError(t, err)
+	assert.Equal(t, "command key", key)
+	assert.Equal(t, EncryptionKeySourceCommand, source)
+
+	key, source, err = ResolveEncryptionKey(&EncryptionKeyConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, "env_key", key)
+	assert.Equal(t, EncryptionKeySourceEnv, source)
+
+	t.Setenv(EncryptionKeyEnvName, "")
+
+	_, source, err = ResolveEncryptionKey(&EncryptionKeyConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, EncryptionKeySourceUnknown, source)
+
+	// failing sources
+	_, _, err = ResolveEncryptionKey(&EncryptionKeyConfig{KeyFile: keyPath + ".missing"})
+	assert.Error(t, err)
+
+	_, _, err = ResolveEncryptionKey(&EncryptionKeyConfig{KeyCommand: "false"})
+	assert.Error(t, err)
+}
+
+func testGetEncryptionKeyFingerprint(t *testing.T) {
+	salt := []byte("test_salt")
+
+	fingerprint, err := GetEncryptionKeyFingerprint([]byte("test_password"), salt)
+	assert.NoError(t, err)
+	assert.Len(t, fingerprint, 32)
+	assert.NotContains(t, fingerprint, "test_password")
+
+	fingerprint2, err := GetEncryptionKeyFingerprint([]byte("test_password"), salt)
+	assert.NoError(t, err)
+	assert.Equal(t, fingerprint, fingerprint2)
+
+	otherFingerprint, err := GetEncryptionKeyFingerprint([]byte("other_password"), salt)
+	assert.NoError(t, err)
+	assert.NotEqual(t, fingerprint, otherFingerprint)
+
+	otherSaltFingerprint, err := GetEncryptionKeyFingerprint([]byte("test_password"), []byte("other_salt"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, fingerprint, otherSaltFingerprint)
+
+	// salted once per run
+	value, err := MakeEncryptionKeyFingerprintMetaValue([]byte("test_password"))
+	assert.NoError(t, err)
+	assert.NotContains(t, value, hex.EncodeToString(salt))
+
+	value2, err := MakeEncryptionKeyFingerprintMetaValue([]byte("test_password"))
+	assert.NoError(t, err)
+	assert.Equal(t, value, value2)
+
+	matched, err := MatchEncryptionKeyFingerprintMetaValue(value, []byte("test_password"))
+	assert.NoError(t, err)
+	assert.True(t, matched)
+
+	matched, err = MatchEncryptionKeyFingerprintMetaValue(value, []byte("other_password"))
+	assert.NoError(t, err)
+	assert.False(t, matched)
+
+	_, err = MatchEncryptionKeyFingerprintMetaValue(fingerprint, []byte("test_password"))
+	assert.Error(t, err)
+}
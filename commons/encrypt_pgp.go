@@ -29,6 +29,43 @@ func DecryptFilenamePGP(filename string) string {
 	return strings.TrimSuffix(filename, PgpEncryptedFileExtension)
 }
 
+// NewEncryptWriterPGP returns a writer encrypting content to the writer, Close must be called to finish the message. The writer is not closed
+func NewEncryptWriterPGP(writer io.Writer, key []byte) (io.WriteCloser, error) {
+	encryptionConfig := &packet.Config{
+		DefaultCipher: packet.CipherAES256,
+	}
+
+	writeHandle, err := openpgp.SymmetricallyEncrypt(writer, key, nil, encryptionConfig)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create a encrypt writer: %w", err)
+	}
+
+	return writeHandle, nil
+}
+
+// NewDecryptReaderPGP returns a reader decrypting content from the reader
+func NewDecryptReaderPGP(reader io.Reader, key []byte) (io.Reader, error) {
+	encryptionConfig := &packet.Config{
+		DefaultCipher: packet.CipherAES256,
+	}
+
+	failed := false
+	prompt := func(keys []openpgp.Key, symmetric bool) ([]byte, error) {
+		if failed {
+			return nil, xerrors.New("decryption failed")
+		}
+		failed = true
+		return key, nil
+	}
+
+	messageDetail, err := openpgp.ReadMessage(reader, nil, prompt, encryptionConfig)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decrypt: %w", err)
+	}
+
+	return messageDetail.UnverifiedBody, nil
+}
+
 func EncryptFilePGP(source string, target string, key []byte) error {
 	sourceFileHandle, err := os.Open(source)
 	if err != nil {
@@ -44,22 +81,22 @@ func EncryptFilePGP(source string, target string, key []byte) error {
 
 	defer targetFileHandle.Close()
 
-	encryptionConfig := &packet.Config{
-		DefaultCipher: packet.CipherAES256,
-	}
-
-	writeHandle, err := openpgp.SymmetricallyEncrypt(targetFileHandle, key, nil, encryptionConfig)
+	writeHandle, err := NewEncryptWriterPGP(targetFileHandle, key)
 	if err != nil {
 		return xerrors.Errorf("failed to create a encrypt writer for %q: %w", target, err)
 	}
 
-	defer writeHandle.Close()
-
 	_, err = io.Copy(writeHandle, sourceFileHandle)
 	if err != nil {
+		writeHandle.Close()
 		return xerrors.Errorf("failed to encrypt data: %w", err)
 	}
 
+	err = writeHandle.Close()
+	if err != nil {
+		return xerrors.Errorf("failed to finish encryption: %w", err)
+	}
+
 	return nil
 }
 
@@ -78,25 +115,12 @@ func DecryptFilePGP(source string, target string, key []byte) error {
 
 	defer targetFileHandle.Close()
 
-	encryptionConfig := &packet.Config{
-		DefaultCipher: packet.CipherAES256,
-	}
-
-	failed := false
-	prompt := func(keys []openpgp.Key, symmetric bool) ([]byte, error) {
-		if failed {
-			return nil, xerrors.New("decryption failed")
-		}
-		failed = true
-		return key, nil
-	}
-
-	messageDetail, err := openpgp.ReadMessage(sourceFileHandle, nil, prompt, encryptionConfig)
+	reader, err := NewDecryptReaderPGP(sourceFileHandle, key)
 	if err != nil {
 		return xerrors.Errorf("failed to decrypt for %q: %w", source, err)
 	}
 
-	_, err = io.Copy(targetFileHandle, messageDetail.UnverifiedBody)
+	_, err = io.Copy(targetFileHandle, reader)
 	if err != nil {
 		return xerrors.Errorf("failed to decrypt data: %w", err)
 	}
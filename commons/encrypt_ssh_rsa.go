@@ -6,7 +6,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 
 	"crypto/rand"
@@ -91,49 +90,20 @@ func DecryptFilenameSSH(filename string, privatekey *rsa.PrivateKey) (string, er
 	return string(decryptedFilename), nil
 }
 
-func EncryptFileSSH(source string, target string, publickey *rsa.PublicKey) error {
-	sourceFileHandle, err := os.Open(source)
-	if err != nil {
-		return xerrors.Errorf("failed to open file %q: %w", source, err)
-	}
-
-	defer sourceFileHandle.Close()
-
-	targetFileHandle, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
-	if err != nil {
-		return xerrors.Errorf("failed to create file %q: %w", target, err)
-	}
-
-	defer targetFileHandle.Close()
-
-	stat, err := sourceFileHandle.Stat()
-	if err != nil {
-		return xerrors.Errorf("failed to stat file %q: %w", source, err)
-	}
-
-	if stat.Size() == 0 {
-		// empty file
-		return nil
-	}
-
-	// write header
-	_, err = targetFileHandle.Write([]byte(SshRsaAesCtrHeader))
-	if err != nil {
-		return xerrors.Errorf("failed to write header: %w", err)
-	}
-
+// NewSSHCipher creates a cipher with a new random salt and shared key to encrypt file content, the shared key is encrypted with the public key in the header
+func NewSSHCipher(publickey *rsa.PublicKey) (*CTRCipher, error) {
 	// generate salt
 	salt := make([]byte, AesSaltLen)
-	_, err = rand.Read(salt)
+	_, err := rand.Read(salt)
 	if err != nil {
-		return xerrors.Errorf("failed to read random data: %w", err)
+		return nil, xerrors.Errorf("failed to read random data: %w", err)
 	}
 
 	// generate shared key
 	sharedKey := make([]byte, 32)
 	_, err = rand.Read(sharedKey)
 	if err != nil {
-		return xerrors.Errorf("failed to generate random shared key: %w", err)
+		return nil, xerrors.Errorf("failed to generate random shared key: %w", err)
 	}
 
 	headerBuffer := make([]byte, AesSaltLen+32)
@@ -144,99 +114,77 @@ func EncryptFileSSH(source string, target string, publickey *rsa.PublicKey) erro
 	oaepLabel := []byte("")
 	encryptedHeader, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publickey, headerBuffer, oaepLabel)
 	if err != nil {
-		return xerrors.Errorf("failed to encrypt header: %w", err)
+		return nil, xerrors.Errorf("failed to encrypt header: %w", err)
 	}
 
-	// write header len
+	// header, header len, and encrypted salt and shared key
+	header := bytes.Buffer{}
+	header.WriteString(SshRsaAesCtrHeader)
+
 	lenBuffer := make([]byte, 32)
 	binary.LittleEndian.PutUint32(lenBuffer, uint32(len(encryptedHeader)))
-	_, err = targetFileHandle.Write(lenBuffer)
-	if err != nil {
-		return xerrors.Errorf("failed to write encrypted header length: %w", err)
-	}
-
-	// write salt and shared key
-	_, err = targetFileHandle.Write(encryptedHeader)
-	if err != nil {
-		return xerrors.Errorf("failed to write encrypted header: %w", err)
-	}
+	header.Write(lenBuffer)
+	header.Write(encryptedHeader)
 
-	err = EncryptAESCTRReaderWriter(sourceFileHandle, targetFileHandle, salt, sharedKey)
-	if err != nil {
-		return xerrors.Errorf("failed to encrypt file content: %w", err)
-	}
-
-	return nil
+	return NewCTRCipher(header.Bytes(), salt, sharedKey)
 }
 
-func DecryptFileSSH(source string, target string, privatekey *rsa.PrivateKey) error {
-	sourceFileHandle, err := os.Open(source)
-	if err != nil {
-		return xerrors.Errorf("failed to open file %q: %w", source, err)
-	}
-
-	defer sourceFileHandle.Close()
-
-	targetFileHandle, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
-	if err != nil {
-		return xerrors.Errorf("failed to create file %q: %w", target, err)
-	}
-
-	defer targetFileHandle.Close()
-
-	header := make([]byte, 16)
-	readLen, err := sourceFileHandle.Read(header)
+// ReadSSHCipher reads the header of encrypted file content to create a cipher, returns nil for empty content
+func ReadSSHCipher(reader io.Reader, privatekey *rsa.PrivateKey) (*CTRCipher, error) {
+	header := make([]byte, len(SshRsaAesCtrHeader)+32)
+	readLen, err := io.ReadFull(reader, header)
 	if err == io.EOF && readLen == 0 {
-		return nil
+		return nil, nil
 	}
 
 	if err != nil {
-		return xerrors.Errorf("failed to read RSA AES CTR header: %w", err)
+		return nil, xerrors.Errorf("failed to read RSA AES CTR header: %w", err)
 	}
 
-	if !bytes.Equal(header, []byte(SshRsaAesCtrHeader)) {
-		return xerrors.Errorf("failed to read RSA AES CTR header")
+	if !bytes.Equal(header[:len(SshRsaAesCtrHeader)], []byte(SshRsaAesCtrHeader)) {
+		return nil, xerrors.Errorf("failed to read RSA AES CTR header")
 	}
 
-	lenBuffer := make([]byte, 32)
-	readLen, err = sourceFileHandle.Read(lenBuffer)
-	if err != nil {
-		return xerrors.Errorf("failed to read encrypted header length: %w", err)
-	}
-
-	if readLen != 32 {
-		return xerrors.Errorf("failed to read encrypted header length")
+	encryptedHeaderLength := binary.LittleEndian.Uint32(header[len(SshRsaAesCtrHeader):])
+	if encryptedHeaderLength > uint32(privatekey.Size()) {
+		return nil, xerrors.Errorf("failed to read encrypted header, invalid length %d", encryptedHeaderLength)
 	}
 
-	encryptedHeaderLength := binary.LittleEndian.Uint32(lenBuffer)
 	encryptedHeaderBuffer := make([]byte, encryptedHeaderLength)
-	readLen, err = sourceFileHandle.Read(encryptedHeaderBuffer)
+	_, err = io.ReadFull(reader, encryptedHeaderBuffer)
 	if err != nil {
-		return xerrors.Errorf("failed to read encrypted header: %w", err)
-	}
-
-	if readLen != int(encryptedHeaderLength) {
-		return xerrors.Errorf("failed to read encrypted header")
+		return nil, xerrors.Errorf("failed to read encrypted header: %w", err)
 	}
 
 	// RSA decrypt
 	oaepLabel := []byte("")
 	decryptedHeader, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, privatekey, encryptedHeaderBuffer, oaepLabel)
 	if err != nil {
-		return xerrors.Errorf("failed to decrypt header: %w", err)
+		return nil, xerrors.Errorf("failed to decrypt header: %w", err)
 	}
 
 	if len(decryptedHeader) != AesSaltLen+32 {
-		return xerrors.Errorf("failed to decrypt header")
+		return nil, xerrors.Errorf("failed to decrypt header")
 	}
 
 	salt := decryptedHeader[:AesSaltLen]
 	sharedKey := decryptedHeader[AesSaltLen:]
 
-	err = DecryptAESCTRReaderWriter(sourceFileHandle, targetFileHandle, salt, sharedKey)
+	header = append(header, encryptedHeaderBuffer...)
+	return NewCTRCipher(header, salt, sharedKey)
+}
+
+func EncryptFileSSH(source string, target string, publickey *rsa.PublicKey) error {
+	ctrCipher, err := NewSSHCipher(publickey)
 	if err != nil {
-		return xerrors.Errorf("failed to decrypt file content: %w", err)
+		return err
 	}
 
-	return nil
+	return encryptFileCTR(source, target, ctrCipher)
+}
+
+func DecryptFileSSH(source string, target string, privatekey *rsa.PrivateKey) error {
+	return decryptFileCTR(source, target, func(reader io.Reader) (*CTRCipher, error) {
+		return ReadSSHCipher(reader, privatekey)
+	})
 }
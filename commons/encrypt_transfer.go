@@ -0,0 +1,432 @@
+package commons
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_common "github.com/cyverse/go-irodsclient/irods/common"
+	irodsclient_irodsfs "github.com/cyverse/go-irodsclient/irods/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	irodsclient_util "github.com/cyverse/go-irodsclient/irods/util"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+const (
+	encryptTransferBufferSize int = 1024 * 1024 // 1MB
+)
+
+// UploadFileEncrypted encrypts a local file while uploading it, without writing encrypted data to a temp file.
+// Ranges of the file are uploaded in parallel if the encryption mode supports range access and taskNum is not 1, taskNum 0 is for auto.
+func UploadFileEncrypted(fs *irodsclient_fs.FileSystem, manager *EncryptionManager, localPath string, irodsPath string, taskNum int, checksum bool, callback irodsclient_common.TrackerCallBack) (*irodsclient_fs.FileTransferResult, error) {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"function": "UploadFileEncrypted",
+	})
+
+	result := &irodsclient_fs.FileTransferResult{
+		LocalPath: localPath,
+		IRODSPath: irodsPath,
+		StartTime: time.Now(),
+	}
+
+	stat, err := os.Stat(localPath)
+	if err != nil {
+		return result, xerrors.Errorf("failed to stat %q: %w", localPath, err)
+	}
+
+	result.LocalSize = stat.Size()
+
+	sourceFileHandle, err := os.Open(localPath)
+	if err != nil {
+		return result, xerrors.Errorf("failed to open file %q: %w", localPath, err)
+	}
+
+	defer sourceFileHandle.Close()
+
+	// creating the data object first keeps cache of the filesystem valid after uploading ranges at low level
+	targetFileHandle, err := fs.CreateFile(irodsPath, "", "w")
+	if err != nil {
+		return result, xerrors.Errorf("failed to create data object %q: %w", irodsPath, err)
+	}
+
+	if taskNum != 1 && manager.SupportRangeAccess() && fs.SupportParallelUpload() {
+		ctrCipher, err := manager.NewCTRCipher()
+		if err != nil {
+			targetFileHandle.Close()
+			return result, err
+		}
+
+		encryptedSize := ctrCipher.GetEncryptedSize(stat.Size())
+		if taskNum <= 0 {
+			taskNum = irodsclient_util.GetNumTasksForParallelTransfer(encryptedSize)
+		}
+
+		if taskNum > 1 {
+			err = targetFileHandle.Close()
+			if err != nil {
+				return result, xerrors.Errorf("failed to close data object %q: %w", irodsPath, err)
+			}
+
+			logger.Debugf("uploading an encrypted file %q to %q in %d ranges", localPath, irodsPath, taskNum)
+
+			reader := ctrCipher.NewEncryptReaderAt(sourceFileHandle, stat.Size())
+			err = uploadRangesParallel(fs, reader, encryptedSize, irodsPath, taskNum, callback)
+			if err != nil {
+				return result, xerrors.Errorf("failed to upload encrypted file %q to %q: %w", localPath, irodsPath, err)
+			}
+
+			return finishEncryptedUpload(fs, result, checksum)
+		}
+	}
+
+	logger.Debugf("uploading an encrypted file %q to %q", localPath, irodsPath)
+
+	writer, err := manager.NewEncryptWriter(targetFileHandle)
+	if err != nil {
+		targetFileHandle.Close()
+		return result, err
+	}
+
+	reader := &trackingReader{
+		reader:   sourceFileHandle,
+		total:    stat.Size(),
+		callback: callback,
+	}
+
+	_, err = io.CopyBuffer(writer, reader, make([]byte, encryptTransferBufferSize))
+	if err != nil {
+		targetFileHandle.Close()
+		return result, xerrors.Errorf("failed to upload encrypted file %q to %q: %w", localPath, irodsPath, err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		targetFileHandle.Close()
+		return result, xerrors.Errorf("failed to finish encryption of %q: %w", localPath, err)
+	}
+
+	err = targetFileHandle.Close()
+	if err != nil {
+		return result, xerrors.Errorf("failed to close data object %q: %w", irodsPath, err)
+	}
+
+	return finishEncryptedUpload(fs, result, checksum)
+}
+
+func finishEncryptedUpload(fs *irodsclient_fs.FileSystem, result *irodsclient_fs.FileTransferResult, checksum bool) (*irodsclient_fs.FileTransferResult, error) {
+	if checksum {
+		conn, err := fs.GetMetadataConnection()
+		if err != nil {
+			return result, xerrors.Errorf("failed to get connection: %w", err)
+		}
+
+		_, err = irodsclient_irodsfs.GetDataObjectChecksum(conn, result.IRODSPath, "")
+		fs.ReturnMetadataConnection(conn) //nolint
+		if err != nil {
+			return result, xerrors.Errorf("failed to calculate checksum of %q: %w", result.IRODSPath, err)
+		}
+	}
+
+	entry, err := fs.StatFile(result.IRODSPath)
+	if err != nil {
+		return result, xerrors.Errorf("failed to stat %q: %w", result.IRODSPath, err)
+	}
+
+	result.IRODSSize = entry.Size
+	result.IRODSCheckSumAlgorithm = entry.CheckSumAlgorithm
+	result.IRODSCheckSum = entry.CheckSum
+	result.EndTime = time.Now()
+
+	return result, nil
+}
+
+// uploadRangesParallel uploads ranges of the reader to the data object in parallel, using a replica token shared by all ranges
+func uploadRangesParallel(fs *irodsclient_fs.FileSystem, reader io.ReaderAt, size int64, irodsPath string, taskNum int, callback irodsclient_common.TrackerCallBack) error {
+	conn, err := fs.GetIOConnection()
+	if err != nil {
+		return xerrors.Errorf("failed to get connection: %w", err)
+	}
+	defer fs.ReturnIOConnection(conn) //nolint
+
+	keywords := map[irodsclient_common.KeyWord]string{}
+	handle, err := irodsclient_irodsfs.OpenDataObjectForPutParallel(conn, irodsPath, "", "w+", irodsclient_common.OPER_TYPE_NONE, taskNum, size, keywords)
+	if err != nil {
+		return xerrors.Errorf("failed to open data object %q: %w", irodsPath, err)
+	}
+
+	replicaToken, resourceHierarchy, err := irodsclient_irodsfs.GetReplicaAccessInfo(conn, handle)
+	if err != nil {
+		irodsclient_irodsfs.CloseDataObject(conn, handle) //nolint
+		return xerrors.Errorf("failed to get replica access info of %q: %w", irodsPath, err)
+	}
+
+	uploaded := int64(0)
+	if callback != nil {
+		callback(0, size)
+	}
+
+	uploadRange := func(offset int64, length int64) error {
+		taskConn, err := fs.GetIOConnection()
+		if err != nil {
+			return xerrors.Errorf("failed to get connection: %w", err)
+		}
+		defer fs.ReturnIOConnection(taskConn) //nolint
+
+		taskHandle, _, err := irodsclient_irodsfs.OpenDataObjectWithReplicaToken(taskConn, irodsPath, "", "w", replicaToken, resourceHierarchy, taskNum, size, keywords)
+		if err != nil {
+			return xerrors.Errorf("failed to open data object %q with replica token: %w", irodsPath, err)
+		}
+
+		newOffset, err := irodsclient_irodsfs.SeekDataObject(taskConn, taskHandle, offset, irodsclient_types.SeekSet)
+		if err != nil || newOffset != offset {
+			irodsclient_irodsfs.CloseDataObjectReplica(taskConn, taskHandle) //nolint
+			return xerrors.Errorf("failed to seek to offset %d: %w", offset, err)
+		}
+
+		buffer := make([]byte, encryptTransferBufferSize)
+		for remain := length; remain > 0; {
+			bufferLen := int64(len(buffer))
+			if remain < bufferLen {
+				bufferLen = remain
+			}
+
+			readLen, readErr := reader.ReadAt(buffer[:bufferLen], offset+length-remain)
+			if readLen > 0 {
+				err = irodsclient_irodsfs.WriteDataObject(taskConn, taskHandle, buffer[:readLen])
+				if err != nil {
+					irodsclient_irodsfs.CloseDataObjectReplica(taskConn, taskHandle) //nolint
+					return xerrors.Errorf("failed to write data object %q: %w", irodsPath, err)
+				}
+
+				remain -= int64(readLen)
+				processed := atomic.AddInt64(&uploaded, int64(readLen))
+				if callback != nil {
+					callback(processed, size)
+				}
+			}
+
+			if readErr != nil {
+				if readErr == io.EOF {
+					break
+				}
+
+				irodsclient_irodsfs.CloseDataObjectReplica(taskConn, taskHandle) //nolint
+				return xerrors.Errorf("failed to read source: %w", readErr)
+			}
+		}
+
+		return irodsclient_irodsfs.CloseDataObjectReplica(taskConn, taskHandle)
+	}
+
+	errs := runRangesParallel(size, taskNum, uploadRange)
+	if len(errs) > 0 {
+		irodsclient_irodsfs.CloseDataObject(conn, handle) //nolint
+		return errs[0]
+	}
+
+	err = irodsclient_irodsfs.CloseDataObject(conn, handle)
+	if err != nil {
+		return xerrors.Errorf("failed to close data object %q: %w", irodsPath, err)
+	}
+
+	return nil
+}
+
+// DownloadFileDecrypted decrypts a data object while downloading it, without writing encrypted data to a temp file.
+// Ranges of the data object are downloaded in parallel if the encryption mode supports range access and taskNum is not 1, taskNum 0 is for auto.
+func DownloadFileDecrypted(fs *irodsclient_fs.FileSystem, manager *EncryptionManager, sourceEntry *irodsclient_fs.Entry, localPath string, taskNum int, callback irodsclient_common.TrackerCallBack) (*irodsclient_fs.FileTransferResult, error) {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"function": "DownloadFileDecrypted",
+	})
+
+	result := &irodsclient_fs.FileTransferResult{
+		IRODSPath:              sourceEntry.Path,
+		IRODSSize:              sourceEntry.Size,
+		IRODSCheckSumAlgorithm: sourceEntry.CheckSumAlgorithm,
+		IRODSCheckSum:          sourceEntry.CheckSum,
+		LocalPath:              localPath,
+		StartTime:              time.Now(),
+	}
+
+	sourceFileHandle, err := fs.OpenFile(sourceEntry.Path, "", "r")
+	if err != nil {
+		return result, xerrors.Errorf("failed to open data object %q: %w", sourceEntry.Path, err)
+	}
+
+	defer sourceFileHandle.Close()
+
+	targetFileHandle, err := os.OpenFile(localPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return result, xerrors.Errorf("failed to create file %q: %w", localPath, err)
+	}
+
+	defer targetFileHandle.Close()
+
+	if taskNum != 1 && manager.SupportRangeAccess() {
+		if taskNum <= 0 {
+			taskNum = irodsclient_util.GetNumTasksForParallelTransfer(sourceEntry.Size)
+		}
+
+		if taskNum > 1 {
+			ctrCipher, err := manager.ReadCTRCipher(sourceFileHandle)
+			if err != nil {
+				return result, xerrors.Errorf("failed to read encryption header of %q: %w", sourceEntry.Path, err)
+			}
+
+			if ctrCipher != nil {
+				logger.Debugf("downloading an encrypted data object %q to %q in %d ranges", sourceEntry.Path, localPath, taskNum)
+
+				contentSize := sourceEntry.Size - ctrCipher.GetHeaderSize()
+				err = downloadRangesParallel(fs, ctrCipher, sourceEntry.Path, contentSize, targetFileHandle, taskNum, callback)
+				if err != nil {
+					return result, xerrors.Errorf("failed to download encrypted data object %q to %q: %w", sourceEntry.Path, localPath, err)
+				}
+
+				result.LocalSize = contentSize
+			}
+
+			result.EndTime = time.Now()
+			return result, nil
+		}
+	}
+
+	logger.Debugf("downloading an encrypted data object %q to %q", sourceEntry.Path, localPath)
+
+	tracker := &trackingReader{
+		reader:   sourceFileHandle,
+		total:    sourceEntry.Size,
+		callback: callback,
+	}
+
+	reader, err := manager.NewDecryptReader(tracker)
+	if err != nil {
+		return result, xerrors.Errorf("failed to decrypt %q: %w", sourceEntry.Path, err)
+	}
+
+	written, err := io.CopyBuffer(targetFileHandle, reader, make([]byte, encryptTransferBufferSize))
+	if err != nil {
+		return result, xerrors.Errorf("failed to download encrypted data object %q to %q: %w", sourceEntry.Path, localPath, err)
+	}
+
+	result.LocalSize = written
+	result.EndTime = time.Now()
+	return result, nil
+}
+
+// downloadRangesParallel downloads ranges of content of the data object in parallel, each range with its own file handle
+func downloadRangesParallel(fs *irodsclient_fs.FileSystem, ctrCipher *CTRCipher, irodsPath string, contentSize int64, writer io.WriterAt, taskNum int, callback irodsclient_common.TrackerCallBack) error {
+	downloaded := int64(0)
+	if callback != nil {
+		callback(0, contentSize)
+	}
+
+	downloadRange := func(offset int64, length int64) error {
+		handle, err := fs.OpenFile(irodsPath, "", "r")
+		if err != nil {
+			return xerrors.Errorf("failed to open data object %q: %w", irodsPath, err)
+		}
+		defer handle.Close()
+
+		reader := ctrCipher.NewDecryptReaderAt(handle)
+
+		buffer := make([]byte, encryptTransferBufferSize)
+		for remain := length; remain > 0; {
+			bufferLen := int64(len(buffer))
+			if remain < bufferLen {
+				bufferLen = remain
+			}
+
+			rangeOffset := offset + length - remain
+			readLen, readErr := reader.ReadAt(buffer[:bufferLen], rangeOffset)
+			if readLen > 0 {
+				_, err = writer.WriteAt(buffer[:readLen], rangeOffset)
+				if err != nil {
+					return xerrors.Errorf("failed to write: %w", err)
+				}
+
+				remain -= int64(readLen)
+				processed := atomic.AddInt64(&downloaded, int64(readLen))
+				if callback != nil {
+					callback(processed, contentSize)
+				}
+			}
+
+			if readErr != nil {
+				if readErr == io.EOF {
+					break
+				}
+
+				return xerrors.Errorf("failed to read data object %q: %w", irodsPath, readErr)
+			}
+		}
+
+		return nil
+	}
+
+	errs := runRangesParallel(contentSize, taskNum, downloadRange)
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
+	return nil
+}
+
+// runRangesParallel splits the size into ranges and runs the task for each range in parallel, returns errors of tasks
+func runRangesParallel(size int64, taskNum int, task func(offset int64, length int64) error) []error {
+	lengthPerTask := size / int64(taskNum)
+	if size%int64(taskNum) > 0 {
+		lengthPerTask++
+	}
+
+	errs := []error{}
+	errsMutex := sync.Mutex{}
+	waitGroup := sync.WaitGroup{}
+
+	for offset := int64(0); offset < size; offset += lengthPerTask {
+		length := lengthPerTask
+		if offset+length > size {
+			length = size - offset
+		}
+
+		waitGroup.Add(1)
+		go func(offset int64, length int64) {
+			defer waitGroup.Done()
+
+			err := task(offset, length)
+			if err != nil {
+				errsMutex.Lock()
+				errs = append(errs, err)
+				errsMutex.Unlock()
+			}
+		}(offset, length)
+	}
+
+	waitGroup.Wait()
+	return errs
+}
+
+// trackingReader reports bytes read to the callback
+type trackingReader struct {
+	reader    io.Reader
+	processed int64
+	total     int64
+	callback  irodsclient_common.TrackerCallBack
+}
+
+func (reader *trackingReader) Read(buffer []byte) (int, error) {
+	readLen, err := reader.reader.Read(buffer)
+	if readLen > 0 {
+		reader.processed += int64(readLen)
+		if reader.callback != nil {
+			reader.callback(reader.processed, reader.total)
+		}
+	}
+
+	return readLen, err
+}
@@ -6,7 +6,6 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 
 	"golang.org/x/xerrors"
@@ -86,104 +85,54 @@ func DecryptFilenameWinSCP(filename string, key []byte) (string, error) {
 	return string(decryptedFilename), nil
 }
 
-func EncryptFileWinSCP(source string, target string, key []byte) error {
-	sourceFileHandle, err := os.Open(source)
-	if err != nil {
-		return xerrors.Errorf("failed to open file %q: %w", source, err)
-	}
-
-	defer sourceFileHandle.Close()
-
-	targetFileHandle, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
-	if err != nil {
-		return xerrors.Errorf("failed to create file %q: %w", target, err)
-	}
-
-	defer targetFileHandle.Close()
-
-	stat, err := sourceFileHandle.Stat()
-	if err != nil {
-		return xerrors.Errorf("failed to stat file %q: %w", source, err)
-	}
-
-	if stat.Size() == 0 {
-		// empty file
-		return nil
-	}
-
-	// write header
-	_, err = targetFileHandle.Write([]byte(WinSCPAesCtrHeader))
-	if err != nil {
-		return xerrors.Errorf("failed to write header: %w", err)
-	}
-
-	// generate salt
-	// we should use static salt to keep the same file name
+// NewWinSCPCipher creates a cipher with a new random salt to encrypt file content
+func NewWinSCPCipher(key []byte) (*CTRCipher, error) {
 	salt := make([]byte, AesSaltLen)
-	_, err = rand.Read(salt)
+	_, err := rand.Read(salt)
 	// Note that err == nil only if we read len(b) bytes.
 	if err != nil {
-		return xerrors.Errorf("failed to read random data: %w", err)
+		return nil, xerrors.Errorf("failed to read random data: %w", err)
 	}
 
-	// write salt
-	_, err = targetFileHandle.Write(salt)
-	if err != nil {
-		return xerrors.Errorf("failed to write salt: %w", err)
-	}
+	header := make([]byte, len(WinSCPAesCtrHeader)+AesSaltLen)
+	copy(header, WinSCPAesCtrHeader)
+	copy(header[len(WinSCPAesCtrHeader):], salt)
 
-	err = EncryptAESCTRReaderWriter(sourceFileHandle, targetFileHandle, salt, key)
-	if err != nil {
-		return xerrors.Errorf("failed to encrypt file content: %w", err)
-	}
-
-	return nil
+	return NewCTRCipher(header, salt, key)
 }
 
-func DecryptFileWinSCP(source string, target string, key []byte) error {
-	sourceFileHandle, err := os.Open(source)
-	if err != nil {
-		return xerrors.Errorf("failed to open file %q: %w", source, err)
-	}
-
-	defer sourceFileHandle.Close()
-
-	targetFileHandle, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
-	if err != nil {
-		return xerrors.Errorf("failed to create file %q: %w", target, err)
-	}
-
-	defer targetFileHandle.Close()
-
-	header := make([]byte, 16)
+// ReadWinSCPCipher reads the header of encrypted file content to create a cipher, returns nil for empty content
+func ReadWinSCPCipher(reader io.Reader, key []byte) (*CTRCipher, error) {
+	header := make([]byte, len(WinSCPAesCtrHeader)+AesSaltLen)
 
-	readLen, err := sourceFileHandle.Read(header)
+	readLen, err := io.ReadFull(reader, header)
 	if err == io.EOF && readLen == 0 {
-		return nil
+		return nil, nil
 	}
 
 	if err != nil {
-		return xerrors.Errorf("failed to read AES CTR header: %w", err)
+		return nil, xerrors.Errorf("failed to read AES CTR header: %w", err)
 	}
 
-	if !bytes.Equal(header, []byte(WinSCPAesCtrHeader)) {
-		return xerrors.Errorf("failed to read AES CTR header")
+	if !bytes.Equal(header[:len(WinSCPAesCtrHeader)], []byte(WinSCPAesCtrHeader)) {
+		return nil, xerrors.Errorf("failed to read AES CTR header")
 	}
 
-	salt := make([]byte, AesSaltLen)
-	readLen, err = sourceFileHandle.Read(salt)
-	if err != nil {
-		return xerrors.Errorf("failed to read salt: %w", err)
-	}
-
-	if readLen != AesSaltLen {
-		return xerrors.Errorf("failed to read salt, read len %d: %w", readLen, err)
-	}
+	salt := header[len(WinSCPAesCtrHeader):]
+	return NewCTRCipher(header, salt, key)
+}
 
-	err = DecryptAESCTRReaderWriter(sourceFileHandle, targetFileHandle, salt, key)
+func EncryptFileWinSCP(source string, target string, key []byte) error {
+	ctrCipher, err := NewWinSCPCipher(key)
 	if err != nil {
-		return xerrors.Errorf("failed to decrypt file content: %w", err)
+		return err
 	}
 
-	return nil
+	return encryptFileCTR(source, target, ctrCipher)
+}
+
+func DecryptFileWinSCP(source string, target string, key []byte) error {
+	return decryptFileCTR(source, target, func(reader io.Reader) (*CTRCipher, error) {
+		return ReadWinSCPCipher(reader, key)
+	})
 }
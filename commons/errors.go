@@ -66,3 +66,46 @@ func (err *NotFileError) ToString() string {
 func IsNotFileError(err error) bool {
 	return errors.Is(err, &NotFileError{})
 }
+
+type PartialTransferError struct {
+	Done  int64
+	Total int64
+	Err   error
+}
+
+func NewPartialTransferError(done int64, total int64, err error) error {
+	return &PartialTransferError{
+		Done:  done,
+		Total: total,
+		Err:   err,
+	}
+}
+
+// Error returns error message
+func (err *PartialTransferError) Error() string {
+	if err.Err == nil {
+		return fmt.Sprintf("only %d of %d jobs were completed", err.Done, err.Total)
+	}
+	return fmt.Sprintf("only %d of %d jobs were completed: %s", err.Done, err.Total, err.Err.Error())
+}
+
+// Is tests type of error
+func (err *PartialTransferError) Is(other error) bool {
+	_, ok := other.(*PartialTransferError)
+	return ok
+}
+
+// Unwrap returns the error that stopped the rest of jobs
+func (err *PartialTransferError) Unwrap() error {
+	return err.Err
+}
+
+// ToString stringifies the object
+func (err *PartialTransferError) ToString() string {
+	return fmt.Sprintf("PartialTransferError: %d/%d", err.Done, err.Total)
+}
+
+// IsPartialTransferError evaluates if the given error is PartialTransferError
+func IsPartialTransferError(err error) bool {
+	return errors.Is(err, &PartialTransferError{})
+}
@@ -0,0 +1,271 @@
+package commons
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/progress"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+// EventType is a type of event emitted to follow transfers
+type EventType string
+
+const (
+	EventTypeScheduled   EventType = "scheduled"
+	EventTypeStarted     EventType = "started"
+	EventTypeProgress    EventType = "progress"
+	EventTypeCompleted   EventType = "completed"
+	EventTypeFailed      EventType = "failed"
+	EventTypeSkipped     EventType = "skipped"
+	EventTypeBundlePhase EventType = "bundle_phase"
+)
+
+const (
+	// progress events of a job are emitted at most once in the interval
+	eventProgressInterval time.Duration = time.Second
+)
+
+// Event is an event emitted as a line of JSON
+type Event struct {
+	Time      time.Time `json:"time"`
+	Type      EventType `json:"type"`
+	Name      string    `json:"name,omitempty"`
+	Target    string    `json:"target,omitempty"`
+	Processed int64     `json:"processed,omitempty"`
+	Total     int64     `json:"total,omitempty"`
+	Unit      string    `json:"unit,omitempty"`
+	Bundle    *int64    `json:"bundle,omitempty"`
+	Phase     string    `json:"phase,omitempty"`
+	Status    EventType `json:"status,omitempty"`
+	Notes     []string  `json:"notes,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// EventWriter writes events as newline-delimited JSON to a file, a file descriptor or a unix socket.
+// All methods do nothing on nil EventWriter, so it can be passed around when events are off.
+type EventWriter struct {
+	writer       io.WriteCloser
+	encoder      *json.Encoder
+	lastProgress map[string]time.Time
+	failed       bool
+	mutex        sync.Mutex
+}
+
+// NewEventWriter creates a new EventWriter.
+// Target is "fd:<number>" for a file descriptor, "unix:<path>" for a unix socket, or a file path.
+func NewEventWriter(target string) (*EventWriter, error) {
+	var writer io.WriteCloser
+
+	switch {
+	case strings.HasPrefix(target, "fd:"):
+		fd, err := strconv.Atoi(strings.TrimPrefix(target, "fd:"))
+		if err != nil || fd < 0 {
+			return nil, xerrors.Errorf("invalid file descriptor %q for events", target)
+		}
+
+		file := os.NewFile(uintptr(fd), target)
+		if file == nil {
+			return nil, xerrors.Errorf("invalid file descriptor %q for events", target)
+		}
+
+		writer = file
+		if fd <= 2 {
+			// keep stdin, stdout and stderr open on release
+			writer = nopWriteCloser{Writer: file}
+		}
+	case strings.HasPrefix(target, "unix:"):
+		conn, err := net.Dial("unix", strings.TrimPrefix(target, "unix:"))
+		if err != nil {
+			return nil, xerrors.Errorf("failed to connect to unix socket %q for events: %w", target, err)
+		}
+
+		writer = conn
+	default:
+		file, err := os.Create(target)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to create event file %q: %w", target, err)
+		}
+
+		writer = file
+	}
+
+	return &EventWriter{
+		writer:       writer,
+		encoder:      json.NewEncoder(writer),
+		lastProgress: map[string]time.Time{},
+	}, nil
+}
+
+// Release closes the event target
+func (writer *EventWriter) Release() {
+	if writer == nil {
+		return
+	}
+
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	writer.writer.Close()
+}
+
+// Write writes an event, the time is filled in if not set.
+// A write error stops emitting events without failing transfers, e.g., when a listener goes away.
+func (writer *EventWriter) Write(event *Event) {
+	if writer == nil {
+		return
+	}
+
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"struct":   "EventWriter",
+		"function": "Write",
+	})
+
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if writer.failed {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	err := writer.encoder.Encode(event)
+	if err != nil {
+		logger.WithError(err).Warn("failed to write event, stop emitting events")
+		writer.failed = true
+	}
+}
+
+// Job writes a lifecycle event of a job
+func (writer *EventWriter) Job(eventType EventType, name string, err error) {
+	if writer == nil {
+		return
+	}
+
+	event := &Event{
+		Type: eventType,
+		Name: name,
+	}
+
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	writer.Write(event)
+}
+
+// Progress writes a progress event of a job, it can be used as ProgressTrackerCallback.
+// Progress events of a job are throttled, but the first and the last are always written.
+func (writer *EventWriter) Progress(name string, processed int64, total int64, progressUnit progress.Units, errored bool) {
+	if writer == nil || errored || processed < 0 {
+		return
+	}
+
+	now := time.Now()
+	last := processed >= total
+
+	writer.mutex.Lock()
+	lastTime, ok := writer.lastProgress[name]
+	if ok && !last && now.Sub(lastTime) < eventProgressInterval {
+		writer.mutex.Unlock()
+		return
+	}
+
+	if last {
+		delete(writer.lastProgress, name)
+	} else {
+		writer.lastProgress[name] = now
+	}
+	writer.mutex.Unlock()
+
+	unit := "count"
+	if isProgressUnitsBytes(progressUnit) {
+		unit = "bytes"
+	}
+
+	writer.Write(&Event{
+		Time:      now,
+		Type:      EventTypeProgress,
+		Name:      name,
+		Processed: processed,
+		Total:     total,
+		Unit:      unit,
+	})
+}
+
+// Skipped writes an event of a file transfer skipped
+func (writer *EventWriter) Skipped(file *TransferReportFile) {
+	if writer == nil {
+		return
+	}
+
+	writer.Write(&Event{
+		Type:   EventTypeSkipped,
+		Name:   file.SourcePath,
+		Target: file.DestPath,
+		Notes:  file.Notes,
+	})
+}
+
+// BundlePhase writes an event of a bundle entering or leaving a phase
+func (writer *EventWriter) BundlePhase(bundle *Bundle, taskName string, status EventType, err error) {
+	if writer == nil {
+		return
+	}
+
+	index := bundle.Index
+	event := &Event{
+		Type:   EventTypeBundlePhase,
+		Name:   bundle.IRODSBundlePath,
+		Bundle: &index,
+		Phase:  getBundlePhase(taskName),
+		Status: status,
+		Total:  int64(len(bundle.Entries)),
+		Unit:   "count",
+	}
+
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	writer.Write(event)
+}
+
+// getBundlePhase returns a short name of the bundle task for events
+func getBundlePhase(taskName string) string {
+	switch taskName {
+	case BundleTaskNameRemoveFilesAndMakeDirs:
+		return "prepare"
+	case BundleTaskNameTar:
+		return "tar"
+	case BundleTaskNameUpload:
+		return "upload"
+	case BundleTaskNameExtract:
+		return "extract"
+	case BundleTaskNameStage:
+		return "stage"
+	case BundleTaskNameDownload:
+		return "download"
+	default:
+		return strings.ToLower(taskName)
+	}
+}
@@ -0,0 +1,111 @@
+package commons
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jedib0t/go-pretty/v6/progress"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
+)
+
+func TestEvent(t *testing.T) {
+	t.Run("test EventWriterFile", testEventWriterFile)
+	t.Run("test EventWriterUnixSocket", testEventWriterUnixSocket)
+	t.Run("test NilEventWriter", testNilEventWriter)
+}
+
+func readTestEvents(t *testing.T, data string) []*Event {
+	events := []*Event{}
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		event := &Event{}
+		err := json.Unmarshal([]byte(line), event)
+		assert.NoError(t, err)
+		events = append(events, event)
+	}
+	return events
+}
+
+func testEventWriterFile(t *testing.T) {
+	eventPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	writer, err := NewEventWriter(eventPath)
+	assert.NoError(t, err)
+
+	writer.Job(EventTypeScheduled, "/a", nil)
+	writer.Job(EventTypeStarted, "/a", nil)
+	writer.Progress("/a", 0, 100, progress.UnitsBytes, false)
+	// throttled
+	writer.Progress("/a", 50, 100, progress.UnitsBytes, false)
+	writer.Progress("/a", 100, 100, progress.UnitsBytes, false)
+	writer.Job(EventTypeCompleted, "/a", nil)
+	writer.Job(EventTypeFailed, "/b", xerrors.Errorf("failed to upload"))
+	writer.Skipped(&TransferReportFile{SourcePath: "/c", DestPath: "/zone/c", Notes: []string{"skip"}})
+	writer.Release()
+
+	data, err := os.ReadFile(eventPath)
+	assert.NoError(t, err)
+
+	events := readTestEvents(t, string(data))
+	assert.Len(t, events, 7)
+
+	types := []EventType{}
+	for _, event := range events {
+		types = append(types, event.Type)
+		assert.False(t, event.Time.IsZero())
+	}
+	assert.Equal(t, []EventType{EventTypeScheduled, EventTypeStarted, EventTypeProgress, EventTypeProgress, EventTypeCompleted, EventTypeFailed, EventTypeSkipped}, types)
+
+	assert.Equal(t, int64(100), events[3].Processed)
+	assert.Equal(t, "bytes", events[3].Unit)
+	assert.Equal(t, "failed to upload", events[5].Error)
+	assert.Equal(t, "/zone/c", events[6].Target)
+}
+
+func testEventWriterUnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "events.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan string)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			received <- ""
+			return
+		}
+		defer conn.Close()
+
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		received <- line
+	}()
+
+	writer, err := NewEventWriter("unix:" + socketPath)
+	assert.NoError(t, err)
+
+	writer.Job(EventTypeStarted, "/a", nil)
+
+	events := readTestEvents(t, <-received)
+	writer.Release()
+
+	assert.Len(t, events, 1)
+	assert.Equal(t, EventTypeStarted, events[0].Type)
+	assert.Equal(t, "/a", events[0].Name)
+}
+
+func testNilEventWriter(t *testing.T) {
+	var writer *EventWriter
+
+	assert.NotPanics(t, func() {
+		writer.Job(EventTypeStarted, "/a", nil)
+		writer.Progress("/a", 1, 2, progress.UnitsDefault, false)
+		writer.Release()
+	})
+}
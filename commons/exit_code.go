@@ -0,0 +1,184 @@
+package commons
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+)
+
+// ExitCode is an exit code of gocmd, telling which kind of error the command failed with
+type ExitCode int
+
+const (
+	ExitCodeSuccess            ExitCode = 0
+	ExitCodeGeneralError       ExitCode = 1
+	ExitCodeConnectionError    ExitCode = 2
+	ExitCodeAuthError          ExitCode = 3
+	ExitCodeNotFound           ExitCode = 4
+	ExitCodeAlreadyExist       ExitCode = 5
+	ExitCodeCollectionNotEmpty ExitCode = 6
+	ExitCodeInvalidTarget      ExitCode = 7
+	ExitCodeIRODSError         ExitCode = 8
+	ExitCodePartialTransfer    ExitCode = 9
+)
+
+// ClassifiedError is an error classified by its kind, printed to stderr with '--error_format json'
+type ClassifiedError struct {
+	ExitCode ExitCode `json:"code"`
+	Class    string   `json:"class"`
+	Path     string   `json:"path,omitempty"`
+	// Message is a user-friendly message
+	Message string `json:"message"`
+	// Detail is the full error chain
+	Detail         string `json:"detail"`
+	IRODSErrorCode int    `json:"irods_error_code,omitempty"`
+}
+
+// ClassifyError classifies the error to pick an exit code and a user-friendly message
+func ClassifyError(err error) *ClassifiedError {
+	classified := &ClassifiedError{
+		ExitCode: ExitCodeGeneralError,
+		Class:    "unexpected",
+		Path:     getErrorPath(err),
+		Message:  "Unexpected error!",
+		Detail:   err.Error(),
+	}
+
+	if code := irodsclient_types.GetIRODSErrorCode(err); code != 0 {
+		classified.IRODSErrorCode = int(code)
+	}
+
+	// partial transfer is checked first as it wraps the error that stopped the rest of jobs
+	var partialTransferError *PartialTransferError
+	var connectionConfigError *irodsclient_types.ConnectionConfigError
+	var connectionPoolFullError *irodsclient_types.ConnectionPoolFullError
+	var authError *irodsclient_types.AuthError
+	var ticketNotFoundError *irodsclient_types.TicketNotFoundError
+	var userNotFoundError *irodsclient_types.UserNotFoundError
+	var irodsError *irodsclient_types.IRODSError
+
+	switch {
+	case errors.As(err, &partialTransferError):
+		classified.ExitCode = ExitCodePartialTransfer
+		classified.Class = "partial_transfer"
+		classified.Message = fmt.Sprintf("Only %d of %d jobs were completed!", partialTransferError.Done, partialTransferError.Total)
+	case errors.Is(err, os.ErrNotExist):
+		classified.ExitCode = ExitCodeNotFound
+		classified.Class = "not_found"
+		classified.Message = "File or directory not found!"
+	case errors.As(err, &connectionConfigError):
+		classified.ExitCode = ExitCodeConnectionError
+		classified.Class = "connection_error"
+		classified.Message = fmt.Sprintf("Failed to establish a connection to iRODS server (host: %q, port: %d)!", connectionConfigError.Config.Host, connectionConfigError.Config.Port)
+	case irodsclient_types.IsConnectionConfigError(err), irodsclient_types.IsConnectionError(err):
+		classified.ExitCode = ExitCodeConnectionError
+		classified.Class = "connection_error"
+		classified.Message = "Failed to establish a connection to iRODS server!"
+	case errors.As(err, &connectionPoolFullError):
+		classified.ExitCode = ExitCodeConnectionError
+		classified.Class = "connection_error"
+		classified.Message = fmt.Sprintf("Failed to establish a new connection to iRODS server as connection pool is full (occupied: %d, max: %d)!", connectionPoolFullError.Occupied, connectionPoolFullError.Max)
+	case irodsclient_types.IsConnectionPoolFullError(err):
+		classified.ExitCode = ExitCodeConnectionError
+		classified.Class = "connection_error"
+		classified.Message = "Failed to establish a new connection to iRODS server as connection pool is full!"
+	case errors.As(err, &authError):
+		classified.ExitCode = ExitCodeAuthError
+		classified.Class = "auth_error"
+		classified.Message = fmt.Sprintf("Authentication failed (auth scheme: %q, username: %q, zone: %q)!", authError.Config.AuthenticationScheme, authError.Config.ClientUser, authError.Config.ClientZone)
+	case irodsclient_types.IsAuthError(err):
+		classified.ExitCode = ExitCodeAuthError
+		classified.Class = "auth_error"
+		classified.Message = "Authentication failed!"
+	case irodsclient_types.IsFileNotFoundError(err):
+		classified.ExitCode = ExitCodeNotFound
+		classified.Class = "not_found"
+		classified.Message = "File or directory is not found!"
+		if len(classified.Path) > 0 {
+			classified.Message = fmt.Sprintf("File or directory %q is not found!", classified.Path)
+		}
+	case irodsclient_types.IsCollectionNotEmptyError(err):
+		classified.ExitCode = ExitCodeCollectionNotEmpty
+		classified.Class = "collection_not_empty"
+		classified.Message = "Directory is not empty!"
+		if len(classified.Path) > 0 {
+			classified.Message = fmt.Sprintf("Directory %q is not empty!", classified.Path)
+		}
+	case irodsclient_types.IsFileAlreadyExistError(err):
+		classified.ExitCode = ExitCodeAlreadyExist
+		classified.Class = "already_exist"
+		classified.Message = "File or directory already exists!"
+		if len(classified.Path) > 0 {
+			classified.Message = fmt.Sprintf("File or directory %q already exists!", classified.Path)
+		}
+	case errors.As(err, &ticketNotFoundError):
+		classified.ExitCode = ExitCodeNotFound
+		classified.Class = "not_found"
+		classified.Message = fmt.Sprintf("Ticket %q is not found!", ticketNotFoundError.Ticket)
+	case errors.As(err, &userNotFoundError):
+		classified.ExitCode = ExitCodeNotFound
+		classified.Class = "not_found"
+		classified.Message = fmt.Sprintf("User %q is not found!", userNotFoundError.Name)
+	case errors.As(err, &irodsError):
+		classified.ExitCode = ExitCodeIRODSError
+		classified.Class = "irods_error"
+		classified.Message = fmt.Sprintf("iRODS Error (code: '%d', message: %q)", irodsError.Code, irodsError.Error())
+	case irodsclient_types.IsIRODSError(err):
+		classified.ExitCode = ExitCodeIRODSError
+		classified.Class = "irods_error"
+		classified.Message = "iRODS Error!"
+	case IsNotDirError(err):
+		classified.ExitCode = ExitCodeInvalidTarget
+		classified.Class = "not_dir"
+		classified.Message = "Destination is not a directory!"
+		if len(classified.Path) > 0 {
+			classified.Message = fmt.Sprintf("Destination %q is not a directory!", classified.Path)
+		}
+	case IsNotFileError(err):
+		classified.ExitCode = ExitCodeInvalidTarget
+		classified.Class = "not_file"
+		classified.Message = "Destination is not a file!"
+		if len(classified.Path) > 0 {
+			classified.Message = fmt.Sprintf("Destination %q is not a file!", classified.Path)
+		}
+	}
+
+	return classified
+}
+
+// getErrorPath returns a path the error is about, or empty string if unknown
+func getErrorPath(err error) string {
+	var pathError *os.PathError
+	if errors.As(err, &pathError) {
+		return pathError.Path
+	}
+
+	var fileNotFoundError *irodsclient_types.FileNotFoundError
+	if errors.As(err, &fileNotFoundError) {
+		return fileNotFoundError.Path
+	}
+
+	var collectionNotEmptyError *irodsclient_types.CollectionNotEmptyError
+	if errors.As(err, &collectionNotEmptyError) {
+		return collectionNotEmptyError.Path
+	}
+
+	var fileAlreadyExistError *irodsclient_types.FileAlreadyExistError
+	if errors.As(err, &fileAlreadyExistError) {
+		return fileAlreadyExistError.Path
+	}
+
+	var notDirError *NotDirError
+	if errors.As(err, &notDirError) {
+		return notDirError.Path
+	}
+
+	var notFileError *NotFileError
+	if errors.As(err, &notFileError) {
+		return notFileError.Path
+	}
+
+	return ""
+}
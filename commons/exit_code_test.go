@@ -0,0 +1,39 @@
+package commons
+
+import (
+	"os"
+	"testing"
+
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
+)
+
+func TestExitCode(t *testing.T) {
+	t.Run("test ClassifyError", testClassifyError)
+}
+
+func testClassifyError(t *testing.T) {
+	notFoundErr := xerrors.Errorf("failed to stat: %w", irodsclient_types.NewFileNotFoundError("/zone/home/user/a.txt"))
+	classified := ClassifyError(notFoundErr)
+	assert.Equal(t, ExitCodeNotFound, classified.ExitCode)
+	assert.Equal(t, "/zone/home/user/a.txt", classified.Path)
+
+	_, statErr := os.Stat("/nonexistent/gocommands/test")
+	classified = ClassifyError(xerrors.Errorf("failed to stat local file: %w", statErr))
+	assert.Equal(t, ExitCodeNotFound, classified.ExitCode)
+	assert.Equal(t, "/nonexistent/gocommands/test", classified.Path)
+
+	// partial transfer wins over the error that stopped the rest of jobs
+	partialErr := xerrors.Errorf("failed to perform parallel jobs: %w", NewPartialTransferError(3, 5, notFoundErr))
+	classified = ClassifyError(partialErr)
+	assert.Equal(t, ExitCodePartialTransfer, classified.ExitCode)
+	assert.Equal(t, "/zone/home/user/a.txt", classified.Path)
+
+	classified = ClassifyError(NewNotDirError("/local/dir"))
+	assert.Equal(t, ExitCodeInvalidTarget, classified.ExitCode)
+
+	classified = ClassifyError(xerrors.Errorf("something went wrong"))
+	assert.Equal(t, ExitCodeGeneralError, classified.ExitCode)
+	assert.Empty(t, classified.Path)
+}
@@ -69,6 +69,27 @@ func GetIRODSFSClientForLargeFileIO(account *irodsclient_types.IRODSAccount, max
 	return irodsclient_fs.NewFileSystem(account, fsConfig)
 }
 
+// GetIRODSFSClientForMetadataOperation returns a file system client for concurrent metadata operations
+func GetIRODSFSClientForMetadataOperation(account *irodsclient_types.IRODSAccount, maxMetadataConnection int) (*irodsclient_fs.FileSystem, error) {
+	fsConfig := irodsclient_fs.NewFileSystemConfig(ClientProgramName)
+
+	// set operation time out
+	fsConfig.MetadataConnection.OperationTimeout = FilesystemTimeout
+	fsConfig.IOConnection.OperationTimeout = FilesystemTimeout
+
+	// max connection for metadata
+	if maxMetadataConnection < irodsclient_fs.FileSystemMetadataConnectionMaxNumberDefault {
+		maxMetadataConnection = irodsclient_fs.FileSystemMetadataConnectionMaxNumberDefault
+	}
+	fsConfig.MetadataConnection.MaxNumber = maxMetadataConnection
+
+	// set tcp buffer size
+	fsConfig.MetadataConnection.TCPBufferSize = TCPBufferSizeDefault
+	fsConfig.IOConnection.TCPBufferSize = TCPBufferSizeDefault
+
+	return irodsclient_fs.NewFileSystem(account, fsConfig)
+}
+
 // GetIRODSConnection returns a connection
 func GetIRODSConnection(account *irodsclient_types.IRODSAccount) (*irodsclient_conn.IRODSConnection, error) {
 	conn := irodsclient_conn.NewIRODSConnection(account, time.Duration(FilesystemTimeout), ClientProgramName)
@@ -0,0 +1,176 @@
+package commons
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v3"
+)
+
+// MetadataFileFormat determines the format of a metadata file
+type MetadataFileFormat string
+
+const (
+	// MetadataFileFormatCSV is for CSV, rows of path,attribute,value,unit
+	MetadataFileFormatCSV MetadataFileFormat = "csv"
+	// MetadataFileFormatJSON is for JSON, an array of rows
+	MetadataFileFormatJSON MetadataFileFormat = "json"
+	// MetadataFileFormatYAML is for YAML, a list of rows
+	MetadataFileFormatYAML MetadataFileFormat = "yaml"
+	// MetadataFileFormatUnknown is for unknown format
+	MetadataFileFormatUnknown MetadataFileFormat = ""
+)
+
+var (
+	metadataCSVHeader = []string{"path", "attribute", "value", "unit"}
+)
+
+// MetadataRow is an AVU of a data object or a collection
+type MetadataRow struct {
+	Path      string `json:"path" yaml:"path"`
+	Attribute string `json:"attribute" yaml:"attribute"`
+	Value     string `json:"value" yaml:"value"`
+	Unit      string `json:"unit,omitempty" yaml:"unit,omitempty"`
+}
+
+// GetMetadataFileFormat returns MetadataFileFormat from string
+func GetMetadataFileFormat(format string) MetadataFileFormat {
+	switch strings.ToLower(format) {
+	case string(MetadataFileFormatCSV):
+		return MetadataFileFormatCSV
+	case string(MetadataFileFormatJSON):
+		return MetadataFileFormatJSON
+	case string(MetadataFileFormatYAML), "yml":
+		return MetadataFileFormatYAML
+	default:
+		return MetadataFileFormatUnknown
+	}
+}
+
+// DetectMetadataFileFormat detects the format of a metadata file from its extension
+func DetectMetadataFileFormat(p string) MetadataFileFormat {
+	ext := strings.TrimPrefix(filepath.Ext(p), ".")
+	return GetMetadataFileFormat(ext)
+}
+
+// Validate checks if the row has all mandatory fields
+func (row *MetadataRow) Validate() error {
+	if len(row.Path) == 0 {
+		return xerrors.Errorf("path is empty")
+	}
+
+	if len(row.Attribute) == 0 {
+		return xerrors.Errorf("attribute is empty")
+	}
+
+	if len(row.Value) == 0 {
+		return xerrors.Errorf("value is empty")
+	}
+
+	return nil
+}
+
+// ReadMetadataRows reads metadata rows in the given format
+func ReadMetadataRows(reader io.Reader, format MetadataFileFormat) ([]*MetadataRow, error) {
+	switch format {
+	case MetadataFileFormatCSV:
+		return readMetadataRowsCSV(reader)
+	case MetadataFileFormatJSON:
+		rows := []*MetadataRow{}
+		err := json.NewDecoder(reader).Decode(&rows)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode metadata json: %w", err)
+		}
+		return rows, nil
+	case MetadataFileFormatYAML:
+		rows := []*MetadataRow{}
+		err := yaml.NewDecoder(reader).Decode(&rows)
+		if err != nil && err != io.EOF {
+			return nil, xerrors.Errorf("failed to decode metadata yaml: %w", err)
+		}
+		return rows, nil
+	default:
+		return nil, xerrors.Errorf("unknown metadata file format %q", format)
+	}
+}
+
+func readMetadataRowsCSV(reader io.Reader) ([]*MetadataRow, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read metadata csv: %w", err)
+	}
+
+	rows := []*MetadataRow{}
+	for idx, record := range records {
+		if idx == 0 && len(record) > 0 && strings.ToLower(record[0]) == metadataCSVHeader[0] {
+			// header
+			continue
+		}
+
+		// extra columns, such as errors in an error report, are ignored
+		if len(record) < 3 {
+			return nil, xerrors.Errorf("malformed metadata csv at line %d, expected path,attribute,value[,unit]", idx+1)
+		}
+
+		row := &MetadataRow{
+			Path:      record[0],
+			Attribute: record[1],
+			Value:     record[2],
+		}
+
+		if len(record) >= 4 {
+			row.Unit = record[3]
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// WriteMetadataRows writes metadata rows in the given format
+func WriteMetadataRows(writer io.Writer, format MetadataFileFormat, rows []*MetadataRow) error {
+	switch format {
+	case MetadataFileFormatCSV:
+		csvWriter := csv.NewWriter(writer)
+		err := csvWriter.Write(metadataCSVHeader)
+		if err != nil {
+			return xerrors.Errorf("failed to write metadata csv header: %w", err)
+		}
+
+		for _, row := range rows {
+			err = csvWriter.Write([]string{row.Path, row.Attribute, row.Value, row.Unit})
+			if err != nil {
+				return xerrors.Errorf("failed to write metadata csv: %w", err)
+			}
+		}
+
+		csvWriter.Flush()
+		return csvWriter.Error()
+	case MetadataFileFormatJSON:
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		err := encoder.Encode(rows)
+		if err != nil {
+			return xerrors.Errorf("failed to encode metadata json: %w", err)
+		}
+		return nil
+	case MetadataFileFormatYAML:
+		encoder := yaml.NewEncoder(writer)
+		err := encoder.Encode(rows)
+		if err != nil {
+			return xerrors.Errorf("failed to encode metadata yaml: %w", err)
+		}
+		return encoder.Close()
+	default:
+		return xerrors.Errorf("unknown metadata file format %q", format)
+	}
+}
@@ -0,0 +1,106 @@
+package commons
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataFile(t *testing.T) {
+	t.Run("test ReadCSV", testReadMetadataCSV)
+	t.Run("test RoundTrip", testMetadataRoundTrip)
+	t.Run("test ParseMetadataAssignment", testParseMetadataAssignment)
+	t.Run("test MetadataSidecar", testMetadataSidecar)
+}
+
+func testReadMetadataCSV(t *testing.T) {
+	input := "path,attribute,value,unit\n/zone/home/a.txt,project,alpha,\n/zone/home/b.txt, sample , 3\n"
+
+	rows, err := ReadMetadataRows(strings.NewReader(input), MetadataFileFormatCSV)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "/zone/home/a.txt", rows[0].Path)
+	assert.Equal(t, "project", rows[0].Attribute)
+	assert.Equal(t, "alpha", rows[0].Value)
+	assert.Equal(t, "", rows[0].Unit)
+	assert.Equal(t, "sample ", rows[1].Attribute)
+
+	_, err = ReadMetadataRows(strings.NewReader("/zone/home/a.txt,project\n"), MetadataFileFormatCSV)
+	assert.Error(t, err)
+}
+
+func testMetadataRoundTrip(t *testing.T) {
+	rows := []*MetadataRow{
+		{Path: "/zone/home/a.txt", Attribute: "project", Value: "alpha", Unit: "name"},
+		{Path: "/zone/home/dir", Attribute: "count, total", Value: "3"},
+	}
+
+	for _, format := range []MetadataFileFormat{MetadataFileFormatCSV, MetadataFileFormatJSON, MetadataFileFormatYAML} {
+		buffer := bytes.Buffer{}
+		err := WriteMetadataRows(&buffer, format, rows)
+		assert.NoError(t, err)
+
+		readRows, err := ReadMetadataRows(&buffer, format)
+		assert.NoError(t, err)
+		assert.Equal(t, rows, readRows)
+	}
+
+	assert.Equal(t, MetadataFileFormatYAML, DetectMetadataFileFormat("meta.yml"))
+	assert.Equal(t, MetadataFileFormatUnknown, DetectMetadataFileFormat("meta.txt"))
+}
+
+func testParseMetadataAssignment(t *testing.T) {
+	tests := []struct {
+		input     string
+		attribute string
+		value     string
+		unit      string
+	}{
+		{"project=alpha", "project", "alpha", ""},
+		{"length[cm]=30", "length", "30", "cm"},
+		{"time=10:30", "time", "10:30", ""},
+		{"source=https://example.org/data?x=1", "source", "https://example.org/data?x=1", ""},
+		{"created[utc]=2024-01-02T03:04:05Z", "created", "2024-01-02T03:04:05Z", "utc"},
+		{"note[]=a:b", "note", "a:b", ""},
+	}
+
+	for _, test := range tests {
+		row, err := ParseMetadataAssignment(test.input)
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.attribute, row.Attribute, test.input)
+		assert.Equal(t, test.value, row.Value, test.input)
+		assert.Equal(t, test.unit, row.Unit, test.input)
+	}
+
+	for _, input := range []string{"=alpha", "project=", "[cm]=30", "project", "length[cm]="} {
+		_, err := ParseMetadataAssignment(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func testMetadataSidecar(t *testing.T) {
+	tempDir, dataPaths := writeLocalTestFiles(t, map[string]string{"sample.txt": "sample"})
+	dataPath := dataPaths["sample.txt"]
+
+	rows, err := ReadMetadataSidecar(dataPath)
+	assert.NoError(t, err)
+	assert.Nil(t, rows)
+
+	sidecarPath := GetMetadataSidecarPath(dataPath)
+	assert.Equal(t, filepath.Join(tempDir, "sample.txt.meta.json"), sidecarPath)
+	assert.True(t, IsMetadataSidecarPath(sidecarPath))
+	assert.False(t, IsMetadataSidecarPath(filepath.Join(tempDir, "other.txt.meta.json")))
+
+	err = os.WriteFile(sidecarPath, []byte(`[{"path":"/zone/home/sample.txt","attribute":"project","value":"alpha","unit":""}]`), 0644)
+	assert.NoError(t, err)
+
+	rows, err = ReadMetadataSidecar(dataPath)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "project", rows[0].Attribute)
+	assert.Equal(t, "alpha", rows[0].Value)
+}
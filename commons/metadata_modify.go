@@ -0,0 +1,133 @@
+package commons
+
+import (
+	"fmt"
+	"strconv"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_message "github.com/cyverse/go-irodsclient/irods/message"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"golang.org/x/xerrors"
+)
+
+// SelectMetadata returns metadata matching the AVU ID or the attribute name
+func SelectMetadata(metas []*irodsclient_types.IRODSMeta, avuIDOrName string) []*irodsclient_types.IRODSMeta {
+	selected := []*irodsclient_types.IRODSMeta{}
+
+	if IsDigitsOnly(avuIDOrName) {
+		avuID, err := strconv.ParseInt(avuIDOrName, 10, 64)
+		if err == nil {
+			for _, meta := range metas {
+				if meta.AVUID == avuID {
+					selected = append(selected, meta)
+				}
+			}
+
+			if len(selected) > 0 {
+				return selected
+			}
+		}
+	}
+
+	// possibly name
+	for _, meta := range metas {
+		if meta.Name == avuIDOrName {
+			selected = append(selected, meta)
+		}
+	}
+
+	return selected
+}
+
+// ModifyPathMetadata replaces an existing AVU of the data object or collection with a new one
+func ModifyPathMetadata(fs *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, oldMeta *irodsclient_types.IRODSMeta, newMeta *irodsclient_types.IRODSMeta) error {
+	return modifyMetadata(fs, getPathMetaItemType(entry), entry.Path, oldMeta, newMeta)
+}
+
+// ModifyUserMetadata replaces an existing AVU of the user with a new one
+func ModifyUserMetadata(fs *irodsclient_fs.FileSystem, username string, zoneName string, oldMeta *irodsclient_types.IRODSMeta, newMeta *irodsclient_types.IRODSMeta) error {
+	return modifyMetadata(fs, irodsclient_types.IRODSUserMetaItemType, fmt.Sprintf("%s#%s", username, zoneName), oldMeta, newMeta)
+}
+
+// ModifyResourceMetadata replaces an existing AVU of the resource with a new one
+func ModifyResourceMetadata(fs *irodsclient_fs.FileSystem, resource string, oldMeta *irodsclient_types.IRODSMeta, newMeta *irodsclient_types.IRODSMeta) error {
+	return modifyMetadata(fs, irodsclient_types.IRODSResourceMetaItemType, resource, oldMeta, newMeta)
+}
+
+// SetPathMetadata replaces all AVUs of the data object or collection having the attribute name with the given AVU
+func SetPathMetadata(fs *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, meta *irodsclient_types.IRODSMeta) error {
+	return setMetadata(fs, getPathMetaItemType(entry), entry.Path, meta)
+}
+
+// SetUserMetadata replaces all AVUs of the user having the attribute name with the given AVU
+func SetUserMetadata(fs *irodsclient_fs.FileSystem, username string, zoneName string, meta *irodsclient_types.IRODSMeta) error {
+	return setMetadata(fs, irodsclient_types.IRODSUserMetaItemType, fmt.Sprintf("%s#%s", username, zoneName), meta)
+}
+
+// SetResourceMetadata replaces all AVUs of the resource having the attribute name with the given AVU
+func SetResourceMetadata(fs *irodsclient_fs.FileSystem, resource string, meta *irodsclient_types.IRODSMeta) error {
+	return setMetadata(fs, irodsclient_types.IRODSResourceMetaItemType, resource, meta)
+}
+
+func getPathMetaItemType(entry *irodsclient_fs.Entry) irodsclient_types.IRODSMetaItemType {
+	if entry.IsDir() {
+		return irodsclient_types.IRODSCollectionMetaItemType
+	}
+
+	return irodsclient_types.IRODSDataObjectMetaItemType
+}
+
+func modifyMetadata(fs *irodsclient_fs.FileSystem, itemType irodsclient_types.IRODSMetaItemType, itemName string, oldMeta *irodsclient_types.IRODSMeta, newMeta *irodsclient_types.IRODSMeta) error {
+	if len(oldMeta.Units) > 0 && len(newMeta.Units) == 0 {
+		// the server keeps the old unit if no new unit is given, so remove and add
+		err := requestModifyMetadata(fs, irodsclient_message.NewIRODSMessageRemoveMetadataRequest(itemType, itemName, oldMeta))
+		if err != nil {
+			return err
+		}
+
+		return requestModifyMetadata(fs, irodsclient_message.NewIRODSMessageAddMetadataRequest(itemType, itemName, newMeta))
+	}
+
+	return requestModifyMetadata(fs, newReplaceMetadataRequest(itemType, itemName, oldMeta, newMeta))
+}
+
+// newReplaceMetadataRequest makes a request replacing the old AVU with the new one.
+// The server takes the new attribute name, value, and unit only with "n:", "v:", and "u:" prefixes, which NewIRODSMessageReplaceMetadataRequest does not add.
+func newReplaceMetadataRequest(itemType irodsclient_types.IRODSMetaItemType, itemName string, oldMeta *irodsclient_types.IRODSMeta, newMeta *irodsclient_types.IRODSMeta) *irodsclient_message.IRODSMessageModifyMetadataRequest {
+	request := irodsclient_message.NewIRODSMessageReplaceMetadataRequest(itemType, itemName, oldMeta, newMeta)
+	request.NewAttrName = "n:" + newMeta.Name
+	request.NewAttrValue = "v:" + newMeta.Value
+	request.NewAttrUnits = ""
+	if len(newMeta.Units) > 0 {
+		request.NewAttrUnits = "u:" + newMeta.Units
+	}
+
+	return request
+}
+
+func setMetadata(fs *irodsclient_fs.FileSystem, itemType irodsclient_types.IRODSMetaItemType, itemName string, meta *irodsclient_types.IRODSMeta) error {
+	// the server removes other AVUs having the same attribute name in a single request
+	request := irodsclient_message.NewIRODSMessageSetMetadataRequest(itemType, itemName, meta)
+	return requestModifyMetadata(fs, request)
+}
+
+func requestModifyMetadata(fs *irodsclient_fs.FileSystem, request *irodsclient_message.IRODSMessageModifyMetadataRequest) error {
+	connection, err := fs.GetMetadataConnection()
+	if err != nil {
+		return xerrors.Errorf("failed to get connection: %w", err)
+	}
+	defer fs.ReturnMetadataConnection(connection)
+
+	connection.Lock()
+	defer connection.Unlock()
+
+	response := irodsclient_message.IRODSMessageModifyMetadataResponse{}
+	err = connection.RequestAndCheck(request, &response, nil)
+	if err != nil {
+		return xerrors.Errorf("received %s metadata error for %q: %w", request.Operation, request.ItemName, err)
+	}
+
+	// cached metadata is stale now
+	fs.ClearCache()
+	return nil
+}
@@ -0,0 +1,63 @@
+package commons
+
+import (
+	"testing"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataModify(t *testing.T) {
+	t.Run("test SelectMetadata", testSelectMetadata)
+	t.Run("test ReplaceMetadataRequest", testReplaceMetadataRequest)
+}
+
+func testSelectMetadata(t *testing.T) {
+	metas := []*irodsclient_types.IRODSMeta{
+		{AVUID: 10, Name: "status", Value: "draft"},
+		{AVUID: 11, Name: "status", Value: "review"},
+		{AVUID: 12, Name: "2024", Value: "year"},
+	}
+
+	selected := SelectMetadata(metas, "11")
+	assert.Len(t, selected, 1)
+	assert.Equal(t, "review", selected[0].Value)
+
+	selected = SelectMetadata(metas, "status")
+	assert.Len(t, selected, 2)
+
+	// digits not matching any AVU ID are treated as a name
+	selected = SelectMetadata(metas, "2024")
+	assert.Len(t, selected, 1)
+	assert.Equal(t, int64(12), selected[0].AVUID)
+
+	selected = SelectMetadata(metas, "missing")
+	assert.Empty(t, selected)
+}
+
+func testReplaceMetadataRequest(t *testing.T) {
+	oldMeta := &irodsclient_types.IRODSMeta{AVUID: 10, Name: "status", Value: "draft", Units: "stage"}
+
+	dirEntry := &irodsclient_fs.Entry{Type: irodsclient_fs.DirectoryEntry, Path: "/zone/home/user/dir"}
+	fileEntry := &irodsclient_fs.Entry{Type: irodsclient_fs.FileEntry, Path: "/zone/home/user/file.txt"}
+	assert.Equal(t, irodsclient_types.IRODSCollectionMetaItemType, getPathMetaItemType(dirEntry))
+	assert.Equal(t, irodsclient_types.IRODSDataObjectMetaItemType, getPathMetaItemType(fileEntry))
+
+	request := newReplaceMetadataRequest(getPathMetaItemType(fileEntry), fileEntry.Path, oldMeta, &irodsclient_types.IRODSMeta{Name: "status", Value: "review", Units: "stage"})
+	assert.Equal(t, "mod", request.Operation)
+	assert.Equal(t, string(irodsclient_types.IRODSDataObjectMetaItemType), request.ItemType)
+	assert.Equal(t, "/zone/home/user/file.txt", request.ItemName)
+	assert.Equal(t, "status", request.AttrName)
+	assert.Equal(t, "draft", request.AttrValue)
+	assert.Equal(t, "stage", request.AttrUnits)
+	assert.Equal(t, "n:status", request.NewAttrName)
+	assert.Equal(t, "v:review", request.NewAttrValue)
+	assert.Equal(t, "u:stage", request.NewAttrUnits)
+
+	// values looking like prefixes are kept
+	request = newReplaceMetadataRequest(irodsclient_types.IRODSUserMetaItemType, "user#zone", oldMeta, &irodsclient_types.IRODSMeta{Name: "n:x", Value: "v:y"})
+	assert.Equal(t, "n:n:x", request.NewAttrName)
+	assert.Equal(t, "v:v:y", request.NewAttrValue)
+	assert.Equal(t, "", request.NewAttrUnits)
+}
@@ -0,0 +1,355 @@
+package commons
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_common "github.com/cyverse/go-irodsclient/irods/common"
+	irodsclient_message "github.com/cyverse/go-irodsclient/irods/message"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"golang.org/x/xerrors"
+)
+
+// MetadataQueryTargetType determines which kind of items to search by metadata
+type MetadataQueryTargetType string
+
+const (
+	MetadataQueryTargetTypeDataObject MetadataQueryTargetType = "data_object"
+	MetadataQueryTargetTypeCollection MetadataQueryTargetType = "collection"
+	MetadataQueryTargetTypeUser       MetadataQueryTargetType = "user"
+	MetadataQueryTargetTypeResource   MetadataQueryTargetType = "resource"
+	MetadataQueryTargetTypeUnknown    MetadataQueryTargetType = ""
+)
+
+// GetMetadataQueryTargetType returns MetadataQueryTargetType from string
+func GetMetadataQueryTargetType(targetType string) MetadataQueryTargetType {
+	switch strings.ToLower(targetType) {
+	case string(MetadataQueryTargetTypeDataObject), "d", "dataobject", "file":
+		return MetadataQueryTargetTypeDataObject
+	case string(MetadataQueryTargetTypeCollection), "c", "coll", "dir":
+		return MetadataQueryTargetTypeCollection
+	case string(MetadataQueryTargetTypeUser), "u":
+		return MetadataQueryTargetTypeUser
+	case string(MetadataQueryTargetTypeResource), "r", "resc":
+		return MetadataQueryTargetTypeResource
+	default:
+		return MetadataQueryTargetTypeUnknown
+	}
+}
+
+// MetadataQueryOperator is a comparison operator in metadata query
+type MetadataQueryOperator string
+
+const (
+	MetadataQueryOperatorEqual        MetadataQueryOperator = "="
+	MetadataQueryOperatorNotEqual     MetadataQueryOperator = "!="
+	MetadataQueryOperatorLike         MetadataQueryOperator = "like"
+	MetadataQueryOperatorNotLike      MetadataQueryOperator = "not like"
+	MetadataQueryOperatorLess         MetadataQueryOperator = "<"
+	MetadataQueryOperatorLessEqual    MetadataQueryOperator = "<="
+	MetadataQueryOperatorGreater      MetadataQueryOperator = ">"
+	MetadataQueryOperatorGreaterEqual MetadataQueryOperator = ">="
+	MetadataQueryOperatorBetween      MetadataQueryOperator = "between"
+)
+
+// MetadataQueryCondition is a condition on an attribute, e.g., 'run_date > 2024-01-01'
+type MetadataQueryCondition struct {
+	Attribute string
+	Operator  MetadataQueryOperator
+	Values    []string
+}
+
+// GetGenQueryCondition returns a condition on attribute value in GenQuery syntax
+func (condition *MetadataQueryCondition) GetGenQueryCondition() string {
+	switch condition.Operator {
+	case MetadataQueryOperatorNotEqual:
+		return fmt.Sprintf("<> '%s'", condition.Values[0])
+	case MetadataQueryOperatorBetween:
+		return fmt.Sprintf("between '%s' '%s'", condition.Values[0], condition.Values[1])
+	default:
+		return fmt.Sprintf("%s '%s'", condition.Operator, condition.Values[0])
+	}
+}
+
+// tokenizeMetadataQuery splits the query by whitespace, respecting single and double quotes
+func tokenizeMetadataQuery(query string) ([]string, error) {
+	tokens := []string{}
+	sb := strings.Builder{}
+	inToken := false
+	var quote rune
+
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				sb.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inToken = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inToken {
+				tokens = append(tokens, sb.String())
+				sb.Reset()
+				inToken = false
+			}
+		default:
+			sb.WriteRune(c)
+			inToken = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, xerrors.Errorf("unterminated quote in query %q", query)
+	}
+
+	if inToken {
+		tokens = append(tokens, sb.String())
+	}
+
+	return tokens, nil
+}
+
+// ParseMetadataQuery parses a query like 'project = X and run_date > 2024-01-01'
+func ParseMetadataQuery(query string) ([]*MetadataQueryCondition, error) {
+	tokens, err := tokenizeMetadataQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := []*MetadataQueryCondition{}
+
+	idx := 0
+	for idx < len(tokens) {
+		if len(conditions) > 0 {
+			if strings.ToLower(tokens[idx]) != "and" {
+				return nil, xerrors.Errorf("expected 'and' but found %q in query %q", tokens[idx], query)
+			}
+			idx++
+		}
+
+		if idx+2 >= len(tokens) {
+			return nil, xerrors.Errorf("incomplete condition in query %q, expected 'attribute operator value'", query)
+		}
+
+		condition := &MetadataQueryCondition{
+			Attribute: tokens[idx],
+		}
+		idx++
+
+		operator := strings.ToLower(tokens[idx])
+		idx++
+
+		if operator == "not" && idx < len(tokens) && strings.ToLower(tokens[idx]) == "like" {
+			operator = string(MetadataQueryOperatorNotLike)
+			idx++
+		}
+
+		valueNum := 1
+		switch MetadataQueryOperator(operator) {
+		case MetadataQueryOperatorEqual, MetadataQueryOperatorNotEqual, MetadataQueryOperatorLike, MetadataQueryOperatorNotLike, MetadataQueryOperatorLess, MetadataQueryOperatorLessEqual, MetadataQueryOperatorGreater, MetadataQueryOperatorGreaterEqual:
+			// single value
+		case MetadataQueryOperatorBetween:
+			valueNum = 2
+		default:
+			return nil, xerrors.Errorf("unknown operator %q in query %q", operator, query)
+		}
+		condition.Operator = MetadataQueryOperator(operator)
+
+		if idx+valueNum > len(tokens) {
+			return nil, xerrors.Errorf("missing value for attribute %q in query %q", condition.Attribute, query)
+		}
+
+		condition.Values = tokens[idx : idx+valueNum]
+		idx += valueNum
+
+		for _, value := range append([]string{condition.Attribute}, condition.Values...) {
+			if strings.Contains(value, "'") {
+				return nil, xerrors.Errorf("single quote is not allowed in attribute or value %q", value)
+			}
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	if len(conditions) == 0 {
+		return nil, xerrors.Errorf("empty query")
+	}
+
+	return conditions, nil
+}
+
+// SearchByMetadata returns paths of data objects or collections, or names of users or resources, matching all conditions.
+// For data objects and collections, scopePath limits the search to the subtree.
+func SearchByMetadata(fs *irodsclient_fs.FileSystem, targetType MetadataQueryTargetType, conditions []*MetadataQueryCondition, scopePath string) ([]string, error) {
+	var results []string
+
+	// GenQuery matches all conditions against a single AVU, so query each condition and intersect
+	for _, condition := range conditions {
+		matches, err := searchByMetadataCondition(fs, targetType, condition, scopePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if results == nil {
+			results = matches
+			continue
+		}
+
+		matchMap := map[string]bool{}
+		for _, match := range matches {
+			matchMap[match] = true
+		}
+
+		intersection := []string{}
+		for _, result := range results {
+			if matchMap[result] {
+				intersection = append(intersection, result)
+			}
+		}
+
+		results = intersection
+		if len(results) == 0 {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+func searchByMetadataCondition(fs *irodsclient_fs.FileSystem, targetType MetadataQueryTargetType, condition *MetadataQueryCondition, scopePath string) ([]string, error) {
+	var selectColumns []irodsclient_common.ICATColumnNumber
+	var attrNameColumn irodsclient_common.ICATColumnNumber
+	var attrValueColumn irodsclient_common.ICATColumnNumber
+
+	switch targetType {
+	case MetadataQueryTargetTypeDataObject:
+		selectColumns = []irodsclient_common.ICATColumnNumber{irodsclient_common.ICAT_COLUMN_COLL_NAME, irodsclient_common.ICAT_COLUMN_DATA_NAME}
+		attrNameColumn = irodsclient_common.ICAT_COLUMN_META_DATA_ATTR_NAME
+		attrValueColumn = irodsclient_common.ICAT_COLUMN_META_DATA_ATTR_VALUE
+	case MetadataQueryTargetTypeCollection:
+		selectColumns = []irodsclient_common.ICATColumnNumber{irodsclient_common.ICAT_COLUMN_COLL_NAME}
+		attrNameColumn = irodsclient_common.ICAT_COLUMN_META_COLL_ATTR_NAME
+		attrValueColumn = irodsclient_common.ICAT_COLUMN_META_COLL_ATTR_VALUE
+	case MetadataQueryTargetTypeUser:
+		selectColumns = []irodsclient_common.ICATColumnNumber{irodsclient_common.ICAT_COLUMN_USER_NAME}
+		attrNameColumn = irodsclient_common.ICAT_COLUMN_META_USER_ATTR_NAME
+		attrValueColumn = irodsclient_common.ICAT_COLUMN_META_USER_ATTR_VALUE
+	case MetadataQueryTargetTypeResource:
+		selectColumns = []irodsclient_common.ICATColumnNumber{irodsclient_common.ICAT_COLUMN_R_RESC_NAME}
+		attrNameColumn = irodsclient_common.ICAT_COLUMN_META_RESC_ATTR_NAME
+		attrValueColumn = irodsclient_common.ICAT_COLUMN_META_RESC_ATTR_VALUE
+	default:
+		return nil, xerrors.Errorf("unknown metadata query target type %q", targetType)
+	}
+
+	connection, err := fs.GetMetadataConnection()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get connection: %w", err)
+	}
+	defer fs.ReturnMetadataConnection(connection)
+
+	connection.Lock()
+	defer connection.Unlock()
+
+	results := []string{}
+
+	continueIndex := 0
+	for {
+		query := irodsclient_message.NewIRODSMessageQueryRequest(irodsclient_common.MaxQueryRows, continueIndex, 0, 0)
+		query.AddKeyVal(irodsclient_common.ZONE_KW, connection.GetAccount().ClientZone)
+
+		for _, selectColumn := range selectColumns {
+			query.AddSelect(selectColumn, 1)
+		}
+
+		query.AddEqualStringCondition(attrNameColumn, condition.Attribute)
+		query.AddCondition(attrValueColumn, condition.GetGenQueryCondition())
+
+		if (targetType == MetadataQueryTargetTypeDataObject || targetType == MetadataQueryTargetTypeCollection) && len(scopePath) > 0 && scopePath != "/" {
+			query.AddCondition(irodsclient_common.ICAT_COLUMN_COLL_NAME, makeCollectionScopeCondition(scopePath))
+		}
+
+		queryResult := irodsclient_message.IRODSMessageQueryResponse{}
+		err = connection.Request(query, &queryResult, nil)
+		if err == nil {
+			err = queryResult.CheckError()
+		}
+
+		if err != nil {
+			if irodsclient_types.GetIRODSErrorCode(err) == irodsclient_common.CAT_NO_ROWS_FOUND {
+				// empty
+				break
+			}
+
+			return nil, xerrors.Errorf("failed to query %s by metadata %q: %w", targetType, condition.Attribute, err)
+		}
+
+		if queryResult.RowCount == 0 {
+			break
+		}
+
+		if queryResult.AttributeCount > len(queryResult.SQLResult) || queryResult.AttributeCount < len(selectColumns) {
+			return nil, xerrors.Errorf("failed to receive query attributes - requires %d, but received %d attributes", queryResult.AttributeCount, len(queryResult.SQLResult))
+		}
+
+		for row := 0; row < queryResult.RowCount; row++ {
+			values := []string{}
+			for attr := 0; attr < len(selectColumns); attr++ {
+				sqlResult := queryResult.SQLResult[attr]
+				if len(sqlResult.Values) != queryResult.RowCount {
+					return nil, xerrors.Errorf("failed to receive query rows - requires %d, but received %d rows", queryResult.RowCount, len(sqlResult.Values))
+				}
+
+				values = append(values, sqlResult.Values[row])
+			}
+
+			if (targetType == MetadataQueryTargetTypeDataObject || targetType == MetadataQueryTargetTypeCollection) && !isInCollectionScope(values[0], scopePath) {
+				// quotes in the scope match any character
+				continue
+			}
+
+			if targetType == MetadataQueryTargetTypeDataObject {
+				results = append(results, path.Join(values[0], values[1]))
+			} else {
+				results = append(results, values[0])
+			}
+		}
+
+		continueIndex = queryResult.ContinueIndex
+		if continueIndex == 0 {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+// escapeGenQueryLikePattern escapes wildcards in the string to match it literally with 'like'.
+// Quotes cannot be escaped in GenQuery, so they match any character and results need to be filtered.
+func escapeGenQueryLikePattern(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "%", `\%`)
+	s = strings.ReplaceAll(s, "_", `\_`)
+	s = strings.ReplaceAll(s, "'", "_")
+	return s
+}
+
+// makeCollectionScopeCondition returns a GenQuery condition matching the collection and its sub-collections
+func makeCollectionScopeCondition(scopePath string) string {
+	pattern := escapeGenQueryLikePattern(scopePath)
+	return fmt.Sprintf("like '%s' || like '%s/%%'", pattern, pattern)
+}
+
+// isInCollectionScope returns true if the collection is the scope collection or its sub-collection
+func isInCollectionScope(collPath string, scopePath string) bool {
+	if len(scopePath) == 0 || scopePath == "/" {
+		return true
+	}
+
+	return collPath == scopePath || strings.HasPrefix(collPath, scopePath+"/")
+}
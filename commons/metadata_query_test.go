@@ -0,0 +1,59 @@
+package commons
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataQuery(t *testing.T) {
+	t.Run("test ParseMetadataQuery", testParseMetadataQuery)
+	t.Run("test ParseMetadataQueryErrors", testParseMetadataQueryErrors)
+	t.Run("test CollectionScopeCondition", testCollectionScopeCondition)
+}
+
+func testParseMetadataQuery(t *testing.T) {
+	conditions, err := ParseMetadataQuery("project = X and run_date > 2024-01-01")
+	assert.NoError(t, err)
+	assert.Len(t, conditions, 2)
+	assert.Equal(t, "project", conditions[0].Attribute)
+	assert.Equal(t, MetadataQueryOperatorEqual, conditions[0].Operator)
+	assert.Equal(t, "= 'X'", conditions[0].GetGenQueryCondition())
+	assert.Equal(t, "> '2024-01-01'", conditions[1].GetGenQueryCondition())
+
+	conditions, err = ParseMetadataQuery("\"sample name\" NOT LIKE 'blood %' AND size between 10 20 and status != done")
+	assert.NoError(t, err)
+	assert.Len(t, conditions, 3)
+	assert.Equal(t, "sample name", conditions[0].Attribute)
+	assert.Equal(t, "not like 'blood %'", conditions[0].GetGenQueryCondition())
+	assert.Equal(t, "between '10' '20'", conditions[1].GetGenQueryCondition())
+	assert.Equal(t, "<> 'done'", conditions[2].GetGenQueryCondition())
+}
+
+func testParseMetadataQueryErrors(t *testing.T) {
+	invalidQueries := []string{
+		"",
+		"project =",
+		"project ~ X",
+		"project = X or run = Y",
+		"size between 10",
+		"project = 'X",
+		"project = \"it's\"",
+	}
+
+	for _, query := range invalidQueries {
+		_, err := ParseMetadataQuery(query)
+		assert.Error(t, err, query)
+	}
+}
+
+func testCollectionScopeCondition(t *testing.T) {
+	assert.Equal(t, `like '/zone/home/user' || like '/zone/home/user/%'`, makeCollectionScopeCondition("/zone/home/user"))
+	assert.Equal(t, `like '/zone/a\_b\%c' || like '/zone/a\_b\%c/%'`, makeCollectionScopeCondition("/zone/a_b%c"))
+	assert.Equal(t, `like '/zone/o_brien' || like '/zone/o_brien/%'`, makeCollectionScopeCondition("/zone/o'brien"))
+
+	assert.True(t, isInCollectionScope("/zone/o'brien/sub", "/zone/o'brien"))
+	assert.False(t, isInCollectionScope("/zone/oxbrien", "/zone/o'brien"))
+	assert.False(t, isInCollectionScope("/zone/o'brien2", "/zone/o'brien"))
+	assert.True(t, isInCollectionScope("/zone/any", "/"))
+}
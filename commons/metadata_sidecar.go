@@ -0,0 +1,103 @@
+package commons
+
+import (
+	"os"
+	"strings"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	"golang.org/x/xerrors"
+)
+
+const (
+	MetadataSidecarExtension string = ".meta.json"
+)
+
+// GetMetadataSidecarPath returns path of the sidecar file holding metadata of the local file
+func GetMetadataSidecarPath(localPath string) string {
+	return localPath + MetadataSidecarExtension
+}
+
+// IsMetadataSidecarPath returns true if the local path is a sidecar of another local file
+func IsMetadataSidecarPath(localPath string) bool {
+	if !strings.HasSuffix(localPath, MetadataSidecarExtension) {
+		return false
+	}
+
+	dataPath := strings.TrimSuffix(localPath, MetadataSidecarExtension)
+	dataStat, err := os.Stat(dataPath)
+	if err != nil {
+		return false
+	}
+
+	return !dataStat.IsDir()
+}
+
+// ExportMetadataSidecar writes metadata of the data object to the sidecar of the local file.
+// A stale sidecar is removed if the data object has no metadata.
+func ExportMetadataSidecar(fs *irodsclient_fs.FileSystem, irodsPath string, localPath string) error {
+	sidecarPath := GetMetadataSidecarPath(localPath)
+
+	metas, err := fs.ListMetadata(irodsPath)
+	if err != nil {
+		return xerrors.Errorf("failed to list metadata of %q: %w", irodsPath, err)
+	}
+
+	if len(metas) == 0 {
+		err = os.Remove(sidecarPath)
+		if err != nil && !os.IsNotExist(err) {
+			return xerrors.Errorf("failed to remove stale metadata sidecar %q: %w", sidecarPath, err)
+		}
+		return nil
+	}
+
+	rows := []*MetadataRow{}
+	for _, meta := range metas {
+		rows = append(rows, &MetadataRow{
+			Path:      irodsPath,
+			Attribute: meta.Name,
+			Value:     meta.Value,
+			Unit:      meta.Units,
+		})
+	}
+
+	file, err := os.Create(sidecarPath)
+	if err != nil {
+		return xerrors.Errorf("failed to create metadata sidecar %q: %w", sidecarPath, err)
+	}
+	defer file.Close()
+
+	err = WriteMetadataRows(file, MetadataFileFormatJSON, rows)
+	if err != nil {
+		return xerrors.Errorf("failed to write metadata sidecar %q: %w", sidecarPath, err)
+	}
+
+	return nil
+}
+
+// ReadMetadataSidecar reads metadata from the sidecar of the local file, returns nil if there is no sidecar
+func ReadMetadataSidecar(localPath string) ([]*MetadataRow, error) {
+	sidecarPath := GetMetadataSidecarPath(localPath)
+
+	file, err := os.Open(sidecarPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, xerrors.Errorf("failed to open metadata sidecar %q: %w", sidecarPath, err)
+	}
+	defer file.Close()
+
+	rows, err := ReadMetadataRows(file, MetadataFileFormatJSON)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read metadata sidecar %q: %w", sidecarPath, err)
+	}
+
+	for _, row := range rows {
+		if len(row.Attribute) == 0 || len(row.Value) == 0 {
+			return nil, xerrors.Errorf("invalid metadata %q=%q in sidecar %q: attribute and value must not be empty", row.Attribute, row.Value, sidecarPath)
+		}
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,254 @@
+package commons
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormat is a format of command output
+type OutputFormat string
+
+const (
+	OutputFormatText    OutputFormat = "text"
+	OutputFormatJSON    OutputFormat = "json"
+	OutputFormatYAML    OutputFormat = "yaml"
+	OutputFormatCSV     OutputFormat = "csv"
+	OutputFormatNDJSON  OutputFormat = "ndjson"
+	OutputFormatUnknown OutputFormat = ""
+)
+
+// GetOutputFormat returns OutputFormat from string
+func GetOutputFormat(format string) OutputFormat {
+	switch strings.ToLower(format) {
+	case string(OutputFormatText), "":
+		return OutputFormatText
+	case string(OutputFormatJSON):
+		return OutputFormatJSON
+	case string(OutputFormatYAML), "yml":
+		return OutputFormatYAML
+	case string(OutputFormatCSV):
+		return OutputFormatCSV
+	case string(OutputFormatNDJSON), "jsonl":
+		return OutputFormatNDJSON
+	default:
+		return OutputFormatUnknown
+	}
+}
+
+// IsStructured returns true if the format is machine-readable
+func (format OutputFormat) IsStructured() bool {
+	return format != OutputFormatText && format != OutputFormatUnknown
+}
+
+// OutputWriter writes records in a machine-readable format.
+// NDJSON and CSV records are written as they come, JSON and YAML records are written as a list on Flush.
+type OutputWriter struct {
+	writer  io.Writer
+	format  OutputFormat
+	records []interface{}
+
+	csvWriter     *csv.Writer
+	csvRecordType reflect.Type
+}
+
+// NewOutputWriter creates a new OutputWriter
+func NewOutputWriter(writer io.Writer, format OutputFormat) *OutputWriter {
+	outputWriter := &OutputWriter{
+		writer:  writer,
+		format:  format,
+		records: []interface{}{},
+	}
+
+	if format == OutputFormatCSV {
+		outputWriter.csvWriter = csv.NewWriter(writer)
+	}
+
+	return outputWriter
+}
+
+// Write writes a record
+func (outputWriter *OutputWriter) Write(record interface{}) error {
+	switch outputWriter.format {
+	case OutputFormatNDJSON:
+		err := json.NewEncoder(outputWriter.writer).Encode(record)
+		if err != nil {
+			return xerrors.Errorf("failed to write record in ndjson: %w", err)
+		}
+		return nil
+	case OutputFormatCSV:
+		return outputWriter.writeCSV(record)
+	case OutputFormatJSON, OutputFormatYAML:
+		outputWriter.records = append(outputWriter.records, record)
+		return nil
+	default:
+		return xerrors.Errorf("unsupported output format %q", outputWriter.format)
+	}
+}
+
+// Flush writes buffered records
+func (outputWriter *OutputWriter) Flush() error {
+	switch outputWriter.format {
+	case OutputFormatJSON:
+		return writeJSON(outputWriter.writer, outputWriter.records)
+	case OutputFormatYAML:
+		return writeYAML(outputWriter.writer, outputWriter.records)
+	case OutputFormatCSV:
+		outputWriter.csvWriter.Flush()
+		err := outputWriter.csvWriter.Error()
+		if err != nil {
+			return xerrors.Errorf("failed to write records in csv: %w", err)
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// writeCSV writes a record as a CSV row, a header row is written whenever the record type changes
+func (outputWriter *OutputWriter) writeCSV(record interface{}) error {
+	recordValue := reflect.Indirect(reflect.ValueOf(record))
+	if recordValue.Kind() != reflect.Struct {
+		return xerrors.Errorf("failed to write record of type %T in csv, must be a struct", record)
+	}
+
+	if recordValue.Type() != outputWriter.csvRecordType {
+		outputWriter.csvRecordType = recordValue.Type()
+
+		err := outputWriter.csvWriter.Write(getCSVHeader(recordValue.Type()))
+		if err != nil {
+			return xerrors.Errorf("failed to write csv header: %w", err)
+		}
+	}
+
+	row := []string{}
+	for i := 0; i < recordValue.NumField(); i++ {
+		if !recordValue.Type().Field(i).IsExported() {
+			continue
+		}
+
+		cell, err := getCSVCell(recordValue.Field(i))
+		if err != nil {
+			return err
+		}
+
+		row = append(row, cell)
+	}
+
+	err := outputWriter.csvWriter.Write(row)
+	if err != nil {
+		return xerrors.Errorf("failed to write csv row: %w", err)
+	}
+
+	return nil
+}
+
+func getCSVHeader(recordType reflect.Type) []string {
+	header := []string{}
+	for i := 0; i < recordType.NumField(); i++ {
+		field := recordType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagName := strings.Split(tag, ",")[0]
+			if len(tagName) > 0 && tagName != "-" {
+				name = tagName
+			}
+		}
+
+		header = append(header, name)
+	}
+	return header
+}
+
+func getCSVCell(value reflect.Value) (string, error) {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return "", nil
+		}
+		value = value.Elem()
+	}
+
+	if t, ok := value.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return "", nil
+		}
+		return t.Format(time.RFC3339), nil
+	}
+
+	switch value.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return fmt.Sprint(value.Interface()), nil
+	case reflect.Slice:
+		if bytes, ok := value.Interface().([]byte); ok {
+			return hex.EncodeToString(bytes), nil
+		}
+	}
+
+	// nested values are written in json
+	marshalled, err := json.Marshal(value.Interface())
+	if err != nil {
+		return "", xerrors.Errorf("failed to marshal csv cell to json: %w", err)
+	}
+	return string(marshalled), nil
+}
+
+// WriteOutput writes a single object in the given format
+func WriteOutput(writer io.Writer, format OutputFormat, object interface{}) error {
+	switch format {
+	case OutputFormatJSON:
+		return writeJSON(writer, object)
+	case OutputFormatYAML:
+		return writeYAML(writer, object)
+	default:
+		outputWriter := NewOutputWriter(writer, format)
+		err := outputWriter.Write(object)
+		if err != nil {
+			return err
+		}
+		return outputWriter.Flush()
+	}
+}
+
+func writeJSON(writer io.Writer, object interface{}) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(object)
+	if err != nil {
+		return xerrors.Errorf("failed to write json: %w", err)
+	}
+	return nil
+}
+
+func writeYAML(writer io.Writer, object interface{}) error {
+	// convert via json to keep field names and time formats same as json
+	marshalled, err := json.Marshal(object)
+	if err != nil {
+		return xerrors.Errorf("failed to marshal to json: %w", err)
+	}
+
+	var generic interface{}
+	err = yaml.Unmarshal(marshalled, &generic)
+	if err != nil {
+		return xerrors.Errorf("failed to convert json to yaml: %w", err)
+	}
+
+	encoder := yaml.NewEncoder(writer)
+	encoder.SetIndent(2)
+	err = encoder.Encode(generic)
+	if err != nil {
+		return xerrors.Errorf("failed to write yaml: %w", err)
+	}
+	return encoder.Close()
+}
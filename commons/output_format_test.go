@@ -0,0 +1,69 @@
+package commons
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testOutputRecord struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	Tags       []string  `json:"tags"`
+	ModifyTime time.Time `json:"modify_time"`
+}
+
+func TestOutputFormat(t *testing.T) {
+	t.Run("test GetOutputFormat", testGetOutputFormat)
+	t.Run("test OutputWriterCSV", testOutputWriterCSV)
+	t.Run("test OutputWriterNDJSON", testOutputWriterNDJSON)
+	t.Run("test OutputWriterJSON", testOutputWriterJSON)
+}
+
+func testGetOutputFormat(t *testing.T) {
+	assert.Equal(t, OutputFormatText, GetOutputFormat(""))
+	assert.Equal(t, OutputFormatJSON, GetOutputFormat("JSON"))
+	assert.Equal(t, OutputFormatYAML, GetOutputFormat("yml"))
+	assert.Equal(t, OutputFormatNDJSON, GetOutputFormat("jsonl"))
+	assert.Equal(t, OutputFormatUnknown, GetOutputFormat("xml"))
+	assert.False(t, OutputFormatText.IsStructured())
+	assert.True(t, OutputFormatCSV.IsStructured())
+}
+
+func testOutputWriterCSV(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer := NewOutputWriter(buffer, OutputFormatCSV)
+
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.NoError(t, writer.Write(&testOutputRecord{Name: "a.txt", Size: 10, Tags: []string{"x", "y"}, ModifyTime: modTime}))
+	assert.NoError(t, writer.Write(&testOutputRecord{Name: "b.txt", Size: 20}))
+	assert.NoError(t, writer.Flush())
+
+	expected := "name,size,tags,modify_time\n" +
+		"a.txt,10,\"[\"\"x\"\",\"\"y\"\"]\",2024-01-02T03:04:05Z\n" +
+		"b.txt,20,null,\n"
+	assert.Equal(t, expected, buffer.String())
+}
+
+func testOutputWriterNDJSON(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer := NewOutputWriter(buffer, OutputFormatNDJSON)
+
+	assert.NoError(t, writer.Write(&testOutputRecord{Name: "a.txt", Size: 10}))
+	// written without waiting for flush
+	assert.Contains(t, buffer.String(), "\"name\":\"a.txt\"")
+
+	assert.NoError(t, writer.Write(&testOutputRecord{Name: "b.txt", Size: 20}))
+	assert.NoError(t, writer.Flush())
+	assert.Equal(t, 2, bytes.Count(buffer.Bytes(), []byte("\n")))
+}
+
+func testOutputWriterJSON(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer := NewOutputWriter(buffer, OutputFormatJSON)
+
+	assert.NoError(t, writer.Flush())
+	assert.Equal(t, "[]\n", buffer.String())
+}
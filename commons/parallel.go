@@ -3,6 +3,7 @@ package commons
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 
 	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
 	"github.com/jedib0t/go-pretty/v6/progress"
@@ -34,6 +35,10 @@ func (job *ParallelJob) Progress(processed int64, total int64, errored bool) {
 
 func (job *ParallelJob) Done() {
 	job.done = true
+
+	if job.manager.progressWriter != nil {
+		job.manager.progressWriter.Done(job.name)
+	}
 }
 
 func newParallelJob(manager *ParallelJobManager, index int64, name string, task ParallelJobTask, threadsRequired int, progressUnit progress.Units) *ParallelJob {
@@ -60,9 +65,11 @@ type ParallelJobManager struct {
 	maxThreads              int
 	showProgress            bool
 	showFullPath            bool
-	progressWriter          progress.Writer
-	progressTrackers        map[string]*progress.Tracker
+	progressMode            ProgressMode
+	progressInterval        time.Duration
+	progressWriter          *TransferProgressWriter
 	progressTrackerCallback ProgressTrackerCallback
+	eventWriter             *EventWriter
 	lastError               error
 	mutex                   sync.RWMutex
 
@@ -80,9 +87,11 @@ func NewParallelJobManager(fs *irodsclient_fs.FileSystem, maxThreads int, showPr
 		maxThreads:              maxThreads,
 		showProgress:            showProgress,
 		showFullPath:            showFullPath,
+		progressMode:            ProgressModeUnknown,
+		progressInterval:        ProgressPlainIntervalDefault,
 		progressWriter:          nil,
-		progressTrackers:        map[string]*progress.Tracker{},
 		progressTrackerCallback: nil,
+		eventWriter:             nil,
 		lastError:               nil,
 		mutex:                   sync.RWMutex{},
 		scheduleWait:            sync.WaitGroup{},
@@ -103,6 +112,31 @@ func (manager *ParallelJobManager) GetFilesystem() *irodsclient_fs.FileSystem {
 	return manager.filesystem
 }
 
+// SetProgressMode sets how progress is displayed, must be called before Start
+func (manager *ParallelJobManager) SetProgressMode(mode ProgressMode, interval time.Duration) {
+	manager.progressMode = mode
+	manager.progressInterval = interval
+}
+
+// SetEventWriter sets a writer to emit events of jobs, must be called before Start
+func (manager *ParallelJobManager) SetEventWriter(eventWriter *EventWriter) {
+	manager.eventWriter = eventWriter
+}
+
+// SkipExpected excludes a file expected but not transferred, e.g., existing files skipped, from expected totals
+func (manager *ParallelJobManager) SkipExpected(bytes int64) {
+	if manager.progressWriter != nil {
+		manager.progressWriter.Skip(bytes)
+	}
+}
+
+// SetExpectedTotal sets number of files and bytes expected to be transferred, used to estimate ETA
+func (manager *ParallelJobManager) SetExpectedTotal(files int64, bytes int64) {
+	if manager.progressWriter != nil {
+		manager.progressWriter.SetExpectedTotal(files, bytes)
+	}
+}
+
 func (manager *ParallelJobManager) getNextJobIndex() int64 {
 	idx := manager.nextJobIndex
 	manager.nextJobIndex++
@@ -113,6 +147,8 @@ func (manager *ParallelJobManager) progress(name string, processed int64, total
 	if manager.progressTrackerCallback != nil {
 		manager.progressTrackerCallback(name, processed, total, progressUnit, errored)
 	}
+
+	manager.eventWriter.Progress(name, processed, total, progressUnit, errored)
 }
 
 func (manager *ParallelJobManager) Schedule(name string, task ParallelJobTask, threadsRequired int, progressUnit progress.Units) error {
@@ -133,6 +169,8 @@ func (manager *ParallelJobManager) Schedule(name string, task ParallelJobTask, t
 	manager.jobWait.Add(1)
 	atomic.AddInt64(&manager.jobsScheduledCounter, 1)
 
+	manager.eventWriter.Job(EventTypeScheduled, name, nil)
+
 	return nil
 }
 
@@ -156,6 +194,11 @@ func (manager *ParallelJobManager) Wait() error {
 	manager.mutex.RLock()
 	defer manager.mutex.RUnlock()
 
+	if manager.jobsDoneCounter > 0 && manager.jobsDoneCounter != manager.jobsScheduledCounter {
+		// some jobs were completed
+		return NewPartialTransferError(manager.jobsDoneCounter, manager.jobsScheduledCounter, manager.lastError)
+	}
+
 	if manager.lastError != nil {
 		return manager.lastError
 	}
@@ -169,67 +212,22 @@ func (manager *ParallelJobManager) Wait() error {
 
 func (manager *ParallelJobManager) startProgress() {
 	if manager.showProgress {
-		manager.progressWriter = GetProgressWriter(true)
-		messageWidth := getProgressMessageWidth(true)
-
-		go manager.progressWriter.Render()
+		manager.progressWriter = NewTransferProgressWriter(manager.progressMode, manager.progressInterval, manager.showFullPath, true, "files")
+		manager.progressWriter.Start()
 
 		// add progress tracker callback
-		manager.progressTrackerCallback = func(name string, processed int64, total int64, progressUnit progress.Units, errored bool) {
-			manager.mutex.Lock()
-			defer manager.mutex.Unlock()
-
-			var tracker *progress.Tracker
-			if t, ok := manager.progressTrackers[name]; !ok {
-				// created a new tracker if not exists
-				msg := name
-				if !manager.showFullPath {
-					msg = GetShortPathMessage(name, messageWidth)
-				}
-
-				tracker = &progress.Tracker{
-					Message: msg,
-					Total:   total,
-					Units:   progressUnit,
-				}
-
-				manager.progressWriter.AppendTracker(tracker)
-				manager.progressTrackers[name] = tracker
-			} else {
-				tracker = t
-			}
-
-			if processed >= 0 {
-				tracker.SetValue(processed)
-			}
-
-			if errored {
-				tracker.MarkAsErrored()
-			} else if processed >= total {
-				tracker.MarkAsDone()
-			}
-		}
+		manager.progressTrackerCallback = manager.progressWriter.Progress
 	}
 }
 
 func (manager *ParallelJobManager) endProgress() {
 	if manager.showProgress {
 		if manager.progressWriter != nil {
-			manager.mutex.Lock()
-
-			for _, tracker := range manager.progressTrackers {
-				if manager.lastError != nil {
-					tracker.MarkAsDone()
-				} else {
-					if !tracker.IsDone() {
-						tracker.MarkAsErrored()
-					}
-				}
-			}
-
-			manager.mutex.Unlock()
+			manager.mutex.RLock()
+			hasError := manager.lastError != nil
+			manager.mutex.RUnlock()
 
-			manager.progressWriter.Stop()
+			manager.progressWriter.Stop(hasError)
 		}
 	}
 }
@@ -277,6 +275,7 @@ func (manager *ParallelJobManager) Start() {
 
 				go func(pjob *ParallelJob) {
 					logger.Debugf("Run job %d, %q", pjob.index, pjob.name)
+					manager.eventWriter.Job(EventTypeStarted, pjob.name, nil)
 
 					err := pjob.task(pjob)
 
@@ -286,8 +285,12 @@ func (manager *ParallelJobManager) Start() {
 						manager.lastError = err
 						manager.mutex.Unlock()
 
+						manager.eventWriter.Job(EventTypeFailed, pjob.name, err)
+
 						logger.Error(err)
 						// don't stop here
+					} else if pjob.done {
+						manager.eventWriter.Job(EventTypeCompleted, pjob.name, nil)
 					}
 
 					currentThreads -= pjob.threadsRequired
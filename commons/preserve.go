@@ -0,0 +1,98 @@
+package commons
+
+import (
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_irodsfs "github.com/cyverse/go-irodsclient/irods/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"golang.org/x/xerrors"
+)
+
+// avu identifies an AVU by its name, value and units
+type avu struct {
+	name  string
+	value string
+	units string
+}
+
+// CopyMetadata adds metadata of the source data object or collection to the target, skipping ones the target already has
+func CopyMetadata(fs *irodsclient_fs.FileSystem, sourcePath string, targetPath string) error {
+	sourceMetas, err := fs.ListMetadata(sourcePath)
+	if err != nil {
+		return xerrors.Errorf("failed to list metadata of %q: %w", sourcePath, err)
+	}
+
+	if len(sourceMetas) == 0 {
+		return nil
+	}
+
+	targetMetas, err := fs.ListMetadata(targetPath)
+	if err != nil {
+		return xerrors.Errorf("failed to list metadata of %q: %w", targetPath, err)
+	}
+
+	existingAVUs := map[avu]bool{}
+	for _, meta := range targetMetas {
+		existingAVUs[avu{meta.Name, meta.Value, meta.Units}] = true
+	}
+
+	for _, meta := range sourceMetas {
+		if existingAVUs[avu{meta.Name, meta.Value, meta.Units}] {
+			continue
+		}
+
+		err = fs.AddMetadata(targetPath, meta.Name, meta.Value, meta.Units)
+		if err != nil {
+			return xerrors.Errorf("failed to add metadata %q to %q: %w", meta.Name, targetPath, err)
+		}
+	}
+
+	return nil
+}
+
+// CopyACLs grants accesses of the source data object or collection on the target, and ACL inheritance for collections.
+// Accesses of the given user are skipped to keep ownership of the target.
+func CopyACLs(fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, targetPath string, username string, zone string) error {
+	accesses, err := fs.ListACLs(sourceEntry.Path)
+	if err != nil {
+		return xerrors.Errorf("failed to list ACLs of %q: %w", sourceEntry.Path, err)
+	}
+
+	var inheritance *irodsclient_types.IRODSAccessInheritance
+	if sourceEntry.IsDir() {
+		inheritance, err = fs.GetDirACLInheritance(sourceEntry.Path)
+		if err != nil {
+			return xerrors.Errorf("failed to get ACL inheritance of %q: %w", sourceEntry.Path, err)
+		}
+	}
+
+	connection, err := fs.GetMetadataConnection()
+	if err != nil {
+		return xerrors.Errorf("failed to get connection: %w", err)
+	}
+	defer fs.ReturnMetadataConnection(connection)
+
+	for _, access := range accesses {
+		if access.UserName == username && access.UserZone == zone {
+			continue
+		}
+
+		if sourceEntry.IsDir() {
+			err = irodsclient_irodsfs.ChangeCollectionAccess(connection, targetPath, access.AccessLevel, access.UserName, access.UserZone, false, false)
+		} else {
+			err = irodsclient_irodsfs.ChangeDataObjectAccess(connection, targetPath, access.AccessLevel, access.UserName, access.UserZone, false)
+		}
+
+		if err != nil {
+			return xerrors.Errorf("failed to grant %q access to %q on %q: %w", access.AccessLevel, access.UserName, targetPath, err)
+		}
+	}
+
+	if inheritance != nil && inheritance.Inheritance {
+		err = irodsclient_irodsfs.SetAccessInherit(connection, targetPath, true, false, false)
+		if err != nil {
+			return xerrors.Errorf("failed to set ACL inheritance on %q: %w", targetPath, err)
+		}
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package commons
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/jedib0t/go-pretty/v6/progress"
@@ -11,6 +12,41 @@ import (
 
 type ProgressTrackerCallback func(name string, processed int64, total int64, unit progress.Units, errored bool)
 
+// ProgressMode is a way of displaying progress
+type ProgressMode string
+
+const (
+	// ProgressModeFull shows a progress bar per file and an overall progress bar
+	ProgressModeFull ProgressMode = "full"
+	// ProgressModeCompact shows overall progress and active transfers only
+	ProgressModeCompact ProgressMode = "compact"
+	// ProgressModePlain writes a line of overall progress periodically, for logs
+	ProgressModePlain   ProgressMode = "plain"
+	ProgressModeUnknown ProgressMode = ""
+)
+
+// GetProgressMode returns ProgressMode from string
+func GetProgressMode(mode string) ProgressMode {
+	switch strings.ToLower(mode) {
+	case string(ProgressModeFull):
+		return ProgressModeFull
+	case string(ProgressModeCompact):
+		return ProgressModeCompact
+	case string(ProgressModePlain):
+		return ProgressModePlain
+	default:
+		return ProgressModeUnknown
+	}
+}
+
+// GetDefaultProgressMode returns ProgressModeFull if stdout is a terminal, ProgressModePlain otherwise
+func GetDefaultProgressMode() ProgressMode {
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		return ProgressModeFull
+	}
+	return ProgressModePlain
+}
+
 const (
 	progressTrackerLength        int = 20
 	progressMessageLengthMin     int = 20
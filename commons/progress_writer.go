@@ -0,0 +1,274 @@
+package commons
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/jedib0t/go-pretty/v6/progress"
+)
+
+const (
+	// overall tracker counts in per-mille as its total may grow
+	progressOverallTrackerTotal  int64         = 1000
+	progressCompactMaxJobs       int           = 10
+	progressCompactInterval      time.Duration = 200 * time.Millisecond
+	ProgressPlainIntervalDefault time.Duration = 10 * time.Second
+)
+
+// TransferProgressWriter displays progress of jobs in the given mode
+type TransferProgressWriter struct {
+	mode         ProgressMode
+	interval     time.Duration
+	showFullPath bool
+	displayPath  bool
+	messageWidth int
+
+	transferProgress *TransferProgress
+
+	// full mode
+	progressWriter   progress.Writer
+	progressTrackers map[string]*progress.Tracker
+	overallTracker   *progress.Tracker
+
+	// compact and plain mode
+	output        io.Writer
+	lastLineCount int
+	stop          chan bool
+	renderWait    sync.WaitGroup
+
+	mutex sync.Mutex
+}
+
+// NewTransferProgressWriter creates a new TransferProgressWriter, item name is used in summaries, e.g., "files".
+// Interval is used in plain mode only.
+func NewTransferProgressWriter(mode ProgressMode, interval time.Duration, showFullPath bool, displayPath bool, itemName string) *TransferProgressWriter {
+	if mode == ProgressModeUnknown {
+		mode = GetDefaultProgressMode()
+	}
+
+	if interval <= 0 {
+		interval = ProgressPlainIntervalDefault
+	}
+
+	if mode == ProgressModeCompact {
+		interval = progressCompactInterval
+	}
+
+	return &TransferProgressWriter{
+		mode:         mode,
+		interval:     interval,
+		showFullPath: showFullPath,
+		displayPath:  displayPath,
+		messageWidth: getProgressMessageWidth(displayPath),
+
+		transferProgress: NewTransferProgress(itemName),
+
+		progressTrackers: map[string]*progress.Tracker{},
+
+		output: GetTerminalWriter(),
+		stop:   make(chan bool),
+	}
+}
+
+// SetExpectedTotal sets number of items and bytes expected to be transferred, used to estimate ETA
+func (writer *TransferProgressWriter) SetExpectedTotal(items int64, bytes int64) {
+	writer.transferProgress.SetExpectedTotal(items, bytes)
+}
+
+// Skip excludes an item expected but not transferred from expected totals
+func (writer *TransferProgressWriter) Skip(bytes int64) {
+	writer.transferProgress.Skip(bytes)
+}
+
+// Done marks the job done, required for jobs of zero size
+func (writer *TransferProgressWriter) Done(name string) {
+	writer.transferProgress.Done(name)
+}
+
+// Start starts rendering progress
+func (writer *TransferProgressWriter) Start() {
+	if writer.mode == ProgressModeFull {
+		writer.progressWriter = GetProgressWriter(writer.displayPath)
+
+		writer.overallTracker = &progress.Tracker{
+			Message: "total",
+			Total:   progressOverallTrackerTotal,
+			Units: progress.Units{
+				Formatter: func(_ int64) string {
+					return writer.transferProgress.GetCountSummary()
+				},
+			},
+		}
+		writer.progressWriter.AppendTracker(writer.overallTracker)
+
+		go writer.progressWriter.Render()
+		return
+	}
+
+	writer.renderWait.Add(1)
+	go func() {
+		defer writer.renderWait.Done()
+
+		ticker := time.NewTicker(writer.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				writer.render()
+			case <-writer.stop:
+				writer.render()
+				return
+			}
+		}
+	}()
+}
+
+// Progress updates progress of a job, it can be used as ProgressTrackerCallback
+func (writer *TransferProgressWriter) Progress(name string, processed int64, total int64, progressUnit progress.Units, errored bool) {
+	writer.transferProgress.Update(name, processed, total, isProgressUnitsBytes(progressUnit), errored)
+
+	if writer.mode != ProgressModeFull {
+		return
+	}
+
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	var tracker *progress.Tracker
+	if t, ok := writer.progressTrackers[name]; !ok {
+		// created a new tracker if not exists
+		msg := name
+		if !writer.showFullPath {
+			msg = GetShortPathMessage(name, writer.messageWidth)
+		}
+
+		tracker = &progress.Tracker{
+			Message: msg,
+			Total:   total,
+			Units:   progressUnit,
+		}
+
+		writer.progressWriter.AppendTracker(tracker)
+		writer.progressTrackers[name] = tracker
+	} else {
+		tracker = t
+	}
+
+	if processed >= 0 {
+		tracker.SetValue(processed)
+	}
+
+	if errored {
+		tracker.MarkAsErrored()
+	} else if processed >= total {
+		tracker.MarkAsDone()
+	}
+
+	writer.updateOverallTracker()
+}
+
+func (writer *TransferProgressWriter) updateOverallTracker() {
+	value := int64(writer.transferProgress.GetFraction() * float64(progressOverallTrackerTotal))
+	if value >= progressOverallTrackerTotal {
+		// keep it active until stop, more jobs may come
+		value = progressOverallTrackerTotal - 1
+	}
+
+	writer.overallTracker.SetValue(value)
+}
+
+// Stop renders the last progress and stops rendering
+func (writer *TransferProgressWriter) Stop(hasError bool) {
+	if writer.mode != ProgressModeFull {
+		close(writer.stop)
+		writer.renderWait.Wait()
+		return
+	}
+
+	writer.mutex.Lock()
+
+	for _, tracker := range writer.progressTrackers {
+		if hasError {
+			tracker.MarkAsDone()
+		} else {
+			if !tracker.IsDone() {
+				tracker.MarkAsErrored()
+			}
+		}
+	}
+
+	if hasError {
+		writer.overallTracker.MarkAsErrored()
+	} else {
+		writer.overallTracker.MarkAsDone()
+	}
+
+	writer.mutex.Unlock()
+
+	writer.progressWriter.Stop()
+}
+
+func (writer *TransferProgressWriter) render() {
+	if writer.mode == ProgressModePlain {
+		fmt.Fprintf(writer.output, "[progress] %s\n", writer.transferProgress.GetSummary())
+		return
+	}
+
+	lines := []string{writer.transferProgress.GetSummary()}
+
+	jobs := writer.transferProgress.GetActiveJobs()
+	for idx, job := range jobs {
+		if idx >= progressCompactMaxJobs {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", len(jobs)-progressCompactMaxJobs))
+			break
+		}
+
+		lines = append(lines, "  "+writer.getJobLine(job))
+	}
+
+	sb := strings.Builder{}
+	if writer.lastLineCount > 0 {
+		// move cursor up to the first line rendered last time and clear below
+		sb.WriteString(fmt.Sprintf("\x1b[%dA\x1b[J", writer.lastLineCount))
+	}
+
+	for _, line := range lines {
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+
+	writer.output.Write([]byte(sb.String()))
+	writer.lastLineCount = len(lines)
+}
+
+func (writer *TransferProgressWriter) getJobLine(job TransferProgressJob) string {
+	msg := job.Name
+	if !writer.showFullPath {
+		msg = GetShortPathMessage(job.Name, writer.messageWidth)
+	}
+
+	percent := 0.0
+	if job.Total > 0 {
+		percent = float64(job.Processed) / float64(job.Total) * 100
+	}
+
+	if job.Bytes {
+		return fmt.Sprintf("%-*s %5.1f%% %s/%s", writer.messageWidth, msg, percent, humanize.Bytes(uint64(job.Processed)), humanize.Bytes(uint64(job.Total)))
+	}
+
+	return fmt.Sprintf("%-*s %5.1f%% %d/%d", writer.messageWidth, msg, percent, job.Processed, job.Total)
+}
+
+// isProgressUnitsBytes returns true if the units format values in bytes, units are not comparable as they have a formatter func
+func isProgressUnitsBytes(units progress.Units) bool {
+	if units.Formatter == nil {
+		return false
+	}
+	return reflect.ValueOf(units.Formatter).Pointer() == reflect.ValueOf(progress.FormatBytes).Pointer()
+}
@@ -11,13 +11,19 @@ import (
 	"golang.org/x/xerrors"
 )
 
-func GetBundleFilename(hash string) string {
-	return fmt.Sprintf("bundle_%s.tar", hash)
+func GetBundleFilename(hash string, format BundleFormat) string {
+	return fmt.Sprintf("bundle_%s%s", hash, format.GetExtension())
 }
 
 func IsBundleFilename(p string) bool {
-	if strings.HasPrefix(p, "bundle_") && strings.HasPrefix(p, ".tar") {
-		return true
+	if !strings.HasPrefix(p, "bundle_") {
+		return false
+	}
+
+	for _, format := range []BundleFormat{BundleFormatTar, BundleFormatTarGzip, BundleFormatTarBzip2, BundleFormatZip} {
+		if strings.HasSuffix(p, format.GetExtension()) {
+			return true
+		}
 	}
 	return false
 }
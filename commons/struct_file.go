@@ -0,0 +1,183 @@
+package commons
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"time"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_common "github.com/cyverse/go-irodsclient/irods/common"
+	irodsclient_message "github.com/cyverse/go-irodsclient/irods/message"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	irodsclient_util "github.com/cyverse/go-irodsclient/irods/util"
+	"golang.org/x/xerrors"
+)
+
+// structFileBundleRequest is a request to create a structured file from data objects in a collection, as 'ibun -c' does.
+// The server takes the same input as structured file extraction, only the API number differs.
+type structFileBundleRequest struct {
+	*irodsclient_message.IRODSMessageExtractStructFileRequest
+}
+
+// GetMessage builds a message
+func (msg *structFileBundleRequest) GetMessage() (*irodsclient_message.IRODSMessage, error) {
+	bytes, err := msg.GetBytes()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get bytes from irods message: %w", err)
+	}
+
+	msgBody := irodsclient_message.IRODSMessageBody{
+		Type:    irodsclient_message.RODS_MESSAGE_API_REQ_TYPE,
+		Message: bytes,
+		Error:   nil,
+		Bs:      nil,
+		IntInfo: int32(irodsclient_common.STRUCT_FILE_BUNDLE_AN),
+	}
+
+	msgHeader, err := msgBody.BuildHeader()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to build header from irods message: %w", err)
+	}
+
+	return &irodsclient_message.IRODSMessage{
+		Header: msgHeader,
+		Body:   &msgBody,
+	}, nil
+}
+
+// BundleStructFile creates a structured file, e.g., a tar file, at the path from all data objects in the collection on the server-side
+func BundleStructFile(fs *irodsclient_fs.FileSystem, irodsPath string, collectionPath string, resource string, dataType irodsclient_types.DataType, force bool) error {
+	irodsPath = irodsclient_util.GetCorrectIRODSPath(irodsPath)
+	collectionPath = irodsclient_util.GetCorrectIRODSPath(collectionPath)
+
+	connection, err := fs.GetMetadataConnection()
+	if err != nil {
+		return xerrors.Errorf("failed to get connection: %w", err)
+	}
+	defer fs.ReturnMetadataConnection(connection)
+
+	// use default resource when resource param is empty
+	if len(resource) == 0 {
+		resource = connection.GetAccount().DefaultResource
+	}
+
+	request := &structFileBundleRequest{
+		IRODSMessageExtractStructFileRequest: irodsclient_message.NewIRODSMessageExtractStructFileRequest(irodsPath, collectionPath, resource, dataType, force, false),
+	}
+
+	connection.Lock()
+	defer connection.Unlock()
+
+	response := irodsclient_message.IRODSMessageExtractStructFileResponse{}
+	err = connection.RequestAndCheck(request, &response, nil)
+	if err != nil {
+		if irodsclient_types.GetIRODSErrorCode(err) == irodsclient_common.CAT_UNKNOWN_COLLECTION {
+			return xerrors.Errorf("failed to find the collection for path %q: %w", collectionPath, irodsclient_types.NewFileNotFoundError(collectionPath))
+		}
+
+		return xerrors.Errorf("received struct file bundle error for %q: %w", irodsPath, err)
+	}
+
+	// the new data object is not in cached listings
+	fs.ClearCache()
+	return nil
+}
+
+// StructFileEntry is a file or a directory in a structured file
+type StructFileEntry struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+	Dir     bool
+}
+
+// StructFileReader reads a structured file, both sequential and random access are required to read any data type
+type StructFileReader interface {
+	io.Reader
+	io.ReaderAt
+}
+
+// ListStructFile returns entries in a structured file, e.g., a tar file, reading the data object without extracting it
+func ListStructFile(fs *irodsclient_fs.FileSystem, irodsPath string, dataType irodsclient_types.DataType) ([]*StructFileEntry, error) {
+	irodsPath = irodsclient_util.GetCorrectIRODSPath(irodsPath)
+
+	handle, err := fs.OpenFile(irodsPath, "", "r")
+	if err != nil {
+		return nil, xerrors.Errorf("failed to open data object %q: %w", irodsPath, err)
+	}
+	defer handle.Close()
+
+	entries, err := ReadStructFileEntries(handle, handle.GetEntry().Size, dataType)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read entries in %q: %w", irodsPath, err)
+	}
+
+	return entries, nil
+}
+
+// ReadStructFileEntries returns entries in a structured file of the data type
+func ReadStructFileEntries(reader StructFileReader, size int64, dataType irodsclient_types.DataType) ([]*StructFileEntry, error) {
+	switch dataType {
+	case irodsclient_types.ZIP_FILE_DT:
+		return readZipEntries(reader, size)
+	case irodsclient_types.GZIP_TAR_DT:
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to create gzip reader: %w", err)
+		}
+		defer gzipReader.Close()
+
+		return readTarEntries(gzipReader)
+	case irodsclient_types.BZIP2_TAR_DT:
+		return readTarEntries(bzip2.NewReader(reader))
+	default:
+		return readTarEntries(reader)
+	}
+}
+
+func readTarEntries(reader io.Reader) ([]*StructFileEntry, error) {
+	entries := []*StructFileEntry{}
+
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			return nil, xerrors.Errorf("failed to read tar header: %w", err)
+		}
+
+		entries = append(entries, &StructFileEntry{
+			Name:    header.Name,
+			Size:    header.Size,
+			ModTime: header.ModTime,
+			Dir:     header.Typeflag == tar.TypeDir,
+		})
+	}
+
+	return entries, nil
+}
+
+func readZipEntries(reader io.ReaderAt, size int64) ([]*StructFileEntry, error) {
+	zipReader, err := zip.NewReader(reader, size)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read zip directory: %w", err)
+	}
+
+	entries := []*StructFileEntry{}
+	for _, file := range zipReader.File {
+		entries = append(entries, &StructFileEntry{
+			Name:    file.Name,
+			Size:    int64(file.UncompressedSize64),
+			ModTime: file.Modified,
+			Dir:     file.FileInfo().IsDir(),
+		})
+	}
+
+	return entries, nil
+}
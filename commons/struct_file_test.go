@@ -0,0 +1,42 @@
+package commons
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStructFile(t *testing.T) {
+	t.Run("test ReadStructFileEntries", testReadStructFileEntries)
+}
+
+func testReadStructFileEntries(t *testing.T) {
+	sourceDir, sources := makeTestBundleSources(t)
+
+	for _, format := range []BundleFormat{BundleFormatTar, BundleFormatTarGzip, BundleFormatTarBzip2, BundleFormatZip} {
+		bundlePath := filepath.Join(t.TempDir(), GetBundleFilename("test", format))
+		err := MakeBundleFile(sourceDir, sources, bundlePath, format, nil)
+		assert.NoError(t, err)
+
+		bundleFile, err := os.Open(bundlePath)
+		assert.NoError(t, err)
+		defer bundleFile.Close()
+
+		bundleStat, err := bundleFile.Stat()
+		assert.NoError(t, err)
+
+		entries, err := ReadStructFileEntries(bundleFile, bundleStat.Size(), format.GetDataType())
+		assert.NoError(t, err)
+
+		sizes := map[string]int64{}
+		for _, entry := range entries {
+			if !entry.Dir {
+				sizes[entry.Name] = entry.Size
+			}
+		}
+
+		assert.Equal(t, map[string]int64{"0": 4, "sub/1": 3}, sizes, "format %q", format)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"archive/tar"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
 	log "github.com/sirupsen/logrus"
@@ -26,12 +28,18 @@ func NewTarEntry(source string, target string) *TarEntry {
 }
 
 func Tar(baseDir string, sources []string, target string, callback TrackerCallBack) error {
+	return MakeBundleFile(baseDir, sources, target, BundleFormatTar, callback)
+}
+
+// MakeBundleFile creates a bundle file in the format, e.g., a gzip-compressed tarball, from the sources.
+// Entries in the bundle file are named by relative paths of the sources to the baseDir.
+func MakeBundleFile(baseDir string, sources []string, target string, format BundleFormat, callback TrackerCallBack) error {
 	logger := log.WithFields(log.Fields{
 		"package":  "commons",
-		"function": "Tar",
+		"function": "MakeBundleFile",
 	})
 
-	logger.Infof("creating a tarball %q", target)
+	logger.Infof("creating a bundle file %q in %q format", target, format)
 
 	entries := []*TarEntry{}
 
@@ -84,10 +92,10 @@ func Tar(baseDir string, sources []string, target string, callback TrackerCallBa
 		}
 	}
 
-	return makeTar(entries, target, callback)
+	return makeBundleFile(entries, target, format, callback)
 }
 
-func makeTar(entries []*TarEntry, target string, callback TrackerCallBack) error {
+func makeBundleFile(entries []*TarEntry, target string, format BundleFormat, callback TrackerCallBack) error {
 	totalSize := int64(0)
 	currentSize := int64(0)
 	for _, entry := range entries {
@@ -109,15 +117,17 @@ func makeTar(entries []*TarEntry, target string, callback TrackerCallBack) error
 		callback(0, totalSize)
 	}
 
-	tarfile, err := os.Create(target)
+	bundleFile, err := os.Create(target)
 	if err != nil {
 		return xerrors.Errorf("failed to create file %q: %w", target, err)
 	}
 
-	defer tarfile.Close()
+	defer bundleFile.Close()
 
-	tarWriter := tar.NewWriter(tarfile)
-	defer tarWriter.Close()
+	bundleWriter, err := newBundleFileWriter(bundleFile, format)
+	if err != nil {
+		return err
+	}
 
 	for _, entry := range entries {
 		sourceStat, err := os.Stat(entry.source)
@@ -129,30 +139,16 @@ func makeTar(entries []*TarEntry, target string, callback TrackerCallBack) error
 			return xerrors.Errorf("failed to stat %q: %w", entry.source, err)
 		}
 
-		header, err := tar.FileInfoHeader(sourceStat, sourceStat.Name())
+		entryWriter, err := bundleWriter.AddEntry(entry.target, sourceStat)
 		if err != nil {
-			return xerrors.Errorf("failed to create tar file info header: %w", err)
-		}
-
-		header.Name = entry.target
-
-		err = tarWriter.WriteHeader(header)
-		if err != nil {
-			return xerrors.Errorf("failed to write tar header: %w", err)
+			return err
 		}
 
 		if !sourceStat.IsDir() {
 			// add file content
-			file, err := os.Open(entry.source)
+			err = copyFileTo(entry.source, entryWriter)
 			if err != nil {
-				return xerrors.Errorf("failed to open tar file %q: %w", entry.source, err)
-			}
-
-			defer file.Close()
-
-			_, err = io.Copy(tarWriter, file)
-			if err != nil {
-				return xerrors.Errorf("failed to write tar file: %w", err)
+				return err
 			}
 
 			currentSize += sourceStat.Size()
@@ -163,5 +159,112 @@ func makeTar(entries []*TarEntry, target string, callback TrackerCallBack) error
 		}
 	}
 
+	return bundleWriter.Close()
+}
+
+func copyFileTo(source string, writer io.Writer) error {
+	file, err := os.Open(source)
+	if err != nil {
+		return xerrors.Errorf("failed to open file %q: %w", source, err)
+	}
+
+	defer file.Close()
+
+	_, err = io.Copy(writer, file)
+	if err != nil {
+		return xerrors.Errorf("failed to write file %q to a bundle file: %w", source, err)
+	}
+
+	return nil
+}
+
+// UntarFiles extracts files in a tarball to the local paths given by names of the files in the tarball.
+// Files not in the targets are ignored, an error is returned if any of the targets is missing in the tarball.
+func UntarFiles(source string, targets map[string]string, callback TrackerCallBack) error {
+	logger := log.WithFields(log.Fields{
+		"package":  "commons",
+		"function": "UntarFiles",
+	})
+
+	logger.Infof("extracting a tarball %q", source)
+
+	tarfile, err := os.Open(source)
+	if err != nil {
+		return xerrors.Errorf("failed to open file %q: %w", source, err)
+	}
+
+	defer tarfile.Close()
+
+	totalFiles := int64(len(targets))
+	extracted := map[string]bool{}
+
+	if callback != nil {
+		callback(0, totalFiles)
+	}
+
+	tarReader := tar.NewReader(tarfile)
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			return xerrors.Errorf("failed to read tar header from %q: %w", source, err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := strings.TrimPrefix(path.Clean(header.Name), "./")
+		target, ok := targets[name]
+		if !ok {
+			logger.Debugf("ignoring %q in a tarball %q", header.Name, source)
+			continue
+		}
+
+		err = untarFile(tarReader, target)
+		if err != nil {
+			return xerrors.Errorf("failed to extract %q in a tarball %q: %w", header.Name, source, err)
+		}
+
+		logger.Infof("extracted %q in a tarball %q ==> %q", header.Name, source, target)
+
+		extracted[name] = true
+
+		if callback != nil {
+			callback(int64(len(extracted)), totalFiles)
+		}
+	}
+
+	for name := range targets {
+		if !extracted[name] {
+			return xerrors.Errorf("failed to find %q in a tarball %q: %w", name, source, irodsclient_types.NewFileNotFoundError(name))
+		}
+	}
+
+	return nil
+}
+
+func untarFile(reader io.Reader, target string) error {
+	targetDir := filepath.Dir(target)
+	err := os.MkdirAll(targetDir, 0766)
+	if err != nil {
+		return xerrors.Errorf("failed to make a directory %q: %w", targetDir, err)
+	}
+
+	file, err := os.Create(target)
+	if err != nil {
+		return xerrors.Errorf("failed to create file %q: %w", target, err)
+	}
+
+	defer file.Close()
+
+	_, err = io.Copy(file, reader)
+	if err != nil {
+		return xerrors.Errorf("failed to write file %q: %w", target, err)
+	}
+
 	return nil
 }
@@ -0,0 +1,163 @@
+package commons
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTar(t *testing.T) {
+	t.Run("test UntarFiles", testUntarFiles)
+	t.Run("test UntarFilesMissing", testUntarFilesMissing)
+	t.Run("test MakeBundleFileCompressed", testMakeBundleFileCompressed)
+	t.Run("test MakeBundleFileZip", testMakeBundleFileZip)
+	t.Run("test IsBundleFilename", testIsBundleFilename)
+}
+
+func makeTestTarball(t *testing.T) string {
+	sourceDir, sources := writeLocalTestFiles(t, map[string]string{"0": "zero", "1": "one", "2": "two"})
+
+	tarPath := filepath.Join(t.TempDir(), "bundle.tar")
+	err := Tar(sourceDir, []string{sources["0"], sources["1"], sources["2"]}, tarPath, nil)
+	assert.NoError(t, err)
+
+	return tarPath
+}
+
+func testUntarFiles(t *testing.T) {
+	tarPath := makeTestTarball(t)
+	targetDir := t.TempDir()
+
+	targets := map[string]string{
+		"0": filepath.Join(targetDir, "a", "zero.txt"),
+		"2": filepath.Join(targetDir, "b", "two.txt"),
+	}
+
+	lastProcessed := int64(0)
+	err := UntarFiles(tarPath, targets, func(processed int64, total int64) {
+		assert.Equal(t, int64(2), total)
+		lastProcessed = processed
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), lastProcessed)
+
+	data, err := os.ReadFile(targets["0"])
+	assert.NoError(t, err)
+	assert.Equal(t, "zero", string(data))
+
+	data, err = os.ReadFile(targets["2"])
+	assert.NoError(t, err)
+	assert.Equal(t, "two", string(data))
+
+	// files not in targets are not extracted
+	entries, err := os.ReadDir(targetDir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func testUntarFilesMissing(t *testing.T) {
+	tarPath := makeTestTarball(t)
+	targetDir := t.TempDir()
+
+	targets := map[string]string{
+		"0": filepath.Join(targetDir, "zero.txt"),
+		"3": filepath.Join(targetDir, "three.txt"),
+	}
+
+	err := UntarFiles(tarPath, targets, nil)
+	assert.Error(t, err)
+	assert.True(t, irodsclient_types.IsFileNotFoundError(err))
+}
+
+func makeTestBundleSources(t *testing.T) (string, []string) {
+	sourceDir, sources := writeLocalTestFiles(t, map[string]string{"0": "zero", "sub/1": "one"})
+	return sourceDir, []string{sources["0"], sources["sub/1"]}
+}
+
+func testMakeBundleFileCompressed(t *testing.T) {
+	sourceDir, sources := makeTestBundleSources(t)
+
+	for _, format := range []BundleFormat{BundleFormatTarGzip, BundleFormatTarBzip2} {
+		bundlePath := filepath.Join(t.TempDir(), GetBundleFilename("test", format))
+		err := MakeBundleFile(sourceDir, sources, bundlePath, format, nil)
+		assert.NoError(t, err)
+
+		bundleFile, err := os.Open(bundlePath)
+		assert.NoError(t, err)
+		defer bundleFile.Close()
+
+		var reader io.Reader
+		if format == BundleFormatTarGzip {
+			reader, err = gzip.NewReader(bundleFile)
+			assert.NoError(t, err)
+		} else {
+			reader = bzip2.NewReader(bundleFile)
+		}
+
+		contents := map[string]string{}
+		tarReader := tar.NewReader(reader)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+
+			data, err := io.ReadAll(tarReader)
+			assert.NoError(t, err)
+			contents[header.Name] = string(data)
+		}
+
+		assert.Equal(t, map[string]string{"0": "zero", "sub/1": "one"}, contents)
+	}
+}
+
+func testMakeBundleFileZip(t *testing.T) {
+	sourceDir, sources := makeTestBundleSources(t)
+
+	bundlePath := filepath.Join(t.TempDir(), GetBundleFilename("test", BundleFormatZip))
+	err := MakeBundleFile(sourceDir, sources, bundlePath, BundleFormatZip, nil)
+	assert.NoError(t, err)
+
+	zipReader, err := zip.OpenReader(bundlePath)
+	assert.NoError(t, err)
+	defer zipReader.Close()
+
+	contents := map[string]string{}
+	for _, file := range zipReader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		reader, err := file.Open()
+		assert.NoError(t, err)
+
+		data, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		reader.Close()
+
+		contents[file.Name] = string(data)
+	}
+
+	assert.Equal(t, map[string]string{"0": "zero", "sub/1": "one"}, contents)
+}
+
+func testIsBundleFilename(t *testing.T) {
+	assert.True(t, IsBundleFilename(GetBundleFilename("abc", BundleFormatTar)))
+	assert.True(t, IsBundleFilename(GetBundleFilename("abc", BundleFormatTarGzip)))
+	assert.True(t, IsBundleFilename(GetBundleFilename("abc", BundleFormatZip)))
+	assert.False(t, IsBundleFilename("bundle_abc.txt"))
+	assert.False(t, IsBundleFilename("abc.tar"))
+}
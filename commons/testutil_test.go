@@ -0,0 +1,29 @@
+package commons
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeLocalTestFiles writes files having the contents, keyed by slash-separated relative paths, in a new temp dir.
+// Returns the temp dir and the file paths keyed by the same relative paths
+func writeLocalTestFiles(t *testing.T, contents map[string]string) (string, map[string]string) {
+	tempDir := t.TempDir()
+
+	paths := map[string]string{}
+	for name, content := range contents {
+		filePath := filepath.Join(tempDir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(filePath), 0755)
+		assert.NoError(t, err)
+
+		err = os.WriteFile(filePath, []byte(content), 0644)
+		assert.NoError(t, err)
+
+		paths[name] = filePath
+	}
+
+	return tempDir, paths
+}
@@ -0,0 +1,409 @@
+package commons
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	"github.com/dustin/go-humanize"
+	"golang.org/x/xerrors"
+)
+
+type transferProgressJobState int
+
+const (
+	transferProgressJobActive transferProgressJobState = iota
+	transferProgressJobDone
+	transferProgressJobFailed
+)
+
+// TransferProgressJob is a job being tracked for overall progress
+type TransferProgressJob struct {
+	Name      string
+	Processed int64
+	Total     int64
+	Bytes     bool
+	StartTime time.Time
+
+	state transferProgressJobState
+}
+
+// TransferProgress has overall progress of jobs, expected totals can be given from a pre-scan to estimate ETA
+type TransferProgress struct {
+	itemName  string
+	startTime time.Time
+
+	expectedItems int64
+	expectedBytes int64
+	skippedItems  int64
+	skippedBytes  int64
+
+	jobs        map[string]*TransferProgressJob
+	doneItems   int64
+	failedItems int64
+	doneBytes   int64
+
+	mutex sync.Mutex
+}
+
+// NewTransferProgress creates a new TransferProgress, item name is used in summaries, e.g., "files"
+func NewTransferProgress(itemName string) *TransferProgress {
+	return &TransferProgress{
+		itemName:  itemName,
+		startTime: time.Now(),
+		jobs:      map[string]*TransferProgressJob{},
+	}
+}
+
+// SetExpectedTotal sets number of items and bytes expected to be transferred
+func (transferProgress *TransferProgress) SetExpectedTotal(items int64, bytes int64) {
+	transferProgress.mutex.Lock()
+	defer transferProgress.mutex.Unlock()
+
+	transferProgress.expectedItems = items
+	transferProgress.expectedBytes = bytes
+}
+
+// Skip excludes an item expected but not transferred, e.g., existing files skipped, from expected totals
+func (transferProgress *TransferProgress) Skip(bytes int64) {
+	transferProgress.mutex.Lock()
+	defer transferProgress.mutex.Unlock()
+
+	transferProgress.skippedItems++
+	transferProgress.skippedBytes += bytes
+}
+
+// Update updates progress of the job, a job seen first is added.
+// Jobs of zero size are not done until Done is called.
+func (transferProgress *TransferProgress) Update(name string, processed int64, total int64, bytes bool, errored bool) {
+	transferProgress.mutex.Lock()
+	defer transferProgress.mutex.Unlock()
+
+	job, ok := transferProgress.jobs[name]
+	if !ok {
+		job = &TransferProgressJob{
+			Name:      name,
+			Bytes:     bytes,
+			StartTime: time.Now(),
+			state:     transferProgressJobActive,
+		}
+		transferProgress.jobs[name] = job
+	}
+
+	if job.state == transferProgressJobFailed {
+		return
+	}
+
+	job.Total = total
+	if processed >= 0 {
+		job.Processed = processed
+	}
+
+	if errored {
+		if job.state == transferProgressJobDone {
+			// failed after transfer, e.g., on adding metadata
+			transferProgress.doneItems--
+			if job.Bytes {
+				transferProgress.doneBytes -= job.Total
+			}
+		}
+
+		job.state = transferProgressJobFailed
+		transferProgress.failedItems++
+		return
+	}
+
+	if job.Total > 0 && job.Processed >= job.Total {
+		transferProgress.setDone(job)
+	}
+}
+
+// Done marks the job done
+func (transferProgress *TransferProgress) Done(name string) {
+	transferProgress.mutex.Lock()
+	defer transferProgress.mutex.Unlock()
+
+	job, ok := transferProgress.jobs[name]
+	if !ok {
+		return
+	}
+
+	job.Processed = job.Total
+	transferProgress.setDone(job)
+}
+
+func (transferProgress *TransferProgress) setDone(job *TransferProgressJob) {
+	if job.state != transferProgressJobActive {
+		return
+	}
+
+	job.state = transferProgressJobDone
+	transferProgress.doneItems++
+	if job.Bytes {
+		transferProgress.doneBytes += job.Total
+	}
+}
+
+// GetItems returns numbers of items done, failed and in total
+func (transferProgress *TransferProgress) GetItems() (int64, int64, int64) {
+	transferProgress.mutex.Lock()
+	defer transferProgress.mutex.Unlock()
+
+	return transferProgress.getItems()
+}
+
+func (transferProgress *TransferProgress) getItems() (int64, int64, int64) {
+	total := int64(len(transferProgress.jobs))
+	if expected := transferProgress.expectedItems - transferProgress.skippedItems; expected > total {
+		total = expected
+	}
+
+	return transferProgress.doneItems, transferProgress.failedItems, total
+}
+
+// GetBytes returns bytes processed and in total
+func (transferProgress *TransferProgress) GetBytes() (int64, int64) {
+	transferProgress.mutex.Lock()
+	defer transferProgress.mutex.Unlock()
+
+	return transferProgress.getBytes()
+}
+
+func (transferProgress *TransferProgress) getBytes() (int64, int64) {
+	processed := transferProgress.doneBytes
+	total := transferProgress.doneBytes
+
+	for _, job := range transferProgress.jobs {
+		if job.Bytes && job.state == transferProgressJobActive {
+			processed += job.Processed
+			total += job.Total
+		}
+	}
+
+	if expected := transferProgress.expectedBytes - transferProgress.skippedBytes; expected > total {
+		total = expected
+	}
+
+	return processed, total
+}
+
+// GetFraction returns fraction of work done, in bytes if known, in items otherwise
+func (transferProgress *TransferProgress) GetFraction() float64 {
+	transferProgress.mutex.Lock()
+	defer transferProgress.mutex.Unlock()
+
+	return transferProgress.getFraction()
+}
+
+func (transferProgress *TransferProgress) getFraction() float64 {
+	processedBytes, totalBytes := transferProgress.getBytes()
+	if totalBytes > 0 {
+		return float64(processedBytes) / float64(totalBytes)
+	}
+
+	doneItems, failedItems, totalItems := transferProgress.getItems()
+	if totalItems > 0 {
+		return float64(doneItems+failedItems) / float64(totalItems)
+	}
+
+	return 0
+}
+
+// GetThroughput returns bytes processed per second
+func (transferProgress *TransferProgress) GetThroughput() float64 {
+	transferProgress.mutex.Lock()
+	defer transferProgress.mutex.Unlock()
+
+	return transferProgress.getThroughput()
+}
+
+func (transferProgress *TransferProgress) getThroughput() float64 {
+	elapsed := time.Since(transferProgress.startTime).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	processedBytes, _ := transferProgress.getBytes()
+	return float64(processedBytes) / elapsed
+}
+
+// GetETA returns estimated time to complete, returns false if it can't be estimated without expected totals
+func (transferProgress *TransferProgress) GetETA() (time.Duration, bool) {
+	transferProgress.mutex.Lock()
+	defer transferProgress.mutex.Unlock()
+
+	return transferProgress.getETA()
+}
+
+func (transferProgress *TransferProgress) getETA() (time.Duration, bool) {
+	if transferProgress.expectedItems <= 0 && transferProgress.expectedBytes <= 0 {
+		return 0, false
+	}
+
+	fraction := transferProgress.getFraction()
+	if fraction <= 0 {
+		return 0, false
+	}
+
+	if fraction >= 1 {
+		return 0, true
+	}
+
+	elapsed := time.Since(transferProgress.startTime)
+	return time.Duration(float64(elapsed) * (1 - fraction) / fraction), true
+}
+
+// GetActiveJobs returns jobs in progress, oldest first
+func (transferProgress *TransferProgress) GetActiveJobs() []TransferProgressJob {
+	transferProgress.mutex.Lock()
+	defer transferProgress.mutex.Unlock()
+
+	jobs := []TransferProgressJob{}
+	for _, job := range transferProgress.jobs {
+		if job.state == transferProgressJobActive {
+			jobs = append(jobs, *job)
+		}
+	}
+
+	sort.Slice(jobs, func(i int, j int) bool {
+		if jobs[i].StartTime.Equal(jobs[j].StartTime) {
+			return jobs[i].Name < jobs[j].Name
+		}
+		return jobs[i].StartTime.Before(jobs[j].StartTime)
+	})
+
+	return jobs
+}
+
+// GetCountSummary returns a short summary of items and bytes, e.g., "12/100 files, 1.2 GB/3.4 GB"
+func (transferProgress *TransferProgress) GetCountSummary() string {
+	transferProgress.mutex.Lock()
+	defer transferProgress.mutex.Unlock()
+
+	return transferProgress.getCountSummary()
+}
+
+func (transferProgress *TransferProgress) getCountSummary() string {
+	doneItems, failedItems, totalItems := transferProgress.getItems()
+	processedBytes, totalBytes := transferProgress.getBytes()
+
+	summary := fmt.Sprintf("%d/%d %s", doneItems, totalItems, transferProgress.itemName)
+	if failedItems > 0 {
+		summary += fmt.Sprintf(" (%d failed)", failedItems)
+	}
+
+	if totalBytes > 0 {
+		summary += fmt.Sprintf(", %s/%s", humanize.Bytes(uint64(processedBytes)), humanize.Bytes(uint64(totalBytes)))
+	}
+
+	return summary
+}
+
+// GetSummary returns a line of overall progress with percentage, throughput and ETA
+func (transferProgress *TransferProgress) GetSummary() string {
+	transferProgress.mutex.Lock()
+	defer transferProgress.mutex.Unlock()
+
+	elapsed := time.Since(transferProgress.startTime).Round(time.Second)
+
+	sb := strings.Builder{}
+	sb.WriteString(transferProgress.getCountSummary())
+	sb.WriteString(fmt.Sprintf(" (%.1f%%)", transferProgress.getFraction()*100))
+	sb.WriteString(fmt.Sprintf(", %s/s", humanize.Bytes(uint64(transferProgress.getThroughput()))))
+	sb.WriteString(fmt.Sprintf(", elapsed %s", elapsed))
+
+	eta, ok := transferProgress.getETA()
+	if ok {
+		sb.WriteString(fmt.Sprintf(", ETA %s", eta.Round(time.Second)))
+	} else {
+		sb.WriteString(", ETA unknown")
+	}
+
+	return sb.String()
+}
+
+// ScanLocalPaths returns number of files and their total size under the local paths, used to estimate ETA.
+// Hidden files and directories under the paths are not counted if excludeHidden is true.
+func ScanLocalPaths(localPaths []string, excludeHidden bool) (int64, int64, error) {
+	files := int64(0)
+	bytes := int64(0)
+
+	for _, localPath := range localPaths {
+		rootPath := MakeLocalPath(localPath)
+		err := filepath.Walk(rootPath, func(walkPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if excludeHidden && walkPath != rootPath && strings.HasPrefix(info.Name(), ".") {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if info.Mode().IsRegular() {
+				files++
+				bytes += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, 0, xerrors.Errorf("failed to scan %q: %w", localPath, err)
+		}
+	}
+
+	return files, bytes, nil
+}
+
+// ScanIRODSPaths returns number of data objects and their total size under the iRODS paths, used to estimate ETA.
+// Hidden data objects and collections under the paths are not counted if excludeHidden is true.
+func ScanIRODSPaths(fs *irodsclient_fs.FileSystem, irodsPaths []string, excludeHidden bool) (int64, int64, error) {
+	files := int64(0)
+	bytes := int64(0)
+
+	var scan func(entry *irodsclient_fs.Entry) error
+	scan = func(entry *irodsclient_fs.Entry) error {
+		if !entry.IsDir() {
+			files++
+			bytes += entry.Size
+			return nil
+		}
+
+		entries, err := fs.List(entry.Path)
+		if err != nil {
+			return xerrors.Errorf("failed to list %q: %w", entry.Path, err)
+		}
+
+		for _, childEntry := range entries {
+			if excludeHidden && strings.HasPrefix(childEntry.Name, ".") {
+				continue
+			}
+
+			err = scan(childEntry)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, irodsPath := range irodsPaths {
+		entry, err := fs.Stat(irodsPath)
+		if err != nil {
+			return 0, 0, xerrors.Errorf("failed to stat %q: %w", irodsPath, err)
+		}
+
+		err = scan(entry)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return files, bytes, nil
+}
@@ -0,0 +1,118 @@
+package commons
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/progress"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferProgress(t *testing.T) {
+	t.Run("test Update", testTransferProgressUpdate)
+	t.Run("test ETA", testTransferProgressETA)
+	t.Run("test ZeroSizeAndSkip", testTransferProgressZeroSizeAndSkip)
+	t.Run("test PlainProgressWriter", testPlainProgressWriter)
+}
+
+func testTransferProgressUpdate(t *testing.T) {
+	transferProgress := NewTransferProgress("files")
+
+	transferProgress.Update("/a", 0, 100, true, false)
+	transferProgress.Update("/b", 0, 300, true, false)
+	transferProgress.Update("/a", 100, 100, true, false)
+	// progress reported again on job done must not be counted twice
+	transferProgress.Update("/a", 100, 100, true, false)
+	transferProgress.Update("/b", 150, 300, true, false)
+
+	done, failed, total := transferProgress.GetItems()
+	assert.Equal(t, int64(1), done)
+	assert.Equal(t, int64(0), failed)
+	assert.Equal(t, int64(2), total)
+
+	processed, totalBytes := transferProgress.GetBytes()
+	assert.Equal(t, int64(250), processed)
+	assert.Equal(t, int64(400), totalBytes)
+
+	activeJobs := transferProgress.GetActiveJobs()
+	assert.Len(t, activeJobs, 1)
+	assert.Equal(t, "/b", activeJobs[0].Name)
+
+	// failed after done
+	transferProgress.Update("/a", -1, 100, true, true)
+	done, failed, _ = transferProgress.GetItems()
+	assert.Equal(t, int64(0), done)
+	assert.Equal(t, int64(1), failed)
+
+	assert.Equal(t, "0/2 files (1 failed), 150 B/300 B", transferProgress.GetCountSummary())
+}
+
+func testTransferProgressETA(t *testing.T) {
+	transferProgress := NewTransferProgress("files")
+	transferProgress.startTime = time.Now().Add(-10 * time.Second)
+
+	transferProgress.Update("/a", 250, 1000, true, false)
+
+	_, ok := transferProgress.GetETA()
+	assert.False(t, ok)
+
+	transferProgress.SetExpectedTotal(10, 1000)
+	eta, ok := transferProgress.GetETA()
+	assert.True(t, ok)
+	assert.InDelta(t, (30 * time.Second).Seconds(), eta.Seconds(), 1)
+
+	_, _, total := transferProgress.GetItems()
+	assert.Equal(t, int64(10), total)
+}
+
+func testTransferProgressZeroSizeAndSkip(t *testing.T) {
+	transferProgress := NewTransferProgress("files")
+	transferProgress.SetExpectedTotal(4, 300)
+
+	// zero size files are done when they finish, not when they start
+	transferProgress.Update("/empty", 0, 0, true, false)
+	transferProgress.Update("/empty", 0, 0, true, false)
+
+	done, _, _ := transferProgress.GetItems()
+	assert.Equal(t, int64(0), done)
+
+	transferProgress.Done("/empty")
+	transferProgress.Done("/empty")
+
+	done, _, _ = transferProgress.GetItems()
+	assert.Equal(t, int64(1), done)
+
+	// files skipped are not expected anymore
+	transferProgress.Skip(100)
+	transferProgress.Skip(100)
+	transferProgress.Update("/a", 100, 100, true, false)
+
+	done, _, total := transferProgress.GetItems()
+	assert.Equal(t, int64(2), done)
+	assert.Equal(t, int64(2), total)
+
+	processed, totalBytes := transferProgress.GetBytes()
+	assert.Equal(t, int64(100), processed)
+	assert.Equal(t, int64(100), totalBytes)
+	assert.Equal(t, 1.0, transferProgress.GetFraction())
+}
+
+func testPlainProgressWriter(t *testing.T) {
+	output := &bytes.Buffer{}
+
+	writer := NewTransferProgressWriter(ProgressModePlain, time.Hour, false, false, "files")
+	writer.output = output
+
+	writer.Start()
+	writer.Progress("/a", 0, 10, progress.UnitsBytes, false)
+	writer.Progress("/a", 10, 10, progress.UnitsBytes, false)
+	writer.Progress("/b", 1, 1, progress.UnitsDefault, false)
+	writer.Stop(false)
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Len(t, lines, 1)
+	assert.True(t, strings.HasPrefix(lines[0], "[progress] 2/2 files, 10 B/10 B (100.0%)"))
+	assert.NotContains(t, lines[0], "\x1b")
+}
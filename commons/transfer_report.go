@@ -1,10 +1,16 @@
 package commons
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -31,6 +37,49 @@ const (
 	TransferMethodBputUnknown TransferMethod = "UNKNOWN"
 )
 
+// TransferReportFormat determines format of transfer report
+type TransferReportFormat string
+
+const (
+	// TransferReportFormatText is for tab-separated lines
+	TransferReportFormatText TransferReportFormat = "text"
+	// TransferReportFormatNDJSON is for JSON lines
+	TransferReportFormatNDJSON TransferReportFormat = "ndjson"
+	// TransferReportFormatCSV is for CSV with a header row
+	TransferReportFormatCSV TransferReportFormat = "csv"
+	// TransferReportFormatSummary is for totals only, written at the end
+	TransferReportFormatSummary TransferReportFormat = "summary"
+	// TransferReportFormatUnknown is for unknown format
+	TransferReportFormatUnknown TransferReportFormat = ""
+)
+
+// GetTransferReportFormat returns TransferReportFormat from string
+func GetTransferReportFormat(format string) TransferReportFormat {
+	switch strings.ToLower(format) {
+	case string(TransferReportFormatText), "txt":
+		return TransferReportFormatText
+	case string(TransferReportFormatNDJSON), "json", "jsonl":
+		return TransferReportFormatNDJSON
+	case string(TransferReportFormatCSV):
+		return TransferReportFormatCSV
+	case string(TransferReportFormatSummary):
+		return TransferReportFormatSummary
+	default:
+		return TransferReportFormatUnknown
+	}
+}
+
+// TransferReportStatus is a result of a file transfer
+type TransferReportStatus string
+
+const (
+	TransferReportStatusTransferred TransferReportStatus = "transferred"
+	TransferReportStatusSkipped     TransferReportStatus = "skipped"
+	TransferReportStatusFailed      TransferReportStatus = "failed"
+	TransferReportStatusDeleted     TransferReportStatus = "deleted"
+	TransferReportStatusDirectory   TransferReportStatus = "directory"
+)
+
 type TransferReportFile struct {
 	Method TransferMethod `json:"method"` // get, put, bput ...
 
@@ -50,6 +99,143 @@ type TransferReportFile struct {
 	Notes []string `json:"notes"` // additional notes
 }
 
+// transferReportFileJSON is TransferReportFile in JSON, with error in string and computed duration and throughput
+type transferReportFileJSON struct {
+	Method TransferMethod `json:"method"`
+
+	StartAt time.Time `json:"start_time"`
+	EndAt   time.Time `json:"end_at"`
+
+	SourcePath              string `json:"source_path"`
+	DestPath                string `json:"dest_path"`
+	SourceSize              int64  `json:"source_size"`
+	SourceChecksumAlgorithm string `json:"source_checksum_algorithm"`
+	SourceChecksum          string `json:"source_checksum"`
+	DestSize                int64  `json:"dest_size"`
+	DestChecksumAlgorithm   string `json:"dest_checksum_algorithm"`
+	DestChecksum            string `json:"dest_checksum"`
+
+	Error string   `json:"error,omitempty"`
+	Notes []string `json:"notes"`
+
+	Status     TransferReportStatus `json:"status"`
+	Duration   float64              `json:"duration_sec"`
+	Throughput float64              `json:"throughput_bytes_per_sec"`
+}
+
+// MarshalJSON marshals the file transfer with error in string.
+// It has a value receiver to marshal both values and pointers, otherwise errors are marshalled to '{}'
+func (file TransferReportFile) MarshalJSON() ([]byte, error) {
+	fileJSON := transferReportFileJSON{
+		Method:                  file.Method,
+		StartAt:                 file.StartAt,
+		EndAt:                   file.EndAt,
+		SourcePath:              file.SourcePath,
+		DestPath:                file.DestPath,
+		SourceSize:              file.SourceSize,
+		SourceChecksumAlgorithm: file.SourceChecksumAlgorithm,
+		SourceChecksum:          file.SourceChecksum,
+		DestSize:                file.DestSize,
+		DestChecksumAlgorithm:   file.DestChecksumAlgorithm,
+		DestChecksum:            file.DestChecksum,
+		Notes:                   file.Notes,
+		Status:                  file.GetStatus(),
+		Duration:                file.GetDuration().Seconds(),
+		Throughput:              file.GetThroughput(),
+	}
+
+	if file.Error != nil {
+		fileJSON.Error = file.Error.Error()
+	}
+
+	return json.Marshal(&fileJSON)
+}
+
+// UnmarshalJSON unmarshals the file transfer, error string is restored as an error
+func (file *TransferReportFile) UnmarshalJSON(data []byte) error {
+	// old reports have errors marshalled to '{}'
+	fileJSON := struct {
+		transferReportFileJSON
+		Error json.RawMessage `json:"error,omitempty"`
+	}{}
+
+	err := json.Unmarshal(data, &fileJSON)
+	if err != nil {
+		return err
+	}
+
+	file.Method = fileJSON.Method
+	file.StartAt = fileJSON.StartAt
+	file.EndAt = fileJSON.EndAt
+	file.SourcePath = fileJSON.SourcePath
+	file.DestPath = fileJSON.DestPath
+	file.SourceSize = fileJSON.SourceSize
+	file.SourceChecksumAlgorithm = fileJSON.SourceChecksumAlgorithm
+	file.SourceChecksum = fileJSON.SourceChecksum
+	file.DestSize = fileJSON.DestSize
+	file.DestChecksumAlgorithm = fileJSON.DestChecksumAlgorithm
+	file.DestChecksum = fileJSON.DestChecksum
+	file.Notes = fileJSON.Notes
+	file.Error = nil
+
+	if len(fileJSON.Error) > 0 && string(fileJSON.Error) != "null" {
+		errString := ""
+		err = json.Unmarshal(fileJSON.Error, &errString)
+		if err != nil || len(errString) == 0 {
+			errString = "unknown error"
+		}
+
+		file.Error = errors.New(errString)
+	}
+
+	return nil
+}
+
+// GetDuration returns time taken to transfer the file
+func (file *TransferReportFile) GetDuration() time.Duration {
+	if file.EndAt.Before(file.StartAt) {
+		return 0
+	}
+
+	return file.EndAt.Sub(file.StartAt)
+}
+
+// GetThroughput returns bytes transferred per second, 0 if not measurable
+func (file *TransferReportFile) GetThroughput() float64 {
+	duration := file.GetDuration().Seconds()
+	if duration <= 0 || file.GetStatus() != TransferReportStatusTransferred {
+		return 0
+	}
+
+	return float64(file.SourceSize) / duration
+}
+
+// HasNote returns true if the file transfer has the note
+func (file *TransferReportFile) HasNote(note string) bool {
+	for _, fileNote := range file.Notes {
+		if fileNote == note {
+			return true
+		}
+	}
+	return false
+}
+
+// GetStatus returns the result of the file transfer
+func (file *TransferReportFile) GetStatus() TransferReportStatus {
+	switch {
+	case file.Error != nil:
+		return TransferReportStatusFailed
+	case file.Method == TransferMethodDelete:
+		return TransferReportStatusDeleted
+	case file.HasNote("skip"):
+		return TransferReportStatusSkipped
+	case file.HasNote("directory"):
+		return TransferReportStatusDirectory
+	default:
+		return TransferReportStatusTransferred
+	}
+}
+
 // GetTransferMethod returns transfer method
 func GetTransferMethod(method string) TransferMethod {
 	switch strings.ToUpper(method) {
@@ -120,13 +306,28 @@ type TransferReportManager struct {
 	reportPath     string
 	report         bool
 	reportToStdout bool
+	format         TransferReportFormat
+
+	writer    io.WriteCloser
+	csvWriter *csv.Writer
+	summary   *TransferReportSummary
+	lock      sync.Mutex
 
-	writer io.WriteCloser
-	lock   sync.Mutex
+	eventWriter        *EventWriter
+	transferStats      *TransferStats
+	parallelJobManager *ParallelJobManager
 }
 
 // NewTransferReportManager creates a new TransferReportManager
-func NewTransferReportManager(report bool, reportPath string, reportToStdout bool) (*TransferReportManager, error) {
+func NewTransferReportManager(report bool, reportPath string, reportToStdout bool, format TransferReportFormat) (*TransferReportManager, error) {
+	if format == TransferReportFormatUnknown {
+		// text for stdout, json lines for files
+		format = TransferReportFormatNDJSON
+		if reportToStdout {
+			format = TransferReportFormatText
+		}
+	}
+
 	var writer io.WriteCloser
 	if !report {
 		writer = nil
@@ -146,17 +347,42 @@ func NewTransferReportManager(report bool, reportPath string, reportToStdout boo
 		report:         report,
 		reportPath:     reportPath,
 		reportToStdout: reportToStdout,
+		format:         format,
 
 		writer: writer,
 		lock:   sync.Mutex{},
 	}
 
+	if writer != nil {
+		switch format {
+		case TransferReportFormatCSV:
+			manager.csvWriter = csv.NewWriter(writer)
+			err := manager.csvWriter.Write(transferReportCSVHeader)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to write csv header: %w", err)
+			}
+		case TransferReportFormatSummary:
+			manager.summary = NewTransferReportSummary(0)
+		}
+	}
+
 	return manager, nil
 }
 
 // Release releases resources
 func (manager *TransferReportManager) Release() {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
 	if manager.writer != nil {
+		if manager.csvWriter != nil {
+			manager.csvWriter.Flush()
+		}
+
+		if manager.summary != nil {
+			manager.summary.Write(manager.writer)
+		}
+
 		if !manager.reportToStdout {
 			manager.writer.Close()
 		}
@@ -165,39 +391,82 @@ func (manager *TransferReportManager) Release() {
 	}
 }
 
+// SetEventWriter sets a writer to emit events of skipped file transfers
+func (manager *TransferReportManager) SetEventWriter(eventWriter *EventWriter) {
+	manager.eventWriter = eventWriter
+}
+
+// SetTransferStats sets stats to collect all file transfers, even if reporting is off
+func (manager *TransferReportManager) SetTransferStats(transferStats *TransferStats) {
+	manager.transferStats = transferStats
+}
+
+// SetParallelJobManager sets a job manager to exclude skipped files from its progress
+func (manager *TransferReportManager) SetParallelJobManager(parallelJobManager *ParallelJobManager) {
+	manager.parallelJobManager = parallelJobManager
+}
+
 // AddFile adds a new file transfer
 func (manager *TransferReportManager) AddFile(file *TransferReportFile) error {
+	if file.GetStatus() == TransferReportStatusSkipped {
+		manager.eventWriter.Skipped(file)
+
+		if manager.parallelJobManager != nil {
+			manager.parallelJobManager.SkipExpected(file.SourceSize)
+		}
+	}
+
+	manager.transferStats.AddFile(file)
+
 	if !manager.report {
 		return nil
 	}
 
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
 	if manager.writer == nil {
 		return nil
 	}
 
-	manager.lock.Lock()
-	defer manager.lock.Unlock()
+	switch manager.format {
+	case TransferReportFormatText:
+		errString := ""
+		if file.Error != nil {
+			errString = file.Error.Error()
+		}
 
-	lineOutput := ""
-	if manager.reportToStdout {
-		// line print
-		Printf("[%s]\t%s\t%s\t%s\t%d\t%s\t%s\t%d\t%s\n", file.Method, file.StartAt, file.EndAt, file.SourcePath, file.SourceSize, file.SourceChecksum, file.DestPath, file.DestSize, file.DestChecksum)
-	} else {
+		line := fmt.Sprintf("[%s]\t%s\t%s\t%s\t%d\t%s\t%s\t%d\t%s\t%s\t%s\n", file.Method, file.StartAt, file.EndAt, file.SourcePath, file.SourceSize, file.SourceChecksum, file.DestPath, file.DestSize, file.DestChecksum, file.GetDuration(), errString)
+		if manager.reportToStdout {
+			// line print
+			Print(line)
+			return nil
+		}
+
+		_, err := manager.writer.Write([]byte(line))
+		return err
+	case TransferReportFormatCSV:
+		err := manager.csvWriter.Write(makeTransferReportCSVRow(file))
+		if err != nil {
+			return err
+		}
+
+		// flush each row not to lose records if the command is interrupted
+		manager.csvWriter.Flush()
+		return manager.csvWriter.Error()
+	case TransferReportFormatSummary:
+		manager.summary.Add(file)
+		return nil
+	default:
 		// json
 		fileBytes, err := json.Marshal(file)
 		if err != nil {
 			return err
 		}
 
-		lineOutput = string(fileBytes) + "\n"
-	}
-
-	_, err := manager.writer.Write([]byte(lineOutput))
-	if err != nil {
+		_, err = manager.writer.Write(append(fileBytes, '\n'))
 		return err
 	}
-
-	return nil
 }
 
 // AddTransfer adds a new file transfer
@@ -209,3 +478,229 @@ func (manager *TransferReportManager) AddTransfer(result *irodsclient_fs.FileTra
 
 	return manager.AddFile(file)
 }
+
+var transferReportCSVHeader = []string{
+	"method", "start_time", "end_at",
+	"source_path", "dest_path",
+	"source_size", "source_checksum_algorithm", "source_checksum",
+	"dest_size", "dest_checksum_algorithm", "dest_checksum",
+	"error", "notes",
+	"status", "duration_sec", "throughput_bytes_per_sec",
+}
+
+func makeTransferReportCSVRow(file *TransferReportFile) []string {
+	errString := ""
+	if file.Error != nil {
+		errString = file.Error.Error()
+	}
+
+	return []string{
+		string(file.Method), file.StartAt.Format(time.RFC3339Nano), file.EndAt.Format(time.RFC3339Nano),
+		file.SourcePath, file.DestPath,
+		strconv.FormatInt(file.SourceSize, 10), file.SourceChecksumAlgorithm, file.SourceChecksum,
+		strconv.FormatInt(file.DestSize, 10), file.DestChecksumAlgorithm, file.DestChecksum,
+		errString, strings.Join(file.Notes, ";"),
+		string(file.GetStatus()), strconv.FormatFloat(file.GetDuration().Seconds(), 'f', 3, 64), strconv.FormatFloat(file.GetThroughput(), 'f', 0, 64),
+	}
+}
+
+func parseTransferReportCSVRow(header []string, row []string) (*TransferReportFile, error) {
+	file := &TransferReportFile{}
+
+	for idx, column := range header {
+		if idx >= len(row) {
+			break
+		}
+
+		value := row[idx]
+
+		var err error
+		switch column {
+		case "method":
+			file.Method = TransferMethod(value)
+		case "start_time":
+			file.StartAt, err = time.Parse(time.RFC3339Nano, value)
+		case "end_at":
+			file.EndAt, err = time.Parse(time.RFC3339Nano, value)
+		case "source_path":
+			file.SourcePath = value
+		case "dest_path":
+			file.DestPath = value
+		case "source_size":
+			file.SourceSize, err = strconv.ParseInt(value, 10, 64)
+		case "source_checksum_algorithm":
+			file.SourceChecksumAlgorithm = value
+		case "source_checksum":
+			file.SourceChecksum = value
+		case "dest_size":
+			file.DestSize, err = strconv.ParseInt(value, 10, 64)
+		case "dest_checksum_algorithm":
+			file.DestChecksumAlgorithm = value
+		case "dest_checksum":
+			file.DestChecksum = value
+		case "error":
+			if len(value) > 0 {
+				file.Error = errors.New(value)
+			}
+		case "notes":
+			if len(value) > 0 {
+				file.Notes = strings.Split(value, ";")
+			}
+		}
+
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse column %q value %q: %w", column, value, err)
+		}
+	}
+
+	return file, nil
+}
+
+// ReadTransferReportFiles reads file transfers from a report in JSON lines or CSV, detected from the content
+func ReadTransferReportFiles(reader io.Reader) ([]*TransferReportFile, error) {
+	bufReader := bufio.NewReader(reader)
+
+	// detect format
+	peek, err := bufReader.Peek(1)
+	if err != nil {
+		if err == io.EOF {
+			// empty
+			return []*TransferReportFile{}, nil
+		}
+		return nil, xerrors.Errorf("failed to read report: %w", err)
+	}
+
+	if peek[0] == '{' {
+		return readTransferReportFilesJSON(bufReader)
+	}
+
+	return readTransferReportFilesCSV(bufReader)
+}
+
+func readTransferReportFilesJSON(reader io.Reader) ([]*TransferReportFile, error) {
+	files := []*TransferReportFile{}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		file := &TransferReportFile{}
+		err := json.Unmarshal(line, file)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse report line %d: %w", lineNum, err)
+		}
+
+		files = append(files, file)
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read report: %w", err)
+	}
+
+	return files, nil
+}
+
+func readTransferReportFilesCSV(reader io.Reader) ([]*TransferReportFile, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read report header: %w", err)
+	}
+
+	if len(header) == 0 || header[0] != transferReportCSVHeader[0] {
+		return nil, xerrors.Errorf("unknown report format, must be JSON lines or CSV")
+	}
+
+	files := []*TransferReportFile{}
+	for {
+		row, err := csvReader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, xerrors.Errorf("failed to read report row: %w", err)
+		}
+
+		file, err := parseTransferReportCSVRow(header, row)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse report row: %w", err)
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// ReadTransferReportFilesFromPath reads file transfers from a report file
+func ReadTransferReportFilesFromPath(reportPath string) ([]*TransferReportFile, error) {
+	reportFile, err := os.Open(reportPath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to open report %q: %w", reportPath, err)
+	}
+	defer reportFile.Close()
+
+	files, err := ReadTransferReportFiles(reportFile)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read report %q: %w", reportPath, err)
+	}
+
+	return files, nil
+}
+
+// GetTransferReportFilesToRetry returns transfers of the given methods to retry if they ended in one of the given statuses.
+// Only the last transfer is looked at for each pair of source and destination, so files retried successfully before are not retried again.
+func GetTransferReportFilesToRetry(files []*TransferReportFile, methods []TransferMethod, statuses []TransferReportStatus) []*TransferReportFile {
+	lastFiles := map[string]*TransferReportFile{}
+	keys := []string{}
+
+	for _, file := range files {
+		if !containsTransferMethod(methods, file.Method) {
+			continue
+		}
+
+		status := file.GetStatus()
+		if status == TransferReportStatusDirectory || status == TransferReportStatusDeleted || len(file.DestPath) == 0 {
+			continue
+		}
+
+		key := file.SourcePath + "\x00" + file.DestPath
+		if _, ok := lastFiles[key]; !ok {
+			keys = append(keys, key)
+		}
+		lastFiles[key] = file
+	}
+
+	retryFiles := []*TransferReportFile{}
+	for _, key := range keys {
+		file := lastFiles[key]
+		for _, status := range statuses {
+			if file.GetStatus() == status {
+				retryFiles = append(retryFiles, file)
+				break
+			}
+		}
+	}
+
+	return retryFiles
+}
+
+func containsTransferMethod(methods []TransferMethod, method TransferMethod) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
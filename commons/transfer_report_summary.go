@@ -0,0 +1,193 @@
+package commons
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// TransferReportSummary has totals of file transfers in reports
+type TransferReportSummary struct {
+	Files            int     `json:"files"`
+	Transferred      int     `json:"transferred"`
+	Skipped          int     `json:"skipped"`
+	Failed           int     `json:"failed"`
+	Deleted          int     `json:"deleted"`
+	Directories      int     `json:"directories"`
+	TransferredBytes int64   `json:"transferred_bytes"`
+	Duration         float64 `json:"duration_sec"`
+	Throughput       float64 `json:"throughput_bytes_per_sec"`
+	PeakThroughput   float64 `json:"peak_throughput_bytes_per_sec"`
+
+	// transferred files by transfer mode, e.g., "icat" or "redirect-to-resource"
+	Modes map[string]int `json:"modes"`
+
+	StartAt time.Time `json:"start_time"`
+	EndAt   time.Time `json:"end_at"`
+
+	Failures []*TransferReportFile `json:"failures"`
+	Slowest  []*TransferReportFile `json:"slowest"`
+
+	slowestCount int
+	// transferred bytes per second, keyed by unix time
+	bytesPerSecond map[int64]float64
+}
+
+// transferModeNotes are notes of transferred files telling how they were transferred
+var transferModeNotes = []string{"icat", "redirect-to-resource", "single-thread", "multi-thread", "resume"}
+
+// NewTransferReportSummary creates a new TransferReportSummary, keeping the given number of slowest transfers
+func NewTransferReportSummary(slowestCount int) *TransferReportSummary {
+	return &TransferReportSummary{
+		Failures:     []*TransferReportFile{},
+		Slowest:      []*TransferReportFile{},
+		Modes:        map[string]int{},
+		slowestCount: slowestCount,
+
+		bytesPerSecond: map[int64]float64{},
+	}
+}
+
+// Add adds a file transfer to the totals
+func (summary *TransferReportSummary) Add(file *TransferReportFile) {
+	summary.Files++
+
+	switch file.GetStatus() {
+	case TransferReportStatusFailed:
+		summary.Failed++
+		summary.Failures = append(summary.Failures, file)
+	case TransferReportStatusSkipped:
+		summary.Skipped++
+	case TransferReportStatusDeleted:
+		summary.Deleted++
+	case TransferReportStatusDirectory:
+		summary.Directories++
+	case TransferReportStatusTransferred:
+		summary.Transferred++
+		summary.TransferredBytes += file.SourceSize
+		summary.addSlowest(file)
+		summary.addModes(file)
+		summary.addBytesPerSecond(file)
+	}
+
+	if !file.StartAt.IsZero() && (summary.StartAt.IsZero() || file.StartAt.Before(summary.StartAt)) {
+		summary.StartAt = file.StartAt
+	}
+
+	if file.EndAt.After(summary.EndAt) {
+		summary.EndAt = file.EndAt
+	}
+
+	summary.Duration = 0
+	summary.Throughput = 0
+	if !summary.StartAt.IsZero() && summary.EndAt.After(summary.StartAt) {
+		summary.Duration = summary.EndAt.Sub(summary.StartAt).Seconds()
+		summary.Throughput = float64(summary.TransferredBytes) / summary.Duration
+	}
+}
+
+func (summary *TransferReportSummary) addModes(file *TransferReportFile) {
+	for _, note := range transferModeNotes {
+		if file.HasNote(note) {
+			summary.Modes[note]++
+		}
+	}
+}
+
+// addBytesPerSecond spreads bytes of the file over its transfer time evenly to find the peak throughput
+func (summary *TransferReportSummary) addBytesPerSecond(file *TransferReportFile) {
+	if file.StartAt.IsZero() || file.SourceSize <= 0 {
+		return
+	}
+
+	add := func(sec int64, bytes float64) {
+		summary.bytesPerSecond[sec] += bytes
+		if summary.bytesPerSecond[sec] > summary.PeakThroughput {
+			summary.PeakThroughput = summary.bytesPerSecond[sec]
+		}
+	}
+
+	if !file.EndAt.After(file.StartAt) {
+		add(file.StartAt.Unix(), float64(file.SourceSize))
+		return
+	}
+
+	rate := float64(file.SourceSize) / file.EndAt.Sub(file.StartAt).Seconds()
+	for sec := file.StartAt.Unix(); sec <= file.EndAt.Unix(); sec++ {
+		from := time.Unix(sec, 0)
+		if from.Before(file.StartAt) {
+			from = file.StartAt
+		}
+
+		to := time.Unix(sec+1, 0)
+		if to.After(file.EndAt) {
+			to = file.EndAt
+		}
+
+		if to.After(from) {
+			add(sec, rate*to.Sub(from).Seconds())
+		}
+	}
+}
+
+func (summary *TransferReportSummary) addSlowest(file *TransferReportFile) {
+	if summary.slowestCount <= 0 {
+		return
+	}
+
+	summary.Slowest = append(summary.Slowest, file)
+	sort.SliceStable(summary.Slowest, func(i int, j int) bool {
+		return summary.Slowest[i].GetDuration() > summary.Slowest[j].GetDuration()
+	})
+
+	if len(summary.Slowest) > summary.slowestCount {
+		summary.Slowest = summary.Slowest[:summary.slowestCount]
+	}
+}
+
+// GetModes returns transfer modes seen, sorted
+func (summary *TransferReportSummary) GetModes() []string {
+	modes := []string{}
+	for mode := range summary.Modes {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+	return modes
+}
+
+// Write writes the totals, failures and slowest transfers in text
+func (summary *TransferReportSummary) Write(writer io.Writer) {
+	fmt.Fprintf(writer, "files: %d\n", summary.Files)
+	fmt.Fprintf(writer, "  transferred: %d (%s)\n", summary.Transferred, humanize.Bytes(uint64(summary.TransferredBytes)))
+	fmt.Fprintf(writer, "  skipped: %d\n", summary.Skipped)
+	fmt.Fprintf(writer, "  failed: %d\n", summary.Failed)
+	fmt.Fprintf(writer, "  deleted: %d\n", summary.Deleted)
+	fmt.Fprintf(writer, "  directories: %d\n", summary.Directories)
+	fmt.Fprintf(writer, "duration: %s\n", time.Duration(summary.Duration*float64(time.Second)).Round(time.Millisecond))
+	fmt.Fprintf(writer, "throughput: %s/s\n", humanize.Bytes(uint64(summary.Throughput)))
+	fmt.Fprintf(writer, "peak throughput: %s/s\n", humanize.Bytes(uint64(summary.PeakThroughput)))
+
+	if len(summary.Modes) > 0 {
+		fmt.Fprintf(writer, "modes:\n")
+		for _, mode := range summary.GetModes() {
+			fmt.Fprintf(writer, "  %s: %d\n", mode, summary.Modes[mode])
+		}
+	}
+
+	if len(summary.Failures) > 0 {
+		fmt.Fprintf(writer, "failures:\n")
+		for _, file := range summary.Failures {
+			fmt.Fprintf(writer, "  [%s] %s -> %s: %s\n", file.Method, file.SourcePath, file.DestPath, file.Error.Error())
+		}
+	}
+
+	if len(summary.Slowest) > 0 {
+		fmt.Fprintf(writer, "slowest:\n")
+		for _, file := range summary.Slowest {
+			fmt.Fprintf(writer, "  [%s] %s -> %s: %s, %s, %s/s\n", file.Method, file.SourcePath, file.DestPath, file.GetDuration().Round(time.Millisecond), humanize.Bytes(uint64(file.SourceSize)), humanize.Bytes(uint64(file.GetThroughput())))
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package commons
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
+)
+
+func TestTransferReport(t *testing.T) {
+	t.Run("test TransferReportFileJSON", testTransferReportFileJSON)
+	t.Run("test TransferReportFileCSV", testTransferReportFileCSV)
+	t.Run("test TransferReportSummary", testTransferReportSummary)
+	t.Run("test TransferReportFilesToRetry", testTransferReportFilesToRetry)
+	t.Run("test TransferReportFailedBundles", testTransferReportFailedBundles)
+	t.Run("test TransferReportFailedDownloadBundles", testTransferReportFailedDownloadBundles)
+}
+
+func makeTestTransferReportFiles() []*TransferReportFile {
+	startAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	return []*TransferReportFile{
+		{
+			Method:     TransferMethodPut,
+			StartAt:    startAt,
+			EndAt:      startAt.Add(2 * time.Second),
+			SourcePath: "/local/a.txt",
+			DestPath:   "/zone/home/user/a.txt",
+			SourceSize: 2000,
+			DestSize:   2000,
+			Notes:      []string{"put"},
+		},
+		{
+			Method:     TransferMethodPut,
+			StartAt:    startAt,
+			EndAt:      startAt.Add(time.Second),
+			SourcePath: "/local/b.txt",
+			DestPath:   "/zone/home/user/b.txt",
+			Error:      xerrors.Errorf("failed to upload"),
+		},
+		{
+			Method:     TransferMethodPut,
+			StartAt:    startAt,
+			EndAt:      startAt,
+			SourcePath: "/local/c.txt",
+			DestPath:   "/zone/home/user/c.txt",
+			Notes:      []string{"no_overwrite", "skip"},
+		},
+	}
+}
+
+func testTransferReportFileJSON(t *testing.T) {
+	files := makeTestTransferReportFiles()
+
+	marshalled, err := json.Marshal(files[1])
+	assert.NoError(t, err)
+	assert.Contains(t, string(marshalled), "\"error\":\"failed to upload\"")
+	assert.Contains(t, string(marshalled), "\"status\":\"failed\"")
+
+	// values and plain errors
+	plainErrorFile := *files[1]
+	plainErrorFile.Error = errors.New("connection refused")
+
+	marshalled, err = json.Marshal(plainErrorFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(marshalled), "\"error\":\"connection refused\"")
+
+	marshalled, err = json.Marshal(files[0])
+	assert.NoError(t, err)
+	assert.Contains(t, string(marshalled), "\"duration_sec\":2")
+	assert.Contains(t, string(marshalled), "\"throughput_bytes_per_sec\":1000")
+
+	buffer := &bytes.Buffer{}
+	for _, file := range files {
+		fileBytes, err := json.Marshal(file)
+		assert.NoError(t, err)
+		buffer.Write(append(fileBytes, '\n'))
+	}
+
+	readFiles, err := ReadTransferReportFiles(buffer)
+	assert.NoError(t, err)
+	assert.Len(t, readFiles, 3)
+	assert.EqualError(t, readFiles[1].Error, "failed to upload")
+	assert.Equal(t, TransferReportStatusSkipped, readFiles[2].GetStatus())
+	assert.Equal(t, 2*time.Second, readFiles[0].GetDuration())
+}
+
+func testTransferReportFileCSV(t *testing.T) {
+	files := makeTestTransferReportFiles()
+
+	buffer := &bytes.Buffer{}
+	csvWriter := csv.NewWriter(buffer)
+	csvWriter.Write(transferReportCSVHeader)
+	for _, file := range files {
+		csvWriter.Write(makeTransferReportCSVRow(file))
+	}
+	csvWriter.Flush()
+
+	readFiles, err := ReadTransferReportFiles(buffer)
+	assert.NoError(t, err)
+	assert.Len(t, readFiles, 3)
+	assert.Equal(t, "/local/a.txt", readFiles[0].SourcePath)
+	assert.Equal(t, int64(2000), readFiles[0].SourceSize)
+	assert.EqualError(t, readFiles[1].Error, "failed to upload")
+	assert.Equal(t, []string{"no_overwrite", "skip"}, readFiles[2].Notes)
+}
+
+func testTransferReportSummary(t *testing.T) {
+	summary := NewTransferReportSummary(1)
+	for _, file := range makeTestTransferReportFiles() {
+		summary.Add(file)
+	}
+
+	assert.Equal(t, 3, summary.Files)
+	assert.Equal(t, 1, summary.Transferred)
+	assert.Equal(t, 1, summary.Failed)
+	assert.Equal(t, 1, summary.Skipped)
+	assert.Equal(t, int64(2000), summary.TransferredBytes)
+	assert.Equal(t, 2.0, summary.Duration)
+	assert.Len(t, summary.Failures, 1)
+	assert.Len(t, summary.Slowest, 1)
+	assert.Equal(t, "/local/a.txt", summary.Slowest[0].SourcePath)
+}
+
+func testTransferReportFilesToRetry(t *testing.T) {
+	files := makeTestTransferReportFiles()
+
+	// a later successful retry of c.txt
+	files = append(files, &TransferReportFile{
+		Method:     TransferMethodBput,
+		SourcePath: "/local/c.txt",
+		DestPath:   "/zone/home/user/c.txt",
+	}, &TransferReportFile{
+		Method:     TransferMethodGet,
+		SourcePath: "/zone/home/user/d.txt",
+		DestPath:   "/local/d.txt",
+		Error:      xerrors.Errorf("failed to download"),
+	})
+
+	retryFiles := GetTransferReportFilesToRetry(files, []TransferMethod{TransferMethodPut, TransferMethodBput}, []TransferReportStatus{TransferReportStatusFailed})
+	assert.Len(t, retryFiles, 1)
+	assert.Equal(t, "/local/b.txt", retryFiles[0].SourcePath)
+
+	retryFiles = GetTransferReportFilesToRetry(files, []TransferMethod{TransferMethodPut, TransferMethodBput}, []TransferReportStatus{TransferReportStatusFailed, TransferReportStatusSkipped})
+	assert.Len(t, retryFiles, 1)
+
+	retryFiles = GetTransferReportFilesToRetry(files, []TransferMethod{TransferMethodGet}, []TransferReportStatus{TransferReportStatusFailed})
+	assert.Len(t, retryFiles, 1)
+	assert.Equal(t, "/local/d.txt", retryFiles[0].DestPath)
+}
+
+func testTransferReportFailedBundles(t *testing.T) {
+	reportPath := filepath.Join(t.TempDir(), "report.ndjson")
+
+	reportManager, err := NewTransferReportManager(true, reportPath, false, TransferReportFormatNDJSON)
+	assert.NoError(t, err)
+
+	completedEntries := makeTestBundleEntries(10, 10)
+	failedEntries := makeTestBundleEntries(10, 10, 10)
+	notProcessedEntries := makeTestBundleEntries(10)
+
+	// the first file of the failed bundle was uploaded before failure
+	failedEntries[0].reported = true
+	notProcessedEntries[0].LocalPath = "/data/not_processed"
+
+	manager := &BundleTransferManager{
+		transferReportManager: reportManager,
+		bundles: []*Bundle{
+			{Entries: completedEntries, Completed: true},
+			{Entries: failedEntries, LastError: xerrors.Errorf("failed to upload"), LastErrorTaskName: BundleTaskNameUpload},
+			{Entries: notProcessedEntries},
+		},
+	}
+
+	manager.reportFailedBundles(xerrors.Errorf("failed to bundle-put"))
+	reportManager.Release()
+
+	reportFiles, err := ReadTransferReportFilesFromPath(reportPath)
+	assert.NoError(t, err)
+	assert.Len(t, reportFiles, 3)
+
+	retryFiles := GetTransferReportFilesToRetry(reportFiles, []TransferMethod{TransferMethodPut, TransferMethodBput}, []TransferReportStatus{TransferReportStatusFailed})
+	assert.Len(t, retryFiles, 3)
+
+	retryPaths := []string{}
+	for _, retryFile := range retryFiles {
+		retryPaths = append(retryPaths, retryFile.SourcePath)
+	}
+	assert.ElementsMatch(t, []string{"/data/1", "/data/2", "/data/not_processed"}, retryPaths)
+
+	// reported only once
+	manager.reportFailedBundles(xerrors.Errorf("failed to bundle-put"))
+	assert.True(t, notProcessedEntries[0].reported)
+}
+
+func testTransferReportFailedDownloadBundles(t *testing.T) {
+	reportPath := filepath.Join(t.TempDir(), "report.ndjson")
+
+	reportManager, err := NewTransferReportManager(true, reportPath, false, TransferReportFormatNDJSON)
+	assert.NoError(t, err)
+
+	completedEntries := makeTestBundleEntries(10, 10)
+	failedEntries := makeTestBundleEntries(10, 10, 10)
+	notProcessedEntries := makeTestBundleEntries(10)
+
+	// the first data object of the failed bundle was extracted before failure
+	failedEntries[0].reported = true
+	notProcessedEntries[0].IRODSPath = "/zone/home/user/not_processed"
+
+	manager := &BundleDownloadManager{
+		transferReportManager: reportManager,
+		bundles: []*Bundle{
+			{Entries: completedEntries, Completed: true},
+			{Entries: failedEntries, LastError: xerrors.Errorf("failed to extract"), LastErrorTaskName: BundleTaskNameExtract},
+			{Entries: notProcessedEntries},
+		},
+	}
+
+	manager.reportFailedBundles(xerrors.Errorf("failed to bundle-get"))
+	reportManager.Release()
+
+	reportFiles, err := ReadTransferReportFilesFromPath(reportPath)
+	assert.NoError(t, err)
+	assert.Len(t, reportFiles, 3)
+
+	retryFiles := GetTransferReportFilesToRetry(reportFiles, []TransferMethod{TransferMethodGet}, []TransferReportStatus{TransferReportStatusFailed})
+	assert.Len(t, retryFiles, 3)
+
+	retryPaths := []string{}
+	for _, retryFile := range retryFiles {
+		retryPaths = append(retryPaths, retryFile.SourcePath)
+	}
+	assert.ElementsMatch(t, []string{"/zone/home/user/1", "/zone/home/user/2", "/zone/home/user/not_processed"}, retryPaths)
+}
@@ -0,0 +1,229 @@
+package commons
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"golang.org/x/xerrors"
+)
+
+// TransferStats collects statistics of a transfer command run.
+// All methods do nothing on nil TransferStats, so it can be passed around when stats are off.
+type TransferStats struct {
+	command         string
+	show            bool
+	openMetricsPath string
+
+	summary      *TransferReportSummary
+	startTime    time.Time
+	endTime      time.Time
+	listingTime  time.Duration
+	bundlingTime time.Duration
+	err          error
+
+	mutex sync.Mutex
+}
+
+// NewTransferStats creates a new TransferStats for the command.
+// Stats are displayed on finish if show is set, and written in OpenMetrics text format if the path is given.
+func NewTransferStats(command string, show bool, openMetricsPath string) *TransferStats {
+	return &TransferStats{
+		command:         command,
+		show:            show,
+		openMetricsPath: openMetricsPath,
+
+		summary:   NewTransferReportSummary(0),
+		startTime: time.Now(),
+	}
+}
+
+// AddFile adds a file transfer
+func (stats *TransferStats) AddFile(file *TransferReportFile) {
+	if stats == nil {
+		return
+	}
+
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+
+	stats.summary.Add(file)
+}
+
+// AddListingTime adds time spent in listing sources and scheduling transfers
+func (stats *TransferStats) AddListingTime(duration time.Duration) {
+	if stats == nil {
+		return
+	}
+
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+
+	stats.listingTime += duration
+}
+
+// AddBundlingTime adds time spent in making bundle files
+func (stats *TransferStats) AddBundlingTime(duration time.Duration) {
+	if stats == nil {
+		return
+	}
+
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+
+	stats.bundlingTime += duration
+}
+
+// Finish ends the stats with the result of the command, then displays and writes them
+func (stats *TransferStats) Finish(commandErr error) error {
+	if stats == nil {
+		return nil
+	}
+
+	stats.mutex.Lock()
+	stats.endTime = time.Now()
+	stats.err = commandErr
+	stats.mutex.Unlock()
+
+	if stats.show {
+		stats.Write(GetTerminalWriter())
+	}
+
+	if len(stats.openMetricsPath) > 0 {
+		err := stats.WriteOpenMetricsFile(stats.openMetricsPath)
+		if err != nil {
+			return xerrors.Errorf("failed to write stats to %q: %w", stats.openMetricsPath, err)
+		}
+	}
+
+	return nil
+}
+
+// GetWallTime returns time from the start to the finish
+func (stats *TransferStats) GetWallTime() time.Duration {
+	endTime := stats.endTime
+	if endTime.IsZero() {
+		endTime = time.Now()
+	}
+	return endTime.Sub(stats.startTime)
+}
+
+// GetTransferTime returns time from the first file transfer started to the last ended
+func (stats *TransferStats) GetTransferTime() time.Duration {
+	return time.Duration(stats.summary.Duration * float64(time.Second))
+}
+
+// Write writes the stats in text
+func (stats *TransferStats) Write(writer io.Writer) {
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+
+	summary := stats.summary
+
+	fmt.Fprintf(writer, "stats:\n")
+	fmt.Fprintf(writer, "  files: %d transferred, %d skipped, %d failed\n", summary.Transferred, summary.Skipped, summary.Failed)
+	fmt.Fprintf(writer, "  bytes: %s\n", humanize.Bytes(uint64(summary.TransferredBytes)))
+	fmt.Fprintf(writer, "  wall time: %s\n", stats.GetWallTime().Round(time.Millisecond))
+	fmt.Fprintf(writer, "    listing: %s\n", stats.listingTime.Round(time.Millisecond))
+	fmt.Fprintf(writer, "    transfer: %s\n", stats.GetTransferTime().Round(time.Millisecond))
+	fmt.Fprintf(writer, "    bundling: %s\n", stats.bundlingTime.Round(time.Millisecond))
+	fmt.Fprintf(writer, "  throughput: %s/s average, %s/s peak\n", humanize.Bytes(uint64(summary.Throughput)), humanize.Bytes(uint64(summary.PeakThroughput)))
+
+	if len(summary.Modes) > 0 {
+		modes := []string{}
+		for _, mode := range summary.GetModes() {
+			modes = append(modes, fmt.Sprintf("%s %d", mode, summary.Modes[mode]))
+		}
+		fmt.Fprintf(writer, "  modes: %s\n", strings.Join(modes, ", "))
+	}
+}
+
+// WriteOpenMetrics writes the stats in OpenMetrics text format
+func (stats *TransferStats) WriteOpenMetrics(writer io.Writer) {
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+
+	summary := stats.summary
+	command := fmt.Sprintf("command=%q", stats.command)
+
+	writeMetric := func(name string, metricType string, help string, samples ...string) {
+		fmt.Fprintf(writer, "# TYPE %s %s\n", name, metricType)
+		fmt.Fprintf(writer, "# HELP %s %s\n", name, help)
+		for _, sample := range samples {
+			fmt.Fprintf(writer, "%s%s\n", name, sample)
+		}
+	}
+
+	sample := func(value float64, labels ...string) string {
+		return fmt.Sprintf("{%s} %s", strings.Join(append([]string{command}, labels...), ","), strconv.FormatFloat(value, 'g', -1, 64))
+	}
+
+	success := 1.0
+	if stats.err != nil {
+		success = 0
+	}
+
+	writeMetric("gocmd_transfer_files", "gauge", "Number of files by transfer status.",
+		sample(float64(summary.Transferred), `status="transferred"`),
+		sample(float64(summary.Skipped), `status="skipped"`),
+		sample(float64(summary.Failed), `status="failed"`),
+	)
+	writeMetric("gocmd_transfer_bytes", "gauge", "Bytes transferred.", sample(float64(summary.TransferredBytes)))
+	writeMetric("gocmd_transfer_wall_time_seconds", "gauge", "Wall time of the run.", sample(stats.GetWallTime().Seconds()))
+	writeMetric("gocmd_transfer_phase_seconds", "gauge", "Time spent in each phase of the run.",
+		sample(stats.listingTime.Seconds(), `phase="listing"`),
+		sample(stats.GetTransferTime().Seconds(), `phase="transfer"`),
+		sample(stats.bundlingTime.Seconds(), `phase="bundling"`),
+	)
+	writeMetric("gocmd_transfer_throughput_bytes_per_second", "gauge", "Throughput of file transfers.",
+		sample(summary.Throughput, `kind="average"`),
+		sample(summary.PeakThroughput, `kind="peak"`),
+	)
+
+	modeSamples := []string{}
+	for _, mode := range summary.GetModes() {
+		modeSamples = append(modeSamples, sample(float64(summary.Modes[mode]), fmt.Sprintf("mode=%q", mode)))
+	}
+	writeMetric("gocmd_transfer_mode_files", "gauge", "Number of files transferred by transfer mode.", modeSamples...)
+
+	writeMetric("gocmd_transfer_success", "gauge", "Whether the run finished without error.", sample(success))
+	writeMetric("gocmd_transfer_last_run_timestamp_seconds", "gauge", "Time the run finished.", sample(float64(stats.endTime.Unix())))
+
+	fmt.Fprintf(writer, "# EOF\n")
+}
+
+// WriteOpenMetricsFile writes the stats in OpenMetrics text format to the file.
+// The file is replaced atomically, so collectors, e.g., node_exporter textfile collector, never read a partial file.
+func (stats *TransferStats) WriteOpenMetricsFile(metricsPath string) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(metricsPath), "."+filepath.Base(metricsPath)+".*")
+	if err != nil {
+		return xerrors.Errorf("failed to create a temp file for %q: %w", metricsPath, err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	stats.WriteOpenMetrics(tempFile)
+
+	err = tempFile.Close()
+	if err != nil {
+		return xerrors.Errorf("failed to write %q: %w", tempFile.Name(), err)
+	}
+
+	// temp files are created with mode 0600, collectors may run as another user
+	err = os.Chmod(tempFile.Name(), 0644)
+	if err != nil {
+		return xerrors.Errorf("failed to change mode of %q: %w", tempFile.Name(), err)
+	}
+
+	err = os.Rename(tempFile.Name(), metricsPath)
+	if err != nil {
+		return xerrors.Errorf("failed to rename %q to %q: %w", tempFile.Name(), metricsPath, err)
+	}
+
+	return nil
+}
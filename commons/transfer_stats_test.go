@@ -0,0 +1,98 @@
+package commons
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
+)
+
+func TestTransferStats(t *testing.T) {
+	t.Run("test PeakThroughputAndModes", testTransferStatsPeakThroughputAndModes)
+	t.Run("test OpenMetrics", testTransferStatsOpenMetrics)
+	t.Run("test NilTransferStats", testNilTransferStats)
+}
+
+func makeTestTransferStats() *TransferStats {
+	stats := NewTransferStats("put", false, "")
+
+	files := makeTestTransferReportFiles()
+	// overlaps with the first second of a.txt
+	files = append(files, &TransferReportFile{
+		Method:     TransferMethodPut,
+		StartAt:    files[0].StartAt,
+		EndAt:      files[0].StartAt.Add(time.Second),
+		SourcePath: "/local/d.txt",
+		DestPath:   "/zone/home/user/d.txt",
+		SourceSize: 3000,
+		DestSize:   3000,
+		Notes:      []string{"put", "icat", "multi-thread"},
+	})
+
+	for _, file := range files {
+		stats.AddFile(file)
+	}
+
+	stats.AddListingTime(time.Second)
+	stats.AddBundlingTime(2 * time.Second)
+	return stats
+}
+
+func testTransferStatsPeakThroughputAndModes(t *testing.T) {
+	stats := makeTestTransferStats()
+
+	assert.Equal(t, 2, stats.summary.Transferred)
+	assert.Equal(t, int64(5000), stats.summary.TransferredBytes)
+	assert.Equal(t, 2500.0, stats.summary.Throughput)
+	assert.Equal(t, 4000.0, stats.summary.PeakThroughput)
+	assert.Equal(t, map[string]int{"icat": 1, "multi-thread": 1}, stats.summary.Modes)
+	assert.Equal(t, 2*time.Second, stats.GetTransferTime())
+
+	output := &bytes.Buffer{}
+	stats.Write(output)
+	assert.Contains(t, output.String(), "files: 2 transferred, 1 skipped, 1 failed")
+	assert.Contains(t, output.String(), "modes: icat 1, multi-thread 1")
+}
+
+func testTransferStatsOpenMetrics(t *testing.T) {
+	metricsPath := filepath.Join(t.TempDir(), "gocmd.prom")
+
+	stats := makeTestTransferStats()
+	stats.openMetricsPath = metricsPath
+
+	err := stats.Finish(xerrors.Errorf("failed to put"))
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(metricsPath)
+	assert.NoError(t, err)
+
+	metrics := string(data)
+	assert.Contains(t, metrics, "# TYPE gocmd_transfer_files gauge\n")
+	assert.Contains(t, metrics, "gocmd_transfer_files{command=\"put\",status=\"failed\"} 1\n")
+	assert.Contains(t, metrics, "gocmd_transfer_bytes{command=\"put\"} 5000\n")
+	assert.Contains(t, metrics, "gocmd_transfer_phase_seconds{command=\"put\",phase=\"bundling\"} 2\n")
+	assert.Contains(t, metrics, "gocmd_transfer_throughput_bytes_per_second{command=\"put\",kind=\"peak\"} 4000\n")
+	assert.Contains(t, metrics, "gocmd_transfer_mode_files{command=\"put\",mode=\"icat\"} 1\n")
+	assert.Contains(t, metrics, "gocmd_transfer_success{command=\"put\"} 0\n")
+	assert.True(t, strings.HasSuffix(metrics, "# EOF\n"))
+
+	// no temp files left behind
+	entries, err := os.ReadDir(filepath.Dir(metricsPath))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func testNilTransferStats(t *testing.T) {
+	var stats *TransferStats
+
+	assert.NotPanics(t, func() {
+		stats.AddFile(&TransferReportFile{})
+		stats.AddListingTime(time.Second)
+		assert.NoError(t, stats.Finish(nil))
+	})
+}
@@ -22,6 +22,9 @@ func ParseSize(size string) (int64, error) {
 	size = strings.TrimSpace(size)
 	size = strings.ToUpper(size)
 	size = strings.TrimSuffix(size, "B")
+	if len(size) == 0 {
+		return 0, xerrors.Errorf("empty size string")
+	}
 
 	sizeNum := int64(0)
 	var err error
@@ -57,6 +60,9 @@ func ParseSize(size string) (int64, error) {
 func ParseTime(t string) (int, error) {
 	t = strings.TrimSpace(t)
 	t = strings.ToUpper(t)
+	if len(t) == 0 {
+		return 0, xerrors.Errorf("empty time string")
+	}
 
 	tNum := int64(0)
 	var err error
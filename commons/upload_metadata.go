@@ -0,0 +1,197 @@
+package commons
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	"golang.org/x/xerrors"
+)
+
+// UploadMetadataManager attaches metadata to data objects right after they are uploaded
+type UploadMetadataManager struct {
+	commonMetadata []*MetadataRow
+	fileMetadata   map[string][]*MetadataRow // keyed by local path
+	importSidecar  bool
+}
+
+// ParseMetadataAssignment parses 'attr=value' or 'attr[unit]=value'. The value is taken as is, so it may contain ':' or '=', e.g., URLs and timestamps
+func ParseMetadataAssignment(input string) (*MetadataRow, error) {
+	eqIdx := strings.Index(input, "=")
+	if eqIdx <= 0 {
+		return nil, xerrors.Errorf("malformed metadata %q, expected attr=value or attr[unit]=value", input)
+	}
+
+	row := &MetadataRow{
+		Attribute: input[:eqIdx],
+		Value:     input[eqIdx+1:],
+	}
+
+	if strings.HasSuffix(row.Attribute, "]") {
+		bracketIdx := strings.Index(row.Attribute, "[")
+		if bracketIdx <= 0 {
+			return nil, xerrors.Errorf("malformed metadata %q, expected attr[unit]=value", input)
+		}
+
+		row.Unit = row.Attribute[bracketIdx+1 : len(row.Attribute)-1]
+		row.Attribute = row.Attribute[:bracketIdx]
+	}
+
+	if len(row.Value) == 0 {
+		return nil, xerrors.Errorf("malformed metadata %q, value is empty", input)
+	}
+
+	return row, nil
+}
+
+// NewUploadMetadataManager creates a new UploadMetadataManager from 'attr=value' or 'attr[unit]=value' inputs applied to every data object and a metadata file mapping local paths to AVUs
+func NewUploadMetadataManager(metadataInputs []string, metadataFilePath string) (*UploadMetadataManager, error) {
+	manager := &UploadMetadataManager{
+		commonMetadata: []*MetadataRow{},
+		fileMetadata:   map[string][]*MetadataRow{},
+	}
+
+	for _, metadataInput := range metadataInputs {
+		row, err := ParseMetadataAssignment(metadataInput)
+		if err != nil {
+			return nil, err
+		}
+
+		manager.commonMetadata = append(manager.commonMetadata, row)
+	}
+
+	if len(metadataFilePath) > 0 {
+		metadataFilePath = MakeLocalPath(metadataFilePath)
+
+		format := DetectMetadataFileFormat(metadataFilePath)
+		if format == MetadataFileFormatUnknown {
+			format = MetadataFileFormatCSV
+		}
+
+		file, err := os.Open(metadataFilePath)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to open metadata file %q: %w", metadataFilePath, err)
+		}
+		defer file.Close()
+
+		rows, err := ReadMetadataRows(file, format)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read metadata file %q: %w", metadataFilePath, err)
+		}
+
+		for _, row := range rows {
+			err = row.Validate()
+			if err != nil {
+				return nil, xerrors.Errorf("invalid metadata for %q in %q: %w", row.Path, metadataFilePath, err)
+			}
+
+			localPath := MakeLocalPath(row.Path)
+			manager.fileMetadata[localPath] = append(manager.fileMetadata[localPath], row)
+		}
+	}
+
+	return manager, nil
+}
+
+// SetImportSidecar sets whether to import metadata from sidecar files next to uploaded files
+func (manager *UploadMetadataManager) SetImportSidecar(importSidecar bool) {
+	manager.importSidecar = importSidecar
+}
+
+// IsEmpty returns true if there is no metadata to attach
+func (manager *UploadMetadataManager) IsEmpty() bool {
+	if manager == nil {
+		return true
+	}
+
+	return len(manager.commonMetadata) == 0 && len(manager.fileMetadata) == 0 && !manager.importSidecar
+}
+
+// GetMetadata returns metadata to attach to the data object uploaded from the local path
+func (manager *UploadMetadataManager) GetMetadata(localPath string) []*MetadataRow {
+	if manager.IsEmpty() {
+		return nil
+	}
+
+	rows := []*MetadataRow{}
+	rows = append(rows, manager.commonMetadata...)
+	rows = append(rows, manager.fileMetadata[MakeLocalPath(localPath)]...)
+	return rows
+}
+
+// Apply adds metadata to the data object uploaded from the local path, returns notes for transfer report.
+// A sidecar holds the complete set of AVUs, so AVUs of the data object missing in the sidecar are removed.
+func (manager *UploadMetadataManager) Apply(fs *irodsclient_fs.FileSystem, localPath string, irodsPath string) ([]string, error) {
+	notes := []string{}
+
+	if manager.IsEmpty() {
+		return notes, nil
+	}
+
+	rows := manager.GetMetadata(localPath)
+
+	var sidecarRows []*MetadataRow
+	if manager.importSidecar {
+		var err error
+		sidecarRows, err = ReadMetadataSidecar(localPath)
+		if err != nil {
+			return notes, err
+		}
+	}
+
+	if len(rows) == 0 && sidecarRows == nil {
+		return notes, nil
+	}
+
+	metas, err := fs.ListMetadata(irodsPath)
+	if err != nil {
+		return notes, xerrors.Errorf("failed to list metadata of %q: %w", irodsPath, err)
+	}
+
+	existingAVUs := map[avu]bool{}
+	for _, meta := range metas {
+		existingAVUs[avu{meta.Name, meta.Value, meta.Units}] = true
+	}
+
+	if sidecarRows != nil {
+		sidecarAVUs := map[avu]bool{}
+		for _, row := range sidecarRows {
+			sidecarAVUs[avu{row.Attribute, row.Value, row.Unit}] = true
+		}
+
+		for _, meta := range metas {
+			avuKey := avu{meta.Name, meta.Value, meta.Units}
+			if sidecarAVUs[avuKey] {
+				continue
+			}
+
+			err = fs.DeleteMetadata(irodsPath, meta.AVUID)
+			if err != nil {
+				return notes, xerrors.Errorf("failed to delete metadata %q from %q: %w", meta.Name, irodsPath, err)
+			}
+
+			delete(existingAVUs, avuKey)
+		}
+
+		notes = append(notes, "meta_sidecar")
+		rows = append(sidecarRows, rows...)
+	}
+
+	for _, row := range rows {
+		avuKey := avu{row.Attribute, row.Value, row.Unit}
+		if existingAVUs[avuKey] {
+			continue
+		}
+
+		err := fs.AddMetadata(irodsPath, row.Attribute, row.Value, row.Unit)
+		if err != nil {
+			return notes, xerrors.Errorf("failed to add metadata %q to %q: %w", row.Attribute, irodsPath, err)
+		}
+
+		existingAVUs[avuKey] = true
+		notes = append(notes, fmt.Sprintf("meta:%s=%s:%s", row.Attribute, row.Value, row.Unit))
+	}
+
+	return notes, nil
+}
@@ -3,8 +3,11 @@ module github.com/cyverse/gocommands
 go 1.21
 
 require (
+	filippo.io/age v1.1.1
+	github.com/anmitsu/go-shlex v0.0.0-20200514113438-38f4b401e2be
 	github.com/creativeprojects/go-selfupdate v1.0.1
 	github.com/cyverse/go-irodsclient v0.16.4
+	github.com/dsnet/compress v0.0.1
 	github.com/dustin/go-humanize v1.0.1
 	github.com/gliderlabs/ssh v0.3.5
 	github.com/jedib0t/go-pretty/v6 v6.3.1
@@ -12,6 +15,7 @@ require (
 	github.com/sirupsen/logrus v1.8.1
 	github.com/spf13/cobra v1.5.0
 	github.com/stretchr/testify v1.8.2
+	github.com/zalando/go-keyring v0.2.3
 	golang.org/x/crypto v0.21.0
 	golang.org/x/term v0.18.0
 	golang.org/x/xerrors v0.0.0-20220907171357-04be3eba64a2
@@ -20,11 +24,14 @@ require (
 
 require (
 	code.gitea.io/sdk/gitea v0.15.1 // indirect
+	filippo.io/edwards25519 v1.0.0 // indirect
 	github.com/Masterminds/semver/v3 v3.2.0 // indirect
-	github.com/anmitsu/go-shlex v0.0.0-20200514113438-38f4b401e2be // indirect
+	github.com/alessio/shellescape v1.4.1 // indirect
+	github.com/danieljoos/wincred v1.2.0 // indirect
 	github.com/danwakefield/fnmatch v0.0.0-20160403171240-cbb64ac3d964 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/dlclark/regexp2 v1.11.5 // indirect
+	github.com/godbus/dbus/v5 v5.1.0 // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/google/go-github/v30 v30.1.0 // indirect
 	github.com/google/go-querystring v1.1.0 // indirect